@@ -2,22 +2,44 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/devices"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/health"
 	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/oidc"
 	"github.com/MitP1997/golang-user-management/internal/server"
+	"github.com/MitP1997/golang-user-management/internal/suspensions"
+	"github.com/MitP1997/golang-user-management/internal/tracing"
+	"github.com/MitP1997/golang-user-management/internal/webhooks"
+	"google.golang.org/grpc"
 )
 
 func main() {
-	r := server.NewServer()
+	printConfig := flag.Bool("print-config", false, "print the resolved configuration with secrets masked and exit")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *printConfig {
+		fmt.Print(cfg)
+		return
+	}
+
+	r := server.NewServer(cfg)
 
 	srv := &http.Server{
-		Addr:    ":8080",
+		Addr:    cfg.Server.ListenAddress,
 		Handler: r,
 	}
 
@@ -30,6 +52,20 @@ func main() {
 		}
 	}()
 
+	// the gRPC server shares the clients and database connection set up by server.NewServer
+	grpcSrv := server.NewGrpcServer()
+	go func() {
+		listener, err := net.Listen("tcp", cfg.Server.GrpcAddress)
+		if err != nil {
+			fmt.Println(err)
+			panic(err)
+		}
+		if err := grpcSrv.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+			fmt.Println(err)
+			panic(err)
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown the server with
 	// a timeout of 5 seconds.
 	quit := make(chan os.Signal, 1)
@@ -40,19 +76,58 @@ func main() {
 	<-quit
 	fmt.Println("Shutting down server...")
 
-	err := models.CloseMongoConnection()
-	if err != nil {
-		fmt.Println(err)
-	}
+	// fail the readiness probe first and give the orchestrator time to notice
+	// so no new traffic is routed here while the servers drain
+	health.MarkShuttingDown()
+	time.Sleep(cfg.Server.ShutdownReadinessDelay)
 
-	// The context is used to inform the server it has 5 seconds to finish
+	grpcSrv.GracefulStop()
+
+	// The context is used to inform the server how long it has to finish
 	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
 		fmt.Println("Server forced to shutdown: ", err)
 		panic(err)
 	}
 
+	// before the event subscribers as lifting a suspension publishes an event
+	if err := suspensions.Shutdown(ctx); err != nil {
+		fmt.Println("Suspension expiry job forced to stop: ", err)
+	}
+
+	// before the webhook workers as the subscribers queue webhooks
+	if err := events.Shutdown(ctx); err != nil {
+		fmt.Println("Event subscribers forced to stop: ", err)
+	}
+
+	// the workers finish the deliveries they are sending, the rest stay queued for the next start
+	if err := webhooks.Shutdown(ctx); err != nil {
+		fmt.Println("Webhook workers forced to stop: ", err)
+	}
+
+	if err := oidc.Shutdown(ctx); err != nil {
+		fmt.Println("Signing key rotation forced to stop: ", err)
+	}
+
+	// after the event subscribers as the login alerts look up countries
+	if err := devices.Close(); err != nil {
+		fmt.Println(err)
+	}
+
+	// closed after the servers stopped so the requests being drained can still use it
+	err = models.CloseMongoConnection()
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println("Disconnected from MongoDB!")
+	}
+
+	// flush the spans of the requests that were drained above
+	if err := tracing.Shutdown(ctx); err != nil {
+		fmt.Println(err)
+	}
+
 	fmt.Println("Server exiting")
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func createInvitationCode(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("create-invitation-code", flag.ExitOnError)
+	code := fs.String("code", "", "the code, generated when empty")
+	maxUses := fs.Int("max-uses", 1, "how many signups can use the code, 0 for unlimited")
+	expiresIn := fs.Duration("expires-in", 0, "how long the code is valid, e.g. 168h, never expires when 0")
+	_ = fs.Parse(args)
+	if *maxUses < 0 {
+		return fmt.Errorf("-max-uses must not be negative")
+	}
+
+	if *code == "" {
+		buf := make([]byte, 6)
+		_, _ = rand.Read(buf)
+		*code = hex.EncodeToString(buf)
+	}
+	invitationCode := models.InvitationCode{
+		Code:      *code,
+		MaxUses:   int32(*maxUses),
+		CreatedBy: "usermgmt",
+	}
+	if *expiresIn > 0 {
+		invitationCode.ExpiresAt = timestamppb.New(time.Now().Add(*expiresIn))
+	}
+	if e := invitationCode.Insert(c); e != nil {
+		return cliError(e)
+	}
+	fmt.Printf("created invitation code %s\n", invitationCode.Code)
+	return nil
+}
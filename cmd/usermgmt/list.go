@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// filters shared by list-users and export-users
+type userFilter struct {
+	email          *string
+	name           *string
+	status         *string
+	organizationId *string
+}
+
+func addUserFilter(fs *flag.FlagSet) *userFilter {
+	return &userFilter{
+		email:          fs.String("email", "", "only users whose email contains this text, case insensitive"),
+		name:           fs.String("name", "", "only users whose given or family name contains this text, case insensitive"),
+		status:         fs.String("status", "", "only users with this status"),
+		organizationId: fs.String("organization-id", "", "only users of this organization"),
+	}
+}
+
+func (f *userFilter) build() (bson.M, error) {
+	filter := bson.M{}
+	if *f.email != "" {
+		filter["email"] = containsPattern(*f.email)
+	}
+	if *f.name != "" {
+		filter["$or"] = bson.A{
+			bson.M{"given_name": containsPattern(*f.name)},
+			bson.M{"family_name": containsPattern(*f.name)},
+		}
+	}
+	if *f.status != "" {
+		status, err := parseUserStatus(*f.status)
+		if err != nil {
+			return nil, err
+		}
+		filter["status"] = status
+	}
+	if *f.organizationId != "" {
+		filter["organization_id"] = *f.organizationId
+	}
+	return filter, nil
+}
+
+func containsPattern(text string) bson.M {
+	return bson.M{"$regex": regexp.QuoteMeta(text), "$options": "i"}
+}
+
+func listUsers(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("list-users", flag.ExitOnError)
+	userFilter := addUserFilter(fs)
+	limit := fs.Int64("limit", 50, "maximum number of users to print")
+	skip := fs.Int64("skip", 0, "number of users to skip, for paging")
+	asJson := fs.Bool("json", false, "print one JSON object per line instead of a table")
+	_ = fs.Parse(args)
+	filter, err := userFilter.build()
+	if err != nil {
+		return err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at.seconds", Value: -1}}).SetSkip(*skip).SetLimit(*limit)
+	users, e := models.FindUsers(c, filter, opts)
+	if e != nil {
+		return cliError(e)
+	}
+	if *asJson {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, user := range users {
+			if err = encoder.Encode(newUserRecord(user, false)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tEMAIL\tNAME\tSTATUS\tORGANIZATION\tCREATED AT")
+	for _, user := range users {
+		record := newUserRecord(user, false)
+		fmt.Fprintf(writer, "%s\t%s\t%s %s\t%s\t%s\t%s\n", record.Id, record.Email, record.GivenName, record.FamilyName, record.Status, record.OrganizationId, record.CreatedAt)
+	}
+	return writer.Flush()
+}
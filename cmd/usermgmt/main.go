@@ -0,0 +1,114 @@
+// usermgmt is the admin tool for support staff, it works directly against the configured Mongo and Redis.
+//
+//	usermgmt [config flags] <command> [command flags]
+//
+// It reads the same configuration as the server, run `usermgmt -h` for the config flags
+// and `usermgmt <command> -h` for the flags of a command.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/oidc"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type command struct {
+	usage string
+	run   func(c *gin.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"create-user":            {usage: "create a user, verified with -verified", run: createUser},
+	"set-password":           {usage: "set the password of a user and revoke their sessions", run: setPassword},
+	"reset-password":         {usage: "replace the password of a user with a generated one and revoke their sessions", run: resetPassword},
+	"verify-email":           {usage: "mark the email of a user as verified", run: verifyEmail},
+	"set-status":             {usage: "change the status of a user", run: setStatus},
+	"set-role":               {usage: "make a user a platform admin or revoke it", run: setRole},
+	"revoke-sessions":        {usage: "log a user out everywhere", run: revokeSessions},
+	"list-users":             {usage: "list and search users", run: listUsers},
+	"export-users":           {usage: "export users as CSV or JSON Lines", run: exportUsers},
+	"import-users":           {usage: "import users from CSV or JSON Lines", run: importUsers},
+	"create-invitation-code": {usage: "create an invitation code for invite_only signup", run: createInvitationCode},
+	"rotate-signing-key":     {usage: "create a new OpenID Connect signing key right away", run: rotateSigningKey},
+}
+
+func main() {
+	flag.CommandLine.Usage = usage
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	args := flag.CommandLine.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		usage()
+		os.Exit(2)
+	}
+
+	serviceRegistry.InitServiceRegistry(cfg)
+	oidc.Configure(cfg.Oidc, cfg.App.BaseUrl)
+	events.Configure(cfg.Events)
+	handler.RegisterEventSubscribers()
+	if err = models.InitMongoConnection(serviceRegistry.GetLogger(), cfg.Database, cfg.Tenancy, cfg.Audit); err != nil {
+		fmt.Fprintln(os.Stderr, "connecting to mongo:", err)
+		os.Exit(1)
+	}
+	defer models.CloseMongoConnection()
+
+	c := newContext()
+	err = cmd.run(c, args[1:])
+	// without the outbox the asynchronous event subscribers, e.g. webhooks, run in goroutines that must finish first
+	waitCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if waitErr := events.Shutdown(waitCtx); waitErr != nil {
+		fmt.Fprintln(os.Stderr, "warning: event subscribers did not finish:", waitErr)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		models.CloseMongoConnection()
+		os.Exit(1)
+	}
+}
+
+// the models and clients expect the request scoped values the gin middlewares set up,
+// the actor type attributes the audit entries of the commands to the cli
+func newContext() *gin.Context {
+	c := utils.NewContext(context.Background(), nil)
+	logger := serviceRegistry.GetLogger().With(zap.String("source", "usermgmt"))
+	utils.SetContextLogger(c, logger)
+	utils.SetContextActorType(c, constants.AuditActorCli)
+	return c
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: usermgmt [config flags] <command> [command flags]\n\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-24s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nconfig flags:\n")
+	flag.CommandLine.PrintDefaults()
+}
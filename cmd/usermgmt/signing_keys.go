@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/MitP1997/golang-user-management/internal/oidc"
+	"github.com/gin-gonic/gin"
+)
+
+// rotateSigningKey creates a new oidc signing key right away, e.g. when a key leaked, the servers publish it
+// at once, sign with it once every instance reloaded the keys and keep the previous ones published until their
+// id tokens expired
+func rotateSigningKey(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("rotate-signing-key", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	key, e := oidc.RotateSigningKeys(c, true)
+	if e != nil {
+		return cliError(e)
+	}
+	fmt.Printf("created signing key %s\n", key.Kid)
+	return nil
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	formatCsv   = "csv"
+	formatJsonl = "jsonl"
+)
+
+// one user in an export or import file, timestamps are RFC 3339
+type userRecord struct {
+	Id             string `json:"id,omitempty"`
+	Email          string `json:"email"`
+	GivenName      string `json:"given_name"`
+	FamilyName     string `json:"family_name"`
+	Status         string `json:"status"`
+	OrganizationId string `json:"organization_id,omitempty"`
+	CreatedAt      string `json:"created_at,omitempty"`
+	VerifiedAt     string `json:"verified_at,omitempty"`
+	// bcrypt hash, only exported on request so accounts can be moved between deployments
+	PasswordHash string `json:"password_hash,omitempty"`
+	// plain password, only read on import
+	Password string `json:"password,omitempty"`
+}
+
+var csvHeader = []string{"id", "email", "given_name", "family_name", "status", "organization_id", "created_at", "verified_at", "password_hash", "password"}
+
+func newUserRecord(user *models.User, withPasswordHash bool) userRecord {
+	record := userRecord{
+		Id:             user.Id,
+		Email:          user.Email,
+		GivenName:      user.GivenName,
+		FamilyName:     user.FamilyName,
+		Status:         strings.ToLower(user.Status.String()),
+		OrganizationId: user.OrganizationId,
+		CreatedAt:      formatTimestamp(user.CreatedAt),
+		VerifiedAt:     formatTimestamp(user.VerifiedAt),
+	}
+	if withPasswordHash {
+		record.PasswordHash = user.Password
+	}
+	return record
+}
+
+func (r userRecord) csvRow() []string {
+	return []string{r.Id, r.Email, r.GivenName, r.FamilyName, r.Status, r.OrganizationId, r.CreatedAt, r.VerifiedAt, r.PasswordHash, r.Password}
+}
+
+// toUser builds a new user from an imported record, the id and created_at of the record are not kept
+func (r userRecord) toUser() (*models.User, error) {
+	if r.Email == "" {
+		return nil, fmt.Errorf("email is required")
+	}
+	status := models.UserStatus_UNVERIFIED
+	if r.Status != "" {
+		var err error
+		if status, err = parseUserStatus(r.Status); err != nil {
+			return nil, err
+		}
+	}
+	user := &models.User{
+		Email:          r.Email,
+		GivenName:      r.GivenName,
+		FamilyName:     r.FamilyName,
+		Status:         status,
+		OrganizationId: r.OrganizationId,
+	}
+
+	switch {
+	case r.PasswordHash != "":
+		if _, err := bcrypt.Cost([]byte(r.PasswordHash)); err != nil {
+			return nil, fmt.Errorf("password_hash is not a bcrypt hash")
+		}
+		user.Password = r.PasswordHash
+	case r.Password != "":
+		hashedPassword, err := hashPassword(r.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.Password = hashedPassword
+	default:
+		// the user has to go through the change password flow before logging in
+		hashedPassword, err := hashPassword(generatePassword())
+		if err != nil {
+			return nil, err
+		}
+		user.Password = hashedPassword
+	}
+
+	if r.VerifiedAt != "" {
+		verifiedAt, err := time.Parse(time.RFC3339, r.VerifiedAt)
+		if err != nil {
+			return nil, fmt.Errorf("verified_at %q is not an RFC 3339 time", r.VerifiedAt)
+		}
+		user.VerifiedAt = timestamppb.New(verifiedAt)
+	} else if status == models.UserStatus_VERIFIED {
+		user.VerifiedAt = timestamppb.Now()
+	}
+	return user, nil
+}
+
+func exportUsers(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("export-users", flag.ExitOnError)
+	userFilter := addUserFilter(fs)
+	file := fs.String("file", "-", "file to write, - for stdout")
+	format := fs.String("format", "", "csv or jsonl, guessed from the file extension by default")
+	withPasswordHashes := fs.Bool("with-password-hashes", false, "include the bcrypt password hashes")
+	_ = fs.Parse(args)
+	filter, err := userFilter.build()
+	if err != nil {
+		return err
+	}
+	if *format, err = resolveFormat(*format, *file); err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	buffered := bufio.NewWriter(out)
+	defer buffered.Flush()
+
+	var write func(record userRecord) error
+	if *format == formatCsv {
+		writer := csv.NewWriter(buffered)
+		defer writer.Flush()
+		if err = writer.Write(csvHeader[:len(csvHeader)-1]); err != nil {
+			return err
+		}
+		write = func(record userRecord) error {
+			row := record.csvRow()
+			return writer.Write(row[:len(row)-1])
+		}
+	} else {
+		encoder := json.NewEncoder(buffered)
+		write = func(record userRecord) error {
+			return encoder.Encode(record)
+		}
+	}
+
+	count := 0
+	e := models.EachUser(c, filter, func(user *models.User) error {
+		count++
+		return write(newUserRecord(user, *withPasswordHashes))
+	})
+	if e != nil {
+		return cliError(e)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d users\n", count)
+	return nil
+}
+
+func importUsers(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("import-users", flag.ExitOnError)
+	file := fs.String("file", "-", "file to read, - for stdin")
+	format := fs.String("format", "", "csv or jsonl, guessed from the file extension by default")
+	dryRun := fs.Bool("dry-run", false, "validate the records without inserting them")
+	_ = fs.Parse(args)
+	var err error
+	if *format, err = resolveFormat(*format, *file); err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	imported, failed := 0, 0
+	err = readRecords(in, *format, func(line int, record userRecord, readErr error) {
+		err := readErr
+		var user *models.User
+		if err == nil {
+			user, err = record.toUser()
+		}
+		if err == nil && !*dryRun {
+			err = cliError(user.Insert(c))
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "line %d: %s: %v\n", line, record.Email, err)
+			return
+		}
+		imported++
+	})
+	if err != nil {
+		return err
+	}
+	verb := "imported"
+	if *dryRun {
+		verb = "validated"
+	}
+	fmt.Fprintf(os.Stderr, "%s %d users, %d failed\n", verb, imported, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d records were not imported", failed)
+	}
+	return nil
+}
+
+// readRecords calls fn for every record with its line number, a record that cannot be decoded is passed with its error
+func readRecords(in io.Reader, format string, fn func(line int, record userRecord, err error)) error {
+	if format == formatJsonl {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			var record userRecord
+			decoder := json.NewDecoder(strings.NewReader(text))
+			decoder.DisallowUnknownFields()
+			fn(line, record, decoder.Decode(&record))
+		}
+		return scanner.Err()
+	}
+
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("reading csv header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range header {
+		if !contains(csvHeader, strings.TrimSpace(strings.ToLower(name))) {
+			return fmt.Errorf("unknown csv column %q, the columns are %s", name, strings.Join(csvHeader, ", "))
+		}
+	}
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			fn(line, userRecord{}, err)
+			continue
+		}
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		fn(line, userRecord{
+			Email:          get("email"),
+			GivenName:      get("given_name"),
+			FamilyName:     get("family_name"),
+			Status:         get("status"),
+			OrganizationId: get("organization_id"),
+			VerifiedAt:     get("verified_at"),
+			PasswordHash:   get("password_hash"),
+			Password:       get("password"),
+		}, nil)
+	}
+}
+
+func resolveFormat(format string, file string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".csv":
+			format = formatCsv
+		default:
+			format = formatJsonl
+		}
+	}
+	if format != formatCsv && format != formatJsonl {
+		return "", fmt.Errorf("invalid format %q, must be csv or jsonl", format)
+	}
+	return format, nil
+}
+
+func formatTimestamp(timestamp *timestamppb.Timestamp) string {
+	if timestamp == nil {
+		return ""
+	}
+	return timestamp.AsTime().UTC().Format(time.RFC3339)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const minPasswordLength = 8
+
+// picks the user a command acts on, by id or by email (within an organization when emails are unique per organization)
+type userSelector struct {
+	id             *string
+	email          *string
+	organizationId *string
+}
+
+func addUserSelector(fs *flag.FlagSet) *userSelector {
+	return &userSelector{
+		id:             fs.String("id", "", "id of the user"),
+		email:          fs.String("email", "", "email of the user"),
+		organizationId: fs.String("organization-id", "", "organization of the user, needed with -email when emails are unique per organization"),
+	}
+}
+
+func (s *userSelector) find(c *gin.Context) (*models.User, error) {
+	var filter bson.M
+	switch {
+	case *s.id != "":
+		filter = bson.M{"_id": *s.id}
+	case *s.email != "":
+		filter = bson.M{"email": *s.email}
+		if models.IsEmailUniquePerOrganization() {
+			if *s.organizationId == "" {
+				return nil, fmt.Errorf("-organization-id is required with -email when emails are unique per organization")
+			}
+			filter["organization_id"] = *s.organizationId
+		}
+	default:
+		return nil, fmt.Errorf("-id or -email is required")
+	}
+	var user models.User
+	if e := user.FindOne(c, filter); e != nil {
+		return nil, cliError(e)
+	}
+	return &user, nil
+}
+
+func createUser(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("create-user", flag.ExitOnError)
+	email := fs.String("email", "", "email of the user (required)")
+	givenName := fs.String("given-name", "", "given name")
+	familyName := fs.String("family-name", "", "family name")
+	password := fs.String("password", "", "password, generated and printed when empty")
+	organizationId := fs.String("organization-id", "", "organization the user belongs to")
+	verified := fs.Bool("verified", false, "mark the email as verified")
+	_ = fs.Parse(args)
+	if *email == "" {
+		return fmt.Errorf("-email is required")
+	}
+
+	generated := *password == ""
+	if generated {
+		*password = generatePassword()
+	}
+	hashedPassword, err := hashPassword(*password)
+	if err != nil {
+		return err
+	}
+	user := models.User{
+		GivenName:      *givenName,
+		FamilyName:     *familyName,
+		Email:          *email,
+		Password:       hashedPassword,
+		OrganizationId: *organizationId,
+		Status:         models.UserStatus_UNVERIFIED,
+	}
+	if *verified {
+		user.Status = models.UserStatus_VERIFIED
+		user.VerifiedAt = timestamppb.Now()
+	}
+	if e := user.Insert(c); e != nil {
+		return cliError(e)
+	}
+	fmt.Printf("created user %s\n", user.Id)
+	if generated {
+		fmt.Printf("password: %s\n", *password)
+	}
+	return nil
+}
+
+func setPassword(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("set-password", flag.ExitOnError)
+	selector := addUserSelector(fs)
+	password := fs.String("password", "", "new password (required)")
+	_ = fs.Parse(args)
+	if *password == "" {
+		return fmt.Errorf("-password is required")
+	}
+	user, err := selector.find(c)
+	if err != nil {
+		return err
+	}
+	if err = updatePassword(c, user, *password); err != nil {
+		return err
+	}
+	fmt.Printf("password of user %s changed, sessions revoked\n", user.Id)
+	return nil
+}
+
+func resetPassword(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	selector := addUserSelector(fs)
+	_ = fs.Parse(args)
+	user, err := selector.find(c)
+	if err != nil {
+		return err
+	}
+	password := generatePassword()
+	if err = updatePassword(c, user, password); err != nil {
+		return err
+	}
+	fmt.Printf("password of user %s reset, sessions revoked\npassword: %s\n", user.Id, password)
+	return nil
+}
+
+func verifyEmail(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("verify-email", flag.ExitOnError)
+	selector := addUserSelector(fs)
+	_ = fs.Parse(args)
+	user, err := selector.find(c)
+	if err != nil {
+		return err
+	}
+	if user.VerifiedAt != nil {
+		return cliError(errors.EmailAlreadyVerifiedError())
+	}
+	set := bson.M{"status": models.UserStatus_VERIFIED, "verified_at": timestamppb.Now()}
+	// a suspended user stays suspended, and is verified once reinstated
+	if user.Status == models.UserStatus_SUSPENDED || user.Status == models.UserStatus_BANNED {
+		delete(set, "status")
+		if user.Suspension != nil {
+			set["suspension.previous_status"] = models.UserStatus_VERIFIED
+		}
+	}
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := user.Update(c, bson.M{"_id": user.Id}, set); e != nil {
+			return e
+		}
+		return events.Publish(c, events.EmailVerified{User: events.NewUser(user)})
+	})
+	if e != nil {
+		return cliError(e)
+	}
+	fmt.Printf("email of user %s verified\n", user.Id)
+	return nil
+}
+
+func setStatus(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("set-status", flag.ExitOnError)
+	selector := addUserSelector(fs)
+	statusName := fs.String("status", "", "new status: "+strings.ToLower(strings.Join(userStatusNames(), ", ")))
+	_ = fs.Parse(args)
+	status, err := parseUserStatus(*statusName)
+	if err != nil {
+		return err
+	}
+	// a suspension needs the reason and the admin who decided it
+	if status == models.UserStatus_SUSPENDED || status == models.UserStatus_BANNED {
+		return fmt.Errorf("users are suspended and banned with POST /api/v1/admin/users/<id>/suspend and /ban")
+	}
+	user, err := selector.find(c)
+	if err != nil {
+		return err
+	}
+
+	// any other status lifts the suspension or ban
+	set := bson.M{"status": status, "suspension": nil}
+	switch status {
+	case models.UserStatus_VERIFIED:
+		if user.VerifiedAt == nil {
+			set["verified_at"] = timestamppb.Now()
+		}
+	case models.UserStatus_DELETED:
+		set["deleted_at"] = timestamppb.Now()
+	}
+	previousStatus := user.Status
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := user.Update(c, bson.M{"_id": user.Id}, set); e != nil {
+			return e
+		}
+		if status == models.UserStatus_DELETED {
+			return events.Publish(c, events.UserDeleted{User: events.NewUser(user)})
+		}
+		return events.Publish(c, events.StatusChanged{User: events.NewUser(user), PreviousStatus: previousStatus.String()})
+	})
+	if e != nil {
+		return cliError(e)
+	}
+	// a deleted user must not keep using a session issued before
+	if status == models.UserStatus_DELETED {
+		if e := serviceRegistry.GetRedisClient().RevokeUserSessions(c, user.Id); e != nil {
+			return cliError(e)
+		}
+	}
+	fmt.Printf("status of user %s set to %s\n", user.Id, status.String())
+	return nil
+}
+
+func setRole(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("set-role", flag.ExitOnError)
+	selector := addUserSelector(fs)
+	roleName := fs.String("role", "", "new platform role: "+strings.ToLower(strings.Join(userRoleNames(), ", ")))
+	_ = fs.Parse(args)
+	value, ok := models.UserRole_value[strings.ToUpper(*roleName)]
+	if !ok {
+		return fmt.Errorf("invalid role %q, must be one of %s", *roleName, strings.ToLower(strings.Join(userRoleNames(), ", ")))
+	}
+	user, err := selector.find(c)
+	if err != nil {
+		return err
+	}
+	previousRole := user.Role
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := user.Update(c, bson.M{"_id": user.Id}, bson.M{"role": models.UserRole(value)}); e != nil {
+			return e
+		}
+		return events.Publish(c, events.RoleChanged{User: events.NewUser(user), Role: user.Role.String(), PreviousRole: previousRole.String()})
+	})
+	if e != nil {
+		return cliError(e)
+	}
+	fmt.Printf("role of user %s set to %s\n", user.Id, user.Role.String())
+	return nil
+}
+
+func revokeSessions(c *gin.Context, args []string) error {
+	fs := flag.NewFlagSet("revoke-sessions", flag.ExitOnError)
+	selector := addUserSelector(fs)
+	_ = fs.Parse(args)
+	user, err := selector.find(c)
+	if err != nil {
+		return err
+	}
+	if e := serviceRegistry.GetRedisClient().RevokeUserSessions(c, user.Id); e != nil {
+		return cliError(e)
+	}
+	if e := events.Publish(c, events.SessionsRevoked{User: events.NewUser(user)}); e != nil {
+		return cliError(e)
+	}
+	fmt.Printf("sessions of user %s revoked\n", user.Id)
+	return nil
+}
+
+func updatePassword(c *gin.Context, user *models.User, password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := user.Update(c, bson.M{"_id": user.Id}, bson.M{"password": hashedPassword, "password_reset_required": false}); e != nil {
+			return e
+		}
+		return events.Publish(c, events.PasswordChanged{User: events.NewUser(user)})
+	})
+	if e != nil {
+		return cliError(e)
+	}
+	if e := serviceRegistry.GetRedisClient().RevokeUserSessions(c, user.Id); e != nil {
+		return cliError(e)
+	}
+	return nil
+}
+
+// same rules and hashing as the signup and change password apis
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", cliError(errors.PasswordTooShortError())
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
+func generatePassword() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func parseUserStatus(name string) (models.UserStatus, error) {
+	value, ok := models.UserStatus_value[strings.ToUpper(name)]
+	if !ok {
+		return 0, fmt.Errorf("invalid status %q, must be one of %s", name, strings.ToLower(strings.Join(userStatusNames(), ", ")))
+	}
+	return models.UserStatus(value), nil
+}
+
+func userStatusNames() []string {
+	names := make([]string, 0, len(models.UserStatus_name))
+	for i := 0; i < len(models.UserStatus_name); i++ {
+		names = append(names, models.UserStatus_name[int32(i)])
+	}
+	return names
+}
+
+func userRoleNames() []string {
+	names := make([]string, 0, len(models.UserRole_name))
+	for i := 0; i < len(models.UserRole_name); i++ {
+		names = append(names, models.UserRole_name[int32(i)])
+	}
+	return names
+}
+
+// the models report *errors.Error, the command line shows its code and cause
+func cliError(e *errors.Error) error {
+	if e == nil {
+		return nil
+	}
+	if cause := e.Error(); cause != nil {
+		return fmt.Errorf("%s: %s: %w", e.UserErrorCode(), e.UserErrorString(), cause)
+	}
+	return fmt.Errorf("%s: %s", e.UserErrorCode(), e.UserErrorString())
+}
@@ -2,6 +2,7 @@ package clients
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 	"strings"
 
@@ -10,16 +11,16 @@ import (
 	"github.com/google/uuid"
 )
 
-func generateToken(tokenType datatypes.TokenType) (token string) {
+func generateToken(tokenType datatypes.TokenType, otpLength int) (token string) {
 	if tokenType == constants.TokenTypeUuid {
 		uuid := uuid.New().String()
 		token = strings.Replace(uuid, "-", "", -1)
 		return
 	}
-	if tokenType == constants.TokenType6DigitOtp {
-		// generate 6 digit otp
-		otp := rand.Intn(1000000)
-		token = fmt.Sprintf("%06d", otp)
+	if tokenType == constants.TokenTypeOtp {
+		// generate a zero padded otp of the configured length
+		otp := rand.Int63n(int64(math.Pow10(otpLength)))
+		token = fmt.Sprintf("%0*d", otpLength, otp)
 		return
 	}
 	return
@@ -1,13 +1,20 @@
 package clients
 
 import (
+	"context"
 	"crypto/tls"
-	"os"
+	"net"
+	"net/smtp"
 	"strconv"
 
+	"github.com/MitP1997/golang-user-management/internal/config"
 	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/metrics"
+	"github.com/MitP1997/golang-user-management/internal/tracing"
 	"github.com/MitP1997/golang-user-management/internal/utils"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	gomail "gopkg.in/mail.v2"
@@ -22,23 +29,28 @@ type MailerClient struct {
 	insecureSkipVerify bool
 }
 
-func NewMailerClient(logger *zap.Logger) *MailerClient {
-	smtpPort, _ := strconv.Atoi(os.Getenv("EMAIL_SMTP_PORT"))
-	skipVerify, _ := strconv.ParseBool(os.Getenv("EMAIL_TLS_INSECURE_SKIP_VERIFY"))
+func NewMailerClient(logger *zap.Logger, emailConfig config.EmailConfig) *MailerClient {
 	return &MailerClient{
 		// can't use context logger as this will be processed in a goroutine and the context might end before the goroutine finishes
 		logger:             logger,
-		from:               os.Getenv("EMAIL_FROM_ADDRESS"),
-		password:           os.Getenv("EMAIL_FROM_PASSWORD"),
-		smtpHost:           os.Getenv("EMAIL_SMTP_HOST"),
-		smtpPort:           smtpPort,
-		insecureSkipVerify: skipVerify,
+		from:               emailConfig.FromAddress,
+		password:           emailConfig.FromPassword,
+		smtpHost:           emailConfig.SmtpHost,
+		smtpPort:           emailConfig.SmtpPort,
+		insecureSkipVerify: emailConfig.TlsInsecureSkipVerify,
 	}
 }
 
 func (m *MailerClient) SendMail(ctx *gin.Context, to string, subject string, body string) *errors.Error {
 	logger := utils.GetContextLogger(ctx)
 	logger.Info("Sending email", zap.String("to", to), zap.String("subject", subject))
+	// emails are usually sent from a goroutine, the span still joins the trace of the request that queued it
+	_, span := tracing.Tracer().Start(ctx, "smtp.send", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("net.peer.name", m.smtpHost),
+		attribute.Int("net.peer.port", m.smtpPort),
+		attribute.String("email.subject", subject),
+	))
+	defer span.End()
 	mail := gomail.NewMessage()
 	mail.SetHeader("From", m.from)
 	mail.SetHeader("To", to)
@@ -48,10 +60,32 @@ func (m *MailerClient) SendMail(ctx *gin.Context, to string, subject string, bod
 	d.TLSConfig = &tls.Config{InsecureSkipVerify: m.insecureSkipVerify}
 	if e := d.DialAndSend(mail); e != nil {
 		logger.Error("Error sending email", zap.Error(e))
+		metrics.EmailsTotal.WithLabelValues(metrics.EmailFailed).Inc()
 		// add retry logic in case of network glitches
 		// ideally use a queue to store the email and retry sending it later
-		return errors.SendMailError(e)
+		err := errors.SendMailError(e)
+		tracing.RecordError(span, err)
+		return err
 	}
+	metrics.EmailsTotal.WithLabelValues(metrics.EmailSent).Inc()
 	m.logger.Info("Email sent successfully", zap.String("to", to), zap.String("subject", subject))
 	return nil
 }
+
+// Ping opens a connection to the SMTP server and waits for its greeting, without authenticating or sending anything
+func (m *MailerClient) Ping(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.smtpHost, strconv.Itoa(m.smtpPort)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, m.smtpHost)
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -2,21 +2,28 @@ package clients
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/MitP1997/golang-user-management/internal/config"
 	"github.com/MitP1997/golang-user-management/internal/constants"
 	"github.com/MitP1997/golang-user-management/internal/datatypes"
 	"github.com/MitP1997/golang-user-management/internal/errors"
 	"github.com/MitP1997/golang-user-management/internal/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
 type RedisClient struct {
-	client *redis.Client
+	client    *redis.Client
+	scopeTtls map[datatypes.RedisScope]time.Duration
+	otpLength int
 }
 
 type RedisKey struct {
@@ -28,16 +35,48 @@ func (r *RedisKey) String() string {
 	return fmt.Sprintf("%s_|_%s", r.Scope, r.Key)
 }
 
-func NewRedisClient() *RedisClient {
+func NewRedisClient(redisConfig config.RedisConfig, tokenConfig config.TokenConfig) *RedisClient {
+	client := redis.NewClient(&redis.Options{
+		Addr:     net.JoinHostPort(redisConfig.Host, strconv.Itoa(redisConfig.Port)),
+		Password: redisConfig.Password,
+		DB:       redisConfig.Db,
+	})
+	client.AddHook(redisMetricsHook{})
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		panic(err)
+	}
 	return &RedisClient{
-		client: redis.NewClient(&redis.Options{
-			Addr:     fmt.Sprintf("%s:%s", os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT")),
-			Password: os.Getenv("REDIS_PASSWORD"),
-			DB:       0,
-		}),
+		client: client,
+		// the organization claim and the authentication live as long as the auth token they belong to,
+		// the link token is used right away like the login code
+		scopeTtls: map[datatypes.RedisScope]time.Duration{
+			constants.RedisUserAuthTokenScope:           tokenConfig.AuthTokenTtl,
+			constants.RedisAuthTokenUserScope:           tokenConfig.AuthTokenTtl,
+			constants.RedisAuthTokenOrganizationScope:   tokenConfig.AuthTokenTtl,
+			constants.RedisAuthTokenAuthenticationScope: tokenConfig.AuthTokenTtl,
+			constants.RedisUserEmailVerificationScope:   tokenConfig.EmailVerificationOtpTtl,
+			constants.RedisUserChangePasswordScope:      tokenConfig.ChangePasswordOtpTtl,
+			constants.RedisSecureAccountTokenScope:      tokenConfig.SecureAccountLinkTtl,
+			constants.RedisOAuthCodeScope:               tokenConfig.OAuthCodeTtl,
+			constants.RedisOAuthAccessTokenScope:        tokenConfig.OAuthAccessTokenTtl,
+			constants.RedisOAuthRefreshTokenScope:       tokenConfig.OAuthRefreshTokenTtl,
+			constants.RedisSocialLoginStateScope:        tokenConfig.SocialLoginStateTtl,
+			constants.RedisSocialLoginCodeScope:         tokenConfig.SocialLoginCodeTtl,
+			constants.RedisSocialLinkTokenScope:         tokenConfig.SocialLoginCodeTtl,
+			constants.RedisSamlRequestScope:             tokenConfig.SamlRequestTtl,
+			constants.RedisSamlLoginCodeScope:           tokenConfig.SamlLoginCodeTtl,
+			constants.RedisImpersonationTokenScope:      tokenConfig.ImpersonationTtl,
+			constants.RedisImpersonatorTokensScope:      tokenConfig.ImpersonationTtl,
+		},
+		otpLength: tokenConfig.OtpLength,
 	}
 }
 
+// Ping checks the connection to the server for the readiness probe
+func (r *RedisClient) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 func (r *RedisClient) SetWithExpiration(ctx context.Context, key RedisKey, value interface{}, expiration int32) (err *errors.Error) {
 	e := r.client.Set(ctx, key.String(), value, time.Duration(expiration)*time.Second).Err()
 	if e != nil {
@@ -86,8 +125,8 @@ func (r *RedisClient) GetAndResetExpiration(ctx context.Context, key RedisKey, e
 func (r *RedisClient) SetUserTokenForScope(ctx *gin.Context, userId string, scope datatypes.RedisScope, tokenType datatypes.TokenType) (token string, err *errors.Error) {
 	logger := utils.GetContextLogger(ctx)
 	logger = logger.With(zap.String("scope", string(scope)))
-	token = generateToken(tokenType)
-	err = r.SetWithExpiration(ctx, RedisKey{Key: userId, Scope: scope}, token, getRedisScopeTtl(scope))
+	token = generateToken(tokenType, r.otpLength)
+	err = r.SetWithExpiration(ctx, RedisKey{Key: userId, Scope: scope}, token, r.GetScopeTtl(scope))
 	if err != nil {
 		logger.Error("Error while setting token in redis", zap.Error(err.Error()))
 		return "", err
@@ -107,6 +146,23 @@ func (r *RedisClient) GetUserTokenForScope(ctx *gin.Context, userId string, scop
 	return
 }
 
+// deletes the key only when it holds the token, so two requests with the same token can not both use it
+var consumeTokenScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+
+// ConsumeUserTokenForScope deletes the token of the user for the scope when it matches, so an otp works once
+// a wrong token leaves the stored one in place and, like an expired one, is reported as not found
+func (r *RedisClient) ConsumeUserTokenForScope(ctx context.Context, userId string, scope datatypes.RedisScope, token string) (err *errors.Error) {
+	key := RedisKey{Key: userId, Scope: scope}
+	deleted, e := consumeTokenScript.Run(ctx, r.client, []string{key.String()}, token).Int()
+	if e != nil {
+		return errors.RedisInternalServerError(e)
+	}
+	if deleted == 0 {
+		return errors.RedisNotFoundError(redis.Nil)
+	}
+	return nil
+}
+
 // common function that generates and refreshes both the keys i.e. user key and token key in redis at the same time
 func (r *RedisClient) GetOrCreateAndSetExpiryAuthToken(ctx *gin.Context, userId string, token string) (string, string, *errors.Error) {
 	logger := utils.GetContextLogger(ctx)
@@ -134,13 +190,13 @@ func (r *RedisClient) GetOrCreateAndSetExpiryAuthToken(ctx *gin.Context, userId
 
 	pipe := r.client.Pipeline()
 	if token == "" {
-		token = generateToken(constants.TokenTypeUuid)
+		token = generateToken(constants.TokenTypeUuid, r.otpLength)
 	}
 
 	tokenRedisKey = RedisKey{Key: token, Scope: constants.RedisAuthTokenUserScope}
 	userRedisKey = RedisKey{Key: userId, Scope: constants.RedisUserAuthTokenScope}
-	pipe.Set(ctx, userRedisKey.String(), token, time.Duration(getRedisScopeTtl(constants.RedisUserAuthTokenScope))*time.Second)
-	pipe.Set(ctx, tokenRedisKey.String(), userId, time.Duration(getRedisScopeTtl(constants.RedisAuthTokenUserScope))*time.Second)
+	pipe.Set(ctx, userRedisKey.String(), token, r.scopeTtls[constants.RedisUserAuthTokenScope])
+	pipe.Set(ctx, tokenRedisKey.String(), userId, r.scopeTtls[constants.RedisAuthTokenUserScope])
 
 	_, e := pipe.Exec(ctx)
 	if e != nil {
@@ -150,6 +206,32 @@ func (r *RedisClient) GetOrCreateAndSetExpiryAuthToken(ctx *gin.Context, userId
 	return userId, token, nil
 }
 
+// RevokeUserSessions deletes the auth token of the user together with its reverse lookup, organization claim and
+// authentication,
+// and the oauth tokens issued for the user
+func (r *RedisClient) RevokeUserSessions(ctx context.Context, userId string) (err *errors.Error) {
+	if err = r.revokeUserOAuthTokens(ctx, userId); err != nil {
+		return
+	}
+	userRedisKey := RedisKey{Key: userId, Scope: constants.RedisUserAuthTokenScope}
+	val, err := r.Get(ctx, userRedisKey)
+	if err != nil {
+		if err.IsNotFound() {
+			return nil
+		}
+		return
+	}
+	token := val.(string)
+	tokenRedisKey := RedisKey{Key: token, Scope: constants.RedisAuthTokenUserScope}
+	organizationRedisKey := RedisKey{Key: token, Scope: constants.RedisAuthTokenOrganizationScope}
+	authenticationRedisKey := RedisKey{Key: token, Scope: constants.RedisAuthTokenAuthenticationScope}
+	e := r.client.Del(ctx, userRedisKey.String(), tokenRedisKey.String(), organizationRedisKey.String(), authenticationRedisKey.String()).Err()
+	if e != nil {
+		return errors.RedisInternalServerError(e)
+	}
+	return
+}
+
 func (r *RedisClient) GetOtpTtl(ctx *gin.Context, userId string) (ttl time.Duration, err *errors.Error) {
 	ttl, e := r.GetTtl(ctx, RedisKey{Key: userId, Scope: constants.RedisUserEmailVerificationScope})
 	if e != nil {
@@ -158,6 +240,75 @@ func (r *RedisClient) GetOtpTtl(ctx *gin.Context, userId string) (ttl time.Durat
 	return
 }
 
-func getRedisScopeTtl(scope datatypes.RedisScope) int32 {
-	return constants.RedisScopeTtl[scope]
+// stores the organization claim of an auth token, it lives as long as the token itself
+func (r *RedisClient) SetAuthTokenOrganization(ctx *gin.Context, token string, organizationId string) (err *errors.Error) {
+	scope := constants.RedisAuthTokenOrganizationScope
+	return r.SetWithExpiration(ctx, RedisKey{Key: token, Scope: scope}, organizationId, r.GetScopeTtl(scope))
+}
+
+func (r *RedisClient) GetAuthTokenOrganization(ctx *gin.Context, token string) (organizationId string, err *errors.Error) {
+	scope := constants.RedisAuthTokenOrganizationScope
+	val, err := r.GetAndResetExpiration(ctx, RedisKey{Key: token, Scope: scope}, r.GetScopeTtl(scope))
+	if err != nil {
+		if err.IsNotFound() {
+			return "", nil
+		}
+		return
+	}
+	organizationId = val.(string)
+	return
+}
+
+// SetAuthTokenAuthentication stores how and when the user of the auth token authenticated, on login and on
+// re-authentication
+func (r *RedisClient) SetAuthTokenAuthentication(ctx context.Context, token string, authentication datatypes.SessionAuthentication) (err *errors.Error) {
+	return r.setJson(ctx, RedisKey{Key: token, Scope: constants.RedisAuthTokenAuthenticationScope}, authentication)
+}
+
+// GetAuthTokenAuthentication returns the authentication of the auth token and keeps it alive with the token,
+// sessions from before it was tracked have an empty one
+func (r *RedisClient) GetAuthTokenAuthentication(ctx context.Context, token string) (authentication *datatypes.SessionAuthentication, err *errors.Error) {
+	scope := constants.RedisAuthTokenAuthenticationScope
+	val, err := r.GetAndResetExpiration(ctx, RedisKey{Key: token, Scope: scope}, r.GetScopeTtl(scope))
+	if err != nil {
+		if err.IsNotFound() {
+			return &datatypes.SessionAuthentication{}, nil
+		}
+		return nil, err
+	}
+	authentication = &datatypes.SessionAuthentication{}
+	if e := json.Unmarshal([]byte(val.(string)), authentication); e != nil {
+		return nil, errors.InternalServerError(e)
+	}
+	return
+}
+
+// SetSecureAccountToken creates the single use token of a "this wasn't me" link for the device a login alert is about
+func (r *RedisClient) SetSecureAccountToken(ctx context.Context, userId string, deviceId string) (token string, err *errors.Error) {
+	token = generateToken(constants.TokenTypeUuid, r.otpLength)
+	scope := constants.RedisSecureAccountTokenScope
+	err = r.SetWithExpiration(ctx, RedisKey{Key: token, Scope: scope}, userId+":"+deviceId, r.GetScopeTtl(scope))
+	if err != nil {
+		return "", err
+	}
+	return
+}
+
+// ConsumeSecureAccountToken returns the user and device of the token and deletes it, so a link works once
+func (r *RedisClient) ConsumeSecureAccountToken(ctx context.Context, token string) (userId string, deviceId string, err *errors.Error) {
+	key := RedisKey{Key: token, Scope: constants.RedisSecureAccountTokenScope}
+	val, e := r.client.GetDel(ctx, key.String()).Result()
+	if e != nil {
+		if e == redis.Nil {
+			return "", "", errors.RedisNotFoundError(e)
+		}
+		return "", "", errors.RedisInternalServerError(e)
+	}
+	userId, deviceId, _ = strings.Cut(val, ":")
+	return
+}
+
+// GetScopeTtl returns the configured lifetime of the keys of a scope in seconds
+func (r *RedisClient) GetScopeTtl(scope datatypes.RedisScope) int32 {
+	return int32(r.scopeTtls[scope].Seconds())
 }
@@ -0,0 +1,70 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+)
+
+// SetImpersonationToken stores the impersonation under a new token, it expires after the impersonation ttl.
+// The token is added to the set of the admin's tokens for RevokeImpersonatorTokens.
+func (r *RedisClient) SetImpersonationToken(ctx context.Context, impersonation datatypes.Impersonation) (token string, expiresAt time.Time, err *errors.Error) {
+	scope := constants.RedisImpersonationTokenScope
+	token = constants.ImpersonationTokenPrefix + generateToken(constants.TokenTypeUuid, r.otpLength)
+	expiresAt = time.Now().Add(r.scopeTtls[scope])
+	impersonation.ExpiresAt = expiresAt.Unix()
+	val, e := json.Marshal(impersonation)
+	if e != nil {
+		return "", time.Time{}, errors.InternalServerError(e)
+	}
+	tokenKey := RedisKey{Key: token, Scope: scope}
+	impersonatorKey := RedisKey{Key: impersonation.ImpersonatorId, Scope: constants.RedisImpersonatorTokensScope}
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, tokenKey.String(), val, r.scopeTtls[scope])
+	pipe.SAdd(ctx, impersonatorKey.String(), tokenKey.String())
+	// the set outlives every token in it, expired members are harmless
+	pipe.Expire(ctx, impersonatorKey.String(), r.scopeTtls[constants.RedisImpersonatorTokensScope])
+	if _, e = pipe.Exec(ctx); e != nil {
+		return "", time.Time{}, errors.RedisInternalServerError(e)
+	}
+	return
+}
+
+// GetImpersonationToken returns the impersonation of the token, it is not extended by use
+func (r *RedisClient) GetImpersonationToken(ctx context.Context, token string) (impersonation *datatypes.Impersonation, err *errors.Error) {
+	val, err := r.Get(ctx, RedisKey{Key: token, Scope: constants.RedisImpersonationTokenScope})
+	if err != nil {
+		return nil, err
+	}
+	impersonation = &datatypes.Impersonation{}
+	if e := json.Unmarshal([]byte(val.(string)), impersonation); e != nil {
+		return nil, errors.InternalServerError(e)
+	}
+	return
+}
+
+// DeleteImpersonationToken ends the impersonation before the token expires
+func (r *RedisClient) DeleteImpersonationToken(ctx context.Context, token string) (err *errors.Error) {
+	key := RedisKey{Key: token, Scope: constants.RedisImpersonationTokenScope}
+	if e := r.client.Del(ctx, key.String()).Err(); e != nil {
+		return errors.RedisInternalServerError(e)
+	}
+	return
+}
+
+// RevokeImpersonatorTokens deletes every impersonation token the admin got
+func (r *RedisClient) RevokeImpersonatorTokens(ctx context.Context, impersonatorId string) (err *errors.Error) {
+	impersonatorKey := RedisKey{Key: impersonatorId, Scope: constants.RedisImpersonatorTokensScope}
+	keys, e := r.client.SMembers(ctx, impersonatorKey.String()).Result()
+	if e != nil {
+		return errors.RedisInternalServerError(e)
+	}
+	if e = r.client.Del(ctx, append(keys, impersonatorKey.String())...).Err(); e != nil {
+		return errors.RedisInternalServerError(e)
+	}
+	return
+}
@@ -0,0 +1,45 @@
+package clients
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// records the latency of every command sent through the redis client
+type redisMetricsHook struct{}
+
+func (redisMetricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (redisMetricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		metrics.RedisCommandDuration.WithLabelValues(cmd.Name(), redisResult(err)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+func (redisMetricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		metrics.RedisCommandDuration.WithLabelValues("pipeline", redisResult(err)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// a missing key is an answer, not a failed call
+func redisResult(err error) string {
+	if err != nil && err != redis.Nil {
+		return metrics.ResultError
+	}
+	return metrics.ResultOk
+}
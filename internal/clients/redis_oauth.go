@@ -0,0 +1,172 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// SetOAuthCode stores the grant behind a new authorization code
+func (r *RedisClient) SetOAuthCode(ctx context.Context, grant datatypes.OAuthGrant) (code string, err *errors.Error) {
+	code = generateToken(constants.TokenTypeUuid, r.otpLength)
+	scope := constants.RedisOAuthCodeScope
+	grant.IssuedAt = time.Now().Unix()
+	grant.ExpiresAt = grant.IssuedAt + int64(r.GetScopeTtl(scope))
+	val, e := json.Marshal(grant)
+	if e != nil {
+		return "", errors.InternalServerError(e)
+	}
+	err = r.SetWithExpiration(ctx, RedisKey{Key: code, Scope: scope}, val, r.GetScopeTtl(scope))
+	if err != nil {
+		return "", err
+	}
+	return
+}
+
+// GetOAuthCode returns the grant of the code without using it up
+func (r *RedisClient) GetOAuthCode(ctx context.Context, code string) (grant *datatypes.OAuthGrant, err *errors.Error) {
+	return r.getOAuthGrant(ctx, RedisKey{Key: code, Scope: constants.RedisOAuthCodeScope})
+}
+
+// ConsumeOAuthCode returns the grant of the code and deletes it, so a code is exchanged once
+func (r *RedisClient) ConsumeOAuthCode(ctx context.Context, code string) (grant *datatypes.OAuthGrant, err *errors.Error) {
+	return r.getDelOAuthGrant(ctx, RedisKey{Key: code, Scope: constants.RedisOAuthCodeScope})
+}
+
+// IssueOAuthTokens creates an access token with accessScopes, the scopes of the grant or fewer, and, with
+// withRefreshToken, a refresh token that remembers the grant with all its scopes.
+// Both are added to the set of the user's tokens so RevokeUserSessions revokes them too
+func (r *RedisClient) IssueOAuthTokens(ctx context.Context, grant datatypes.OAuthGrant, accessScopes []string, withRefreshToken bool) (accessToken string, refreshToken string, expiresIn int32, err *errors.Error) {
+	now := time.Now().Unix()
+	grant.RedirectUri, grant.CodeChallenge, grant.Nonce = "", "", ""
+	pipe := r.client.Pipeline()
+
+	accessToken = generateToken(constants.TokenTypeUuid, r.otpLength)
+	accessKey := RedisKey{Key: accessToken, Scope: constants.RedisOAuthAccessTokenScope}
+	expiresIn = r.GetScopeTtl(constants.RedisOAuthAccessTokenScope)
+	accessGrant := grant
+	accessGrant.Scopes = accessScopes
+	accessGrant.IssuedAt, accessGrant.ExpiresAt = now, now+int64(expiresIn)
+	val, e := json.Marshal(accessGrant)
+	if e != nil {
+		return "", "", 0, errors.InternalServerError(e)
+	}
+	pipe.Set(ctx, accessKey.String(), val, r.scopeTtls[constants.RedisOAuthAccessTokenScope])
+	userKey := RedisKey{Key: grant.UserId, Scope: constants.RedisUserOAuthTokensScope}
+	pipe.SAdd(ctx, userKey.String(), accessKey.String())
+
+	if withRefreshToken {
+		refreshToken = generateToken(constants.TokenTypeUuid, r.otpLength)
+		refreshKey := RedisKey{Key: refreshToken, Scope: constants.RedisOAuthRefreshTokenScope}
+		grant.AccessToken = accessToken
+		grant.IssuedAt, grant.ExpiresAt = now, now+int64(r.GetScopeTtl(constants.RedisOAuthRefreshTokenScope))
+		val, e = json.Marshal(grant)
+		if e != nil {
+			return "", "", 0, errors.InternalServerError(e)
+		}
+		pipe.Set(ctx, refreshKey.String(), val, r.scopeTtls[constants.RedisOAuthRefreshTokenScope])
+		pipe.SAdd(ctx, userKey.String(), refreshKey.String())
+	}
+	// the set outlives every token in it, expired members are harmless
+	pipe.Expire(ctx, userKey.String(), r.scopeTtls[constants.RedisOAuthRefreshTokenScope])
+
+	if _, e = pipe.Exec(ctx); e != nil {
+		return "", "", 0, errors.RedisInternalServerError(e)
+	}
+	return
+}
+
+// GetOAuthAccessToken returns the grant of an access token
+func (r *RedisClient) GetOAuthAccessToken(ctx context.Context, token string) (grant *datatypes.OAuthGrant, err *errors.Error) {
+	return r.getOAuthGrant(ctx, RedisKey{Key: token, Scope: constants.RedisOAuthAccessTokenScope})
+}
+
+// GetOAuthRefreshToken returns the grant of a refresh token without using it up
+func (r *RedisClient) GetOAuthRefreshToken(ctx context.Context, token string) (grant *datatypes.OAuthGrant, err *errors.Error) {
+	return r.getOAuthGrant(ctx, RedisKey{Key: token, Scope: constants.RedisOAuthRefreshTokenScope})
+}
+
+// ConsumeOAuthRefreshToken returns the grant of the refresh token and revokes it together with its access token,
+// refresh tokens are rotated on every use
+func (r *RedisClient) ConsumeOAuthRefreshToken(ctx context.Context, token string) (grant *datatypes.OAuthGrant, err *errors.Error) {
+	refreshKey := RedisKey{Key: token, Scope: constants.RedisOAuthRefreshTokenScope}
+	grant, err = r.getDelOAuthGrant(ctx, refreshKey)
+	if err != nil {
+		return nil, err
+	}
+	accessKey := RedisKey{Key: grant.AccessToken, Scope: constants.RedisOAuthAccessTokenScope}
+	if err = r.revokeOAuthKeys(ctx, grant.UserId, refreshKey, accessKey); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// RevokeOAuthAccessToken deletes the access token
+func (r *RedisClient) RevokeOAuthAccessToken(ctx context.Context, token string, grant *datatypes.OAuthGrant) (err *errors.Error) {
+	return r.revokeOAuthKeys(ctx, grant.UserId, RedisKey{Key: token, Scope: constants.RedisOAuthAccessTokenScope})
+}
+
+// RevokeOAuthRefreshToken deletes the refresh token and the access token issued with it
+func (r *RedisClient) RevokeOAuthRefreshToken(ctx context.Context, token string, grant *datatypes.OAuthGrant) (err *errors.Error) {
+	refreshKey := RedisKey{Key: token, Scope: constants.RedisOAuthRefreshTokenScope}
+	accessKey := RedisKey{Key: grant.AccessToken, Scope: constants.RedisOAuthAccessTokenScope}
+	return r.revokeOAuthKeys(ctx, grant.UserId, refreshKey, accessKey)
+}
+
+// revokeUserOAuthTokens deletes every oauth token issued for the user
+func (r *RedisClient) revokeUserOAuthTokens(ctx context.Context, userId string) (err *errors.Error) {
+	userKey := RedisKey{Key: userId, Scope: constants.RedisUserOAuthTokensScope}
+	keys, e := r.client.SMembers(ctx, userKey.String()).Result()
+	if e != nil {
+		return errors.RedisInternalServerError(e)
+	}
+	if e = r.client.Del(ctx, append(keys, userKey.String())...).Err(); e != nil {
+		return errors.RedisInternalServerError(e)
+	}
+	return
+}
+
+func (r *RedisClient) revokeOAuthKeys(ctx context.Context, userId string, keys ...RedisKey) (err *errors.Error) {
+	userKey := RedisKey{Key: userId, Scope: constants.RedisUserOAuthTokensScope}
+	pipe := r.client.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key.String())
+		pipe.SRem(ctx, userKey.String(), key.String())
+	}
+	if _, e := pipe.Exec(ctx); e != nil {
+		return errors.RedisInternalServerError(e)
+	}
+	return
+}
+
+func (r *RedisClient) getOAuthGrant(ctx context.Context, key RedisKey) (grant *datatypes.OAuthGrant, err *errors.Error) {
+	val, err := r.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOAuthGrant(val.(string))
+}
+
+func (r *RedisClient) getDelOAuthGrant(ctx context.Context, key RedisKey) (grant *datatypes.OAuthGrant, err *errors.Error) {
+	val, e := r.client.GetDel(ctx, key.String()).Result()
+	if e != nil {
+		if e == redis.Nil {
+			return nil, errors.RedisNotFoundError(e)
+		}
+		return nil, errors.RedisInternalServerError(e)
+	}
+	return decodeOAuthGrant(val)
+}
+
+func decodeOAuthGrant(val string) (grant *datatypes.OAuthGrant, err *errors.Error) {
+	grant = &datatypes.OAuthGrant{}
+	if e := json.Unmarshal([]byte(val), grant); e != nil {
+		return nil, errors.InternalServerError(e)
+	}
+	return
+}
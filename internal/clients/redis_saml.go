@@ -0,0 +1,60 @@
+package clients
+
+import (
+	"context"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+)
+
+// SetSamlRequest remembers the authentication request of a SAML login under a new relay state
+func (r *RedisClient) SetSamlRequest(ctx context.Context, request datatypes.SamlRequest) (relayState string, err *errors.Error) {
+	relayState = generateToken(constants.TokenTypeUuid, r.otpLength)
+	if err = r.setJson(ctx, RedisKey{Key: relayState, Scope: constants.RedisSamlRequestScope}, request); err != nil {
+		return "", err
+	}
+	return
+}
+
+// ConsumeSamlRequest returns the authentication request of the relay state and deletes it, so a response is handled once
+func (r *RedisClient) ConsumeSamlRequest(ctx context.Context, relayState string) (request *datatypes.SamlRequest, err *errors.Error) {
+	request = &datatypes.SamlRequest{}
+	if err = r.getDelJson(ctx, RedisKey{Key: relayState, Scope: constants.RedisSamlRequestScope}, request); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// MarkSamlAssertionConsumed remembers the assertion until it expires, used is true when it was consumed before
+func (r *RedisClient) MarkSamlAssertionConsumed(ctx context.Context, organizationId string, assertionId string, expiresAt time.Time) (used bool, err *errors.Error) {
+	key := RedisKey{Key: organizationId + ":" + assertionId, Scope: constants.RedisSamlAssertionScope}
+	ttl := time.Until(expiresAt)
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	set, e := r.client.SetNX(ctx, key.String(), 1, ttl).Result()
+	if e != nil {
+		return false, errors.RedisInternalServerError(e)
+	}
+	return !set, nil
+}
+
+// SetSamlLoginCode stores the user of a finished SAML login under a new single use login code
+func (r *RedisClient) SetSamlLoginCode(ctx context.Context, login datatypes.SamlLogin) (code string, err *errors.Error) {
+	code = generateToken(constants.TokenTypeUuid, r.otpLength)
+	if err = r.setJson(ctx, RedisKey{Key: code, Scope: constants.RedisSamlLoginCodeScope}, login); err != nil {
+		return "", err
+	}
+	return
+}
+
+// ConsumeSamlLoginCode returns the login of the code and deletes it
+func (r *RedisClient) ConsumeSamlLoginCode(ctx context.Context, code string) (login *datatypes.SamlLogin, err *errors.Error) {
+	login = &datatypes.SamlLogin{}
+	if err = r.getDelJson(ctx, RedisKey{Key: code, Scope: constants.RedisSamlLoginCodeScope}, login); err != nil {
+		return nil, err
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// SetSocialLoginState remembers the verifier and nonce of a social login under a new state
+func (r *RedisClient) SetSocialLoginState(ctx context.Context, login datatypes.SocialLoginState) (state string, err *errors.Error) {
+	state = generateToken(constants.TokenTypeUuid, r.otpLength)
+	if err = r.setJson(ctx, RedisKey{Key: state, Scope: constants.RedisSocialLoginStateScope}, login); err != nil {
+		return "", err
+	}
+	return
+}
+
+// ConsumeSocialLoginState returns the social login of the state and deletes it, so a callback is handled once
+func (r *RedisClient) ConsumeSocialLoginState(ctx context.Context, state string) (login *datatypes.SocialLoginState, err *errors.Error) {
+	login = &datatypes.SocialLoginState{}
+	if err = r.getDelJson(ctx, RedisKey{Key: state, Scope: constants.RedisSocialLoginStateScope}, login); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// SetSocialLoginCode stores the identity of a finished social login behind a new single use login code
+func (r *RedisClient) SetSocialLoginCode(ctx context.Context, identity datatypes.SocialIdentity) (code string, err *errors.Error) {
+	code = generateToken(constants.TokenTypeUuid, r.otpLength)
+	if err = r.setJson(ctx, RedisKey{Key: code, Scope: constants.RedisSocialLoginCodeScope}, identity); err != nil {
+		return "", err
+	}
+	return
+}
+
+// ConsumeSocialLoginCode returns the identity of the login code and deletes it
+func (r *RedisClient) ConsumeSocialLoginCode(ctx context.Context, code string) (identity *datatypes.SocialIdentity, err *errors.Error) {
+	identity = &datatypes.SocialIdentity{}
+	if err = r.getDelJson(ctx, RedisKey{Key: code, Scope: constants.RedisSocialLoginCodeScope}, identity); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// SetSocialLinkToken creates the single use token that starts a social login linking the identity to the user
+func (r *RedisClient) SetSocialLinkToken(ctx context.Context, userId string) (token string, err *errors.Error) {
+	token = generateToken(constants.TokenTypeUuid, r.otpLength)
+	scope := constants.RedisSocialLinkTokenScope
+	if err = r.SetWithExpiration(ctx, RedisKey{Key: token, Scope: scope}, userId, r.GetScopeTtl(scope)); err != nil {
+		return "", err
+	}
+	return
+}
+
+// ConsumeSocialLinkToken returns the user of the link token and deletes it
+func (r *RedisClient) ConsumeSocialLinkToken(ctx context.Context, token string) (userId string, err *errors.Error) {
+	key := RedisKey{Key: token, Scope: constants.RedisSocialLinkTokenScope}
+	userId, e := r.client.GetDel(ctx, key.String()).Result()
+	if e != nil {
+		if e == redis.Nil {
+			return "", errors.RedisNotFoundError(e)
+		}
+		return "", errors.RedisInternalServerError(e)
+	}
+	return
+}
+
+func (r *RedisClient) setJson(ctx context.Context, key RedisKey, value interface{}) *errors.Error {
+	val, e := json.Marshal(value)
+	if e != nil {
+		return errors.InternalServerError(e)
+	}
+	return r.SetWithExpiration(ctx, key, val, r.GetScopeTtl(key.Scope))
+}
+
+func (r *RedisClient) getDelJson(ctx context.Context, key RedisKey, dest interface{}) *errors.Error {
+	val, e := r.client.GetDel(ctx, key.String()).Result()
+	if e != nil {
+		if e == redis.Nil {
+			return errors.RedisNotFoundError(e)
+		}
+		return errors.RedisInternalServerError(e)
+	}
+	if e = json.Unmarshal([]byte(val), dest); e != nil {
+		return errors.InternalServerError(e)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+)
+
+// Config holds every setting of the service. Each leaf field is read, in increasing order of precedence,
+// from the defaults below, the config file (yaml or toml, keyed by the yaml tags), the environment
+// variable named by the env tag and the command line flag named after the dotted yaml path, e.g. -redis.host.
+// Fields tagged secret are masked when the config is printed.
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Redis       RedisConfig       `yaml:"redis"`
+	Email       EmailConfig       `yaml:"email"`
+	Tenancy     TenancyConfig     `yaml:"tenancy"`
+	Signup      SignupConfig      `yaml:"signup"`
+	Tokens      TokenConfig       `yaml:"tokens"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Health      HealthConfig      `yaml:"health"`
+	Webhooks    WebhookConfig     `yaml:"webhooks"`
+	Events      EventsConfig      `yaml:"events"`
+	Audit       AuditConfig       `yaml:"audit"`
+	LoginAlerts LoginAlertsConfig `yaml:"login_alerts"`
+	Oidc        OidcConfig        `yaml:"oidc"`
+	SocialLogin SocialLoginConfig `yaml:"social_login"`
+	Saml        SamlConfig        `yaml:"saml"`
+	App         AppConfig         `yaml:"app"`
+}
+
+type ServerConfig struct {
+	ListenAddress string   `yaml:"listen_address" env:"SERVER_LISTEN_ADDRESS"`
+	GrpcAddress   string   `yaml:"grpc_address" env:"GRPC_ADDRESS"`
+	AllowOrigins  []string `yaml:"allow_origins" env:"SERVER_ALLOW_ORIGINS"`
+	// how long /readyz reports shutting_down before the servers stop, should exceed the probe period
+	ShutdownReadinessDelay time.Duration `yaml:"shutdown_readiness_delay" env:"SHUTDOWN_READINESS_DELAY"`
+	ShutdownTimeout        time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+}
+
+type DatabaseConfig struct {
+	Host string `yaml:"host" env:"DATABASE_HOST"`
+	Port int    `yaml:"port" env:"DATABASE_PORT"`
+	Name string `yaml:"name" env:"DATABASE_NAME"`
+}
+
+type RedisConfig struct {
+	Host     string `yaml:"host" env:"REDIS_HOST"`
+	Port     int    `yaml:"port" env:"REDIS_PORT"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	Db       int    `yaml:"db" env:"REDIS_DB"`
+}
+
+type EmailConfig struct {
+	SmtpHost              string `yaml:"smtp_host" env:"EMAIL_SMTP_HOST"`
+	SmtpPort              int    `yaml:"smtp_port" env:"EMAIL_SMTP_PORT"`
+	FromAddress           string `yaml:"from_address" env:"EMAIL_FROM_ADDRESS"`
+	FromPassword          string `yaml:"from_password" env:"EMAIL_FROM_PASSWORD" secret:"true"`
+	TlsInsecureSkipVerify bool   `yaml:"tls_insecure_skip_verify" env:"EMAIL_TLS_INSECURE_SKIP_VERIFY"`
+}
+
+type TenancyConfig struct {
+	// global: an email can register once, organization: once per organization
+	EmailUniqueness string `yaml:"email_uniqueness" env:"TENANT_EMAIL_UNIQUENESS"`
+}
+
+type SignupConfig struct {
+	Mode           string   `yaml:"mode" env:"SIGNUP_MODE"`
+	AllowedDomains []string `yaml:"allowed_domains" env:"SIGNUP_ALLOWED_DOMAINS"`
+	// optional file with more disposable domains to block on top of the bundled list
+	DisposableDomainsFile string `yaml:"disposable_domains_file" env:"SIGNUP_DISPOSABLE_DOMAINS_FILE"`
+}
+
+type TokenConfig struct {
+	AuthTokenTtl            time.Duration `yaml:"auth_token_ttl" env:"AUTH_TOKEN_TTL"`
+	EmailVerificationOtpTtl time.Duration `yaml:"email_verification_otp_ttl" env:"EMAIL_VERIFICATION_OTP_TTL"`
+	ChangePasswordOtpTtl    time.Duration `yaml:"change_password_otp_ttl" env:"CHANGE_PASSWORD_OTP_TTL"`
+	// a new email verification otp can be requested once the previous one is this old
+	OtpResendAfter time.Duration `yaml:"otp_resend_after" env:"OTP_RESEND_AFTER"`
+	OtpLength      int           `yaml:"otp_length" env:"OTP_LENGTH"`
+	InvitationTtl  time.Duration `yaml:"invitation_ttl" env:"INVITATION_TTL"`
+	// lifetime of the "this wasn't me" link of a login alert
+	SecureAccountLinkTtl time.Duration `yaml:"secure_account_link_ttl" env:"SECURE_ACCOUNT_LINK_TTL"`
+	// oauth authorization codes are exchanged right after the redirect, refresh tokens are rotated on every use
+	OAuthCodeTtl         time.Duration `yaml:"oauth_code_ttl" env:"OAUTH_CODE_TTL"`
+	OAuthAccessTokenTtl  time.Duration `yaml:"oauth_access_token_ttl" env:"OAUTH_ACCESS_TOKEN_TTL"`
+	OAuthRefreshTokenTtl time.Duration `yaml:"oauth_refresh_token_ttl" env:"OAUTH_REFRESH_TOKEN_TTL"`
+	// a social login has to come back from the provider within the state ttl, the login code is exchanged right after
+	SocialLoginStateTtl time.Duration `yaml:"social_login_state_ttl" env:"SOCIAL_LOGIN_STATE_TTL"`
+	SocialLoginCodeTtl  time.Duration `yaml:"social_login_code_ttl" env:"SOCIAL_LOGIN_CODE_TTL"`
+	SamlRequestTtl      time.Duration `yaml:"saml_request_ttl" env:"SAML_REQUEST_TTL"`
+	SamlLoginCodeTtl    time.Duration `yaml:"saml_login_code_ttl" env:"SAML_LOGIN_CODE_TTL"`
+	// how long the token an admin impersonates a user with lives
+	ImpersonationTtl time.Duration `yaml:"impersonation_ttl" env:"IMPERSONATION_TTL"`
+	// personal access tokens must expire within this, 0 lets users create tokens that never expire
+	PersonalAccessTokenMaxTtl time.Duration `yaml:"personal_access_token_max_ttl" env:"PERSONAL_ACCESS_TOKEN_MAX_TTL"`
+}
+
+type TracingConfig struct {
+	// none, stdout or otlp, the otlp exporter reads the standard OTEL_EXPORTER_OTLP_* variables
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT"`
+	// also dial the smtp server in /readyz
+	CheckSmtp bool `yaml:"check_smtp" env:"HEALTH_CHECK_SMTP"`
+}
+
+type WebhookConfig struct {
+	// a delivery is marked failed after this many attempts, it can still be replayed from the admin api
+	MaxAttempts    int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"WEBHOOK_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"WEBHOOK_MAX_BACKOFF"`
+	RequestTimeout time.Duration `yaml:"request_timeout" env:"WEBHOOK_REQUEST_TIMEOUT"`
+	// how often the workers look for due retries when no new event woke them up
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL"`
+	Workers      int           `yaml:"workers" env:"WEBHOOK_WORKERS"`
+}
+
+type EventsConfig struct {
+	// hand events to the asynchronous subscribers through the event_outbox collection instead of goroutines,
+	// they then survive restarts, and on a replica set they are written in the same transaction as the change
+	Outbox         bool          `yaml:"outbox" env:"EVENTS_OUTBOX"`
+	PollInterval   time.Duration `yaml:"poll_interval" env:"EVENTS_OUTBOX_POLL_INTERVAL"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"EVENTS_OUTBOX_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"EVENTS_OUTBOX_MAX_BACKOFF"`
+}
+
+type AuditConfig struct {
+	// how long audit entries are kept before Mongo expires them, 0 keeps them forever
+	Retention time.Duration `yaml:"retention" env:"AUDIT_RETENTION"`
+}
+
+type LoginAlertsConfig struct {
+	// email the user when they log in from a new device or country
+	Enabled bool `yaml:"enabled" env:"LOGIN_ALERTS_ENABLED"`
+	// optional MaxMind GeoLite2 or GeoIP2 country or city database, logins from a new country are only detected with one
+	GeoIpDatabase string `yaml:"geoip_database" env:"LOGIN_ALERTS_GEOIP_DATABASE"`
+}
+
+type OidcConfig struct {
+	// the iss of the id tokens, the public url this service is reached at, discovery is served below it
+	Issuer     string        `yaml:"issuer" env:"OIDC_ISSUER"`
+	IdTokenTtl time.Duration `yaml:"id_token_ttl" env:"OIDC_ID_TOKEN_TTL"`
+	// a new signing key is created when the newest one is this old
+	KeyRotationInterval time.Duration `yaml:"key_rotation_interval" env:"OIDC_KEY_ROTATION_INTERVAL"`
+}
+
+type SocialLoginConfig struct {
+	// optional yaml file listing the OpenID Connect providers users can log in with, see docs/social_login_providers.example.yaml
+	ProvidersFile string `yaml:"providers_file" env:"SOCIAL_LOGIN_PROVIDERS_FILE"`
+}
+
+type SamlConfig struct {
+	// pem files of the service provider certificate and its rsa key, which sign the authentication requests
+	// and decrypt encrypted assertions, without them SAML single sign-on is disabled
+	CertificateFile string `yaml:"certificate_file" env:"SAML_SP_CERTIFICATE_FILE"`
+	KeyFile         string `yaml:"key_file" env:"SAML_SP_KEY_FILE"`
+}
+
+type AppConfig struct {
+	// frontend url used to build links sent in emails
+	BaseUrl string `yaml:"base_url" env:"APP_BASE_URL"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			ListenAddress:   ":8080",
+			GrpcAddress:     ":9090",
+			ShutdownTimeout: 5 * time.Second,
+		},
+		Database: DatabaseConfig{Host: "localhost", Port: 27017},
+		Redis:    RedisConfig{Host: "localhost", Port: 6379},
+		Email:    EmailConfig{SmtpPort: 587},
+		Tenancy:  TenancyConfig{EmailUniqueness: constants.EmailUniquenessGlobal},
+		Signup:   SignupConfig{Mode: string(constants.SignupModeOpen)},
+		Tokens: TokenConfig{
+			AuthTokenTtl:            24 * time.Hour,
+			EmailVerificationOtpTtl: 15 * time.Minute,
+			ChangePasswordOtpTtl:    24 * time.Hour,
+			OtpResendAfter:          30 * time.Second,
+			OtpLength:               6,
+			InvitationTtl:           7 * 24 * time.Hour,
+			SecureAccountLinkTtl:    7 * 24 * time.Hour,
+			OAuthCodeTtl:            time.Minute,
+			OAuthAccessTokenTtl:     time.Hour,
+			OAuthRefreshTokenTtl:    30 * 24 * time.Hour,
+			SocialLoginStateTtl:     10 * time.Minute,
+			SocialLoginCodeTtl:      time.Minute,
+			SamlRequestTtl:          10 * time.Minute,
+			SamlLoginCodeTtl:        time.Minute,
+			ImpersonationTtl:        30 * time.Minute,
+		},
+		Tracing: TracingConfig{Exporter: constants.TracingExporterNone, SampleRatio: 1},
+		Health:  HealthConfig{CheckTimeout: 2 * time.Second},
+		Webhooks: WebhookConfig{
+			MaxAttempts:    8,
+			InitialBackoff: 30 * time.Second,
+			MaxBackoff:     time.Hour,
+			RequestTimeout: 10 * time.Second,
+			PollInterval:   5 * time.Second,
+			Workers:        2,
+		},
+		Events: EventsConfig{
+			PollInterval:   2 * time.Second,
+			InitialBackoff: 5 * time.Second,
+			MaxBackoff:     10 * time.Minute,
+		},
+		Audit:       AuditConfig{Retention: 90 * 24 * time.Hour},
+		LoginAlerts: LoginAlertsConfig{Enabled: true},
+		Oidc: OidcConfig{
+			Issuer:              "http://localhost:8080",
+			IdTokenTtl:          time.Hour,
+			KeyRotationInterval: 30 * 24 * time.Hour,
+		},
+		App: AppConfig{BaseUrl: "http://localhost:3000"},
+	}
+}
@@ -0,0 +1,250 @@
+package config_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+)
+
+// the settings without a default, set through the environment so every test starts from a valid config
+func requireSettings(t *testing.T) {
+	t.Setenv("DATABASE_NAME", "users")
+	t.Setenv("EMAIL_SMTP_HOST", "smtp.example.com")
+	t.Setenv("EMAIL_FROM_ADDRESS", "noreply@example.com")
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func load(args ...string) (*config.Config, error) {
+	return config.Load(flag.NewFlagSet("test", flag.ContinueOnError), args)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := "redis:\n  port: 6380\n  host: yaml.example.com\nwebhooks:\n  max_backoff: 2h\n"
+	tomlFile := "[redis]\nport = 6380\nhost = \"toml.example.com\"\n[webhooks]\nmax_backoff = \"2h\"\n"
+	tests := []struct {
+		name string
+		file string
+		body string
+		env  string
+		args []string
+		// the redis port and host picked
+		port int
+		host string
+	}{
+		{name: "default", port: 6379, host: "localhost"},
+		{name: "yaml file over default", file: "config.yaml", body: yamlFile, port: 6380, host: "yaml.example.com"},
+		{name: "toml file over default", file: "config.toml", body: tomlFile, port: 6380, host: "toml.example.com"},
+		{name: "env over yaml file", file: "config.yml", body: yamlFile, env: "6381", port: 6381, host: "yaml.example.com"},
+		{name: "env over toml file", file: "config.toml", body: tomlFile, env: "6381", port: 6381, host: "toml.example.com"},
+		{name: "flag over env and file", file: "config.yaml", body: yamlFile, env: "6381", args: []string{"-redis.port", "6382"}, port: 6382, host: "yaml.example.com"},
+		{name: "flag over default", args: []string{"-redis.host=flag.example.com"}, port: 6379, host: "flag.example.com"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requireSettings(t)
+			args := test.args
+			if test.file != "" {
+				args = append([]string{"-config", writeFile(t, test.file, test.body)}, args...)
+			}
+			if test.env != "" {
+				t.Setenv("REDIS_PORT", test.env)
+			}
+			cfg, err := load(args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Redis.Port != test.port || cfg.Redis.Host != test.host {
+				t.Errorf("redis = %s:%d, want %s:%d", cfg.Redis.Host, cfg.Redis.Port, test.host, test.port)
+			}
+			if test.file != "" && cfg.Webhooks.MaxBackoff != 2*time.Hour {
+				t.Errorf("webhooks.max_backoff = %s, want the 2h of the file", cfg.Webhooks.MaxBackoff)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileFromEnv(t *testing.T) {
+	requireSettings(t)
+	t.Setenv("CONFIG_FILE", writeFile(t, "config.yaml", "signup:\n  mode: domain_allowlist\n  allowed_domains: [example.com, example.org]\n"))
+	cfg, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cfg.Signup.AllowedDomains, ","); got != "example.com,example.org" {
+		t.Errorf("signup.allowed_domains = %s, want the list of the file", got)
+	}
+}
+
+func TestLoadRefusesBadValues(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		file string
+		body string
+		args []string
+		want string
+	}{
+		{name: "non numeric port", env: map[string]string{"EMAIL_SMTP_PORT": "abc"}, want: `env EMAIL_SMTP_PORT: "abc" is not an integer`},
+		{name: "bad duration", env: map[string]string{"AUTH_TOKEN_TTL": "1 day"}, want: `env AUTH_TOKEN_TTL: "1 day" is not a duration like 30s or 24h`},
+		{name: "bad boolean", env: map[string]string{"EVENTS_OUTBOX": "sometimes"}, want: `env EVENTS_OUTBOX: "sometimes" is not a boolean`},
+		{name: "bad number", env: map[string]string{"TRACING_SAMPLE_RATIO": "half"}, want: `env TRACING_SAMPLE_RATIO: "half" is not a number`},
+		{name: "bad flag", args: []string{"-redis.db", "first"}, want: `flag -redis.db: "first" is not an integer`},
+		{name: "bad value in the file", file: "config.yaml", body: "database:\n  port: mongo\n", want: `database.port: "mongo" is not an integer`},
+		{name: "unknown setting in the file", file: "config.toml", body: "[database]\nhostname = \"db\"\n", want: "unknown setting database.hostname"},
+		{name: "unsupported file", file: "config.json", body: "{}", want: "unsupported extension"},
+		{name: "unparsable file", file: "config.yaml", body: "redis: [", want: "parsing config file"},
+		{name: "missing file", file: "missing", want: "reading config file"},
+		{name: "invalid setting", env: map[string]string{"SIGNUP_MODE": "sometimes"}, want: `signup.mode (SIGNUP_MODE) is "sometimes", must be one of open, invite_only, domain_allowlist, closed`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requireSettings(t)
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			args := test.args
+			if test.file == "missing" {
+				args = append([]string{"-config", filepath.Join(t.TempDir(), "config.yaml")}, args...)
+			} else if test.file != "" {
+				args = append([]string{"-config", writeFile(t, test.file, test.body)}, args...)
+			}
+			_, err := load(args...)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Load = %v, want an error with %q", err, test.want)
+			}
+		})
+	}
+}
+
+func validConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Database.Name = "users"
+	cfg.Email.SmtpHost = "smtp.example.com"
+	cfg.Email.FromAddress = "noreply@example.com"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("valid config refused: %v", err)
+	}
+
+	tests := []struct {
+		change func(cfg *config.Config)
+		want   string
+	}{
+		{func(cfg *config.Config) { cfg.Server.ListenAddress = " " }, "server.listen_address (SERVER_LISTEN_ADDRESS) is required"},
+		{func(cfg *config.Config) { cfg.Server.GrpcAddress = "" }, "server.grpc_address (GRPC_ADDRESS) is required"},
+		{func(cfg *config.Config) { cfg.Server.ShutdownTimeout = 0 }, "server.shutdown_timeout (SERVER_SHUTDOWN_TIMEOUT) must be positive"},
+		{func(cfg *config.Config) { cfg.Server.ShutdownReadinessDelay = -time.Second }, "server.shutdown_readiness_delay (SHUTDOWN_READINESS_DELAY) must not be negative"},
+		{func(cfg *config.Config) { cfg.Database.Host = "" }, "database.host (DATABASE_HOST) is required"},
+		{func(cfg *config.Config) { cfg.Database.Port = 0 }, "database.port (DATABASE_PORT) must be between 1 and 65535"},
+		{func(cfg *config.Config) { cfg.Database.Name = "" }, "database.name (DATABASE_NAME) is required"},
+		{func(cfg *config.Config) { cfg.Redis.Host = "" }, "redis.host (REDIS_HOST) is required"},
+		{func(cfg *config.Config) { cfg.Redis.Port = 65536 }, "redis.port (REDIS_PORT) must be between 1 and 65535"},
+		{func(cfg *config.Config) { cfg.Redis.Db = -1 }, "redis.db (REDIS_DB) must not be negative"},
+		{func(cfg *config.Config) { cfg.Email.SmtpHost = "" }, "email.smtp_host (EMAIL_SMTP_HOST) is required"},
+		{func(cfg *config.Config) { cfg.Email.SmtpPort = -25 }, "email.smtp_port (EMAIL_SMTP_PORT) must be between 1 and 65535"},
+		{func(cfg *config.Config) { cfg.Email.FromAddress = "" }, "email.from_address (EMAIL_FROM_ADDRESS) is required"},
+		{func(cfg *config.Config) { cfg.Tenancy.EmailUniqueness = "tenant" }, `tenancy.email_uniqueness (TENANT_EMAIL_UNIQUENESS) is "tenant", must be one of global, organization`},
+		{func(cfg *config.Config) { cfg.Signup.Mode = "" }, `signup.mode (SIGNUP_MODE) is "", must be one of open, invite_only, domain_allowlist, closed`},
+		{func(cfg *config.Config) { cfg.Signup.Mode = "domain_allowlist" }, "signup.allowed_domains (SIGNUP_ALLOWED_DOMAINS) is required when signup.mode is domain_allowlist"},
+		{func(cfg *config.Config) { cfg.Tokens.AuthTokenTtl = 0 }, "tokens.auth_token_ttl (AUTH_TOKEN_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.EmailVerificationOtpTtl = 0 }, "tokens.email_verification_otp_ttl (EMAIL_VERIFICATION_OTP_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.ChangePasswordOtpTtl = 0 }, "tokens.change_password_otp_ttl (CHANGE_PASSWORD_OTP_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.InvitationTtl = 0 }, "tokens.invitation_ttl (INVITATION_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.SecureAccountLinkTtl = 0 }, "tokens.secure_account_link_ttl (SECURE_ACCOUNT_LINK_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.OAuthCodeTtl = 0 }, "tokens.oauth_code_ttl (OAUTH_CODE_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.OAuthAccessTokenTtl = 0 }, "tokens.oauth_access_token_ttl (OAUTH_ACCESS_TOKEN_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.OAuthRefreshTokenTtl = 0 }, "tokens.oauth_refresh_token_ttl (OAUTH_REFRESH_TOKEN_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.SocialLoginStateTtl = 0 }, "tokens.social_login_state_ttl (SOCIAL_LOGIN_STATE_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.SocialLoginCodeTtl = 0 }, "tokens.social_login_code_ttl (SOCIAL_LOGIN_CODE_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.SamlRequestTtl = 0 }, "tokens.saml_request_ttl (SAML_REQUEST_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.SamlLoginCodeTtl = 0 }, "tokens.saml_login_code_ttl (SAML_LOGIN_CODE_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.ImpersonationTtl = 0 }, "tokens.impersonation_ttl (IMPERSONATION_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Tokens.PersonalAccessTokenMaxTtl = -time.Hour }, "tokens.personal_access_token_max_ttl (PERSONAL_ACCESS_TOKEN_MAX_TTL) must not be negative"},
+		{func(cfg *config.Config) { cfg.Tokens.OtpResendAfter = cfg.Tokens.EmailVerificationOtpTtl }, "tokens.otp_resend_after (OTP_RESEND_AFTER) must be shorter than tokens.email_verification_otp_ttl"},
+		{func(cfg *config.Config) { cfg.Tokens.OtpLength = 3 }, "tokens.otp_length (OTP_LENGTH) must be between 4 and 10"},
+		{func(cfg *config.Config) { cfg.Tracing.Exporter = "jaeger" }, `tracing.exporter (TRACING_EXPORTER) is "jaeger", must be one of none, stdout, otlp`},
+		{func(cfg *config.Config) { cfg.Tracing.SampleRatio = 1.5 }, "tracing.sample_ratio (TRACING_SAMPLE_RATIO) must be between 0 and 1"},
+		{func(cfg *config.Config) { cfg.Health.CheckTimeout = 0 }, "health.check_timeout (HEALTH_CHECK_TIMEOUT) must be positive"},
+		{func(cfg *config.Config) { cfg.Webhooks.MaxAttempts = 0 }, "webhooks.max_attempts (WEBHOOK_MAX_ATTEMPTS) must be positive"},
+		{func(cfg *config.Config) { cfg.Webhooks.InitialBackoff = 0 }, "webhooks.initial_backoff (WEBHOOK_INITIAL_BACKOFF) must be positive"},
+		{func(cfg *config.Config) { cfg.Webhooks.MaxBackoff = time.Second }, "webhooks.max_backoff (WEBHOOK_MAX_BACKOFF) must not be shorter than webhooks.initial_backoff"},
+		{func(cfg *config.Config) { cfg.Webhooks.RequestTimeout = 0 }, "webhooks.request_timeout (WEBHOOK_REQUEST_TIMEOUT) must be positive"},
+		{func(cfg *config.Config) { cfg.Webhooks.PollInterval = 0 }, "webhooks.poll_interval (WEBHOOK_POLL_INTERVAL) must be positive"},
+		{func(cfg *config.Config) { cfg.Webhooks.Workers = 0 }, "webhooks.workers (WEBHOOK_WORKERS) must be positive"},
+		{func(cfg *config.Config) { cfg.Events.PollInterval = 0 }, "events.poll_interval (EVENTS_OUTBOX_POLL_INTERVAL) must be positive"},
+		{func(cfg *config.Config) { cfg.Events.InitialBackoff = 0 }, "events.initial_backoff (EVENTS_OUTBOX_INITIAL_BACKOFF) must be positive"},
+		{func(cfg *config.Config) { cfg.Events.MaxBackoff = time.Second }, "events.max_backoff (EVENTS_OUTBOX_MAX_BACKOFF) must not be shorter than events.initial_backoff"},
+		{func(cfg *config.Config) { cfg.Audit.Retention = 100 * 365 * 24 * time.Hour }, "audit.retention (AUDIT_RETENTION) must be between 0 and 68 years"},
+		{func(cfg *config.Config) { cfg.Oidc.Issuer = "https://auth.example.com?tenant=a" }, "oidc.issuer (OIDC_ISSUER) must be an http or https url without query or fragment"},
+		{func(cfg *config.Config) { cfg.Oidc.Issuer = "auth.example.com" }, "oidc.issuer (OIDC_ISSUER) must be an http or https url without query or fragment"},
+		{func(cfg *config.Config) { cfg.Oidc.IdTokenTtl = 0 }, "oidc.id_token_ttl (OIDC_ID_TOKEN_TTL) must be positive"},
+		{func(cfg *config.Config) { cfg.Oidc.KeyRotationInterval = 0 }, "oidc.key_rotation_interval (OIDC_KEY_ROTATION_INTERVAL) must be positive"},
+		{func(cfg *config.Config) { cfg.Saml.CertificateFile = "sp.crt" }, "saml.key_file (SAML_SP_KEY_FILE) must be set together with saml.certificate_file"},
+		{func(cfg *config.Config) { cfg.App.BaseUrl = "/app" }, "app.base_url (APP_BASE_URL) must be an absolute url"},
+	}
+	for _, test := range tests {
+		cfg := validConfig()
+		test.change(cfg)
+		err := cfg.Validate()
+		// an otp ttl of 0 also makes the resend delay too long, so only the first problem is compared
+		if err == nil || !strings.HasPrefix(err.Error()+"\n", "invalid configuration:\n  - "+test.want+"\n") {
+			t.Errorf("Validate = %v, want %q first", err, test.want)
+		}
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.Database.Name = ""
+	cfg.Redis.Port = 0
+	err := cfg.Validate()
+	want := "invalid configuration:\n  - database.name (DATABASE_NAME) is required\n  - redis.port (REDIS_PORT) must be between 1 and 65535"
+	if err == nil || err.Error() != want {
+		t.Errorf("Validate = %v, want %q", err, want)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := validConfig()
+	cfg.Redis.Password = "redis-secret"
+	cfg.Email.FromPassword = "smtp-secret"
+
+	redacted := cfg.Redacted()
+	if redacted.Redis.Password != "******" || redacted.Email.FromPassword != "******" {
+		t.Errorf("secrets not masked: redis %q, email %q", redacted.Redis.Password, redacted.Email.FromPassword)
+	}
+	if redacted.Email.FromAddress != cfg.Email.FromAddress {
+		t.Errorf("email.from_address = %q, want it unmasked", redacted.Email.FromAddress)
+	}
+	if cfg.Redis.Password != "redis-secret" || cfg.Email.FromPassword != "smtp-secret" {
+		t.Error("Redacted masked the secrets of the config itself")
+	}
+	if unset := validConfig().Redacted(); unset.Redis.Password != "" {
+		t.Errorf("unset secret printed as %q, want it empty", unset.Redis.Password)
+	}
+
+	printed := cfg.String()
+	for _, secret := range []string{"redis-secret", "smtp-secret"} {
+		if strings.Contains(printed, secret) {
+			t.Errorf("String prints the secret %s", secret)
+		}
+	}
+	if !strings.Contains(printed, "password: '******'") && !strings.Contains(printed, `password: "******"`) {
+		t.Errorf("String does not print the masked password:\n%s", printed)
+	}
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const redactedValue = "******"
+
+// a leaf setting of the config with the names it can be set by
+type field struct {
+	path   string
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+// Load builds the config from the defaults, the config file, the environment and the flags, in increasing precedence.
+// The -config flag (or CONFIG_FILE) and a flag per setting are registered on fs and parsed from args,
+// so callers can register their own flags on fs beforehand. The returned config is validated.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	// a .env file only fills the variables that are not set in the environment
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("loading .env: %w", err)
+	}
+
+	cfg := Default()
+	fields := cfg.fields()
+	configPath := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a yaml or toml config file, env CONFIG_FILE")
+	flagValues := make(map[string]*string, len(fields))
+	for _, f := range fields {
+		usage := "overrides " + f.path
+		if f.env != "" {
+			usage += ", env " + f.env
+		}
+		flagValues[f.path] = fs.String(f.path, "", usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configPath != "" {
+		if err := loadFile(*configPath, fields); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range fields {
+		if raw, ok := os.LookupEnv(f.env); ok && f.env != "" {
+			if err := f.set(raw); err != nil {
+				return nil, fmt.Errorf("env %s: %w", f.env, err)
+			}
+		}
+	}
+	var flagErr error
+	fs.Visit(func(fl *flag.Flag) {
+		for _, f := range fields {
+			if f.path == fl.Name && flagErr == nil {
+				if err := f.set(*flagValues[f.path]); err != nil {
+					flagErr = fmt.Errorf("flag -%s: %w", f.path, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func loadFile(path string, fields []field) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &values)
+	case ".toml":
+		err = toml.Unmarshal(raw, &values)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	leaves := map[string]interface{}{}
+	flatten("", values, leaves)
+	byPath := make(map[string]field, len(fields))
+	for _, f := range fields {
+		byPath[f.path] = f
+	}
+	// sorted so the first error reported is stable
+	paths := make([]string, 0, len(leaves))
+	for path := range leaves {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, key := range paths {
+		f, ok := byPath[key]
+		if !ok {
+			return fmt.Errorf("config file %s: unknown setting %s", path, key)
+		}
+		if err := f.set(fileValueString(leaves[key])); err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+func flatten(prefix string, values map[string]interface{}, leaves map[string]interface{}) {
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(path, nested, leaves)
+			continue
+		}
+		leaves[path] = value
+	}
+}
+
+// file values go through the same parsing as the environment, lists become comma separated
+func fileValueString(value interface{}) string {
+	if list, ok := value.([]interface{}); ok {
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+func (c *Config) fields() (fields []field) {
+	collectFields(reflect.ValueOf(c).Elem(), "", &fields)
+	return
+}
+
+func collectFields(v reflect.Value, prefix string, fields *[]field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		path := structField.Tag.Get("yaml")
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if structField.Type.Kind() == reflect.Struct {
+			collectFields(v.Field(i), path, fields)
+			continue
+		}
+		*fields = append(*fields, field{
+			path:   path,
+			env:    structField.Tag.Get("env"),
+			secret: structField.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+}
+
+func (f field) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if f.value.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s or 24h", raw)
+		}
+		f.value.SetInt(int64(duration))
+		return nil
+	}
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(raw)
+	case reflect.Int:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		f.value.SetInt(int64(value))
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		f.value.SetBool(value)
+	case reflect.Float64:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		f.value.SetFloat(value)
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", f.value.Type())
+	}
+	return nil
+}
+
+// Redacted returns a copy of the config with the secrets masked
+func (c Config) Redacted() Config {
+	for _, f := range c.fields() {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString(redactedValue)
+		}
+	}
+	return c
+}
+
+// String prints the config as yaml with the secrets masked, so it is safe to log
+func (c Config) String() string {
+	redacted := c.Redacted()
+	out, err := yaml.Marshal(&redacted)
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+)
+
+// Validate reports every invalid setting at once so a deployment can be fixed in one go
+func (c *Config) Validate() error {
+	v := validator{envs: map[string]string{}}
+	for _, f := range c.fields() {
+		v.envs[f.path] = f.env
+	}
+
+	v.required("server.listen_address", c.Server.ListenAddress)
+	v.required("server.grpc_address", c.Server.GrpcAddress)
+	v.check("server.shutdown_timeout", c.Server.ShutdownTimeout > 0, "must be positive")
+	v.check("server.shutdown_readiness_delay", c.Server.ShutdownReadinessDelay >= 0, "must not be negative")
+
+	v.required("database.host", c.Database.Host)
+	v.port("database.port", c.Database.Port)
+	v.required("database.name", c.Database.Name)
+
+	v.required("redis.host", c.Redis.Host)
+	v.port("redis.port", c.Redis.Port)
+	v.check("redis.db", c.Redis.Db >= 0, "must not be negative")
+
+	v.required("email.smtp_host", c.Email.SmtpHost)
+	v.port("email.smtp_port", c.Email.SmtpPort)
+	v.required("email.from_address", c.Email.FromAddress)
+
+	v.oneOf("tenancy.email_uniqueness", c.Tenancy.EmailUniqueness, constants.EmailUniquenessGlobal, constants.EmailUniquenessOrganization)
+
+	v.oneOf("signup.mode", c.Signup.Mode, string(constants.SignupModeOpen), string(constants.SignupModeInviteOnly), string(constants.SignupModeDomainAllowlist), string(constants.SignupModeClosed))
+	if c.Signup.Mode == string(constants.SignupModeDomainAllowlist) {
+		v.check("signup.allowed_domains", len(c.Signup.AllowedDomains) > 0, "is required when signup.mode is "+c.Signup.Mode)
+	}
+
+	v.check("tokens.auth_token_ttl", c.Tokens.AuthTokenTtl > 0, "must be positive")
+	v.check("tokens.email_verification_otp_ttl", c.Tokens.EmailVerificationOtpTtl > 0, "must be positive")
+	v.check("tokens.change_password_otp_ttl", c.Tokens.ChangePasswordOtpTtl > 0, "must be positive")
+	v.check("tokens.invitation_ttl", c.Tokens.InvitationTtl > 0, "must be positive")
+	v.check("tokens.secure_account_link_ttl", c.Tokens.SecureAccountLinkTtl > 0, "must be positive")
+	v.check("tokens.oauth_code_ttl", c.Tokens.OAuthCodeTtl > 0, "must be positive")
+	v.check("tokens.oauth_access_token_ttl", c.Tokens.OAuthAccessTokenTtl > 0, "must be positive")
+	v.check("tokens.oauth_refresh_token_ttl", c.Tokens.OAuthRefreshTokenTtl > 0, "must be positive")
+	v.check("tokens.social_login_state_ttl", c.Tokens.SocialLoginStateTtl > 0, "must be positive")
+	v.check("tokens.social_login_code_ttl", c.Tokens.SocialLoginCodeTtl > 0, "must be positive")
+	v.check("tokens.saml_request_ttl", c.Tokens.SamlRequestTtl > 0, "must be positive")
+	v.check("tokens.saml_login_code_ttl", c.Tokens.SamlLoginCodeTtl > 0, "must be positive")
+	v.check("tokens.impersonation_ttl", c.Tokens.ImpersonationTtl > 0, "must be positive")
+	v.check("tokens.personal_access_token_max_ttl", c.Tokens.PersonalAccessTokenMaxTtl >= 0, "must not be negative")
+	v.check("tokens.otp_resend_after", c.Tokens.OtpResendAfter >= 0 && c.Tokens.OtpResendAfter < c.Tokens.EmailVerificationOtpTtl, "must be shorter than tokens.email_verification_otp_ttl")
+	v.check("tokens.otp_length", c.Tokens.OtpLength >= 4 && c.Tokens.OtpLength <= 10, "must be between 4 and 10")
+
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, constants.TracingExporterNone, constants.TracingExporterStdout, constants.TracingExporterOtlp)
+	v.check("tracing.sample_ratio", c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "must be between 0 and 1")
+
+	v.check("health.check_timeout", c.Health.CheckTimeout > 0, "must be positive")
+
+	v.check("webhooks.max_attempts", c.Webhooks.MaxAttempts > 0, "must be positive")
+	v.check("webhooks.initial_backoff", c.Webhooks.InitialBackoff > 0, "must be positive")
+	v.check("webhooks.max_backoff", c.Webhooks.MaxBackoff >= c.Webhooks.InitialBackoff, "must not be shorter than webhooks.initial_backoff")
+	v.check("webhooks.request_timeout", c.Webhooks.RequestTimeout > 0, "must be positive")
+	v.check("webhooks.poll_interval", c.Webhooks.PollInterval > 0, "must be positive")
+	v.check("webhooks.workers", c.Webhooks.Workers > 0, "must be positive")
+
+	v.check("events.poll_interval", c.Events.PollInterval > 0, "must be positive")
+	v.check("events.initial_backoff", c.Events.InitialBackoff > 0, "must be positive")
+	v.check("events.max_backoff", c.Events.MaxBackoff >= c.Events.InitialBackoff, "must not be shorter than events.initial_backoff")
+
+	// the ttl index takes the expiry as 32 bit seconds
+	v.check("audit.retention", c.Audit.Retention >= 0 && c.Audit.Retention.Seconds() <= math.MaxInt32, "must be between 0 and 68 years")
+
+	issuer, err := url.Parse(c.Oidc.Issuer)
+	v.check("oidc.issuer", err == nil && (issuer.Scheme == "https" || issuer.Scheme == "http") && issuer.Host != "" && issuer.RawQuery == "" && issuer.Fragment == "", "must be an http or https url without query or fragment")
+	v.check("oidc.id_token_ttl", c.Oidc.IdTokenTtl > 0, "must be positive")
+	v.check("oidc.key_rotation_interval", c.Oidc.KeyRotationInterval > 0, "must be positive")
+
+	v.check("saml.key_file", (c.Saml.CertificateFile == "") == (c.Saml.KeyFile == ""), "must be set together with saml.certificate_file")
+
+	baseUrl, err := url.Parse(c.App.BaseUrl)
+	v.check("app.base_url", err == nil && baseUrl.Scheme != "" && baseUrl.Host != "", "must be an absolute url")
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(v.problems, "\n  - "))
+}
+
+type validator struct {
+	envs     map[string]string
+	problems []string
+}
+
+func (v *validator) check(path string, ok bool, problem string) {
+	if ok {
+		return
+	}
+	name := path
+	if env := v.envs[path]; env != "" {
+		name = fmt.Sprintf("%s (%s)", path, env)
+	}
+	v.problems = append(v.problems, name+" "+problem)
+}
+
+func (v *validator) required(path string, value string) {
+	v.check(path, strings.TrimSpace(value) != "", "is required")
+}
+
+func (v *validator) port(path string, port int) {
+	v.check(path, port > 0 && port <= 65535, "must be between 1 and 65535")
+}
+
+func (v *validator) oneOf(path string, value string, allowed ...string) {
+	for _, option := range allowed {
+		if value == option {
+			return
+		}
+	}
+	v.check(path, false, fmt.Sprintf("is %q, must be one of %s", value, strings.Join(allowed, ", ")))
+}
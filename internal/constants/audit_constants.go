@@ -0,0 +1,17 @@
+package constants
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// who performed an audited action
+const (
+	AuditActorUser      = "user"
+	AuditActorCli       = "cli"
+	AuditActorAnonymous = "anonymous"
+	// an identity provider provisioning with the SCIM token of an organization
+	AuditActorScim = "scim"
+	// the background jobs of the service, e.g. lifting expired suspensions
+	AuditActorSystem = "system"
+)
@@ -0,0 +1,49 @@
+package constants
+
+// names of the domain events published on the event bus, the user lifecycle ones double as webhook event types
+const (
+	EventUserSignedUp         = "user.signed_up"
+	EventSignupFailed         = "user.signup_failed"
+	EventUserLoggedIn         = "user.logged_in"
+	EventUserReauthenticated  = "user.reauthenticated"
+	EventLoginFailed          = "user.login_failed"
+	EventUserEmailVerified    = "user.email_verified"
+	EventUserPasswordChanged  = "user.password_changed"
+	EventUserDeleted          = "user.deleted"
+	EventUserStatusChanged    = "user.status_changed"
+	EventUserRoleChanged      = "user.role_changed"
+	EventUserSuspended        = "user.suspended"
+	EventUserReinstated       = "user.reinstated"
+	EventUserSessionsRevoked  = "user.sessions_revoked"
+	EventUserAccountSecured   = "user.account_secured"
+	EventUserDeviceForgotten  = "user.device_forgotten"
+	EventUserIdentityLinked   = "user.identity_linked"
+	EventUserIdentityUnlinked = "user.identity_unlinked"
+	EventUserProfileUpdated   = "user.profile_updated"
+	EventUserTokenCreated     = "user.token_created"
+	EventUserTokenDeleted     = "user.token_deleted"
+	EventImpersonationStarted = "user.impersonation_started"
+	EventImpersonationEnded   = "user.impersonation_ended"
+	EventImpersonatedRequest  = "user.impersonated_request"
+	EventOtpSent              = "otp.sent"
+	EventOtpVerified          = "otp.verified"
+	EventOtpVerifyFailed      = "otp.verification_failed"
+
+	EventWebhookEndpointCreated    = "webhook.endpoint_created"
+	EventWebhookEndpointDeleted    = "webhook.endpoint_deleted"
+	EventWebhookDeliveriesReplayed = "webhook.deliveries_replayed"
+
+	EventOAuthClientCreated  = "oauth.client_created"
+	EventOAuthClientDeleted  = "oauth.client_deleted"
+	EventOAuthConsentGranted = "oauth.consent_granted"
+
+	EventSamlConnectionSaved   = "saml.connection_saved"
+	EventSamlConnectionDeleted = "saml.connection_deleted"
+
+	EventInvitationCodeCreated = "signup.invitation_code_created"
+
+	EventScimTokenCreated = "scim.token_created"
+	EventScimTokenDeleted = "scim.token_deleted"
+	EventScimGroupSaved   = "scim.group_saved"
+	EventScimGroupDeleted = "scim.group_deleted"
+)
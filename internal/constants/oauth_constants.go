@@ -0,0 +1,47 @@
+package constants
+
+// scopes clients can request, openid is needed for an id token and offline_access for a refresh token
+const (
+	OAuthScopeOpenId        = "openid"
+	OAuthScopeProfile       = "profile"
+	OAuthScopeEmail         = "email"
+	OAuthScopeOfflineAccess = "offline_access"
+)
+
+// descriptions of the scopes shown on the consent screen
+var OAuthScopeDescriptions = map[string]string{
+	OAuthScopeOpenId:        "Sign you in with your account",
+	OAuthScopeProfile:       "Read your name",
+	OAuthScopeEmail:         "Read your email address",
+	OAuthScopeOfflineAccess: "Keep access when you are not using the app",
+	// the api scopes apps may be granted, managing the account and platform administration stay with the user
+	ScopeProfileRead:       "Read your account, its devices, linked identities and activity",
+	ScopeProfileWrite:      "Verify your email",
+	ScopeOrganizationRead:  "Read your organizations and their members",
+	ScopeOrganizationWrite: "Manage your organizations, their members, invitations and single sign-on",
+}
+
+const (
+	OAuthResponseTypeCode           = "code"
+	OAuthGrantTypeAuthorizationCode = "authorization_code"
+	OAuthGrantTypeRefreshToken      = "refresh_token"
+	OAuthCodeChallengeMethodS256    = "S256"
+	OAuthTokenTypeBearer            = "Bearer"
+	OAuthTokenTypeHintAccessToken   = "access_token"
+	OAuthTokenTypeHintRefreshToken  = "refresh_token"
+)
+
+// error codes of the token, introspection and revocation endpoints, see RFC 6749 section 5.2
+const (
+	OAuthErrorInvalidRequest       = "invalid_request"
+	OAuthErrorInvalidClient        = "invalid_client"
+	OAuthErrorInvalidGrant         = "invalid_grant"
+	OAuthErrorUnauthorizedClient   = "unauthorized_client"
+	OAuthErrorUnsupportedGrantType = "unsupported_grant_type"
+	OAuthErrorInvalidScope         = "invalid_scope"
+	OAuthErrorAccessDenied         = "access_denied"
+	OAuthErrorServerError          = "server_error"
+	// bearer token errors of the userinfo endpoint, see RFC 6750 section 3.1
+	OAuthErrorInvalidToken      = "invalid_token"
+	OAuthErrorInsufficientScope = "insufficient_scope"
+)
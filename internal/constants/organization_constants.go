@@ -0,0 +1,9 @@
+package constants
+
+const (
+	EmailUniquenessGlobal       = "global"
+	EmailUniquenessOrganization = "organization"
+
+	// header used by clients to pick the organization a request acts on
+	OrganizationHeader = "X-Organization-Id"
+)
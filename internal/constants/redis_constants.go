@@ -3,21 +3,43 @@ package constants
 import "github.com/MitP1997/golang-user-management/internal/datatypes"
 
 const (
-	RedisUserAuthTokenScope         datatypes.RedisScope = "user_auth_token"
-	RedisAuthTokenUserScope         datatypes.RedisScope = "auth_token_user"
-	RedisUserEmailVerificationScope datatypes.RedisScope = "user_email_verification"
-	RedisUserChangePasswordScope    datatypes.RedisScope = "user_change_password"
+	RedisUserAuthTokenScope           datatypes.RedisScope = "user_auth_token"
+	RedisAuthTokenUserScope           datatypes.RedisScope = "auth_token_user"
+	RedisUserEmailVerificationScope   datatypes.RedisScope = "user_email_verification"
+	RedisUserChangePasswordScope      datatypes.RedisScope = "user_change_password"
+	RedisAuthTokenOrganizationScope   datatypes.RedisScope = "auth_token_organization"
+	RedisSecureAccountTokenScope      datatypes.RedisScope = "secure_account_token"
+	RedisAuthTokenAuthenticationScope datatypes.RedisScope = "auth_token_authentication"
+)
+
+// the impersonation tokens of admins, keyed by the token, and the set of an admin's tokens to revoke them
+const (
+	RedisImpersonationTokenScope datatypes.RedisScope = "impersonation_token"
+	RedisImpersonatorTokensScope datatypes.RedisScope = "impersonator_tokens"
+)
+
+// oauth authorization codes and tokens, keyed by the code or token, and the set of a user's tokens to revoke them
+const (
+	RedisOAuthCodeScope         datatypes.RedisScope = "oauth_code"
+	RedisOAuthAccessTokenScope  datatypes.RedisScope = "oauth_access_token"
+	RedisOAuthRefreshTokenScope datatypes.RedisScope = "oauth_refresh_token"
+	RedisUserOAuthTokensScope   datatypes.RedisScope = "user_oauth_tokens"
+)
 
-	// allowing resend email verification otp after 5 mins
-	RedisResendEmailAllowedAfter = 30
+// social logins between the redirect to the provider and the callback, keyed by the state,
+// the provider identities of finished ones until the frontend exchanges the login code
+// and the users that confirmed their password to link an identity, keyed by the link token
+const (
+	RedisSocialLoginStateScope datatypes.RedisScope = "social_login_state"
+	RedisSocialLoginCodeScope  datatypes.RedisScope = "social_login_code"
+	RedisSocialLinkTokenScope  datatypes.RedisScope = "social_link_token"
 )
 
-var RedisScopeTtl = map[datatypes.RedisScope]int32{
-	// ttl of 1 day
-	// TODO: Tweak the below params or may be fetch fro config/env
-	RedisUserAuthTokenScope:      86400,
-	RedisAuthTokenUserScope:      86400,
-	RedisUserChangePasswordScope: 86400,
-	// ttl of 15 mins
-	RedisUserEmailVerificationScope: 900,
-}
+// SAML logins between the authentication request and the assertion, keyed by the relay state,
+// the ids of consumed assertions until they expire so they cannot be replayed,
+// and the users of finished logins until the frontend exchanges the login code
+const (
+	RedisSamlRequestScope   datatypes.RedisScope = "saml_request"
+	RedisSamlAssertionScope datatypes.RedisScope = "saml_assertion"
+	RedisSamlLoginCodeScope datatypes.RedisScope = "saml_login_code"
+)
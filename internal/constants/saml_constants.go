@@ -0,0 +1,11 @@
+package constants
+
+const (
+	// the service provider endpoints of an organization live below this path followed by the organization id
+	SamlPathPrefix = "/api/v1/saml/"
+	// the frontend page the assertion consumer service redirects to with the login code or the error code
+	SamlLoginResultPath = "/saml-login/complete"
+	SamlRequestCookie   = "saml_request"
+	// the provider of POST /user/me/identities that links the identity provider of the selected organization
+	SamlLinkProvider = "saml"
+)
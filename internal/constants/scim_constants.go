@@ -0,0 +1,31 @@
+package constants
+
+// schema urns of the resources and messages, see RFC 7643 and RFC 7644
+const (
+	ScimSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	ScimSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	ScimSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	ScimSchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ScimSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ScimSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+const (
+	// the SCIM endpoints live below this path, the location of a resource is this path followed by its type and id
+	ScimPathPrefix  = "/api/v1/scim/v2"
+	ScimContentType = "application/scim+json"
+	// prefix of the tokens identity providers provision with, it tells them apart from auth tokens in logs and leaks
+	ScimTokenPrefix = "scim_"
+
+	ScimDefaultCount = 100
+	ScimMaxCount     = 200
+)
+
+// scimType of the error responses, see RFC 7644 section 3.12
+const (
+	ScimTypeInvalidFilter = "invalidFilter"
+	ScimTypeInvalidValue  = "invalidValue"
+	ScimTypeInvalidPath   = "invalidPath"
+	ScimTypeInvalidSyntax = "invalidSyntax"
+	ScimTypeUniqueness    = "uniqueness"
+)
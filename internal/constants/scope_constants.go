@@ -0,0 +1,25 @@
+package constants
+
+// scopes of the tokens, each one grants a part of what the user can do and routes declare the one they need
+// login sessions have all of them, personal access tokens and oauth access tokens the ones they were created with
+const (
+	ScopeProfileRead       = "profile:read"
+	ScopeProfileWrite      = "profile:write"
+	ScopeAccountWrite      = "account:write"
+	ScopeOrganizationRead  = "org:read"
+	ScopeOrganizationWrite = "org:write"
+	ScopeAdmin             = "admin"
+)
+
+// an admin impersonating a user sees what the user sees but can not act for them
+var ImpersonationScopes = []string{ScopeProfileRead, ScopeOrganizationRead}
+
+// descriptions of the scopes shown when creating a token
+var ScopeDescriptions = map[string]string{
+	ScopeProfileRead:       "Read the account, its devices, linked identities and activity",
+	ScopeProfileWrite:      "Verify the email and change the profile",
+	ScopeAccountWrite:      "Manage devices, linked identities and tokens, approve oauth apps and delete the account",
+	ScopeOrganizationRead:  "Read the organizations and their members",
+	ScopeOrganizationWrite: "Manage the organizations, members, invitations and single sign-on",
+	ScopeAdmin:             "Platform administration, only for platform admins",
+}
@@ -0,0 +1,14 @@
+package constants
+
+import "github.com/MitP1997/golang-user-management/internal/datatypes"
+
+const (
+	// anyone can sign up
+	SignupModeOpen datatypes.SignupMode = "open"
+	// signup needs a valid invitation code or an organization invitation
+	SignupModeInviteOnly datatypes.SignupMode = "invite_only"
+	// only emails from SIGNUP_ALLOWED_DOMAINS can sign up
+	SignupModeDomainAllowlist datatypes.SignupMode = "domain_allowlist"
+	// nobody can sign up
+	SignupModeClosed datatypes.SignupMode = "closed"
+)
@@ -0,0 +1,9 @@
+package constants
+
+const (
+	// the redirect uri to register at the social login providers is the oidc issuer followed by this path
+	SocialLoginCallbackPath = "/api/v1/social/callback"
+	// the frontend page the callback redirects to with the login code or the error code
+	SocialLoginResultPath  = "/social-login/complete"
+	SocialLoginStateCookie = "social_login_state"
+)
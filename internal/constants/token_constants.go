@@ -4,5 +4,19 @@ import "github.com/MitP1997/golang-user-management/internal/datatypes"
 
 const (
 	TokenTypeUuid datatypes.TokenType = iota
-	TokenType6DigitOtp
+	TokenTypeOtp
+)
+
+// prefixes of the personal access tokens and the impersonation tokens, they tell them apart from the auth tokens
+// of a login
+const (
+	PersonalAccessTokenPrefix = "pat_"
+	ImpersonationTokenPrefix  = "imp_"
+)
+
+// how the user of a session authenticated, kept with the auth token for step-up re-authentication
+const (
+	AuthMethodPassword = "password"
+	AuthMethodSocial   = "social"
+	AuthMethodSaml     = "saml"
 )
@@ -0,0 +1,8 @@
+package constants
+
+// exporters of the tracing spans
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOtlp   = "otlp"
+)
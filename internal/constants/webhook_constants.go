@@ -0,0 +1,17 @@
+package constants
+
+// the events that can be sent to webhook endpoints
+var WebhookEventTypes = []string{
+	EventUserSignedUp,
+	EventUserEmailVerified,
+	EventUserPasswordChanged,
+	EventUserDeleted,
+}
+
+// headers of a webhook delivery, the signature is sha256=<hex hmac of "<timestamp>.<body>"> keyed with the endpoint secret
+const (
+	WebhookIdHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
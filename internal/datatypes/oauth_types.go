@@ -0,0 +1,17 @@
+package datatypes
+
+// OAuthGrant is what an authorization code, access token or refresh token stands for, stored as json in redis
+type OAuthGrant struct {
+	UserId   string   `json:"user_id"`
+	ClientId string   `json:"client_id"`
+	Scopes   []string `json:"scopes"`
+	// only set on authorization codes, the token request must repeat the redirect uri and prove the challenge
+	RedirectUri   string `json:"redirect_uri,omitempty"`
+	CodeChallenge string `json:"code_challenge,omitempty"`
+	Nonce         string `json:"nonce,omitempty"`
+	// only set on refresh tokens, the access token issued with it is revoked together with it
+	AccessToken string `json:"access_token,omitempty"`
+	// unix seconds
+	IssuedAt  int64 `json:"issued_at"`
+	ExpiresAt int64 `json:"expires_at"`
+}
@@ -0,0 +1,29 @@
+package datatypes
+
+import "time"
+
+// SamlRequest is the authentication request a SAML login waits on, stored as json in redis under the relay state
+type SamlRequest struct {
+	OrganizationId string `json:"organization_id"`
+	RequestId      string `json:"request_id"`
+	// set when the login links the name id to this user instead of logging in
+	LinkUserId string `json:"link_user_id,omitempty"`
+}
+
+// SamlIdentity is the user the identity provider of an organization vouched for in a verified assertion
+type SamlIdentity struct {
+	// the name id, stable for the user at the identity provider
+	Subject    string
+	Email      string
+	GivenName  string
+	FamilyName string
+	// the id of the assertion and when it stops being valid, consumed assertions are remembered until then
+	AssertionId string
+	ExpiresAt   time.Time
+}
+
+// SamlLogin is the user a finished SAML login logs in as, stored as json in redis under the login code
+type SamlLogin struct {
+	UserId         string `json:"user_id"`
+	OrganizationId string `json:"organization_id"`
+}
@@ -0,0 +1,3 @@
+package datatypes
+
+type SignupMode string
@@ -0,0 +1,21 @@
+package datatypes
+
+// SocialLoginState is what the callback of a social login needs from its start, stored as json in redis under the state
+type SocialLoginState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+	// set when a logged in user links the identity instead of logging in with it
+	LinkUserId string `json:"link_user_id,omitempty"`
+}
+
+// SocialIdentity is the user a social login provider vouched for, stored as json in redis under the login code
+type SocialIdentity struct {
+	Provider string `json:"provider"`
+	// the sub claim, stable for the user at the provider
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+}
@@ -1,3 +1,22 @@
 package datatypes
 
 type TokenType uint8
+
+// SessionAuthentication is how and when the user of an auth token last proved who they are, stored as json in redis
+// under the token
+type SessionAuthentication struct {
+	Method string `json:"method"`
+	// unix seconds
+	AuthTime int64 `json:"auth_time"`
+	// set by SAML logins, the identity provider of an organization only vouches for the user within it
+	OrganizationId string `json:"organization_id,omitempty"`
+}
+
+// Impersonation is what an impersonation token stands for, stored as json in redis under the token
+type Impersonation struct {
+	UserId         string `json:"user_id"`
+	ImpersonatorId string `json:"impersonator_id"`
+	Reason         string `json:"reason"`
+	// unix seconds
+	ExpiresAt int64 `json:"expires_at"`
+}
@@ -0,0 +1,86 @@
+// Package devices recognizes the devices users log in from, for the new device and new country login alerts.
+package devices
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/mssola/useragent"
+	"github.com/oschwald/geoip2-golang"
+)
+
+var enabled bool
+var geoIpReader *geoip2.Reader
+
+// Identity describes the device of a login
+type Identity struct {
+	// stable while the browser, the os and the network stay the same, versions are left out so updates keep it
+	Fingerprint string
+	Browser     string
+	Os          string
+	IpPrefix    string
+	Country     string
+}
+
+// InitLoginAlerts opens the GeoIP database, when one is configured
+func InitLoginAlerts(loginAlertsConfig config.LoginAlertsConfig) (err error) {
+	enabled = loginAlertsConfig.Enabled
+	if !enabled || loginAlertsConfig.GeoIpDatabase == "" {
+		return nil
+	}
+	geoIpReader, err = geoip2.Open(loginAlertsConfig.GeoIpDatabase)
+	return
+}
+
+func Enabled() bool {
+	return enabled
+}
+
+func Close() error {
+	if geoIpReader == nil {
+		return nil
+	}
+	return geoIpReader.Close()
+}
+
+// Identify describes the device from the user agent and the ip of a login
+func Identify(userAgent string, ip string) Identity {
+	ua := useragent.New(userAgent)
+	browser, _ := ua.Browser()
+	identity := Identity{
+		Browser:  browser,
+		Os:       ua.OSInfo().Name,
+		IpPrefix: ipPrefix(ip),
+		Country:  country(ip),
+	}
+	sum := sha256.Sum256([]byte(identity.Browser + "|" + identity.Os + "|" + identity.IpPrefix))
+	identity.Fingerprint = hex.EncodeToString(sum[:16])
+	return identity
+}
+
+// the /24 of an ipv4 address and the /48 of an ipv6 one, the address itself changes too often to identify a device
+func ipPrefix(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if ipv4 := parsed.To4(); ipv4 != nil {
+		return (&net.IPNet{IP: ipv4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
+
+// the iso code of the country of the ip, empty without a GeoIP database or for private addresses
+func country(ip string) string {
+	parsed := net.ParseIP(ip)
+	if geoIpReader == nil || parsed == nil {
+		return ""
+	}
+	record, err := geoIpReader.Country(parsed)
+	if err != nil {
+		return ""
+	}
+	return record.Country.IsoCode
+}
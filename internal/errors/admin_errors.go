@@ -0,0 +1,15 @@
+package errors
+
+import "fmt"
+
+var (
+	AdminRequiredError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeAdminRequired, DisplayString: "Only platform admins can do this"}
+	}
+	WebhookInvalidUrlError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeWebhookInvalidUrl, DisplayString: "Webhook url must be an absolute http or https url", Details: []FieldError{{Field: "url", Issue: "url"}}}
+	}
+	WebhookUnknownEventTypeError = func(eventType string) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeWebhookUnknownEventType, DisplayString: fmt.Sprintf("Unknown webhook event type %s", eventType), Details: []FieldError{{Field: "event_types", Issue: "oneof"}}}
+	}
+)
@@ -0,0 +1,39 @@
+package errors
+
+import "fmt"
+
+var (
+	MissingAuthorizationError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeAuthMissingToken, DisplayString: "No Authorization headers provided"}
+	}
+	InvalidAuthTokenError = func(e error) *Error {
+		return &Error{Type: typeForbidden, Code: CodeAuthInvalidToken, Err: e, DisplayString: "Please login!"}
+	}
+	InvalidCredentialsError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeAuthInvalidCredentials, DisplayString: "User login password verification failed"}
+	}
+	OtpResendTooSoonError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeAuthOtpResendTooSoon, DisplayString: "Resend email otp not allowed"}
+	}
+	PasswordResetRequiredError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeAuthPasswordResetRequired, DisplayString: "The account was secured, reset the password with the emailed otp at /user/reset-password"}
+	}
+	InvalidSecureAccountLinkError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeAuthInvalidSecureLink, DisplayString: "The link is unknown, used or expired"}
+	}
+	TokenExpiredError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeAuthTokenExpired, DisplayString: "The personal access token expired, create a new one"}
+	}
+	InsufficientScopeError = func(scope string) *Error {
+		return &Error{Type: typeForbidden, Code: CodeAuthInsufficientScope, DisplayString: fmt.Sprintf("The token needs the %s scope", scope)}
+	}
+	RecentAuthenticationRequiredError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeAuthRecentAuthRequired, DisplayString: "Confirm your identity again to continue"}
+	}
+	SessionRequiredError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeAuthSessionRequired, DisplayString: "Log in to do this, tokens can not"}
+	}
+	ReauthenticationRequiredError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeAuthReauthRequired, DisplayString: "Confirm your identity with your password first"}
+	}
+)
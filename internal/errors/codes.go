@@ -0,0 +1,99 @@
+package errors
+
+// Stable machine readable error codes sent in the error envelope.
+// Clients switch on these, so a code must never be renamed or reused for a different meaning.
+// Every code is documented in docs/errors.md.
+const (
+	CodeInternal         = "INTERNAL_ERROR"
+	CodeInternalDatabase = "INTERNAL_DATABASE_ERROR"
+	CodeInternalCache    = "INTERNAL_CACHE_ERROR"
+	CodeInternalMail     = "INTERNAL_MAIL_ERROR"
+
+	CodeRequestInvalid          = "REQUEST_INVALID"
+	CodeRequestMalformed        = "REQUEST_MALFORMED"
+	CodeRequestValidationFailed = "REQUEST_VALIDATION_FAILED"
+	CodeRequestMissingField     = "REQUEST_MISSING_FIELD"
+	CodeRequestInvalidId        = "REQUEST_INVALID_ID"
+	CodeRouteNotFound           = "ROUTE_NOT_FOUND"
+	CodeResourceNotFound        = "RESOURCE_NOT_FOUND"
+	CodeForbidden               = "FORBIDDEN"
+
+	CodeAuthMissingToken          = "AUTH_MISSING_TOKEN"
+	CodeAuthInvalidToken          = "AUTH_INVALID_TOKEN"
+	CodeAuthUnauthenticated       = "AUTH_UNAUTHENTICATED"
+	CodeAuthInvalidCredentials    = "AUTH_INVALID_CREDENTIALS"
+	CodeAuthInvalidOtp            = "AUTH_INVALID_OTP"
+	CodeAuthOtpResendTooSoon      = "AUTH_OTP_RESEND_TOO_SOON"
+	CodeAuthPasswordResetRequired = "AUTH_PASSWORD_RESET_REQUIRED"
+	CodeAuthInvalidSecureLink     = "AUTH_INVALID_SECURE_ACCOUNT_LINK"
+	CodeAuthReauthRequired        = "AUTH_REAUTHENTICATION_REQUIRED"
+	CodeAuthTokenExpired          = "AUTH_TOKEN_EXPIRED"
+	CodeAuthInsufficientScope     = "AUTH_INSUFFICIENT_SCOPE"
+	CodeAuthRecentAuthRequired    = "AUTH_RECENT_AUTHENTICATION_REQUIRED"
+	CodeAuthSessionRequired       = "AUTH_SESSION_REQUIRED"
+
+	CodeUserPasswordTooShort       = "USER_PASSWORD_TOO_SHORT"
+	CodeUserEmailAlreadyVerified   = "USER_EMAIL_ALREADY_VERIFIED"
+	CodeUserSuspended              = "USER_SUSPENDED"
+	CodeUserBanned                 = "USER_BANNED"
+	CodeOrgNotMember               = "ORG_NOT_MEMBER"
+	CodeOrgNotSelected             = "ORG_NOT_SELECTED"
+	CodeOrgInsufficientRole        = "ORG_INSUFFICIENT_ROLE"
+	CodeOrgLastOwner               = "ORG_LAST_OWNER"
+	CodeOrgInvalidInvitation       = "ORG_INVALID_INVITATION"
+	CodeOrgInvitationEmailMismatch = "ORG_INVITATION_EMAIL_MISMATCH"
+	CodeOrgSessionRestricted       = "ORG_SESSION_RESTRICTED"
+
+	CodeSignupClosed                 = "SIGNUP_CLOSED"
+	CodeSignupInvitationCodeRequired = "SIGNUP_INVITATION_CODE_REQUIRED"
+	CodeSignupInvalidInvitationCode  = "SIGNUP_INVALID_INVITATION_CODE"
+	CodeSignupEmailDomainNotAllowed  = "SIGNUP_EMAIL_DOMAIN_NOT_ALLOWED"
+	CodeSignupDisposableEmail        = "SIGNUP_DISPOSABLE_EMAIL"
+
+	CodeAdminRequired = "ADMIN_REQUIRED"
+
+	CodeImpersonationTargetNotAllowed = "IMPERSONATION_TARGET_NOT_ALLOWED"
+	CodeImpersonationForbidden        = "IMPERSONATION_FORBIDDEN"
+	CodeImpersonationNotActive        = "IMPERSONATION_NOT_ACTIVE"
+
+	CodeSuspensionTargetNotAllowed = "SUSPENSION_TARGET_NOT_ALLOWED"
+	CodeSuspensionInvalidExpiry    = "SUSPENSION_INVALID_EXPIRY"
+	CodeSuspensionNotActive        = "SUSPENSION_NOT_ACTIVE"
+
+	CodeTokenInvalidScope  = "TOKEN_INVALID_SCOPE"
+	CodeTokenExpiryTooLong = "TOKEN_EXPIRY_TOO_LONG"
+	CodeTokenInvalidExpiry = "TOKEN_INVALID_EXPIRY"
+
+	CodeWebhookInvalidUrl       = "WEBHOOK_INVALID_URL"
+	CodeWebhookUnknownEventType = "WEBHOOK_UNKNOWN_EVENT_TYPE"
+
+	CodeOAuthUnknownClient      = "OAUTH_UNKNOWN_CLIENT"
+	CodeOAuthInvalidRedirectUri = "OAUTH_INVALID_REDIRECT_URI"
+	CodeOAuthInvalidScope       = "OAUTH_INVALID_SCOPE"
+	CodeOAuthInvalidRequest     = "OAUTH_INVALID_REQUEST"
+
+	CodeSocialUnknownProvider  = "SOCIAL_UNKNOWN_PROVIDER"
+	CodeSocialInvalidState     = "SOCIAL_INVALID_STATE"
+	CodeSocialProviderError    = "SOCIAL_PROVIDER_ERROR"
+	CodeSocialEmailRequired    = "SOCIAL_EMAIL_REQUIRED"
+	CodeSocialEmailNotVerified = "SOCIAL_EMAIL_NOT_VERIFIED"
+	CodeSocialInvalidLoginCode = "SOCIAL_INVALID_LOGIN_CODE"
+	CodeSocialAlreadyLinked    = "SOCIAL_IDENTITY_ALREADY_LINKED"
+	CodeSocialLastLoginMethod  = "SOCIAL_LAST_LOGIN_METHOD"
+
+	CodeSamlNotConfigured     = "SAML_NOT_CONFIGURED"
+	CodeSamlInvalidMetadata   = "SAML_INVALID_METADATA"
+	CodeSamlInvalidState      = "SAML_INVALID_STATE"
+	CodeSamlInvalidResponse   = "SAML_INVALID_RESPONSE"
+	CodeSamlAssertionReplayed = "SAML_ASSERTION_REPLAYED"
+	CodeSamlEmailRequired     = "SAML_EMAIL_REQUIRED"
+	CodeSamlEmailInUse        = "SAML_EMAIL_IN_USE"
+	CodeSamlInvalidLoginCode  = "SAML_INVALID_LOGIN_CODE"
+
+	CodeScimInvalidToken  = "SCIM_INVALID_TOKEN"
+	CodeScimInvalidFilter = "SCIM_INVALID_FILTER"
+	CodeScimInvalidValue  = "SCIM_INVALID_VALUE"
+	CodeScimInvalidPath   = "SCIM_INVALID_PATH"
+	CodeScimInvalidSyntax = "SCIM_INVALID_SYNTAX"
+	CodeScimUniqueness    = "SCIM_UNIQUENESS"
+)
@@ -2,33 +2,70 @@ package errors
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	validator10 "github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var (
-	ValidationError = func(e error) *Error { return &Error{Type: typeBadRequest, Err: e, DisplayString: e.Error()} }
-	DatabaseError   = func(e error) *Error {
-		return &Error{Type: typeInternalServer, Err: e, DisplayString: "Internal server error"}
+	ValidationError = func(e error) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeRequestValidationFailed, Err: e, DisplayString: e.Error(), Details: validationDetails(e)}
+	}
+	DatabaseError = func(e error) *Error {
+		return &Error{Type: typeInternalServer, Code: CodeInternalDatabase, Err: e, DisplayString: "Internal server error"}
 	}
 	NoDocumentsError = func(e error, document string) *Error {
-		return &Error{Type: typeNotFound, Err: e, DisplayString: fmt.Sprintf("No %s found", document)}
+		return &Error{Type: typeNotFound, Code: resourceCode(document, "NOT_FOUND"), Err: e, DisplayString: fmt.Sprintf("No %s found", document)}
 	}
-	DuplicateKeyError = func(we mongo.WriteException) *Error {
+	DuplicateKeyError = func(we mongo.WriteException, document string) *Error {
 		var doc map[string]interface{}
 		err := bson.Unmarshal([]byte(we.WriteErrors[0].Raw), &doc)
 		if err != nil {
 			fmt.Println(err)
 		}
-		keyValue := doc["keyValue"].(map[string]interface{})
+		keyValue, _ := doc["keyValue"].(map[string]interface{})
+		keys := make([]string, 0, len(keyValue))
+		for key := range keyValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
 		var displayString string
-		for key, value := range keyValue {
-			displayString = fmt.Sprintf("%s %s: %s", displayString, key, value)
+		details := make([]FieldError, 0, len(keys))
+		for _, key := range keys {
+			displayString = fmt.Sprintf("%s %s: %s", displayString, key, keyValue[key])
+			details = append(details, FieldError{Field: key, Issue: "duplicate"})
 		}
-		return &Error{Type: typeBadRequest, Err: we, DisplayString: fmt.Sprintf("%s already exists in the database", displayString)}
+		// the tenant of a compound index is not part of the code, e.g. USER_DUPLICATE_EMAIL in both tenancy modes
+		codeKeys := []string{}
+		for _, key := range keys {
+			if key != "organization_id" || len(keys) == 1 {
+				codeKeys = append(codeKeys, key)
+			}
+		}
+		code := resourceCode(document, strings.Join(append([]string{"DUPLICATE"}, codeKeys...), "_"))
+		return &Error{Type: typeBadRequest, Code: code, Err: we, DisplayString: fmt.Sprintf("%s already exists in the database", displayString), Details: details}
 	}
 	IncorrectIdFormatError = func(e error) *Error {
-		return &Error{Type: typeBadRequest, Err: e, DisplayString: "Incorrect id format"}
+		return &Error{Type: typeBadRequest, Code: CodeRequestInvalidId, Err: e, DisplayString: "Incorrect id format"}
 	}
 )
+
+// e.g. ("invitation code", "NOT_FOUND") gives INVITATION_CODE_NOT_FOUND
+func resourceCode(document string, suffix string) string {
+	return strings.ToUpper(strings.ReplaceAll(fmt.Sprintf("%s_%s", document, suffix), " ", "_"))
+}
+
+func validationDetails(e error) []FieldError {
+	validationErrors, ok := e.(validator10.ValidationErrors)
+	if !ok {
+		return nil
+	}
+	details := make([]FieldError, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		details = append(details, FieldError{Field: fieldError.Field(), Issue: fieldError.Tag()})
+	}
+	return details
+}
@@ -11,8 +11,29 @@ const (
 
 type Error struct {
 	Type          string
+	Code          string
 	Err           error
 	DisplayString string
+	Details       []FieldError
+}
+
+// FieldError points at the request field that caused the error
+type FieldError struct {
+	Field string `json:"field"`
+	Issue string `json:"issue"`
+}
+
+// ErrorEnvelope is the body of every error response, see docs/errors.md
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code      string       `json:"code"`
+	Status    int          `json:"status"`
+	Message   string       `json:"message"`
+	RequestId string       `json:"request_id,omitempty"`
+	Details   []FieldError `json:"details,omitempty"`
 }
 
 func (e *Error) Error() error {
@@ -38,8 +59,8 @@ func (e *Error) UserStatusError() int {
 	if e.Type == typeForbidden {
 		return http.StatusForbidden
 	}
-	// Handle more error scenarios
-	return -1
+	// an error without a known type is a bug on our side, never something the user can fix
+	return http.StatusInternalServerError
 }
 
 func (e *Error) UserErrorString() string {
@@ -49,6 +70,33 @@ func (e *Error) UserErrorString() string {
 	return "Unknown error"
 }
 
+func (e *Error) UserErrorCode() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	if e.Type == typeBadRequest {
+		return CodeRequestInvalid
+	}
+	if e.Type == typeNotFound {
+		return CodeResourceNotFound
+	}
+	if e.Type == typeForbidden {
+		return CodeForbidden
+	}
+	return CodeInternal
+}
+
 func (e *Error) IsNotFound() bool {
 	return e.Type == typeNotFound
 }
+
+// Envelope builds the response body for the error
+func (e *Error) Envelope(requestId string) ErrorEnvelope {
+	return ErrorEnvelope{Error: ErrorBody{
+		Code:      e.UserErrorCode(),
+		Status:    e.UserStatusError(),
+		Message:   e.UserErrorString(),
+		RequestId: requestId,
+		Details:   e.Details,
+	}}
+}
@@ -0,0 +1,14 @@
+package errors
+
+// errors of admins impersonating users
+var (
+	ImpersonationTargetNotAllowedError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeImpersonationTargetNotAllowed, DisplayString: "Yourself, platform admins and deleted users can not be impersonated"}
+	}
+	ImpersonationForbiddenError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeImpersonationForbidden, DisplayString: "This is not allowed while impersonating a user"}
+	}
+	ImpersonationNotActiveError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeImpersonationNotActive, DisplayString: "The request does not impersonate a user"}
+	}
+)
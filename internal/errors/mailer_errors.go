@@ -1,5 +1,7 @@
 package errors
 
 var (
-	SendMailError = func(e error) *Error { return &Error{Type: typeInternalServer, Err: e, DisplayString: e.Error()} }
+	SendMailError = func(e error) *Error {
+		return &Error{Type: typeInternalServer, Code: CodeInternalMail, Err: e, DisplayString: e.Error()}
+	}
 )
@@ -0,0 +1,20 @@
+package errors
+
+import "fmt"
+
+// errors of the authorization request and the client registration, the token, introspection and revocation
+// endpoints answer with the error format of RFC 6749 instead
+var (
+	OAuthUnknownClientError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeOAuthUnknownClient, DisplayString: "Unknown client_id", Details: []FieldError{{Field: "client_id", Issue: "exists"}}}
+	}
+	OAuthInvalidRedirectUriError = func(field string) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeOAuthInvalidRedirectUri, DisplayString: "The redirect uri is not allowed", Details: []FieldError{{Field: field, Issue: "url"}}}
+	}
+	OAuthInvalidScopeError = func(scope string) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeOAuthInvalidScope, DisplayString: fmt.Sprintf("The scope %s is unknown or not allowed for the client", scope), Details: []FieldError{{Field: "scope", Issue: "oneof"}}}
+	}
+	OAuthInvalidRequestError = func(str string) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeOAuthInvalidRequest, DisplayString: str}
+	}
+)
@@ -0,0 +1,25 @@
+package errors
+
+var (
+	NotOrganizationMemberError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeOrgNotMember, DisplayString: "You are not a member of this organization"}
+	}
+	OrganizationSessionRestrictedError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeOrgSessionRestricted, DisplayString: "This single sign-on session only works in the organization it was started from"}
+	}
+	OrganizationRequiredError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeOrgNotSelected, DisplayString: "Organization not selected"}
+	}
+	InsufficientOrganizationRoleError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeOrgInsufficientRole, DisplayString: "Your role in this organization does not allow this action"}
+	}
+	LastOrganizationOwnerError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeOrgLastOwner, DisplayString: "An organization must have at least one owner"}
+	}
+	InvalidInvitationError = func(e error) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeOrgInvalidInvitation, Err: e, DisplayString: "Invitation is invalid or has expired"}
+	}
+	InvitationEmailMismatchError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeOrgInvitationEmailMismatch, DisplayString: "Invitation was sent to a different email address"}
+	}
+)
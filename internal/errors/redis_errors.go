@@ -2,12 +2,12 @@ package errors
 
 var (
 	RedisInternalServerError = func(e error) *Error {
-		return &Error{Type: typeInternalServer, Err: e, DisplayString: "Internal Server Error. Please try again later."}
+		return &Error{Type: typeInternalServer, Code: CodeInternalCache, Err: e, DisplayString: "Internal Server Error. Please try again later."}
 	}
 	RedisNotFoundError = func(e error) *Error {
-		return &Error{Type: typeNotFound, Err: e, DisplayString: "Not Found"}
+		return &Error{Type: typeNotFound, Code: CodeResourceNotFound, Err: e, DisplayString: "Not Found"}
 	}
 	MissingUserIdAndTokenError = func() *Error {
-		return &Error{Type: typeBadRequest, DisplayString: "Missing user_id and token"}
+		return &Error{Type: typeBadRequest, Code: CodeAuthMissingToken, DisplayString: "Missing user_id and token"}
 	}
 )
@@ -1,11 +1,48 @@
 package errors
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	validator10 "github.com/go-playground/validator/v10"
+)
+
 var (
-	BadRequestError   = func(str string) *Error { return &Error{Type: typeBadRequest, Err: nil, DisplayString: str} }
+	BadRequestError = func(str string) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeRequestInvalid, Err: nil, DisplayString: str}
+	}
 	UnauthedUserError = func(e error) *Error {
-		return &Error{Type: typeForbidden, Err: e, DisplayString: "Unauthenticated user"}
+		return &Error{Type: typeForbidden, Code: CodeAuthUnauthenticated, Err: e, DisplayString: "Unauthenticated user"}
 	}
 	InvalidOtpError = func(e error) *Error {
-		return &Error{Type: typeBadRequest, Err: e, DisplayString: "Invalid OTP"}
+		return &Error{Type: typeBadRequest, Code: CodeAuthInvalidOtp, Err: e, DisplayString: "Invalid OTP"}
+	}
+	// the request body could not be bound to the request struct
+	RequestBindingError = func(e error) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeRequestMalformed, Err: e, DisplayString: e.Error(), Details: bindingDetails(e)}
+	}
+	MissingFieldsError = func(fields ...string) *Error {
+		details := make([]FieldError, 0, len(fields))
+		for _, field := range fields {
+			details = append(details, FieldError{Field: field, Issue: "required"})
+		}
+		return &Error{Type: typeBadRequest, Code: CodeRequestMissingField, DisplayString: fmt.Sprintf("Missing required fields: %s", strings.Join(fields, ", ")), Details: details}
+	}
+	RouteNotFoundError = func() *Error {
+		return &Error{Type: typeNotFound, Code: CodeRouteNotFound, DisplayString: "Route not found"}
+	}
+	InternalServerError = func(e error) *Error {
+		return &Error{Type: typeInternalServer, Code: CodeInternal, Err: e, DisplayString: "Internal server error"}
 	}
 )
+
+func bindingDetails(e error) []FieldError {
+	if typeError, ok := e.(*json.UnmarshalTypeError); ok {
+		return []FieldError{{Field: typeError.Field, Issue: fmt.Sprintf("expected %s", typeError.Type)}}
+	}
+	if validationErrors, ok := e.(validator10.ValidationErrors); ok {
+		return validationDetails(validationErrors)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package errors
+
+var (
+	SamlNotConfiguredError = func() *Error {
+		return &Error{Type: typeNotFound, Code: CodeSamlNotConfigured, DisplayString: "SAML single sign-on is not set up"}
+	}
+	SamlInvalidMetadataError = func(e error) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSamlInvalidMetadata, Err: e, DisplayString: "The identity provider metadata is invalid: " + e.Error()}
+	}
+	SamlInvalidStateError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSamlInvalidState, DisplayString: "The SAML login expired or was started in another browser, start it again"}
+	}
+	SamlInvalidResponseError = func(e error) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSamlInvalidResponse, Err: e, DisplayString: "The response of the identity provider could not be verified"}
+	}
+	SamlAssertionReplayedError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSamlAssertionReplayed, DisplayString: "The assertion was already used"}
+	}
+	SamlEmailRequiredError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSamlEmailRequired, DisplayString: "The identity provider did not send an email address"}
+	}
+	SamlEmailInUseError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeSamlEmailInUse, DisplayString: "An account with this email already exists, log in to it and link the identity provider from the account settings"}
+	}
+	SamlInvalidLoginCodeError = func(e error) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSamlInvalidLoginCode, Err: e, DisplayString: "The login code is unknown, used or expired"}
+	}
+)
@@ -0,0 +1,23 @@
+package errors
+
+// the SCIM endpoints answer with the error format of RFC 7644 section 3.12, the codes pick its scimType
+var (
+	ScimInvalidTokenError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeScimInvalidToken, DisplayString: "The SCIM token is missing, unknown or revoked"}
+	}
+	ScimInvalidFilterError = func(detail string) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeScimInvalidFilter, DisplayString: "Invalid filter: " + detail}
+	}
+	ScimInvalidValueError = func(detail string) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeScimInvalidValue, DisplayString: detail}
+	}
+	ScimInvalidPathError = func(detail string) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeScimInvalidPath, DisplayString: "Invalid path: " + detail}
+	}
+	ScimInvalidSyntaxError = func(e error) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeScimInvalidSyntax, Err: e, DisplayString: "The request body is not a valid SCIM resource"}
+	}
+	ScimUniquenessError = func(detail string) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeScimUniqueness, DisplayString: detail}
+	}
+)
@@ -0,0 +1,19 @@
+package errors
+
+var (
+	SignupClosedError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeSignupClosed, DisplayString: "Signups are currently closed"}
+	}
+	InvitationCodeRequiredError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSignupInvitationCodeRequired, DisplayString: "An invitation code is required to sign up"}
+	}
+	InvalidInvitationCodeError = func(e error) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSignupInvalidInvitationCode, Err: e, DisplayString: "Invitation code is invalid, expired or fully used"}
+	}
+	EmailDomainNotAllowedError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeSignupEmailDomainNotAllowed, DisplayString: "Signups from this email domain are not allowed"}
+	}
+	DisposableEmailError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSignupDisposableEmail, DisplayString: "Disposable email addresses are not allowed"}
+	}
+)
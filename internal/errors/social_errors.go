@@ -0,0 +1,30 @@
+package errors
+
+import "fmt"
+
+var (
+	SocialUnknownProviderError = func(provider string) *Error {
+		return &Error{Type: typeNotFound, Code: CodeSocialUnknownProvider, DisplayString: fmt.Sprintf("Unknown social login provider %s", provider)}
+	}
+	SocialInvalidStateError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSocialInvalidState, DisplayString: "The social login expired or was started in another browser, start it again"}
+	}
+	SocialProviderError = func(e error) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSocialProviderError, Err: e, DisplayString: "The login with the provider failed"}
+	}
+	SocialEmailRequiredError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSocialEmailRequired, DisplayString: "The provider did not share an email address"}
+	}
+	SocialEmailNotVerifiedError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeSocialEmailNotVerified, DisplayString: "An account with this email exists but the provider did not verify the email, log in with the password instead"}
+	}
+	SocialInvalidLoginCodeError = func(e error) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSocialInvalidLoginCode, Err: e, DisplayString: "The login code is unknown, used or expired"}
+	}
+	SocialIdentityAlreadyLinkedError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSocialAlreadyLinked, DisplayString: "This account of the provider is already linked to another user"}
+	}
+	SocialLastLoginMethodError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSocialLastLoginMethod, DisplayString: "This is the only way to log in, set a password or link another provider first"}
+	}
+)
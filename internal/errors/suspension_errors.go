@@ -0,0 +1,29 @@
+package errors
+
+import (
+	"fmt"
+	"time"
+)
+
+// errors of suspended and banned users and of the admins suspending them
+var (
+	// expiresAt is nil when the suspension lasts until an admin reinstates the user
+	UserSuspendedError = func(expiresAt *time.Time) *Error {
+		if expiresAt == nil {
+			return &Error{Type: typeForbidden, Code: CodeUserSuspended, DisplayString: "The account is suspended"}
+		}
+		return &Error{Type: typeForbidden, Code: CodeUserSuspended, DisplayString: fmt.Sprintf("The account is suspended until %s", expiresAt.UTC().Format(time.RFC3339))}
+	}
+	UserBannedError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeUserBanned, DisplayString: "The account is banned"}
+	}
+	SuspensionTargetNotAllowedError = func() *Error {
+		return &Error{Type: typeForbidden, Code: CodeSuspensionTargetNotAllowed, DisplayString: "Yourself and deleted users can not be suspended or banned"}
+	}
+	SuspensionInvalidExpiryError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSuspensionInvalidExpiry, DisplayString: "expires_in_hours must not be negative", Details: []FieldError{{Field: "expires_in_hours", Issue: "min=0"}}}
+	}
+	SuspensionNotActiveError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeSuspensionNotActive, DisplayString: "The user is not suspended or banned"}
+	}
+)
@@ -0,0 +1,20 @@
+package errors
+
+import (
+	"fmt"
+	"time"
+)
+
+// errors of the personal access token management
+var (
+	TokenInvalidScopeError = func(scope string) *Error {
+		return &Error{Type: typeBadRequest, Code: CodeTokenInvalidScope, DisplayString: fmt.Sprintf("The scope %s is unknown", scope), Details: []FieldError{{Field: "scopes", Issue: "oneof"}}}
+	}
+	TokenInvalidExpiryError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeTokenInvalidExpiry, DisplayString: "expires_in_days must not be negative", Details: []FieldError{{Field: "expires_in_days", Issue: "min=0"}}}
+	}
+	TokenExpiryTooLongError = func(maxTtl time.Duration) *Error {
+		days := int(maxTtl.Hours() / 24)
+		return &Error{Type: typeBadRequest, Code: CodeTokenExpiryTooLong, DisplayString: fmt.Sprintf("Tokens must expire within %d days", days), Details: []FieldError{{Field: "expires_in_days", Issue: fmt.Sprintf("max=%d", days)}}}
+	}
+)
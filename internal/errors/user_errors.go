@@ -0,0 +1,10 @@
+package errors
+
+var (
+	PasswordTooShortError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeUserPasswordTooShort, DisplayString: "Password length less than 8 characters", Details: []FieldError{{Field: "password", Issue: "min=8"}}}
+	}
+	EmailAlreadyVerifiedError = func() *Error {
+		return &Error{Type: typeBadRequest, Code: CodeUserEmailAlreadyVerified, DisplayString: "User email already verified"}
+	}
+)
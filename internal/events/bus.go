@@ -0,0 +1,144 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type subscriber struct {
+	// identifies an asynchronous subscriber in the outbox
+	name   string
+	handle func(c *gin.Context, event Event) *errors.Error
+}
+
+// the subscribers are registered at startup, before anything is published, so the maps are only read afterwards
+var syncSubscribers = map[string][]subscriber{}
+var asyncSubscribers = map[string][]subscriber{}
+
+// the concrete type of every event with a subscriber, to decode the events read back from the outbox
+var eventTypes = map[string]reflect.Type{}
+
+var eventsConfig config.EventsConfig
+
+// the asynchronous subscribers running in goroutines, waited for by Shutdown
+var inFlight sync.WaitGroup
+
+// Configure picks how the asynchronous subscribers are reached, every process that publishes events calls it
+func Configure(cfg config.EventsConfig) {
+	eventsConfig = cfg
+}
+
+// Subscribe registers a synchronous subscriber, it runs inside Publish with the publisher's context,
+// in the order of registration, and its error fails the publish
+func Subscribe[T Event](handler func(c *gin.Context, event T) *errors.Error) {
+	name := register[T]()
+	syncSubscribers[name] = append(syncSubscribers[name], subscriber{handle: wrap(handler)})
+}
+
+// SubscribeAsync registers a subscriber that runs after Publish, its errors are logged, and retried when the outbox is enabled
+// the name must stay the same across releases as it is stored with the pending events
+func SubscribeAsync[T Event](name string, handler func(c *gin.Context, event T) *errors.Error) {
+	eventName := register[T]()
+	asyncSubscribers[eventName] = append(asyncSubscribers[eventName], subscriber{name: name, handle: wrap(handler)})
+}
+
+func register[T Event]() string {
+	var event T
+	eventTypes[event.Name()] = reflect.TypeOf(event)
+	return event.Name()
+}
+
+func wrap[T Event](handler func(c *gin.Context, event T) *errors.Error) func(c *gin.Context, event Event) *errors.Error {
+	return func(c *gin.Context, event Event) *errors.Error {
+		return handler(c, event.(T))
+	}
+}
+
+// Publish runs the synchronous subscribers of the event and then hands it to the asynchronous ones
+// with the outbox enabled the event is stored with c, so it is part of the transaction c carries, if any,
+// otherwise the asynchronous subscribers start once that transaction committed
+func Publish(c *gin.Context, event Event) (err *errors.Error) {
+	for _, s := range syncSubscribers[event.Name()] {
+		if err = s.handle(c, event); err != nil {
+			return err
+		}
+	}
+	subscribers := asyncSubscribers[event.Name()]
+	if len(subscribers) == 0 {
+		return nil
+	}
+	if eventsConfig.Outbox {
+		return enqueue(c, event, subscribers)
+	}
+
+	logger := utils.GetContextLogger(c)
+	if logger == nil {
+		logger = serviceRegistry.GetLogger()
+	}
+	// the subscribers must not see what a transaction of the publisher writes before it commits, or act on
+	// an event whose transaction aborts
+	utils.AfterCommit(c, func() {
+		for _, s := range subscribers {
+			inFlight.Add(1)
+			go func(s subscriber) {
+				defer inFlight.Done()
+				// the request context ends with the response
+				subscriberLogger := logger.With(zap.String("event", event.Name()), zap.String("subscriber", s.name))
+				if e := s.handle(newContext(subscriberLogger), event); e != nil {
+					subscriberLogger.Error("Asynchronous event subscriber failed", zap.Error(e.Error()), zap.String("code", e.UserErrorCode()))
+				}
+			}(s)
+		}
+	})
+	return nil
+}
+
+func enqueue(c *gin.Context, event Event, subscribers []subscriber) *errors.Error {
+	payload, e := json.Marshal(event)
+	if e != nil {
+		return errors.InternalServerError(e)
+	}
+	names := make([]string, 0, len(subscribers))
+	for _, s := range subscribers {
+		names = append(names, s.name)
+	}
+	outboxEvent := models.OutboxEvent{Name: event.Name(), Payload: string(payload), PendingSubscribers: names}
+	if err := outboxEvent.Insert(c); err != nil {
+		return err
+	}
+	utils.AfterCommit(c, wakeRelay)
+	return nil
+}
+
+func newContext(logger *zap.Logger) *gin.Context {
+	c := utils.NewContext(context.Background(), nil)
+	utils.SetContextLogger(c, logger)
+	return c
+}
+
+// Shutdown waits for the asynchronous subscribers running in goroutines and stops the outbox relay
+func Shutdown(ctx context.Context) error {
+	stopRelay()
+	done := make(chan struct{})
+	go func() {
+		inFlight.Wait()
+		relayWorkers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
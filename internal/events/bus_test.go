@@ -0,0 +1,65 @@
+package events_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type testEvent struct{}
+
+func (testEvent) Name() string { return "test.published" }
+
+func TestAsyncSubscribersWaitForTheCommit(t *testing.T) {
+	var handled atomic.Int32
+	events.SubscribeAsync("test", func(c *gin.Context, event testEvent) *errors.Error {
+		handled.Add(1)
+		return nil
+	})
+
+	tests := []struct {
+		name string
+		err  *errors.Error
+		want int32
+	}{
+		{"aborted", errors.InternalServerError(fmt.Errorf("insert failed")), 0},
+		{"committed", nil, 1},
+	}
+	for _, test := range tests {
+		handled.Store(0)
+		c := utils.NewContext(context.Background(), nil)
+		utils.SetContextLogger(c, zap.NewNop())
+		published := false
+		err := utils.RunInTransaction(c, func() *errors.Error {
+			if err := events.Publish(c, testEvent{}); err != nil {
+				return err
+			}
+			// nothing may run before the transaction ends
+			time.Sleep(10 * time.Millisecond)
+			published = handled.Load() == 0
+			return test.err
+		})
+		if (err != nil) != (test.err != nil) {
+			t.Fatalf("%s: RunInTransaction = %v", test.name, err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if e := events.Shutdown(ctx); e != nil {
+			t.Fatal(e)
+		}
+		cancel()
+		if !published {
+			t.Errorf("%s: an asynchronous subscriber ran before the transaction ended", test.name)
+		}
+		if got := handled.Load(); got != test.want {
+			t.Errorf("%s: asynchronous subscriber ran %d times, want %d", test.name, got, test.want)
+		}
+	}
+}
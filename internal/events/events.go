@@ -0,0 +1,357 @@
+// Package events is the in-process bus that decouples the user account logic from its side effects.
+// The logic publishes what happened, e.g. UserSignedUp, and the subscribers registered at startup
+// react to it: synchronous ones run inside Publish and can fail it, asynchronous ones run afterwards,
+// either in a goroutine or, with the outbox enabled, from a Mongo backed outbox that survives restarts.
+package events
+
+import (
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/models"
+)
+
+// Event is implemented by every domain event, events are serialized as json for the outbox
+type Event interface {
+	Name() string
+}
+
+// User is the snapshot of a user carried by the events, it never holds the password hash
+type User struct {
+	Id             string `json:"id"`
+	Email          string `json:"email"`
+	GivenName      string `json:"given_name"`
+	FamilyName     string `json:"family_name"`
+	Status         string `json:"status"`
+	OrganizationId string `json:"organization_id,omitempty"`
+}
+
+func NewUser(user *models.User) User {
+	return User{
+		Id:             user.Id,
+		Email:          user.Email,
+		GivenName:      user.GivenName,
+		FamilyName:     user.FamilyName,
+		Status:         user.Status.String(),
+		OrganizationId: user.OrganizationId,
+	}
+}
+
+type UserSignedUp struct {
+	User User `json:"user"`
+}
+
+func (UserSignedUp) Name() string { return constants.EventUserSignedUp }
+
+// SignupFailed carries the error code of a rejected or failed signup
+type SignupFailed struct {
+	Email  string `json:"email"`
+	Reason string `json:"reason"`
+}
+
+func (SignupFailed) Name() string { return constants.EventSignupFailed }
+
+// UserLoggedIn carries the client of the login for the new device alerts
+type UserLoggedIn struct {
+	User      User      `json:"user"`
+	Ip        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	At        time.Time `json:"at"`
+}
+
+func (UserLoggedIn) Name() string { return constants.EventUserLoggedIn }
+
+// UserReauthenticated is published when a logged in user confirms their identity again for a sensitive action
+type UserReauthenticated struct {
+	User   User   `json:"user"`
+	Method string `json:"method"`
+}
+
+func (UserReauthenticated) Name() string { return constants.EventUserReauthenticated }
+
+// ImpersonationStarted is published when an admin gets a token to impersonate the user
+type ImpersonationStarted struct {
+	User           User      `json:"user"`
+	ImpersonatorId string    `json:"impersonator_id"`
+	Reason         string    `json:"reason"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+func (ImpersonationStarted) Name() string { return constants.EventImpersonationStarted }
+
+type ImpersonationEnded struct {
+	User           User   `json:"user"`
+	ImpersonatorId string `json:"impersonator_id"`
+}
+
+func (ImpersonationEnded) Name() string { return constants.EventImpersonationEnded }
+
+// ImpersonatedRequest is published for every request made with an impersonation token, Request is the http method
+// and path or the gRPC method and Status the http status or gRPC code
+type ImpersonatedRequest struct {
+	User           User   `json:"user"`
+	ImpersonatorId string `json:"impersonator_id"`
+	Request        string `json:"request"`
+	Status         string `json:"status"`
+	Failed         bool   `json:"failed"`
+}
+
+func (ImpersonatedRequest) Name() string { return constants.EventImpersonatedRequest }
+
+// LoginFailed carries the error code of a failed login, and the user when the email matched one
+type LoginFailed struct {
+	Email  string `json:"email"`
+	UserId string `json:"user_id,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (LoginFailed) Name() string { return constants.EventLoginFailed }
+
+type EmailVerified struct {
+	User User `json:"user"`
+}
+
+func (EmailVerified) Name() string { return constants.EventUserEmailVerified }
+
+type PasswordChanged struct {
+	User User `json:"user"`
+}
+
+func (PasswordChanged) Name() string { return constants.EventUserPasswordChanged }
+
+type UserDeleted struct {
+	User User `json:"user"`
+}
+
+func (UserDeleted) Name() string { return constants.EventUserDeleted }
+
+// StatusChanged is published when an admin changes the status of a user, deletions are published as UserDeleted
+type StatusChanged struct {
+	User           User   `json:"user"`
+	PreviousStatus string `json:"previous_status"`
+}
+
+func (StatusChanged) Name() string { return constants.EventUserStatusChanged }
+
+type RoleChanged struct {
+	User         User   `json:"user"`
+	Role         string `json:"role"`
+	PreviousRole string `json:"previous_role"`
+}
+
+func (RoleChanged) Name() string { return constants.EventUserRoleChanged }
+
+// UserSuspended is published when an admin suspends or bans the user, the status of the user tells which,
+// ExpiresAt is nil when it lasts until the user is reinstated
+type UserSuspended struct {
+	User           User       `json:"user"`
+	PreviousStatus string     `json:"previous_status"`
+	Reason         string     `json:"reason"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+func (UserSuspended) Name() string { return constants.EventUserSuspended }
+
+// UserReinstated is published when an admin lifts the suspension or ban of the user, or it expired
+type UserReinstated struct {
+	User             User   `json:"user"`
+	SuspendedStatus  string `json:"suspended_status"`
+	SuspensionReason string `json:"suspension_reason"`
+	Expired          bool   `json:"expired"`
+}
+
+func (UserReinstated) Name() string { return constants.EventUserReinstated }
+
+type SessionsRevoked struct {
+	User User `json:"user"`
+}
+
+func (SessionsRevoked) Name() string { return constants.EventUserSessionsRevoked }
+
+// OtpSent carries the redis scope the otp was issued for, e.g. user_email_verification
+type OtpSent struct {
+	User  User   `json:"user"`
+	Scope string `json:"scope"`
+}
+
+func (OtpSent) Name() string { return constants.EventOtpSent }
+
+type OtpVerified struct {
+	User  User   `json:"user"`
+	Scope string `json:"scope"`
+}
+
+func (OtpVerified) Name() string { return constants.EventOtpVerified }
+
+// OtpVerificationFailed carries the error code of a rejected otp
+type OtpVerificationFailed struct {
+	User   User   `json:"user"`
+	Scope  string `json:"scope"`
+	Reason string `json:"reason"`
+}
+
+func (OtpVerificationFailed) Name() string { return constants.EventOtpVerifyFailed }
+
+type WebhookEndpointCreated struct {
+	EndpointId string   `json:"endpoint_id"`
+	Url        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+}
+
+func (WebhookEndpointCreated) Name() string { return constants.EventWebhookEndpointCreated }
+
+type WebhookEndpointDeleted struct {
+	EndpointId string `json:"endpoint_id"`
+	Url        string `json:"url"`
+}
+
+func (WebhookEndpointDeleted) Name() string { return constants.EventWebhookEndpointDeleted }
+
+// WebhookDeliveriesReplayed is published when an admin queues deliveries again, DeliveryId is empty
+// when every failed delivery of the endpoint was replayed
+type WebhookDeliveriesReplayed struct {
+	EndpointId string `json:"endpoint_id"`
+	DeliveryId string `json:"delivery_id,omitempty"`
+	Count      int64  `json:"count"`
+}
+
+func (WebhookDeliveriesReplayed) Name() string { return constants.EventWebhookDeliveriesReplayed }
+
+// AccountSecured is published when a user follows the "this wasn't me" link of a login alert
+type AccountSecured struct {
+	User     User   `json:"user"`
+	DeviceId string `json:"device_id"`
+}
+
+func (AccountSecured) Name() string { return constants.EventUserAccountSecured }
+
+type DeviceForgotten struct {
+	User     User   `json:"user"`
+	DeviceId string `json:"device_id"`
+}
+
+func (DeviceForgotten) Name() string { return constants.EventUserDeviceForgotten }
+
+// IdentityLinked is published when an account at a social login provider is linked to the user
+type IdentityLinked struct {
+	User     User   `json:"user"`
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+}
+
+func (IdentityLinked) Name() string { return constants.EventUserIdentityLinked }
+
+type IdentityUnlinked struct {
+	User     User   `json:"user"`
+	Provider string `json:"provider"`
+	Subject  string `json:"subject"`
+}
+
+func (IdentityUnlinked) Name() string { return constants.EventUserIdentityUnlinked }
+
+// UserProfileUpdated is published when the name or email of a user is changed for them, e.g. by SCIM provisioning
+type UserProfileUpdated struct {
+	User User `json:"user"`
+}
+
+func (UserProfileUpdated) Name() string { return constants.EventUserProfileUpdated }
+
+// PersonalAccessTokenCreated is published when a user creates a token for a script or CI job
+type PersonalAccessTokenCreated struct {
+	User      User     `json:"user"`
+	TokenId   string   `json:"token_id"`
+	TokenName string   `json:"token_name"`
+	Scopes    []string `json:"scopes"`
+}
+
+func (PersonalAccessTokenCreated) Name() string { return constants.EventUserTokenCreated }
+
+type PersonalAccessTokenDeleted struct {
+	User      User   `json:"user"`
+	TokenId   string `json:"token_id"`
+	TokenName string `json:"token_name"`
+}
+
+func (PersonalAccessTokenDeleted) Name() string { return constants.EventUserTokenDeleted }
+
+type OAuthClientCreated struct {
+	ClientId   string `json:"client_id"`
+	ClientName string `json:"client_name"`
+}
+
+func (OAuthClientCreated) Name() string { return constants.EventOAuthClientCreated }
+
+type OAuthClientDeleted struct {
+	ClientId   string `json:"client_id"`
+	ClientName string `json:"client_name"`
+}
+
+func (OAuthClientDeleted) Name() string { return constants.EventOAuthClientDeleted }
+
+// OAuthConsentGranted is published when a user approves scopes for a third party client
+type OAuthConsentGranted struct {
+	User     User     `json:"user"`
+	ClientId string   `json:"client_id"`
+	Scopes   []string `json:"scopes"`
+}
+
+func (OAuthConsentGranted) Name() string { return constants.EventOAuthConsentGranted }
+
+// SamlConnectionSaved is published when an organization sets up or changes its SAML identity provider
+type SamlConnectionSaved struct {
+	OrganizationId string `json:"organization_id"`
+	IdpEntityId    string `json:"idp_entity_id"`
+}
+
+func (SamlConnectionSaved) Name() string { return constants.EventSamlConnectionSaved }
+
+type SamlConnectionDeleted struct {
+	OrganizationId string `json:"organization_id"`
+	IdpEntityId    string `json:"idp_entity_id"`
+}
+
+func (SamlConnectionDeleted) Name() string { return constants.EventSamlConnectionDeleted }
+
+// InvitationCodeCreated is published when a platform admin creates a code for invite_only signup
+type InvitationCodeCreated struct {
+	InvitationCodeId string     `json:"invitation_code_id"`
+	MaxUses          int32      `json:"max_uses"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+}
+
+func (InvitationCodeCreated) Name() string { return constants.EventInvitationCodeCreated }
+
+// ScimTokenCreated is published when an organization admin issues a token for its identity provider
+type ScimTokenCreated struct {
+	OrganizationId string `json:"organization_id"`
+	TokenId        string `json:"token_id"`
+	TokenName      string `json:"token_name"`
+}
+
+func (ScimTokenCreated) Name() string { return constants.EventScimTokenCreated }
+
+type ScimTokenDeleted struct {
+	OrganizationId string `json:"organization_id"`
+	TokenId        string `json:"token_id"`
+	TokenName      string `json:"token_name"`
+}
+
+func (ScimTokenDeleted) Name() string { return constants.EventScimTokenDeleted }
+
+// ScimGroupSaved is published when the identity provider of an organization creates or changes a group
+type ScimGroupSaved struct {
+	OrganizationId string `json:"organization_id"`
+	GroupId        string `json:"group_id"`
+	DisplayName    string `json:"display_name"`
+	MemberCount    int    `json:"member_count"`
+}
+
+func (ScimGroupSaved) Name() string { return constants.EventScimGroupSaved }
+
+type ScimGroupDeleted struct {
+	OrganizationId string `json:"organization_id"`
+	GroupId        string `json:"group_id"`
+	DisplayName    string `json:"display_name"`
+}
+
+func (ScimGroupDeleted) Name() string { return constants.EventScimGroupDeleted }
@@ -0,0 +1,159 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"go.uber.org/zap"
+)
+
+// how long a claimed event is reserved for the relay handling it, the asynchronous subscribers must finish well within it
+const outboxLease = time.Minute
+
+var relayWake = make(chan struct{}, 1)
+var relayStop chan struct{}
+var relayWorkers sync.WaitGroup
+
+// StartRelay hands the events stored in the outbox to their asynchronous subscribers until Shutdown,
+// events published by other processes, e.g. the admin CLI, are picked up on the next poll
+func StartRelay(logger *zap.Logger) {
+	if !eventsConfig.Outbox {
+		return
+	}
+	relayStop = make(chan struct{})
+	relayWorkers.Add(1)
+	go relay(logger.With(zap.String("source", "event_outbox")))
+}
+
+func stopRelay() {
+	if relayStop != nil {
+		close(relayStop)
+		relayStop = nil
+	}
+}
+
+func wakeRelay() {
+	select {
+	case relayWake <- struct{}{}:
+	default:
+	}
+}
+
+func relay(logger *zap.Logger) {
+	defer relayWorkers.Done()
+	stop := relayStop
+	timer := time.NewTimer(eventsConfig.PollInterval)
+	defer timer.Stop()
+	for {
+		for relayNext(logger) {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(eventsConfig.PollInterval)
+		select {
+		case <-stop:
+			return
+		case <-relayWake:
+		case <-timer.C:
+		}
+	}
+}
+
+// relayNext handles one due event and reports whether there was one
+func relayNext(logger *zap.Logger) bool {
+	ctx := context.Background()
+	outboxEvent, e := models.ClaimOutboxEvent(ctx, time.Now().Add(outboxLease))
+	if e != nil {
+		if !e.IsNotFound() {
+			logger.Error("Error while claiming outbox event", zap.Error(e.Error()))
+		}
+		return false
+	}
+	logger = logger.With(zap.String("outbox_event_id", outboxEvent.Id), zap.String("event", outboxEvent.Name))
+
+	event, err := decode(outboxEvent)
+	if err != nil {
+		// kept for a release that knows the event
+		logger.Error("Error while decoding outbox event", zap.Error(err))
+		recordFailure(logger, outboxEvent, outboxEvent.PendingSubscribers, []string{err.Error()})
+		return true
+	}
+
+	var pending, problems []string
+	for _, name := range outboxEvent.PendingSubscribers {
+		s, ok := findAsyncSubscriber(outboxEvent.Name, name)
+		if !ok {
+			logger.Warn("Dropping outbox event for a subscriber that no longer exists", zap.String("subscriber", name))
+			continue
+		}
+		subscriberLogger := logger.With(zap.String("subscriber", name))
+		if e := s.handle(newContext(subscriberLogger), event); e != nil {
+			subscriberLogger.Error("Asynchronous event subscriber failed", zap.Error(e.Error()), zap.String("code", e.UserErrorCode()))
+			pending = append(pending, name)
+			problems = append(problems, name+": "+e.UserErrorCode())
+		}
+	}
+	if len(pending) > 0 {
+		recordFailure(logger, outboxEvent, pending, problems)
+		return true
+	}
+	if e = outboxEvent.Delete(ctx); e != nil {
+		logger.Error("Error while deleting handled outbox event", zap.Error(e.Error()))
+	}
+	return true
+}
+
+func recordFailure(logger *zap.Logger, outboxEvent *models.OutboxEvent, pending []string, problems []string) {
+	nextAttemptAt := time.Now().Add(backoff(outboxEvent.Attempts + 1))
+	if e := outboxEvent.RecordFailure(context.Background(), pending, strings.Join(problems, "; "), nextAttemptAt); e != nil {
+		logger.Error("Error while recording outbox event failure", zap.Error(e.Error()))
+	}
+}
+
+func decode(outboxEvent *models.OutboxEvent) (Event, error) {
+	eventType, ok := eventTypes[outboxEvent.Name]
+	if !ok {
+		return nil, fmt.Errorf("no subscriber knows the event %s", outboxEvent.Name)
+	}
+	value := reflect.New(eventType)
+	if err := json.Unmarshal([]byte(outboxEvent.Payload), value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface().(Event), nil
+}
+
+func findAsyncSubscriber(eventName string, name string) (subscriber, bool) {
+	for _, s := range asyncSubscribers[eventName] {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return subscriber{}, false
+}
+
+// backoff doubles the wait after every failed attempt up to the max backoff, the outbox never gives up on an event
+func backoff(attempts int32) time.Duration {
+	wait := eventsConfig.InitialBackoff
+	for i := int32(1); i < attempts && wait < eventsConfig.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > eventsConfig.MaxBackoff {
+		wait = eventsConfig.MaxBackoff
+	}
+	return wait
+}
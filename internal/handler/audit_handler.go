@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+const maxAuditPageSize = 200
+
+// ListAuditEntries lists the audit log newest first, filtered by the actor_id, target_user_id, action, outcome,
+// from and to query params, from and to are RFC 3339 times
+func ListAuditEntries(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	filter := bson.M{}
+	for _, field := range []string{"actor_id", "target_user_id", "action"} {
+		if value := c.Query(field); value != "" {
+			filter[field] = value
+		}
+	}
+	if outcome := c.Query("outcome"); outcome != "" {
+		if outcome != constants.AuditOutcomeSuccess && outcome != constants.AuditOutcomeFailure {
+			logger.Info("Invalid audit outcome", zap.String("outcome", outcome))
+			utils.RespondWithError(c, errors.BadRequestError("outcome must be one of success, failure"))
+			return
+		}
+		filter["outcome"] = outcome
+	}
+	from, e := parseAuditTime(c, "from")
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	to, e := parseAuditTime(c, "to")
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	filter = models.AuditTimeRangeFilter(filter, from, to)
+	listAuditEntries(c, filter)
+}
+
+// ListMyActivity lists the audit entries about the user of the request or performed by them, newest first
+func ListMyActivity(c *gin.Context) {
+	user := utils.GetContextUser(c)
+	listAuditEntries(c, bson.M{"$or": bson.A{bson.M{"target_user_id": user.Id}, bson.M{"actor_id": user.Id}}})
+}
+
+func listAuditEntries(c *gin.Context, filter bson.M) {
+	logger := utils.GetContextLogger(c)
+
+	limit, skip, e := parsePagination(c, maxAuditPageSize)
+	if e != nil {
+		logger.Info("Invalid pagination of audit entries", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	entries, e := models.FindAuditEntries(c, filter, skip, limit)
+	if e != nil {
+		logger.Error("Error while fetching audit entries from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func parseAuditTime(c *gin.Context, param string) (time.Time, *errors.Error) {
+	value := c.Query(param)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		utils.GetContextLogger(c).Info("Invalid audit time", zap.String(param, value))
+		return time.Time{}, errors.BadRequestError(param + " must be an RFC 3339 time")
+	}
+	return parsed, nil
+}
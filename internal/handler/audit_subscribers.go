@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// registerAuditSubscribers records the security relevant events in the audit log. The subscribers are synchronous
+// so the entry gets the details of the request and is written in the transaction of the change, if any.
+func registerAuditSubscribers() {
+	subscribeAudit(func(event events.UserSignedUp) *models.AuditEntry {
+		return &models.AuditEntry{ActorId: event.User.Id, TargetUserId: event.User.Id}
+	})
+	subscribeAudit(func(event events.SignupFailed) *models.AuditEntry {
+		return &models.AuditEntry{Outcome: constants.AuditOutcomeFailure, Reason: event.Reason, Metadata: map[string]string{"email": event.Email}}
+	})
+	subscribeAudit(func(event events.UserLoggedIn) *models.AuditEntry {
+		return &models.AuditEntry{ActorId: event.User.Id, TargetUserId: event.User.Id}
+	})
+	subscribeAudit(func(event events.UserReauthenticated) *models.AuditEntry {
+		return &models.AuditEntry{ActorId: event.User.Id, TargetUserId: event.User.Id, Metadata: map[string]string{"method": event.Method}}
+	})
+	subscribeAudit(func(event events.ImpersonationStarted) *models.AuditEntry {
+		metadata := map[string]string{"reason": event.Reason, "expires_at": event.ExpiresAt.UTC().Format(time.RFC3339)}
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: metadata}
+	})
+	subscribeAudit(func(event events.ImpersonationEnded) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id}
+	})
+	subscribeAudit(func(event events.ImpersonatedRequest) *models.AuditEntry {
+		entry := &models.AuditEntry{TargetUserId: event.User.Id, Metadata: map[string]string{"request": event.Request, "status": event.Status}}
+		if event.Failed {
+			entry.Outcome = constants.AuditOutcomeFailure
+		}
+		return entry
+	})
+	subscribeAudit(func(event events.LoginFailed) *models.AuditEntry {
+		return &models.AuditEntry{Outcome: constants.AuditOutcomeFailure, Reason: event.Reason, TargetUserId: event.UserId, Metadata: map[string]string{"email": event.Email}}
+	})
+	subscribeAudit(func(event events.EmailVerified) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id}
+	})
+	// the change password otp proves who changes it
+	subscribeAudit(func(event events.PasswordChanged) *models.AuditEntry {
+		return &models.AuditEntry{ActorId: event.User.Id, TargetUserId: event.User.Id}
+	})
+	subscribeAudit(func(event events.UserDeleted) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id}
+	})
+	subscribeAudit(func(event events.StatusChanged) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: map[string]string{"status": event.User.Status, "previous_status": event.PreviousStatus}}
+	})
+	subscribeAudit(func(event events.RoleChanged) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: map[string]string{"role": event.Role, "previous_role": event.PreviousRole}}
+	})
+	subscribeAudit(func(event events.UserSuspended) *models.AuditEntry {
+		metadata := map[string]string{"status": event.User.Status, "previous_status": event.PreviousStatus, "reason": event.Reason}
+		if event.ExpiresAt != nil {
+			metadata["expires_at"] = event.ExpiresAt.UTC().Format(time.RFC3339)
+		}
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: metadata}
+	})
+	subscribeAudit(func(event events.UserReinstated) *models.AuditEntry {
+		metadata := map[string]string{"status": event.User.Status, "suspended_status": event.SuspendedStatus, "expired": strconv.FormatBool(event.Expired)}
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: metadata}
+	})
+	subscribeAudit(func(event events.SessionsRevoked) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id}
+	})
+	// the link of the login alert proves who secures the account
+	subscribeAudit(func(event events.AccountSecured) *models.AuditEntry {
+		return &models.AuditEntry{ActorId: event.User.Id, TargetUserId: event.User.Id, Metadata: map[string]string{"device_id": event.DeviceId}}
+	})
+	subscribeAudit(func(event events.DeviceForgotten) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: map[string]string{"device_id": event.DeviceId}}
+	})
+	// the login at the provider proves who links the identity
+	subscribeAudit(func(event events.IdentityLinked) *models.AuditEntry {
+		return &models.AuditEntry{ActorId: event.User.Id, TargetUserId: event.User.Id, Metadata: map[string]string{"provider": event.Provider, "subject": event.Subject}}
+	})
+	subscribeAudit(func(event events.IdentityUnlinked) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: map[string]string{"provider": event.Provider, "subject": event.Subject}}
+	})
+	subscribeAudit(func(event events.UserProfileUpdated) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: map[string]string{"email": event.User.Email}}
+	})
+	subscribeAudit(func(event events.PersonalAccessTokenCreated) *models.AuditEntry {
+		metadata := map[string]string{"token_id": event.TokenId, "name": event.TokenName, "scopes": strings.Join(event.Scopes, " ")}
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: metadata}
+	})
+	subscribeAudit(func(event events.PersonalAccessTokenDeleted) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: map[string]string{"token_id": event.TokenId, "name": event.TokenName}}
+	})
+	subscribeAudit(func(event events.OtpSent) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: map[string]string{"scope": event.Scope}}
+	})
+	subscribeAudit(func(event events.OtpVerified) *models.AuditEntry {
+		return &models.AuditEntry{ActorId: event.User.Id, TargetUserId: event.User.Id, Metadata: map[string]string{"scope": event.Scope}}
+	})
+	subscribeAudit(func(event events.OtpVerificationFailed) *models.AuditEntry {
+		return &models.AuditEntry{Outcome: constants.AuditOutcomeFailure, Reason: event.Reason, TargetUserId: event.User.Id, Metadata: map[string]string{"scope": event.Scope}}
+	})
+	subscribeAudit(func(event events.WebhookEndpointCreated) *models.AuditEntry {
+		return &models.AuditEntry{Metadata: map[string]string{"endpoint_id": event.EndpointId, "url": event.Url}}
+	})
+	subscribeAudit(func(event events.WebhookEndpointDeleted) *models.AuditEntry {
+		return &models.AuditEntry{Metadata: map[string]string{"endpoint_id": event.EndpointId, "url": event.Url}}
+	})
+	subscribeAudit(func(event events.WebhookDeliveriesReplayed) *models.AuditEntry {
+		metadata := map[string]string{"endpoint_id": event.EndpointId, "count": strconv.FormatInt(event.Count, 10)}
+		if event.DeliveryId != "" {
+			metadata["delivery_id"] = event.DeliveryId
+		}
+		return &models.AuditEntry{Metadata: metadata}
+	})
+	subscribeAudit(func(event events.OAuthClientCreated) *models.AuditEntry {
+		return &models.AuditEntry{Metadata: map[string]string{"client_id": event.ClientId, "name": event.ClientName}}
+	})
+	subscribeAudit(func(event events.OAuthClientDeleted) *models.AuditEntry {
+		return &models.AuditEntry{Metadata: map[string]string{"client_id": event.ClientId, "name": event.ClientName}}
+	})
+	subscribeAudit(func(event events.OAuthConsentGranted) *models.AuditEntry {
+		return &models.AuditEntry{TargetUserId: event.User.Id, Metadata: map[string]string{"client_id": event.ClientId, "scopes": strings.Join(event.Scopes, " ")}}
+	})
+	subscribeAudit(func(event events.SamlConnectionSaved) *models.AuditEntry {
+		return &models.AuditEntry{Metadata: map[string]string{"organization_id": event.OrganizationId, "idp_entity_id": event.IdpEntityId}}
+	})
+	subscribeAudit(func(event events.SamlConnectionDeleted) *models.AuditEntry {
+		return &models.AuditEntry{Metadata: map[string]string{"organization_id": event.OrganizationId, "idp_entity_id": event.IdpEntityId}}
+	})
+	subscribeAudit(func(event events.InvitationCodeCreated) *models.AuditEntry {
+		return &models.AuditEntry{Metadata: map[string]string{"invitation_code_id": event.InvitationCodeId, "max_uses": strconv.Itoa(int(event.MaxUses))}}
+	})
+	subscribeAudit(func(event events.ScimTokenCreated) *models.AuditEntry {
+		return &models.AuditEntry{Metadata: map[string]string{"organization_id": event.OrganizationId, "token_id": event.TokenId, "name": event.TokenName}}
+	})
+	subscribeAudit(func(event events.ScimTokenDeleted) *models.AuditEntry {
+		return &models.AuditEntry{Metadata: map[string]string{"organization_id": event.OrganizationId, "token_id": event.TokenId, "name": event.TokenName}}
+	})
+	subscribeAudit(func(event events.ScimGroupSaved) *models.AuditEntry {
+		metadata := map[string]string{"organization_id": event.OrganizationId, "group_id": event.GroupId, "display_name": event.DisplayName, "member_count": strconv.Itoa(event.MemberCount)}
+		return &models.AuditEntry{Metadata: metadata}
+	})
+	subscribeAudit(func(event events.ScimGroupDeleted) *models.AuditEntry {
+		return &models.AuditEntry{Metadata: map[string]string{"organization_id": event.OrganizationId, "group_id": event.GroupId, "display_name": event.DisplayName}}
+	})
+}
+
+// subscribeAudit records every event of the type as the entry describe returns, completed with the action,
+// the actor and the request details. describe sets the actor only when it is the user the event is about
+// and the request is not authenticated yet, e.g. on login.
+func subscribeAudit[T events.Event](describe func(event T) *models.AuditEntry) {
+	events.Subscribe(func(c *gin.Context, event T) *errors.Error {
+		entry := describe(event)
+		entry.Action = event.Name()
+		if entry.Outcome == "" {
+			entry.Outcome = constants.AuditOutcomeSuccess
+		}
+		setAuditActor(c, entry)
+		entry.Ip = c.ClientIP()
+		entry.UserAgent = c.Request.UserAgent()
+		entry.RequestId = utils.GetContextRequestId(c)
+		return entry.Insert(c)
+	})
+}
+
+// an impersonating admin acts, then the authenticated user, then the cli or a background job, then the user the
+// event vouches for
+func setAuditActor(c *gin.Context, entry *models.AuditEntry) {
+	if impersonator := utils.GetContextImpersonator(c); impersonator != nil {
+		entry.ActorId, entry.ActorType = impersonator.Id, constants.AuditActorUser
+		if entry.Metadata == nil {
+			entry.Metadata = map[string]string{}
+		}
+		entry.Metadata["impersonated_user_id"] = utils.GetContextUser(c).Id
+		return
+	}
+	if user := utils.GetContextUser(c); user != nil {
+		entry.ActorId, entry.ActorType = user.Id, constants.AuditActorUser
+		return
+	}
+	if actorType := utils.GetContextActorType(c); actorType != "" {
+		entry.ActorId, entry.ActorType = "", actorType
+		return
+	}
+	if entry.ActorId != "" {
+		entry.ActorType = constants.AuditActorUser
+		return
+	}
+	entry.ActorType = constants.AuditActorAnonymous
+}
@@ -2,9 +2,15 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
 	"github.com/MitP1997/golang-user-management/internal/models"
 	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/suspensions"
 	"github.com/MitP1997/golang-user-management/internal/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -16,30 +22,203 @@ import (
 //	if present, add the user object to context and continue
 //	else return unauthorized error in response
 func IsAuthorized(fn gin.HandlerFunc) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		logger := utils.GetContextLogger(c)
-		token := c.GetHeader("Authorization")
-		if token == "" {
-			logger.Info("No Authorization headers provided")
-			c.IndentedJSON(http.StatusForbidden, gin.H{"message": "No Authorization headers provided"})
+	return decorator(func(c *gin.Context) {
+		if describeRoute(c, fn, func(r *RouteRequirements) { r.Authorized = true }) {
+			return
+		}
+		if e := authorizeRequest(c); e != nil {
+			utils.RespondWithError(c, e)
 			return
 		}
+		fn(c)
+		auditImpersonatedRequest(c, httpRequestName(c), strconv.Itoa(c.Writer.Status()), c.Writer.Status() >= http.StatusBadRequest)
+	})
+}
+
+// resolves the user and organization of the request from its Authorization header and adds them to the context
+// shared by IsAuthorized and the gRPC auth interceptor
+func authorizeRequest(c *gin.Context) *errors.Error {
+	logger := utils.GetContextLogger(c)
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		logger.Info("No Authorization headers provided")
+		return errors.MissingAuthorizationError()
+	}
+	var user models.User
+	// personal access tokens may come with the bearer scheme, as most http clients send them
+	if pat := strings.TrimPrefix(token, "Bearer "); strings.HasPrefix(pat, constants.PersonalAccessTokenPrefix) {
+		token = pat
+		if e := authorizePersonalAccessToken(c, token, &user); e != nil {
+			return e
+		}
+	} else if strings.HasPrefix(token, constants.ImpersonationTokenPrefix) {
+		if e := authorizeImpersonationToken(c, token, &user); e != nil {
+			return e
+		}
+	} else if accessToken, ok := strings.CutPrefix(token, "Bearer "); ok {
+		// auth tokens of a login are sent without a scheme, so a bearer token is an oauth access token
+		token = accessToken
+		if e := authorizeOAuthAccessToken(c, token, &user); e != nil {
+			return e
+		}
+	} else {
 		redisClient := serviceRegistry.GetRedisClient()
 		userId, _, err := redisClient.GetOrCreateAndSetExpiryAuthToken(c, "", token)
 		if err != nil || userId == "" {
 			logger.Error("Error in GetOrCreateAndSetExpiryAuthToken or userId is empty", zap.Error(err.Error()))
-			c.IndentedJSON(http.StatusForbidden, gin.H{"message": "Please login!"})
-			return
+			return errors.InvalidAuthTokenError(err.Error())
 		}
-
-		var user models.User
-		e := user.FindOne(c, bson.M{"_id": userId})
-		if e != nil {
+		if e := user.FindOne(c, bson.M{"_id": userId}); e != nil {
 			logger.Error("Error while finding user in database", zap.Error(e.Error()))
-			c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
+			return e
+		}
+		authentication, e := redisClient.GetAuthTokenAuthentication(c, token)
+		if e != nil {
+			logger.Error("Error while fetching authentication of auth token", zap.Error(e.Error()))
+			return e
+		}
+		utils.SetContextAuthentication(c, authentication)
+		// a login can do everything the user can
+		utils.SetContextScopes(c, allScopes())
+	}
+	// an impersonating admin may look into a suspended account, any other token stops working right away
+	if utils.GetContextImpersonator(c) == nil {
+		if e := suspensions.Refuse(&user); e != nil {
+			logger.Info("Request of a suspended or banned user", zap.String("user_id", user.Id), zap.String("status", user.Status.String()))
+			return e
+		}
+	}
+	utils.SetContextUser(c, &user)
+
+	organization, membership, e := resolveOrganizationContext(c, &user, token)
+	if e != nil {
+		logger.Error("Error while resolving organization context", zap.Error(e.Error()))
+		return e
+	}
+	if organization != nil {
+		utils.SetContextOrganization(c, organization, membership)
+	}
+	return nil
+}
+
+// requires the token of the request to grant the scope, must be wrapped by IsAuthorized as it relies on the scopes
+// set there
+func RequireScope(scope string, fn gin.HandlerFunc) gin.HandlerFunc {
+	return decorator(func(c *gin.Context) {
+		if describeRoute(c, fn, func(r *RouteRequirements) { r.Scope = scope }) {
+			return
+		}
+		if e := authorizeScope(c, scope); e != nil {
+			utils.RespondWithError(c, e)
 			return
 		}
-		utils.SetContextUser(c, &user)
 		fn(c)
+	})
+}
+
+// shared by RequireScope and the gRPC auth interceptor
+func authorizeScope(c *gin.Context, scope string) *errors.Error {
+	if !contains(utils.GetContextScopes(c), scope) {
+		// an impersonating admin only gets to look
+		if utils.GetContextImpersonator(c) != nil {
+			utils.GetContextLogger(c).Info("Impersonating admin requested a route that changes the account", zap.String("scope", scope))
+			return errors.ImpersonationForbiddenError()
+		}
+		utils.GetContextLogger(c).Info("Token lacks the scope of the route", zap.String("scope", scope), zap.Strings("scopes", utils.GetContextScopes(c)))
+		return errors.InsufficientScopeError(scope)
+	}
+	return nil
+}
+
+func allScopes() []string {
+	scopes := make([]string, 0, len(constants.ScopeDescriptions))
+	for scope := range constants.ScopeDescriptions {
+		scopes = append(scopes, scope)
 	}
+	return scopes
+}
+
+// requires the user to have logged in or reauthenticated within maxAge, the client asks for the password with
+// POST /user/reauthenticate and retries, must be wrapped by IsAuthorized as it relies on the authentication set there
+func RequireRecentAuth(maxAge time.Duration, fn gin.HandlerFunc) gin.HandlerFunc {
+	return decorator(func(c *gin.Context) {
+		if describeRoute(c, fn, func(r *RouteRequirements) { r.RecentAuth = true }) {
+			return
+		}
+		logger := utils.GetContextLogger(c)
+		if utils.GetContextImpersonator(c) != nil {
+			logger.Info("Sensitive route requested while impersonating")
+			utils.RespondWithError(c, errors.ImpersonationForbiddenError())
+			return
+		}
+		authentication := utils.GetContextAuthentication(c)
+		// personal access tokens and oauth access tokens can not prove who uses them
+		if authentication == nil {
+			logger.Info("Sensitive route requested with a token instead of a login")
+			utils.RespondWithError(c, errors.SessionRequiredError())
+			return
+		}
+		if time.Since(time.Unix(authentication.AuthTime, 0)) > maxAge {
+			logger.Info("Sensitive route requested without a recent authentication", zap.Int64("auth_time", authentication.AuthTime))
+			utils.RespondWithError(c, errors.RecentAuthenticationRequiredError())
+			return
+		}
+		fn(c)
+	})
+}
+
+// requires the request to be scoped to an organization in which the user has at least the given role
+// must be wrapped by IsAuthorized as it relies on the organization context set there
+func RequireOrganizationRole(role models.MembershipRole, fn gin.HandlerFunc) gin.HandlerFunc {
+	return decorator(func(c *gin.Context) {
+		if describeRoute(c, fn, func(r *RouteRequirements) { r.OrganizationRole = true }) {
+			return
+		}
+		logger := utils.GetContextLogger(c)
+		membership := utils.GetContextMembership(c)
+		if utils.GetContextOrganization(c) == nil || membership == nil {
+			e := errors.OrganizationRequiredError()
+			logger.Info("Organization not selected for request")
+			utils.RespondWithError(c, e)
+			return
+		}
+		if membership.Role < role {
+			e := errors.InsufficientOrganizationRoleError()
+			logger.Info("Insufficient organization role", zap.String("role", membership.Role.String()), zap.String("required_role", role.String()))
+			utils.RespondWithError(c, e)
+			return
+		}
+		fn(c)
+	})
+}
+
+// limits the route to platform admins, must be wrapped by IsAuthorized as it relies on the user set there
+func RequireAdmin(fn gin.HandlerFunc) gin.HandlerFunc {
+	return decorator(func(c *gin.Context) {
+		if describeRoute(c, fn, func(r *RouteRequirements) { r.Admin = true }) {
+			return
+		}
+		user := utils.GetContextUser(c)
+		if user == nil || user.Role != models.UserRole_PLATFORM_ADMIN {
+			utils.GetContextLogger(c).Info("Admin route requested by a user who is not a platform admin")
+			utils.RespondWithError(c, errors.AdminRequiredError())
+			return
+		}
+		fn(c)
+	})
+}
+
+// authenticates the identity provider of an organization by its SCIM token and scopes the request to the
+// organization, errors are answered in the SCIM format
+func RequireScimToken(fn gin.HandlerFunc) gin.HandlerFunc {
+	return decorator(func(c *gin.Context) {
+		if describeRoute(c, fn, func(r *RouteRequirements) { r.ScimToken = true }) {
+			return
+		}
+		if e := authorizeScimRequest(c); e != nil {
+			respondWithScimError(c, e)
+			return
+		}
+		fn(c)
+	})
 }
@@ -0,0 +1,137 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// newContext is the context of a request that IsAuthorized let through
+func newContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	utils.SetContextLogger(c, zap.NewNop())
+	return c, recorder
+}
+
+// serve runs the decorated handler and returns the error code it responded with, empty when the handler ran
+func serve(t *testing.T, c *gin.Context, recorder *httptest.ResponseRecorder, decorate func(gin.HandlerFunc) gin.HandlerFunc) string {
+	t.Helper()
+	called := false
+	decorate(func(c *gin.Context) { called = true })(c)
+	if called {
+		return ""
+	}
+	var envelope errors.ErrorEnvelope
+	if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("handler not called and no error envelope: %s", recorder.Body.String())
+	}
+	return envelope.Error.Code
+}
+
+func requireScope(scope string) func(gin.HandlerFunc) gin.HandlerFunc {
+	return func(fn gin.HandlerFunc) gin.HandlerFunc { return handler.RequireScope(scope, fn) }
+}
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		scope  string
+		want   string
+	}{
+		{"granted", []string{constants.ScopeProfileRead, constants.ScopeAccountWrite}, constants.ScopeAccountWrite, ""},
+		{"not granted", []string{constants.ScopeProfileRead}, constants.ScopeAccountWrite, errors.CodeAuthInsufficientScope},
+		{"read does not grant write", []string{constants.ScopeProfileRead}, constants.ScopeProfileWrite, errors.CodeAuthInsufficientScope},
+		{"admin does not grant the rest", []string{constants.ScopeAdmin}, constants.ScopeOrganizationWrite, errors.CodeAuthInsufficientScope},
+		{"no scopes", nil, constants.ScopeProfileRead, errors.CodeAuthInsufficientScope},
+	}
+	for _, test := range tests {
+		c, recorder := newContext()
+		utils.SetContextScopes(c, test.scopes)
+		if got := serve(t, c, recorder, requireScope(test.scope)); got != test.want {
+			t.Errorf("%s: RequireScope(%s) = %q, want %q", test.name, test.scope, got, test.want)
+		}
+		if test.want != "" && recorder.Code != http.StatusForbidden {
+			t.Errorf("%s: status %d, want %d", test.name, recorder.Code, http.StatusForbidden)
+		}
+	}
+}
+
+func TestRequireRecentAuth(t *testing.T) {
+	maxAge := 5 * time.Minute
+	tests := []struct {
+		name           string
+		authentication *datatypes.SessionAuthentication
+		want           string
+	}{
+		{"recent login", &datatypes.SessionAuthentication{Method: constants.AuthMethodPassword, AuthTime: time.Now().Add(-time.Minute).Unix()}, ""},
+		{"recent saml login", &datatypes.SessionAuthentication{Method: constants.AuthMethodSaml, AuthTime: time.Now().Unix(), OrganizationId: "org-1"}, ""},
+		{"old login", &datatypes.SessionAuthentication{Method: constants.AuthMethodPassword, AuthTime: time.Now().Add(-maxAge - time.Second).Unix()}, errors.CodeAuthRecentAuthRequired},
+		{"session without authentication time", &datatypes.SessionAuthentication{}, errors.CodeAuthRecentAuthRequired},
+		{"token instead of a login", nil, errors.CodeAuthSessionRequired},
+	}
+	for _, test := range tests {
+		c, recorder := newContext()
+		if test.authentication != nil {
+			utils.SetContextAuthentication(c, test.authentication)
+		}
+		got := serve(t, c, recorder, func(fn gin.HandlerFunc) gin.HandlerFunc { return handler.RequireRecentAuth(maxAge, fn) })
+		if got != test.want {
+			t.Errorf("%s: RequireRecentAuth = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+// impersonationContext is the context authorizeImpersonationToken sets up
+func impersonationContext() (*gin.Context, *httptest.ResponseRecorder) {
+	c, recorder := newContext()
+	admin := &models.User{Id: "admin-1", Role: models.UserRole_PLATFORM_ADMIN}
+	utils.SetContextUser(c, &models.User{Id: "user-1"})
+	utils.SetContextImpersonation(c, admin, &datatypes.Impersonation{UserId: "user-1", ImpersonatorId: admin.Id, Reason: "ticket 42"})
+	utils.SetContextScopes(c, constants.ImpersonationScopes)
+	return c, recorder
+}
+
+func TestImpersonationIsReadOnly(t *testing.T) {
+	for scope := range constants.ScopeDescriptions {
+		readOnly := strings.HasSuffix(scope, ":read")
+		granted := false
+		for _, impersonationScope := range constants.ImpersonationScopes {
+			granted = granted || impersonationScope == scope
+		}
+		if granted != readOnly {
+			t.Errorf("impersonation scopes contain %s: %v, want %v", scope, granted, readOnly)
+		}
+		want := errors.CodeImpersonationForbidden
+		if readOnly {
+			want = ""
+		}
+		c, recorder := impersonationContext()
+		if got := serve(t, c, recorder, requireScope(scope)); got != want {
+			t.Errorf("RequireScope(%s) while impersonating = %q, want %q", scope, got, want)
+		}
+	}
+
+	// the admin's own fresh login does not make the impersonation one
+	c, recorder := impersonationContext()
+	utils.SetContextAuthentication(c, &datatypes.SessionAuthentication{Method: constants.AuthMethodPassword, AuthTime: time.Now().Unix()})
+	got := serve(t, c, recorder, func(fn gin.HandlerFunc) gin.HandlerFunc { return handler.RequireRecentAuth(5*time.Minute, fn) })
+	if got != errors.CodeImpersonationForbidden {
+		t.Errorf("RequireRecentAuth while impersonating = %q, want %q", got, errors.CodeImpersonationForbidden)
+	}
+}
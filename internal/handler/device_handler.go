@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// ListDevices lists the devices the user logged in from, most recently seen first
+func ListDevices(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	devices, e := models.FindUserDevices(c, utils.GetContextUser(c).Id)
+	if e != nil {
+		logger.Error("Error while fetching devices from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"devices": devices})
+}
+
+// ForgetDevice removes a device of the user, the next login from it sends a login alert again
+func ForgetDevice(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	user := utils.GetContextUser(c)
+
+	var device models.Device
+	e := device.FindOne(c, bson.M{"_id": c.Param("device_id"), "user_id": user.Id})
+	if e != nil {
+		logger.Error("Error while fetching device from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		if e := device.Delete(c); e != nil {
+			logger.Error("Error while deleting device", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.DeviceForgotten{User: events.NewUser(user), DeviceId: device.Id}); e != nil {
+			logger.Error("Error while publishing device forgotten event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Device forgotten"})
+}
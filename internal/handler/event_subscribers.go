@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/metrics"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/MitP1997/golang-user-management/internal/webhooks"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RegisterEventSubscribers wires the side effects of the user account logic to its events,
+// every process that publishes events calls it once at startup
+func RegisterEventSubscribers() {
+	events.Subscribe(sendSignupVerificationOtp)
+
+	// the stable error code is used as the failure reason so the label set stays bounded
+	events.Subscribe(func(c *gin.Context, event events.UserSignedUp) *errors.Error {
+		metrics.SignupsTotal.Inc()
+		return nil
+	})
+	events.Subscribe(func(c *gin.Context, event events.SignupFailed) *errors.Error {
+		metrics.SignupFailuresTotal.WithLabelValues(event.Reason).Inc()
+		return nil
+	})
+	events.Subscribe(func(c *gin.Context, event events.UserLoggedIn) *errors.Error {
+		metrics.LoginsTotal.Inc()
+		return nil
+	})
+	events.Subscribe(func(c *gin.Context, event events.LoginFailed) *errors.Error {
+		metrics.LoginFailuresTotal.WithLabelValues(event.Reason).Inc()
+		return nil
+	})
+	events.Subscribe(func(c *gin.Context, event events.OtpSent) *errors.Error {
+		metrics.OtpsIssuedTotal.WithLabelValues(event.Scope).Inc()
+		return nil
+	})
+	events.Subscribe(func(c *gin.Context, event events.OtpVerified) *errors.Error {
+		metrics.OtpVerificationsTotal.WithLabelValues(event.Scope, metrics.OtpVerified).Inc()
+		return nil
+	})
+	events.Subscribe(func(c *gin.Context, event events.OtpVerificationFailed) *errors.Error {
+		metrics.OtpVerificationsTotal.WithLabelValues(event.Scope, metrics.OtpFailed).Inc()
+		return nil
+	})
+
+	registerAuditSubscribers()
+
+	events.SubscribeAsync("webhooks", emitWebhook[events.UserSignedUp])
+	events.SubscribeAsync("webhooks", emitWebhook[events.EmailVerified])
+	events.SubscribeAsync("webhooks", emitWebhook[events.PasswordChanged])
+	events.SubscribeAsync("webhooks", emitWebhook[events.UserDeleted])
+
+	events.SubscribeAsync("login_alerts", sendLoginAlert)
+}
+
+// a failure fails the signup, so the user is not left without a way to verify the email
+func sendSignupVerificationOtp(c *gin.Context, event events.UserSignedUp) *errors.Error {
+	// the provider of a social login already verified the email
+	if event.User.Status == models.UserStatus_VERIFIED.String() {
+		return nil
+	}
+	// the otp helpers only read the id, the email and the names
+	user := &models.User{Id: event.User.Id, Email: event.User.Email, GivenName: event.User.GivenName, FamilyName: event.User.FamilyName}
+	return sendEmailVerificationOtp(c, user)
+}
+
+// the event is the data of the webhook payload
+func emitWebhook[T events.Event](c *gin.Context, event T) *errors.Error {
+	return webhooks.Emit(c, event.Name(), event)
+}
+
+// for the events that only report what happened, a subscriber failing them must not change the outcome of the request
+func publishAndLog(c *gin.Context, event events.Event) {
+	if e := events.Publish(c, event); e != nil {
+		utils.GetContextLogger(c).Error("Error while publishing event", zap.String("event", event.Name()), zap.Error(e.Error()))
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// the unexported helpers under test
+var (
+	EnforceSignupPolicy   = enforceSignupPolicy
+	VerifyPkce            = verifyPkce
+	ValidOAuthRedirectUri = validOAuthRedirectUri
+	OAuthRedirect         = oauthRedirect
+	RefreshScopes         = refreshScopes
+	IdTokenClaims         = idTokenClaims
+
+	CheckPersonalAccessTokenRequest = checkPersonalAccessTokenRequest
+	HashPersonalAccessToken         = hashPersonalAccessToken
+
+	AuthorizeRequest      = authorizeRequest
+	GrpcAuthorizedMethods = grpcAuthorizedMethods
+	GrpcCode              = grpcCode
+)
+
+// SetGrpcAuthorization replaces how the gRPC interceptor resolves the user until restore is called
+func SetGrpcAuthorization(authorize func(c *gin.Context) *errors.Error) (restore func()) {
+	previous := authorizeGrpcRequest
+	authorizeGrpcRequest = authorize
+	return func() { authorizeGrpcRequest = previous }
+}
+
+// OAuthErrorCode is the error code of an oauth error, empty without one
+func OAuthErrorCode(err *oauthError) string {
+	if err == nil {
+		return ""
+	}
+	return err.Code
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/services"
+	"github.com/MitP1997/golang-user-management/internal/tracing"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const grpcErrorDomain = "golang-user-management"
+
+type ginContextKey struct{}
+
+// methods that need an authorized user and the scope they need, the gRPC equivalent of wrapping a route with
+// IsAuthorized and RequireScope
+var grpcAuthorizedMethods = map[string]string{
+	services.UserService_VerifyEmail_FullMethodName:                constants.ScopeProfileWrite,
+	services.UserService_ResendEmailVerificationOtp_FullMethodName: constants.ScopeProfileWrite,
+	services.UserService_GetPendingRequirements_FullMethodName:     constants.ScopeProfileRead,
+	services.UserService_ChangePasswordInitiate_FullMethodName:     constants.ScopeAccountWrite,
+	services.UserService_ChangePassword_FullMethodName:             constants.ScopeAccountWrite,
+}
+
+// resolves the user of a call to a method in grpcAuthorizedMethods, the tests swap it as it needs the stores
+var authorizeGrpcRequest = authorizeRequest
+
+// UnaryServerInterceptor prepares the gin context the shared handler logic expects:
+// the incoming metadata becomes the request headers, a request scoped logger is added
+// and, for methods listed in grpcAuthorizedMethods, the user is authorized like IsAuthorized does
+func UnaryServerInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+		header := http.Header{}
+		md, _ := metadata.FromIncomingContext(ctx)
+		for key, values := range md {
+			for _, value := range values {
+				header.Add(key, value)
+			}
+		}
+		c := utils.NewContext(ctx, header)
+		// read by c.ClientIP like for http requests, e.g. for the audit log
+		if p, ok := peer.FromContext(ctx); ok {
+			c.Request.RemoteAddr = p.Addr.String()
+		}
+
+		requestId := uuid.New().String()
+		fields := append([]zap.Field{zap.String("request_id", requestId), zap.String("grpc_method", info.FullMethod)}, tracing.LogFields(ctx)...)
+		utils.SetContextLogger(c, logger.With(fields...))
+		utils.SetContextRequestId(c, requestId)
+		_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestId))
+
+		// a panic must fail the call instead of taking the whole server down, like the gin recovery middleware
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				utils.GetContextLogger(c).Error("Recovered from panic", zap.Any("error", recovered), zap.Stack("stack"))
+				res, err = nil, grpcStatusError(c, errors.InternalServerError(fmt.Errorf("panic: %v", recovered)))
+			}
+		}()
+
+		if scope, ok := grpcAuthorizedMethods[info.FullMethod]; ok {
+			if e := authorizeGrpcRequest(c); e != nil {
+				return nil, grpcStatusError(c, e)
+			}
+			defer func() {
+				auditImpersonatedRequest(c, info.FullMethod, status.Code(err).String(), err != nil)
+			}()
+			if e := authorizeScope(c, scope); e != nil {
+				return nil, grpcStatusError(c, e)
+			}
+		}
+		return handler(context.WithValue(ctx, ginContextKey{}, c), req)
+	}
+}
+
+func grpcGinContext(ctx context.Context) *gin.Context {
+	return ctx.Value(ginContextKey{}).(*gin.Context)
+}
+
+// converts the error to a gRPC status carrying the same stable code as the HTTP error envelope in an ErrorInfo detail
+func grpcStatusError(c *gin.Context, e *errors.Error) error {
+	st := status.New(grpcCode(e), e.UserErrorString())
+	info := &errdetails.ErrorInfo{
+		Reason:   e.UserErrorCode(),
+		Domain:   grpcErrorDomain,
+		Metadata: map[string]string{"request_id": utils.GetContextRequestId(c)},
+	}
+	if withDetails, err := st.WithDetails(info); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+func grpcCode(e *errors.Error) codes.Code {
+	switch e.UserErrorCode() {
+	case errors.CodeAuthMissingToken, errors.CodeAuthInvalidToken, errors.CodeAuthUnauthenticated:
+		return codes.Unauthenticated
+	}
+	switch e.UserStatusError() {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	}
+	return codes.Internal
+}
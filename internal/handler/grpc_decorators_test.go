@@ -0,0 +1,211 @@
+package handler_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/responses"
+	"github.com/MitP1997/golang-user-management/internal/services"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// answers every call the interceptor lets through
+type stubUserService struct {
+	services.UnimplementedUserServiceServer
+}
+
+func (stubUserService) VerifyEmail(context.Context, *requests.VerifyEmailRequest) (*responses.MessageResponse, error) {
+	return &responses.MessageResponse{Message: "verified"}, nil
+}
+
+func (stubUserService) GetPendingRequirements(context.Context, *requests.GetPendingRequirementsRequest) (*responses.PendingRequirementsResponse, error) {
+	return &responses.PendingRequirementsResponse{}, nil
+}
+
+func (stubUserService) Login(context.Context, *requests.LoginRequest) (*responses.AuthTokenResponse, error) {
+	return &responses.AuthTokenResponse{Message: "logged in"}, nil
+}
+
+// a token "scopes:<scope>,<scope>" stands for a login with those scopes, anything else is refused like an unknown token
+func fakeAuthorization(c *gin.Context) *errors.Error {
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		return handler.AuthorizeRequest(c)
+	}
+	scopes, ok := strings.CutPrefix(token, "scopes:")
+	if !ok {
+		return errors.InvalidAuthTokenError(nil)
+	}
+	utils.SetContextUser(c, &models.User{Id: "user"})
+	utils.SetContextScopes(c, strings.Split(scopes, ","))
+	return nil
+}
+
+func dialUserService(t *testing.T) services.UserServiceClient {
+	t.Cleanup(handler.SetGrpcAuthorization(fakeAuthorization))
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(handler.UnaryServerInterceptor(zap.NewNop())))
+	services.RegisterUserServiceServer(srv, stubUserService{})
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return services.NewUserServiceClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", token)
+}
+
+// reason is the stable error code carried in the ErrorInfo detail
+func reason(t *testing.T, err error) string {
+	t.Helper()
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	t.Fatalf("no ErrorInfo in %v", err)
+	return ""
+}
+
+func TestGrpcAuthorization(t *testing.T) {
+	client := dialUserService(t)
+	tests := []struct {
+		name       string
+		call       func(ctx context.Context) error
+		ctx        context.Context
+		wantCode   codes.Code
+		wantReason string
+	}{
+		{
+			name: "no token",
+			call: func(ctx context.Context) error {
+				_, err := client.GetPendingRequirements(ctx, &requests.GetPendingRequirementsRequest{})
+				return err
+			},
+			ctx:        context.Background(),
+			wantCode:   codes.Unauthenticated,
+			wantReason: errors.CodeAuthMissingToken,
+		},
+		{
+			name: "unknown token",
+			call: func(ctx context.Context) error {
+				_, err := client.GetPendingRequirements(ctx, &requests.GetPendingRequirementsRequest{})
+				return err
+			},
+			ctx:        withToken("stolen"),
+			wantCode:   codes.Unauthenticated,
+			wantReason: errors.CodeAuthInvalidToken,
+		},
+		{
+			name: "token without the scope",
+			call: func(ctx context.Context) error {
+				_, err := client.VerifyEmail(ctx, &requests.VerifyEmailRequest{Otp: "123456"})
+				return err
+			},
+			ctx:        withToken("scopes:" + constants.ScopeProfileRead),
+			wantCode:   codes.PermissionDenied,
+			wantReason: errors.CodeAuthInsufficientScope,
+		},
+		{
+			name: "token with the scope",
+			call: func(ctx context.Context) error {
+				_, err := client.VerifyEmail(ctx, &requests.VerifyEmailRequest{Otp: "123456"})
+				return err
+			},
+			ctx:      withToken("scopes:" + constants.ScopeProfileRead + "," + constants.ScopeProfileWrite),
+			wantCode: codes.OK,
+		},
+		{
+			name: "method without authorization",
+			call: func(ctx context.Context) error {
+				_, err := client.Login(ctx, &requests.LoginRequest{})
+				return err
+			},
+			ctx:      context.Background(),
+			wantCode: codes.OK,
+		},
+	}
+	for _, test := range tests {
+		err := test.call(test.ctx)
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%s: code %s, want %s (%v)", test.name, got, test.wantCode, err)
+			continue
+		}
+		if test.wantReason != "" {
+			if got := reason(t, err); got != test.wantReason {
+				t.Errorf("%s: reason %s, want %s", test.name, got, test.wantReason)
+			}
+		}
+	}
+}
+
+func TestGrpcAuthorizedMethods(t *testing.T) {
+	methods := map[string]bool{}
+	for _, method := range services.UserService_ServiceDesc.Methods {
+		methods["/"+services.UserService_ServiceDesc.ServiceName+"/"+method.MethodName] = true
+	}
+	for method, scope := range handler.GrpcAuthorizedMethods {
+		if !methods[method] {
+			t.Errorf("%s is not a method of the UserService", method)
+		}
+		if _, ok := constants.ScopeDescriptions[scope]; !ok {
+			t.Errorf("%s requires the unknown scope %q", method, scope)
+		}
+	}
+	// the methods acting on the logged in user, like their HTTP routes wrapped with IsAuthorized
+	for _, method := range []string{
+		services.UserService_VerifyEmail_FullMethodName,
+		services.UserService_ResendEmailVerificationOtp_FullMethodName,
+		services.UserService_GetPendingRequirements_FullMethodName,
+		services.UserService_ChangePasswordInitiate_FullMethodName,
+		services.UserService_ChangePassword_FullMethodName,
+	} {
+		if _, ok := handler.GrpcAuthorizedMethods[method]; !ok {
+			t.Errorf("%s does not require an authorized user", method)
+		}
+	}
+}
+
+func TestGrpcCode(t *testing.T) {
+	tests := []struct {
+		err  *errors.Error
+		want codes.Code
+	}{
+		{errors.MissingAuthorizationError(), codes.Unauthenticated},
+		{errors.InvalidAuthTokenError(nil), codes.Unauthenticated},
+		{errors.InsufficientScopeError(constants.ScopeProfileWrite), codes.PermissionDenied},
+		{errors.InvalidCredentialsError(), codes.InvalidArgument},
+		{errors.InvalidOtpError(nil), codes.InvalidArgument},
+		{errors.InternalServerError(http.ErrHandlerTimeout), codes.Internal},
+	}
+	for _, test := range tests {
+		if got := handler.GrpcCode(test.err); got != test.want {
+			t.Errorf("%s: code %s, want %s", test.err.UserErrorCode(), got, test.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/MitP1997/golang-user-management/internal/health"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/gin-gonic/gin"
+)
+
+// Healthz only tells that the process is serving requests, dependencies are left to Readyz
+func Healthz(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+func Readyz(c *gin.Context) {
+	if health.IsShuttingDown() {
+		c.IndentedJSON(http.StatusServiceUnavailable, gin.H{"status": health.StatusShuttingDown})
+		return
+	}
+
+	checks := []health.Check{
+		{Name: "mongo", Ping: models.PingMongo},
+		{Name: "redis", Ping: serviceRegistry.GetRedisClient().Ping},
+	}
+	healthConfig := serviceRegistry.GetConfig().Health
+	if healthConfig.CheckSmtp {
+		checks = append(checks, health.Check{Name: "smtp", Ping: serviceRegistry.GetMailerClient().Ping})
+	}
+	results, ok := health.Run(c.Request.Context(), healthConfig.CheckTimeout, checks)
+	status, httpStatus := health.StatusReady, http.StatusOK
+	if !ok {
+		status, httpStatus = health.StatusNotReady, http.StatusServiceUnavailable
+	}
+	c.IndentedJSON(httpStatus, gin.H{"status": status, "checks": results})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// ImpersonateUser issues a token the admin sees the service as the user with, every request with it is audited
+func ImpersonateUser(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	admin := utils.GetContextUser(c)
+
+	var req requests.ImpersonateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to impersonate user struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	if req.Reason == "" {
+		utils.RespondWithError(c, errors.MissingFieldsError("reason"))
+		return
+	}
+	var user models.User
+	if e := user.FindOne(c, bson.M{"_id": c.Param("user_id")}); e != nil {
+		logger.Error("Error while fetching user to impersonate from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	// another admin could be impersonated to get around the audit of their own actions
+	if user.Id == admin.Id || user.Role == models.UserRole_PLATFORM_ADMIN || user.Status == models.UserStatus_DELETED {
+		logger.Info("Impersonation of a user who can not be impersonated", zap.String("target_user_id", user.Id))
+		utils.RespondWithError(c, errors.ImpersonationTargetNotAllowedError())
+		return
+	}
+	impersonation := datatypes.Impersonation{UserId: user.Id, ImpersonatorId: admin.Id, Reason: req.Reason}
+	token, expiresAt, e := serviceRegistry.GetRedisClient().SetImpersonationToken(c, impersonation)
+	if e != nil {
+		logger.Error("Error while storing impersonation token", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	event := events.ImpersonationStarted{User: events.NewUser(&user), ImpersonatorId: admin.Id, Reason: req.Reason, ExpiresAt: expiresAt}
+	if e := events.Publish(c, event); e != nil {
+		logger.Error("Error while publishing impersonation started event", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Impersonation started", "token": token, "expires_at": expiresAt})
+}
+
+// EndImpersonation revokes the impersonation token of the request before it expires
+func EndImpersonation(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	impersonator := utils.GetContextImpersonator(c)
+	if impersonator == nil {
+		utils.RespondWithError(c, errors.ImpersonationNotActiveError())
+		return
+	}
+	if e := serviceRegistry.GetRedisClient().DeleteImpersonationToken(c, c.GetHeader("Authorization")); e != nil {
+		logger.Error("Error while deleting impersonation token", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	publishAndLog(c, events.ImpersonationEnded{User: events.NewUser(utils.GetContextUser(c)), ImpersonatorId: impersonator.Id})
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Impersonation ended"})
+}
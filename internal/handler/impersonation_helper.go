@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/suspensions"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// authorizeImpersonationToken loads the impersonated user of the token into user and the admin into the context,
+// the token stops working when either account can no longer be used or the admin lost the role
+func authorizeImpersonationToken(c *gin.Context, token string, user *models.User) *errors.Error {
+	logger := utils.GetContextLogger(c)
+
+	impersonation, e := serviceRegistry.GetRedisClient().GetImpersonationToken(c, token)
+	if e != nil {
+		if e.IsNotFound() {
+			logger.Info("Unknown or expired impersonation token")
+			return errors.InvalidAuthTokenError(e.Error())
+		}
+		logger.Error("Error while fetching impersonation token", zap.Error(e.Error()))
+		return e
+	}
+	impersonator := &models.User{}
+	if e := impersonator.FindOne(c, bson.M{"_id": impersonation.ImpersonatorId}); e != nil {
+		logger.Error("Error while fetching impersonator from database", zap.Error(e.Error()))
+		return e
+	}
+	if impersonator.Role != models.UserRole_PLATFORM_ADMIN || impersonator.Status == models.UserStatus_DELETED || impersonator.PasswordResetRequired || suspensions.InEffect(impersonator, time.Now()) {
+		logger.Info("Impersonation token of an admin who can no longer impersonate")
+		return errors.InvalidAuthTokenError(nil)
+	}
+	if e := user.FindOne(c, bson.M{"_id": impersonation.UserId}); e != nil {
+		logger.Error("Error while fetching impersonated user from database", zap.Error(e.Error()))
+		return e
+	}
+	if user.Status == models.UserStatus_DELETED {
+		logger.Info("Impersonation token of a deleted user")
+		return errors.InvalidAuthTokenError(nil)
+	}
+	utils.AddKeyToContextLogger(c, "impersonator_id", impersonator.Id)
+	utils.SetContextImpersonation(c, impersonator, impersonation)
+	utils.SetContextScopes(c, constants.ImpersonationScopes)
+	return nil
+}
+
+// auditImpersonatedRequest records a request made with an impersonation token once it is answered
+func auditImpersonatedRequest(c *gin.Context, request string, status string, failed bool) {
+	impersonator := utils.GetContextImpersonator(c)
+	if impersonator == nil {
+		return
+	}
+	publishAndLog(c, events.ImpersonatedRequest{
+		User:           events.NewUser(utils.GetContextUser(c)),
+		ImpersonatorId: impersonator.Id,
+		Request:        request,
+		Status:         status,
+		Failed:         failed,
+	})
+}
+
+func httpRequestName(c *gin.Context) string {
+	return fmt.Sprintf("%s %s", c.Request.Method, c.Request.URL.Path)
+}
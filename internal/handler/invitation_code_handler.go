@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateInvitationCode creates a code that lets people sign up in invite_only mode
+func CreateInvitationCode(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.CreateInvitationCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to invitation code struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	if req.MaxUses < 0 {
+		utils.RespondWithError(c, errors.BadRequestError("max_uses must not be negative"))
+		return
+	}
+	if req.ExpiresInHours < 0 {
+		utils.RespondWithError(c, errors.BadRequestError("expires_in_hours must not be negative"))
+		return
+	}
+	code := req.Code
+	if code == "" {
+		buf := make([]byte, 6)
+		if _, err := rand.Read(buf); err != nil {
+			logger.Error("Error while generating invitation code", zap.Error(err))
+			utils.RespondWithError(c, errors.InternalServerError(err))
+			return
+		}
+		code = hex.EncodeToString(buf)
+	}
+	invitationCode := models.InvitationCode{
+		Code:      code,
+		MaxUses:   req.MaxUses,
+		CreatedBy: utils.GetContextUser(c).Id,
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInHours > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &t
+		invitationCode.ExpiresAt = timestamppb.New(t)
+	}
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := invitationCode.Insert(c); e != nil {
+			logger.Error("Error while inserting invitation code into database", zap.Error(e.Error()))
+			return e
+		}
+		event := events.InvitationCodeCreated{InvitationCodeId: invitationCode.Id, MaxUses: invitationCode.MaxUses, ExpiresAt: expiresAt}
+		if e := events.Publish(c, event); e != nil {
+			logger.Error("Error while publishing invitation code created event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Invitation code created", "invitation_code": &invitationCode})
+}
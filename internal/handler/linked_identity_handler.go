@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/saml"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/social"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// ListLinkedIdentities lists the accounts at social login providers linked to the user,
+// has_password tells whether the user can log in without them
+func ListLinkedIdentities(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	user := utils.GetContextUser(c)
+
+	identities, e := models.FindUserLinkedIdentities(c, user.Id)
+	if e != nil {
+		logger.Error("Error while fetching linked identities from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"identities": identities, "has_password": user.Password != ""})
+}
+
+// LinkIdentity returns the url the browser opens to log in at the provider,
+// the callback links the identity instead of logging in. The saml provider links the identity provider of the
+// selected organization.
+func LinkIdentity(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	user := utils.GetContextUser(c)
+
+	var req requests.LinkIdentityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to link identity struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	if req.Provider == "" {
+		utils.RespondWithError(c, errors.MissingFieldsError("provider"))
+		return
+	}
+	var startUrl string
+	if req.Provider == constants.SamlLinkProvider {
+		organization := utils.GetContextOrganization(c)
+		if organization == nil {
+			logger.Info("Linking SAML identity without an organization")
+			utils.RespondWithError(c, errors.OrganizationRequiredError())
+			return
+		}
+		startUrl = saml.LoginUrl(organization.Id)
+	} else {
+		provider, e := social.GetProvider(req.Provider)
+		if e != nil {
+			logger.Info("Linking identity of an unknown provider", zap.String("provider", req.Provider))
+			utils.RespondWithError(c, e)
+			return
+		}
+		startUrl = socialLoginStartUrl(provider.Name)
+	}
+	token, e := serviceRegistry.GetRedisClient().SetSocialLinkToken(c, user.Id)
+	if e != nil {
+		logger.Error("Error while storing social link token", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"redirect_to": startUrl + "?" + url.Values{"link_token": {token}}.Encode()})
+}
+
+// UnlinkIdentity removes a linked identity unless it is the last way the user can log in
+func UnlinkIdentity(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	user := utils.GetContextUser(c)
+
+	var identity models.LinkedIdentity
+	e := identity.FindOne(c, bson.M{"_id": c.Param("identity_id"), "user_id": user.Id})
+	if e != nil {
+		logger.Error("Error while fetching linked identity from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	if user.Password == "" {
+		identities, e := models.FindUserLinkedIdentities(c, user.Id)
+		if e != nil {
+			logger.Error("Error while fetching linked identities from database", zap.Error(e.Error()))
+			utils.RespondWithError(c, e)
+			return
+		}
+		if len(identities) < 2 {
+			logger.Info("Refusing to unlink the last login method of the user")
+			utils.RespondWithError(c, errors.SocialLastLoginMethodError())
+			return
+		}
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		if e := identity.Delete(c); e != nil {
+			logger.Error("Error while deleting linked identity", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.IdentityUnlinked{User: events.NewUser(user), Provider: identity.Provider, Subject: identity.Subject}); e != nil {
+			logger.Error("Error while publishing identity unlinked event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Identity unlinked"})
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/devices"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// sendLoginAlert remembers the device of the login and emails the user when it was not seen before.
+// The first device of a user is only remembered as there is nothing to compare it with yet.
+func sendLoginAlert(c *gin.Context, event events.UserLoggedIn) *errors.Error {
+	if !devices.Enabled() {
+		return nil
+	}
+	logger := utils.GetContextLogger(c).With(zap.String("user_id", event.User.Id))
+	identity := devices.Identify(event.UserAgent, event.Ip)
+
+	known, e := models.FindUserDevices(c, event.User.Id)
+	if e != nil {
+		logger.Error("Error while fetching devices from database", zap.Error(e.Error()))
+		return e
+	}
+	newCountry := identity.Country != ""
+	for _, device := range known {
+		if device.Fingerprint == identity.Fingerprint {
+			return device.MarkSeen(c, event.Ip)
+		}
+		if device.Country == identity.Country {
+			newCountry = false
+		}
+	}
+
+	device := models.Device{
+		UserId:      event.User.Id,
+		Fingerprint: identity.Fingerprint,
+		Browser:     identity.Browser,
+		Os:          identity.Os,
+		IpPrefix:    identity.IpPrefix,
+		LastIp:      event.Ip,
+		Country:     identity.Country,
+	}
+	if e = device.Insert(c); e != nil {
+		logger.Error("Error while inserting device into database", zap.Error(e.Error()))
+		return e
+	}
+	if len(known) == 0 {
+		return nil
+	}
+
+	token, e := serviceRegistry.GetRedisClient().SetSecureAccountToken(c, event.User.Id, device.Id)
+	if e == nil {
+		e = serviceRegistry.GetMailerClient().SendMail(c, event.User.Email, "New login to your account", prepareMailBodyForLoginAlert(event, &device, newCountry, token))
+	}
+	if e != nil {
+		logger.Error("Error while sending login alert", zap.Error(e.Error()))
+		// forgotten again so that the alert is sent when the subscriber is retried
+		if deleteErr := device.Delete(c); deleteErr != nil {
+			logger.Error("Error while forgetting device after failed login alert", zap.Error(deleteErr.Error()))
+		}
+		return e
+	}
+	return nil
+}
+
+func prepareMailBodyForLoginAlert(event events.UserLoggedIn, device *models.Device, newCountry bool, token string) (mailBody string) {
+	country := device.Country
+	if country == "" {
+		country = "unknown"
+	}
+	if newCountry {
+		country += ", a country you have not logged in from before"
+	}
+	secureLink := fmt.Sprintf("%s/secure-account?token=%s", serviceRegistry.GetConfig().App.BaseUrl, token)
+	mailBody = fmt.Sprintf("Hello %s %s,\n\nYour account was logged into from a new device.\n\nTime: %s\nBrowser: %s\nOperating system: %s\nIP address: %s\nCountry: %s\n\nIf this was you, you can ignore this email.\n\nIf this wasn't you, secure your account: %s\nThis logs you out everywhere and asks you to reset your password. The link expires in %d days.\n\n%s",
+		event.User.GivenName, event.User.FamilyName, event.At.UTC().Format(time.RFC1123), orUnknown(device.Browser), orUnknown(device.Os), device.LastIp, country, secureLink, int(serviceRegistry.GetConfig().Tokens.SecureAccountLinkTtl.Hours()/24), constants.MailSignature)
+	return
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
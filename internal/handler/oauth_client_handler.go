@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// CreateOAuthClient registers an oauth client, the secret of a confidential client is only returned here
+func CreateOAuthClient(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to oauth client struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	missingFields := []string{}
+	if req.Name == "" {
+		missingFields = append(missingFields, "name")
+	}
+	if req.Type == "" {
+		missingFields = append(missingFields, "type")
+	}
+	if len(req.RedirectUris) == 0 {
+		missingFields = append(missingFields, "redirect_uris")
+	}
+	if len(req.Scopes) == 0 {
+		missingFields = append(missingFields, "scopes")
+	}
+	if len(missingFields) > 0 {
+		utils.RespondWithError(c, errors.MissingFieldsError(missingFields...))
+		return
+	}
+	clientType, ok := models.OAuthClientType_value[strings.ToUpper(req.Type)]
+	if !ok {
+		logger.Info("Invalid oauth client type", zap.String("type", req.Type))
+		utils.RespondWithError(c, errors.BadRequestError("type must be one of confidential, public"))
+		return
+	}
+	for _, redirectUri := range req.RedirectUris {
+		if !validOAuthRedirectUri(redirectUri) {
+			logger.Info("Invalid oauth redirect uri", zap.String("redirect_uri", redirectUri))
+			utils.RespondWithError(c, errors.OAuthInvalidRedirectUriError("redirect_uris"))
+			return
+		}
+	}
+	for _, scope := range req.Scopes {
+		if _, ok := constants.OAuthScopeDescriptions[scope]; !ok {
+			logger.Info("Unknown oauth scope", zap.String("scope", scope))
+			utils.RespondWithError(c, errors.OAuthInvalidScopeError(scope))
+			return
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		logger.Error("Error while generating oauth client id", zap.Error(err))
+		utils.RespondWithError(c, errors.InternalServerError(err))
+		return
+	}
+	client := models.OAuthClient{
+		ClientId:     hex.EncodeToString(id),
+		Name:         req.Name,
+		Type:         models.OAuthClientType(clientType),
+		RedirectUris: dedupe(req.RedirectUris),
+		Scopes:       dedupe(req.Scopes),
+		FirstParty:   req.FirstParty,
+		CreatedBy:    utils.GetContextUser(c).Id,
+	}
+	var secret string
+	if client.Type == models.OAuthClientType_CONFIDENTIAL {
+		secretBytes := make([]byte, 32)
+		if _, err := rand.Read(secretBytes); err != nil {
+			logger.Error("Error while generating oauth client secret", zap.Error(err))
+			utils.RespondWithError(c, errors.InternalServerError(err))
+			return
+		}
+		secret = hex.EncodeToString(secretBytes)
+		hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+		if err != nil {
+			logger.Error("Error while hashing oauth client secret", zap.Error(err))
+			utils.RespondWithError(c, errors.InternalServerError(err))
+			return
+		}
+		client.ClientSecretHash = string(hash)
+	}
+
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := client.Insert(c); e != nil {
+			logger.Error("Error while inserting oauth client into database", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.OAuthClientCreated{ClientId: client.ClientId, ClientName: client.Name}); e != nil {
+			logger.Error("Error while publishing oauth client created event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	res := gin.H{"message": "OAuth client created", "client": &client}
+	if secret != "" {
+		res["client_secret"] = secret
+	}
+	c.IndentedJSON(http.StatusOK, res)
+}
+
+func ListOAuthClients(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	clients, e := models.FindOAuthClients(c, bson.M{})
+	if e != nil {
+		logger.Error("Error while fetching oauth clients from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"clients": clients})
+}
+
+// DeleteOAuthClient removes the client and the consents granted to it, tokens already issued stay valid until they expire
+func DeleteOAuthClient(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var client models.OAuthClient
+	e := client.FindOne(c, bson.M{"client_id": c.Param("client_id")})
+	if e != nil {
+		logger.Error("Error while fetching oauth client from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		if e := client.Delete(c); e != nil {
+			logger.Error("Error while deleting oauth client", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.OAuthClientDeleted{ClientId: client.ClientId, ClientName: client.Name}); e != nil {
+			logger.Error("Error while publishing oauth client deleted event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "OAuth client deleted"})
+}
@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/oidc"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetOAuthAuthorization validates the authorization request the frontend was sent to and returns what its consent
+// screen shows, the frontend then posts the same parameters to Authorize with the decision of the user
+func GetOAuthAuthorization(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	req := requests.OAuthAuthorizeRequest{
+		ResponseType:        c.Query("response_type"),
+		ClientId:            c.Query("client_id"),
+		RedirectUri:         c.Query("redirect_uri"),
+		Scope:               c.Query("scope"),
+		State:               c.Query("state"),
+		CodeChallenge:       c.Query("code_challenge"),
+		CodeChallengeMethod: c.Query("code_challenge_method"),
+		Nonce:               c.Query("nonce"),
+	}
+	client, scopes, e := validateAuthorizationRequest(c, &req)
+	if e != nil {
+		logger.Info("Invalid oauth authorization request", zap.String("client_id", req.ClientId), zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	consentRequired, e := oauthConsentRequired(c, client, utils.GetContextUser(c).Id, scopes)
+	if e != nil {
+		logger.Error("Error while fetching oauth consent from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+
+	scopeDescriptions := make([]gin.H, 0, len(scopes))
+	for _, scope := range scopes {
+		scopeDescriptions = append(scopeDescriptions, gin.H{"name": scope, "description": constants.OAuthScopeDescriptions[scope]})
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{
+		"client":           gin.H{"client_id": client.ClientId, "name": client.Name, "first_party": client.FirstParty},
+		"scopes":           scopeDescriptions,
+		"consent_required": consentRequired,
+	})
+}
+
+// Authorize records the decision of the user on an authorization request and returns where to redirect the browser,
+// with an authorization code when the user approved and with the access_denied error otherwise
+func Authorize(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	user := utils.GetContextUser(c)
+
+	var req requests.OAuthAuthorizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to oauth authorize struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	client, scopes, e := validateAuthorizationRequest(c, &req)
+	if e != nil {
+		logger.Info("Invalid oauth authorization request", zap.String("client_id", req.ClientId), zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	if !req.Approve {
+		logger.Info("User denied oauth authorization request", zap.String("client_id", client.ClientId))
+		redirectTo := oauthRedirect(req.RedirectUri, map[string]string{"error": constants.OAuthErrorAccessDenied, "state": req.State, "iss": oidc.Issuer()})
+		c.IndentedJSON(http.StatusOK, gin.H{"redirect_to": redirectTo})
+		return
+	}
+
+	consentRequired, e := oauthConsentRequired(c, client, user.Id, scopes)
+	if e != nil {
+		logger.Error("Error while fetching oauth consent from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	if consentRequired {
+		e = utils.RunInTransaction(c, func() *errors.Error {
+			if e := models.GrantOAuthConsent(c, user.Id, client.ClientId, scopes); e != nil {
+				logger.Error("Error while storing oauth consent", zap.Error(e.Error()))
+				return e
+			}
+			if e := events.Publish(c, events.OAuthConsentGranted{User: events.NewUser(user), ClientId: client.ClientId, Scopes: scopes}); e != nil {
+				logger.Error("Error while publishing oauth consent granted event", zap.Error(e.Error()))
+				return e
+			}
+			return nil
+		})
+		if e != nil {
+			utils.RespondWithError(c, e)
+			return
+		}
+	}
+
+	code, e := serviceRegistry.GetRedisClient().SetOAuthCode(c, datatypes.OAuthGrant{
+		UserId:        user.Id,
+		ClientId:      client.ClientId,
+		Scopes:        scopes,
+		RedirectUri:   req.RedirectUri,
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+	})
+	if e != nil {
+		logger.Error("Error while storing oauth authorization code", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	redirectTo := oauthRedirect(req.RedirectUri, map[string]string{"code": code, "state": req.State, "iss": oidc.Issuer()})
+	c.IndentedJSON(http.StatusOK, gin.H{"redirect_to": redirectTo})
+}
+
+// OAuthToken is the token endpoint, it exchanges authorization codes and refresh tokens for tokens
+func OAuthToken(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	client, oauthErr := authenticateOAuthClient(c)
+	if oauthErr != nil {
+		respondWithOAuthError(c, oauthErr)
+		return
+	}
+
+	var grant *datatypes.OAuthGrant
+	// the scopes of the access token, a refresh can ask for fewer than the grant has
+	var scopes []string
+	switch grantType := c.PostForm("grant_type"); grantType {
+	case constants.OAuthGrantTypeAuthorizationCode:
+		grant, oauthErr = exchangeOAuthCode(c, client)
+	case constants.OAuthGrantTypeRefreshToken:
+		grant, scopes, oauthErr = refreshOAuthGrant(c, client)
+	case "":
+		oauthErr = newOAuthError(http.StatusBadRequest, constants.OAuthErrorInvalidRequest, "grant_type is required")
+	default:
+		logger.Info("Unsupported oauth grant type", zap.String("grant_type", grantType))
+		oauthErr = newOAuthError(http.StatusBadRequest, constants.OAuthErrorUnsupportedGrantType, "")
+	}
+	if oauthErr != nil {
+		respondWithOAuthError(c, oauthErr)
+		return
+	}
+	if scopes == nil {
+		scopes = grant.Scopes
+	}
+
+	user, e := oauthGrantUser(c, grant.UserId)
+	if e != nil {
+		logger.Error("Error while fetching oauth grant user from database", zap.Error(e.Error()))
+		respondWithOAuthError(c, oauthServerError())
+		return
+	}
+	if user == nil {
+		logger.Info("Oauth grant of an inactive user", zap.String("user_id", grant.UserId))
+		respondWithOAuthError(c, newOAuthError(http.StatusBadRequest, constants.OAuthErrorInvalidGrant, "The user can no longer be authorized"))
+		return
+	}
+
+	// the new refresh token keeps every scope of the one it replaces, see RFC 6749 section 6
+	withRefreshToken := contains(grant.Scopes, constants.OAuthScopeOfflineAccess)
+	accessToken, refreshToken, expiresIn, e := serviceRegistry.GetRedisClient().IssueOAuthTokens(c, *grant, scopes, withRefreshToken)
+	if e != nil {
+		logger.Error("Error while issuing oauth tokens", zap.Error(e.Error()))
+		respondWithOAuthError(c, oauthServerError())
+		return
+	}
+	res := gin.H{
+		"access_token": accessToken,
+		"token_type":   constants.OAuthTokenTypeBearer,
+		"expires_in":   expiresIn,
+		"scope":        strings.Join(scopes, " "),
+	}
+	if withRefreshToken {
+		res["refresh_token"] = refreshToken
+	}
+	if contains(scopes, constants.OAuthScopeOpenId) {
+		idGrant := *grant
+		idGrant.Scopes = scopes
+		idToken, err := signIdToken(user, &idGrant)
+		if err != nil {
+			logger.Error("Error while signing id token", zap.Error(err))
+			respondWithOAuthError(c, oauthServerError())
+			return
+		}
+		res["id_token"] = idToken
+	}
+	c.IndentedJSON(http.StatusOK, res)
+}
+
+// exchangeOAuthCode redeems an authorization code, it must come from the client, with the redirect uri and the pkce
+// verifier of the authorization request
+func exchangeOAuthCode(c *gin.Context, client *models.OAuthClient) (*datatypes.OAuthGrant, *oauthError) {
+	logger := utils.GetContextLogger(c)
+
+	code, verifier, redirectUri := c.PostForm("code"), c.PostForm("code_verifier"), c.PostForm("redirect_uri")
+	if code == "" || verifier == "" || redirectUri == "" {
+		return nil, newOAuthError(http.StatusBadRequest, constants.OAuthErrorInvalidRequest, "code, code_verifier and redirect_uri are required")
+	}
+	redisClient := serviceRegistry.GetRedisClient()
+	mismatch := newOAuthError(http.StatusBadRequest, constants.OAuthErrorInvalidGrant, "The authorization code was not issued for this request")
+	// checked before the code is used up, so another client cannot burn it
+	grant, e := redisClient.GetOAuthCode(c, code)
+	if e == nil && grant.ClientId != client.ClientId {
+		logger.Info("Oauth authorization code of another client", zap.String("client_id", client.ClientId))
+		return nil, mismatch
+	}
+	if e == nil {
+		grant, e = redisClient.ConsumeOAuthCode(c, code)
+	}
+	if e != nil {
+		if e.IsNotFound() {
+			logger.Info("Unknown oauth authorization code", zap.String("client_id", client.ClientId))
+			return nil, newOAuthError(http.StatusBadRequest, constants.OAuthErrorInvalidGrant, "The authorization code is invalid, used or expired")
+		}
+		logger.Error("Error while fetching oauth authorization code", zap.Error(e.Error()))
+		return nil, oauthServerError()
+	}
+	if grant.ClientId != client.ClientId || grant.RedirectUri != redirectUri || !verifyPkce(verifier, grant.CodeChallenge) {
+		logger.Info("Oauth authorization code does not match the token request", zap.String("client_id", client.ClientId))
+		return nil, mismatch
+	}
+	return grant, nil
+}
+
+// refreshOAuthGrant redeems a refresh token of the client, the token is used up and a new one is issued.
+// The scopes are those of the grant unless the request asks for fewer, see RFC 6749 section 6
+func refreshOAuthGrant(c *gin.Context, client *models.OAuthClient) (*datatypes.OAuthGrant, []string, *oauthError) {
+	logger := utils.GetContextLogger(c)
+	redisClient := serviceRegistry.GetRedisClient()
+
+	refreshToken := c.PostForm("refresh_token")
+	if refreshToken == "" {
+		return nil, nil, newOAuthError(http.StatusBadRequest, constants.OAuthErrorInvalidRequest, "refresh_token is required")
+	}
+	invalidGrant := newOAuthError(http.StatusBadRequest, constants.OAuthErrorInvalidGrant, "The refresh token is invalid, used or expired")
+	// checked before the token is used up, so another client cannot burn it
+	grant, e := redisClient.GetOAuthRefreshToken(c, refreshToken)
+	if e == nil && grant.ClientId != client.ClientId {
+		logger.Info("Oauth refresh token of another client", zap.String("client_id", client.ClientId))
+		return nil, nil, invalidGrant
+	}
+	var scopes []string
+	if e == nil {
+		var oauthErr *oauthError
+		if scopes, oauthErr = refreshScopes(grant, c.PostForm("scope")); oauthErr != nil {
+			logger.Info("Oauth refresh with a scope exceeding the grant", zap.String("client_id", client.ClientId))
+			return nil, nil, oauthErr
+		}
+		grant, e = redisClient.ConsumeOAuthRefreshToken(c, refreshToken)
+	}
+	if e != nil {
+		if e.IsNotFound() {
+			logger.Info("Unknown oauth refresh token", zap.String("client_id", client.ClientId))
+			return nil, nil, invalidGrant
+		}
+		logger.Error("Error while fetching oauth refresh token", zap.Error(e.Error()))
+		return nil, nil, oauthServerError()
+	}
+	return grant, scopes, nil
+}
+
+// IntrospectOAuthToken tells a confidential client, e.g. a resource server, whether a token is active and what
+// it grants, see RFC 7662
+func IntrospectOAuthToken(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	c.Header("Cache-Control", "no-store")
+
+	client, oauthErr := authenticateOAuthClient(c)
+	if oauthErr != nil {
+		respondWithOAuthError(c, oauthErr)
+		return
+	}
+	if client.Type != models.OAuthClientType_CONFIDENTIAL {
+		logger.Info("Oauth token introspection by a public client", zap.String("client_id", client.ClientId))
+		respondWithOAuthError(c, newOAuthError(http.StatusUnauthorized, constants.OAuthErrorInvalidClient, "Only confidential clients can introspect tokens"))
+		return
+	}
+	token := c.PostForm("token")
+	if token == "" {
+		respondWithOAuthError(c, newOAuthError(http.StatusBadRequest, constants.OAuthErrorInvalidRequest, "token is required"))
+		return
+	}
+
+	grant, refresh, e := findOAuthToken(c, token, c.PostForm("token_type_hint"))
+	if e != nil {
+		logger.Error("Error while fetching oauth token", zap.Error(e.Error()))
+		respondWithOAuthError(c, oauthServerError())
+		return
+	}
+	if grant == nil {
+		c.IndentedJSON(http.StatusOK, gin.H{"active": false})
+		return
+	}
+	res := gin.H{
+		"active":    true,
+		"scope":     strings.Join(grant.Scopes, " "),
+		"client_id": grant.ClientId,
+		"sub":       grant.UserId,
+		"iat":       grant.IssuedAt,
+		"exp":       grant.ExpiresAt,
+	}
+	if !refresh {
+		res["token_type"] = constants.OAuthTokenTypeBearer
+	}
+	c.IndentedJSON(http.StatusOK, res)
+}
+
+// RevokeOAuthToken revokes a token of the client, revoking a refresh token also revokes its access token.
+// Unknown tokens and tokens of other clients are ignored and answered the same way, see RFC 7009
+func RevokeOAuthToken(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	client, oauthErr := authenticateOAuthClient(c)
+	if oauthErr != nil {
+		respondWithOAuthError(c, oauthErr)
+		return
+	}
+	token := c.PostForm("token")
+	if token == "" {
+		respondWithOAuthError(c, newOAuthError(http.StatusBadRequest, constants.OAuthErrorInvalidRequest, "token is required"))
+		return
+	}
+
+	grant, refresh, e := findOAuthToken(c, token, c.PostForm("token_type_hint"))
+	if e != nil {
+		logger.Error("Error while fetching oauth token", zap.Error(e.Error()))
+		respondWithOAuthError(c, oauthServerError())
+		return
+	}
+	if grant != nil && grant.ClientId == client.ClientId {
+		redisClient := serviceRegistry.GetRedisClient()
+		if refresh {
+			e = redisClient.RevokeOAuthRefreshToken(c, token, grant)
+		} else {
+			e = redisClient.RevokeOAuthAccessToken(c, token, grant)
+		}
+		if e != nil {
+			logger.Error("Error while revoking oauth token", zap.Error(e.Error()))
+			respondWithOAuthError(c, oauthServerError())
+			return
+		}
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/suspensions"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// oauthError is the error body of the token, introspection and revocation endpoints, see RFC 6749 section 5.2
+type oauthError struct {
+	status      int
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func newOAuthError(status int, code string, description string) *oauthError {
+	return &oauthError{status: status, Code: code, Description: description}
+}
+
+func oauthServerError() *oauthError {
+	return newOAuthError(http.StatusInternalServerError, constants.OAuthErrorServerError, "")
+}
+
+func respondWithOAuthError(c *gin.Context, e *oauthError) {
+	c.Abort()
+	c.IndentedJSON(e.status, e)
+}
+
+// validateAuthorizationRequest checks the authorization request against the registered client and returns the client
+// and the requested scopes, all of the client's scopes when none are requested
+func validateAuthorizationRequest(c *gin.Context, req *requests.OAuthAuthorizeRequest) (*models.OAuthClient, []string, *errors.Error) {
+	missingFields := []string{}
+	for _, field := range []struct{ name, value string }{
+		{"response_type", req.ResponseType},
+		{"client_id", req.ClientId},
+		{"redirect_uri", req.RedirectUri},
+		{"code_challenge", req.CodeChallenge},
+	} {
+		if field.value == "" {
+			missingFields = append(missingFields, field.name)
+		}
+	}
+	if len(missingFields) > 0 {
+		return nil, nil, errors.MissingFieldsError(missingFields...)
+	}
+
+	var client models.OAuthClient
+	if e := client.FindOne(c, bson.M{"client_id": req.ClientId}); e != nil {
+		if e.IsNotFound() {
+			return nil, nil, errors.OAuthUnknownClientError()
+		}
+		return nil, nil, e
+	}
+	// an unregistered redirect uri is never redirected to, it could hand the code to an attacker
+	if !contains(client.RedirectUris, req.RedirectUri) {
+		return nil, nil, errors.OAuthInvalidRedirectUriError("redirect_uri")
+	}
+	if req.ResponseType != constants.OAuthResponseTypeCode {
+		return nil, nil, errors.OAuthInvalidRequestError("response_type must be code")
+	}
+	if req.CodeChallengeMethod != constants.OAuthCodeChallengeMethodS256 {
+		return nil, nil, errors.OAuthInvalidRequestError("code_challenge_method must be S256")
+	}
+	// the base64url encoding of a sha256 hash
+	if len(req.CodeChallenge) != 43 {
+		return nil, nil, errors.OAuthInvalidRequestError("code_challenge must be the base64url encoded sha256 hash of the code verifier")
+	}
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	for _, scope := range scopes {
+		if !contains(client.Scopes, scope) {
+			return nil, nil, errors.OAuthInvalidScopeError(scope)
+		}
+	}
+	return &client, dedupe(scopes), nil
+}
+
+// oauthConsentRequired tells whether the user has to approve the scopes, first party clients never ask
+func oauthConsentRequired(c *gin.Context, client *models.OAuthClient, userId string, scopes []string) (bool, *errors.Error) {
+	if client.FirstParty {
+		return false, nil
+	}
+	var consent models.OAuthConsent
+	if e := consent.FindOne(c, bson.M{"user_id": userId, "client_id": client.ClientId}); e != nil {
+		if e.IsNotFound() {
+			return true, nil
+		}
+		return false, e
+	}
+	for _, scope := range scopes {
+		if !contains(consent.Scopes, scope) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// oauthRedirect adds the parameters to the query of the redirect uri, keeping the query it was registered with
+func oauthRedirect(redirectUri string, params map[string]string) string {
+	target, _ := url.Parse(redirectUri)
+	query := target.Query()
+	for key, value := range params {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	target.RawQuery = query.Encode()
+	return target.String()
+}
+
+// validOAuthRedirectUri accepts absolute uris without a fragment: https ones, http ones on the loopback interface
+// where native apps listen and the private-use schemes of native apps, e.g. com.example.app:/callback, see RFC 8252
+func validOAuthRedirectUri(raw string) bool {
+	redirectUri, err := url.Parse(raw)
+	if err != nil || !redirectUri.IsAbs() || strings.Contains(raw, "#") {
+		return false
+	}
+	switch redirectUri.Scheme {
+	case "https":
+		return redirectUri.Host != ""
+	case "http":
+		host := redirectUri.Hostname()
+		ip := net.ParseIP(host)
+		return host == "localhost" || (ip != nil && ip.IsLoopback())
+	}
+	return strings.Contains(redirectUri.Scheme, ".")
+}
+
+// verifyPkce checks that the code verifier hashes to the challenge of the authorization request
+func verifyPkce(verifier string, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
+
+// authenticateOAuthClient identifies the client from the basic auth header or the client_id and client_secret form
+// fields, confidential clients must send their secret, public ones only their client_id
+func authenticateOAuthClient(c *gin.Context) (*models.OAuthClient, *oauthError) {
+	logger := utils.GetContextLogger(c)
+
+	clientId, secret, basic := c.Request.BasicAuth()
+	if basic {
+		// the credentials are form encoded before they are put in the header, see RFC 6749 section 2.3.1
+		clientId, _ = url.QueryUnescape(clientId)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientId, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	invalidClient := func(description string) *oauthError {
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		return newOAuthError(http.StatusUnauthorized, constants.OAuthErrorInvalidClient, description)
+	}
+	if clientId == "" {
+		return nil, invalidClient("Client authentication is required")
+	}
+
+	var client models.OAuthClient
+	if e := client.FindOne(c, bson.M{"client_id": clientId}); e != nil {
+		if e.IsNotFound() {
+			logger.Info("Unknown oauth client", zap.String("client_id", clientId))
+			return nil, invalidClient("Unknown client")
+		}
+		logger.Error("Error while fetching oauth client from database", zap.Error(e.Error()))
+		return nil, oauthServerError()
+	}
+	if client.Type == models.OAuthClientType_CONFIDENTIAL {
+		if secret == "" || bcrypt.CompareHashAndPassword([]byte(client.ClientSecretHash), []byte(secret)) != nil {
+			logger.Info("Invalid oauth client secret", zap.String("client_id", clientId))
+			return nil, invalidClient("Invalid client credentials")
+		}
+	}
+	return &client, nil
+}
+
+// oauthGrantUser returns the user of a grant, nil when tokens can no longer be issued for them
+func oauthGrantUser(c *gin.Context, userId string) (*models.User, *errors.Error) {
+	var user models.User
+	if e := user.FindOne(c, bson.M{"_id": userId}); e != nil {
+		if e.IsNotFound() {
+			return nil, nil
+		}
+		return nil, e
+	}
+	if user.Status == models.UserStatus_DELETED || user.PasswordResetRequired || suspensions.InEffect(&user, time.Now()) {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+// authorizeOAuthAccessToken loads the user of an oauth access token into user, the token grants the api scopes the
+// user consented to
+func authorizeOAuthAccessToken(c *gin.Context, token string, user *models.User) *errors.Error {
+	logger := utils.GetContextLogger(c)
+
+	grant, e := serviceRegistry.GetRedisClient().GetOAuthAccessToken(c, token)
+	if e != nil {
+		if e.IsNotFound() {
+			logger.Info("Unknown or expired oauth access token")
+			return errors.InvalidAuthTokenError(e.Error())
+		}
+		logger.Error("Error while fetching oauth access token", zap.Error(e.Error()))
+		return e
+	}
+	if e := user.FindOne(c, bson.M{"_id": grant.UserId}); e != nil {
+		logger.Error("Error while fetching oauth grant user from database", zap.Error(e.Error()))
+		return e
+	}
+	// like oauthGrantUser, deleted and secured accounts can no longer be authorized
+	if user.Status == models.UserStatus_DELETED || user.PasswordResetRequired {
+		logger.Info("Oauth access token of a user who can no longer be authorized")
+		return errors.InvalidAuthTokenError(nil)
+	}
+	utils.AddKeyToContextLogger(c, "client_id", grant.ClientId)
+	scopes := []string{}
+	for _, scope := range grant.Scopes {
+		if _, ok := constants.ScopeDescriptions[scope]; ok {
+			scopes = append(scopes, scope)
+		}
+	}
+	utils.SetContextScopes(c, scopes)
+	return nil
+}
+
+// findOAuthToken looks the token up as an access token and as a refresh token, in the order of the token_type_hint,
+// the grant is nil when the token is unknown or expired
+func findOAuthToken(c *gin.Context, token string, hint string) (grant *datatypes.OAuthGrant, refresh bool, err *errors.Error) {
+	redisClient := serviceRegistry.GetRedisClient()
+	lookups := []bool{false, true}
+	if hint == constants.OAuthTokenTypeHintRefreshToken {
+		lookups = []bool{true, false}
+	}
+	for _, refresh = range lookups {
+		if refresh {
+			grant, err = redisClient.GetOAuthRefreshToken(c, token)
+		} else {
+			grant, err = redisClient.GetOAuthAccessToken(c, token)
+		}
+		if err == nil {
+			return grant, refresh, nil
+		}
+		if !err.IsNotFound() {
+			return nil, false, err
+		}
+	}
+	return nil, false, nil
+}
+
+// refreshScopes returns the requested scopes, or those of the grant when none are requested
+func refreshScopes(grant *datatypes.OAuthGrant, requested string) ([]string, *oauthError) {
+	scopes := dedupe(strings.Fields(requested))
+	if len(scopes) == 0 {
+		return grant.Scopes, nil
+	}
+	for _, scope := range scopes {
+		if !contains(grant.Scopes, scope) {
+			return nil, newOAuthError(http.StatusBadRequest, constants.OAuthErrorInvalidScope, "The scope exceeds the scopes of the refresh token")
+		}
+	}
+	return scopes, nil
+}
+
+func dedupe(values []string) []string {
+	seen := map[string]bool{}
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,100 @@
+package handler_test
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/handler"
+)
+
+func TestVerifyPkce(t *testing.T) {
+	// the example of RFC 7636 appendix B
+	verifier, challenge := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{"matching", verifier, challenge, true},
+		{"other verifier", strings.Replace(verifier, "d", "e", 1), challenge, false},
+		{"plain challenge", verifier, verifier, false},
+		{"no challenge", verifier, "", false},
+		{"verifier too short", verifier[:42], challenge, false},
+		{"verifier too long", strings.Repeat("a", 129), challenge, false},
+	}
+	for _, test := range tests {
+		if got := handler.VerifyPkce(test.verifier, test.challenge); got != test.want {
+			t.Errorf("%s: verifyPkce = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidOAuthRedirectUri(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want bool
+	}{
+		{"https://app.example.com/callback", true},
+		{"https://app.example.com/callback?tenant=a", true},
+		{"https:///callback", false},
+		{"https://app.example.com/callback#fragment", false},
+		{"http://localhost:3000/callback", true},
+		{"http://127.0.0.1/callback", true},
+		{"http://[::1]:3000/callback", true},
+		{"http://app.example.com/callback", false},
+		{"com.example.app:/callback", true},
+		{"javascript:alert(1)", false},
+		{"/callback", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := handler.ValidOAuthRedirectUri(test.uri); got != test.want {
+			t.Errorf("validOAuthRedirectUri(%q) = %v, want %v", test.uri, got, test.want)
+		}
+	}
+}
+
+func TestOAuthRedirect(t *testing.T) {
+	redirect := handler.OAuthRedirect("https://app.example.com/callback?tenant=a", map[string]string{"code": "abc", "state": ""})
+	parsed, err := url.Parse(redirect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if query.Get("tenant") != "a" || query.Get("code") != "abc" {
+		t.Errorf("redirect %s lost the registered query or the code", redirect)
+	}
+	if query.Has("state") {
+		t.Errorf("redirect %s has the empty state", redirect)
+	}
+}
+
+func TestRefreshScopes(t *testing.T) {
+	grant := &datatypes.OAuthGrant{Scopes: []string{constants.OAuthScopeOpenId, constants.OAuthScopeEmail, constants.OAuthScopeOfflineAccess}}
+	tests := []struct {
+		name      string
+		requested string
+		want      []string
+		wantErr   string
+	}{
+		{"not requested", "", grant.Scopes, ""},
+		{"same scopes", "openid email offline_access", grant.Scopes, ""},
+		{"fewer scopes", "email", []string{constants.OAuthScopeEmail}, ""},
+		{"repeated scope", "email  email", []string{constants.OAuthScopeEmail}, ""},
+		{"more scopes", "email profile", nil, constants.OAuthErrorInvalidScope},
+	}
+	for _, test := range tests {
+		scopes, err := handler.RefreshScopes(grant, test.requested)
+		if code := handler.OAuthErrorCode(err); code != test.wantErr {
+			t.Errorf("%s: refreshScopes error = %q, want %q", test.name, code, test.wantErr)
+		}
+		if !reflect.DeepEqual(scopes, test.want) {
+			t.Errorf("%s: refreshScopes = %v, want %v", test.name, scopes, test.want)
+		}
+	}
+}
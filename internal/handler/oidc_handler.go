@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/oidc"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func OpenIdConfiguration(c *gin.Context) {
+	scopes := make([]string, 0, len(constants.OAuthScopeDescriptions))
+	for scope := range constants.OAuthScopeDescriptions {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	c.IndentedJSON(http.StatusOK, oidc.Discovery(scopes, oidcClaimsSupported))
+}
+
+// Jwks publishes the public keys id tokens are signed with, older keys stay listed until their tokens expired
+func Jwks(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, oidc.PublicKeys())
+}
+
+// UserInfo returns the claims of the user of an oauth access token with the openid scope, see OpenID Connect Core 5.3
+func UserInfo(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	c.Header("Cache-Control", "no-store")
+
+	// errors of bearer token requests are also sent in the WWW-Authenticate header, see RFC 6750
+	bearerError := func(e *oauthError) {
+		c.Header("WWW-Authenticate", `Bearer error="`+e.Code+`"`)
+		respondWithOAuthError(c, e)
+	}
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		c.Header("WWW-Authenticate", "Bearer")
+		respondWithOAuthError(c, newOAuthError(http.StatusUnauthorized, constants.OAuthErrorInvalidRequest, "A bearer access token is required"))
+		return
+	}
+	grant, e := serviceRegistry.GetRedisClient().GetOAuthAccessToken(c, token)
+	if e != nil {
+		if e.IsNotFound() {
+			bearerError(newOAuthError(http.StatusUnauthorized, constants.OAuthErrorInvalidToken, "The access token is invalid or expired"))
+			return
+		}
+		logger.Error("Error while fetching oauth access token", zap.Error(e.Error()))
+		respondWithOAuthError(c, oauthServerError())
+		return
+	}
+	if !contains(grant.Scopes, constants.OAuthScopeOpenId) {
+		bearerError(newOAuthError(http.StatusForbidden, constants.OAuthErrorInsufficientScope, "The access token needs the openid scope"))
+		return
+	}
+	user, e := oauthGrantUser(c, grant.UserId)
+	if e != nil {
+		logger.Error("Error while fetching oauth grant user from database", zap.Error(e.Error()))
+		respondWithOAuthError(c, oauthServerError())
+		return
+	}
+	if user == nil {
+		bearerError(newOAuthError(http.StatusUnauthorized, constants.OAuthErrorInvalidToken, "The user can no longer be authorized"))
+		return
+	}
+	c.IndentedJSON(http.StatusOK, userInfoClaims(user, grant.Scopes))
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/oidc"
+)
+
+// signIdToken signs the id token of the grant
+func signIdToken(user *models.User, grant *datatypes.OAuthGrant) (string, error) {
+	return oidc.SignIdToken(idTokenClaims(user, grant, time.Now()))
+}
+
+// idTokenClaims are the claims of the user released by the scopes of the grant, the nonce is only set when the
+// grant comes from an authorization code
+func idTokenClaims(user *models.User, grant *datatypes.OAuthGrant, now time.Time) oidc.IdTokenClaims {
+	claims := oidc.IdTokenClaims{
+		Issuer:   oidc.Issuer(),
+		Subject:  user.Id,
+		Audience: grant.ClientId,
+		IssuedAt: now.Unix(),
+		Expiry:   now.Add(oidc.IdTokenTtl()).Unix(),
+		Nonce:    grant.Nonce,
+	}
+	if contains(grant.Scopes, constants.OAuthScopeProfile) {
+		claims.GivenName, claims.FamilyName = user.GivenName, user.FamilyName
+	}
+	if contains(grant.Scopes, constants.OAuthScopeEmail) {
+		emailVerified := user.VerifiedAt != nil
+		claims.Email, claims.EmailVerified = user.Email, &emailVerified
+	}
+	return claims
+}
+
+// userInfoClaims are the claims of the user released by the scopes
+func userInfoClaims(user *models.User, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{"sub": user.Id}
+	if contains(scopes, constants.OAuthScopeProfile) {
+		claims["given_name"], claims["family_name"] = user.GivenName, user.FamilyName
+	}
+	if contains(scopes, constants.OAuthScopeEmail) {
+		claims["email"], claims["email_verified"] = user.Email, user.VerifiedAt != nil
+	}
+	return claims
+}
+
+// the claims of the id tokens and the userinfo endpoint, given_name and family_name need the profile scope,
+// email and email_verified the email scope
+var oidcClaimsSupported = []string{"iss", "sub", "aud", "exp", "iat", "nonce", "given_name", "family_name", "email", "email_verified"}
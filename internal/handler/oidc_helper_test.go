@@ -0,0 +1,52 @@
+package handler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/oidc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestIdTokenClaims(t *testing.T) {
+	oidc.Configure(config.OidcConfig{Issuer: "https://auth.example.com", IdTokenTtl: time.Hour}, "https://app.example.com")
+	now := time.Unix(1700000000, 0)
+	user := &models.User{Id: "user-1", GivenName: "Jane", FamilyName: "Doe", Email: "jane@example.com", VerifiedAt: timestamppb.New(now)}
+
+	claims := handler.IdTokenClaims(user, &datatypes.OAuthGrant{ClientId: "client-1", Scopes: []string{constants.OAuthScopeOpenId}, Nonce: "n-0S6"}, now)
+	if claims.Issuer != "https://auth.example.com" || claims.Subject != "user-1" || claims.Audience != "client-1" {
+		t.Errorf("iss, sub, aud = %s, %s, %s", claims.Issuer, claims.Subject, claims.Audience)
+	}
+	if claims.IssuedAt != now.Unix() || claims.Expiry != now.Add(time.Hour).Unix() {
+		t.Errorf("iat, exp = %d, %d", claims.IssuedAt, claims.Expiry)
+	}
+	if claims.Nonce != "n-0S6" {
+		t.Errorf("nonce = %q, want the nonce of the authorization request", claims.Nonce)
+	}
+	if claims.GivenName != "" || claims.Email != "" || claims.EmailVerified != nil {
+		t.Error("profile or email claims released without their scope")
+	}
+
+	// a refreshed grant has no nonce
+	claims = handler.IdTokenClaims(user, &datatypes.OAuthGrant{ClientId: "client-1", Scopes: []string{constants.OAuthScopeOpenId, constants.OAuthScopeProfile}}, now)
+	if claims.Nonce != "" {
+		t.Errorf("nonce = %q, want none", claims.Nonce)
+	}
+	if claims.GivenName != "Jane" || claims.FamilyName != "Doe" {
+		t.Errorf("given_name, family_name = %s, %s", claims.GivenName, claims.FamilyName)
+	}
+	if claims.Email != "" {
+		t.Error("email released without the email scope")
+	}
+
+	unverified := &models.User{Id: "user-2", Email: "john@example.com"}
+	claims = handler.IdTokenClaims(unverified, &datatypes.OAuthGrant{Scopes: []string{constants.OAuthScopeOpenId, constants.OAuthScopeEmail}}, now)
+	if claims.Email != "john@example.com" || claims.EmailVerified == nil || *claims.EmailVerified {
+		t.Errorf("email, email_verified = %s, %v", claims.Email, claims.EmailVerified)
+	}
+}
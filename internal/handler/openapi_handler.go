@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/MitP1997/golang-user-management/internal/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+var openApiDocument *openapi.Document
+var openApiDocumentOnce sync.Once
+
+func GetOpenApiSpec(c *gin.Context) {
+	openApiDocumentOnce.Do(func() {
+		openApiDocument = openapi.Generate()
+	})
+	c.IndentedJSON(http.StatusOK, openApiDocument)
+}
+
+func GetApiDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", openapi.SwaggerUi)
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func CreateOrganization(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to organization struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+
+	user := utils.GetContextUser(c)
+	organization := models.Organization{
+		Name:      req.Name,
+		Slug:      strings.ToLower(req.Slug),
+		CreatedBy: user.Id,
+	}
+	// the owner membership is written with the organization, an organization without it could not be managed
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := organization.Insert(c); e != nil {
+			logger.Error("Error while inserting organization into database", zap.Error(e.Error()))
+			return e
+		}
+		membership := models.Membership{
+			OrganizationId: organization.Id,
+			UserId:         user.Id,
+			Role:           models.MembershipRole_OWNER,
+		}
+		if e := membership.Insert(c); e != nil {
+			logger.Error("Error while inserting owner membership into database", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Organization created", "organization": &organization})
+}
+
+func ListOrganizationMembers(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	organization := utils.GetContextOrganization(c)
+	memberships, e := models.FindMemberships(c, bson.M{"organization_id": organization.Id})
+	if e != nil {
+		logger.Error("Error while fetching memberships from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	userIds := make([]string, 0, len(memberships))
+	for _, membership := range memberships {
+		userIds = append(userIds, membership.UserId)
+	}
+	users, e := models.FindUsersByIds(c, userIds)
+	if e != nil {
+		logger.Error("Error while fetching members from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	usersById := make(map[string]*models.User, len(users))
+	for _, user := range users {
+		usersById[user.Id] = user
+	}
+
+	members := make([]gin.H, 0, len(memberships))
+	for _, membership := range memberships {
+		user, ok := usersById[membership.UserId]
+		if !ok {
+			continue
+		}
+		members = append(members, gin.H{
+			"user_id":     user.Id,
+			"email":       user.Email,
+			"given_name":  user.GivenName,
+			"family_name": user.FamilyName,
+			"role":        membership.Role.String(),
+			"joined_at":   membership.CreatedAt.AsTime(),
+		})
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"members": members})
+}
+
+func RemoveOrganizationMember(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	organization := utils.GetContextOrganization(c)
+	requester := utils.GetContextMembership(c)
+	userId := c.Param("user_id")
+
+	var membership models.Membership
+	e := membership.FindOne(c, bson.M{"organization_id": organization.Id, "user_id": userId})
+	if e != nil {
+		logger.Error("Error while fetching membership from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	// members can leave on their own, removing someone else needs a role at least as high as theirs
+	if membership.UserId != requester.UserId && (requester.Role < models.MembershipRole_ADMIN || requester.Role < membership.Role) {
+		e = errors.InsufficientOrganizationRoleError()
+		logger.Error("Requester cannot remove this member", zap.String("member_role", membership.Role.String()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	if membership.Role == models.MembershipRole_OWNER {
+		owners, e := models.CountMemberships(c, bson.M{"organization_id": organization.Id, "role": models.MembershipRole_OWNER})
+		if e != nil {
+			logger.Error("Error while counting organization owners", zap.Error(e.Error()))
+			utils.RespondWithError(c, e)
+			return
+		}
+		if owners <= 1 {
+			e = errors.LastOrganizationOwnerError()
+			logger.Error("Cannot remove the last owner of the organization")
+			utils.RespondWithError(c, e)
+			return
+		}
+	}
+	e = membership.Delete(c)
+	if e != nil {
+		logger.Error("Error while deleting membership", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+func InviteOrganizationMember(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.InviteOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to invitation struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	role, e := parseMembershipRole(req.Role)
+	if e != nil {
+		logger.Error("Error while parsing invitation role", zap.String("role", req.Role))
+		utils.RespondWithError(c, e)
+		return
+	}
+	requester := utils.GetContextMembership(c)
+	if role > requester.Role {
+		e = errors.InsufficientOrganizationRoleError()
+		logger.Error("Cannot invite with a role higher than the requester's", zap.String("role", role.String()))
+		utils.RespondWithError(c, e)
+		return
+	}
+
+	organization := utils.GetContextOrganization(c)
+	user := utils.GetContextUser(c)
+	invitation := models.Invitation{
+		OrganizationId: organization.Id,
+		Email:          strings.ToLower(req.Email),
+		Role:           role,
+		Token:          generateInvitationToken(),
+		InvitedBy:      user.Id,
+		Status:         models.InvitationStatus_PENDING,
+		ExpiresAt:      timestamppb.New(time.Now().Add(serviceRegistry.GetConfig().Tokens.InvitationTtl)),
+	}
+	e = invitation.Insert(c)
+	if e != nil {
+		logger.Error("Error while inserting invitation into database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	sendOrganizationInvitationEmail(c, &invitation, organization, user)
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Invitation sent", "invitation_id": invitation.Id})
+}
+
+func AcceptOrganizationInvitation(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.AcceptOrganizationInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to invitation struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	if req.Token == "" {
+		logger.Error("Invitation token not present in request")
+		utils.RespondWithError(c, errors.MissingFieldsError("token"))
+		return
+	}
+
+	invitation, e := findPendingInvitation(c, req.Token)
+	if e != nil {
+		logger.Error("Error while fetching invitation", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	membership, e := acceptInvitation(c, invitation, utils.GetContextUser(c))
+	if e != nil {
+		logger.Error("Error while accepting invitation", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Invitation accepted", "organization_id": membership.OrganizationId})
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// picks the organization for the request in the order: header, token claim, user's home organization
+// an organization named in the header must be one the user is a member of,
+// the others are silently ignored when the membership no longer exists.
+// A session limited to an organization, e.g. of a SAML login, can not be moved to another one with the header.
+func resolveOrganizationContext(c *gin.Context, user *models.User, token string) (organization *models.Organization, membership *models.Membership, err *errors.Error) {
+	organizationId := c.GetHeader(constants.OrganizationHeader)
+	explicit := organizationId != ""
+	if authentication := utils.GetContextAuthentication(c); authentication != nil && authentication.OrganizationId != "" {
+		if explicit && organizationId != authentication.OrganizationId {
+			return nil, nil, errors.OrganizationSessionRestrictedError()
+		}
+		organizationId, explicit = authentication.OrganizationId, true
+	}
+	if !explicit {
+		organizationId, err = serviceRegistry.GetRedisClient().GetAuthTokenOrganization(c, token)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if organizationId == "" {
+		organizationId = user.OrganizationId
+	}
+	if organizationId == "" {
+		return nil, nil, nil
+	}
+
+	membership = &models.Membership{}
+	e := membership.FindOne(c, bson.M{"organization_id": organizationId, "user_id": user.Id})
+	if e != nil {
+		if e.IsNotFound() && !explicit {
+			return nil, nil, nil
+		}
+		if e.IsNotFound() {
+			return nil, nil, errors.NotOrganizationMemberError()
+		}
+		return nil, nil, e
+	}
+	organization = &models.Organization{}
+	e = organization.FindOne(c, bson.M{"_id": organizationId})
+	if e != nil {
+		return nil, nil, e
+	}
+	return organization, membership, nil
+}
+
+// scopes a user lookup to the tenant of the request when emails are only unique per organization
+// without the header only users of no organization match, which the users created before the switch get at startup
+func tenantScopedFilter(c *gin.Context, filter bson.M) bson.M {
+	if models.IsEmailUniquePerOrganization() {
+		filter["organization_id"] = c.GetHeader(constants.OrganizationHeader)
+	}
+	return filter
+}
+
+// the tenant a new user belongs to, only relevant when emails are unique per organization
+func getSignupOrganizationId(c *gin.Context, invitation *models.Invitation) (string, *errors.Error) {
+	if !models.IsEmailUniquePerOrganization() {
+		return "", nil
+	}
+	if invitation != nil {
+		return invitation.OrganizationId, nil
+	}
+	organizationId := c.GetHeader(constants.OrganizationHeader)
+	if organizationId == "" {
+		return "", nil
+	}
+	var organization models.Organization
+	if err := organization.FindOne(c, bson.M{"_id": organizationId}); err != nil {
+		return "", err
+	}
+	return organization.Id, nil
+}
+
+// remembers the organization selected at login on the token so that later requests need not send the header
+// the header is ignored when the user is not a member of that organization
+func setAuthTokenOrganizationClaim(c *gin.Context, user *models.User, token string) *errors.Error {
+	organizationId := c.GetHeader(constants.OrganizationHeader)
+	if organizationId == "" {
+		return nil
+	}
+	var membership models.Membership
+	err := membership.FindOne(c, bson.M{"organization_id": organizationId, "user_id": user.Id})
+	if err != nil {
+		if err.IsNotFound() {
+			return nil
+		}
+		return err
+	}
+	return serviceRegistry.GetRedisClient().SetAuthTokenOrganization(c, token, organizationId)
+}
+
+func parseMembershipRole(role string) (models.MembershipRole, *errors.Error) {
+	if role == "" {
+		return models.MembershipRole_MEMBER, nil
+	}
+	value, ok := models.MembershipRole_value[strings.ToUpper(role)]
+	if !ok {
+		return 0, errors.BadRequestError(fmt.Sprintf("Unknown role %s", role))
+	}
+	return models.MembershipRole(value), nil
+}
+
+func findPendingInvitation(c *gin.Context, token string) (invitation *models.Invitation, err *errors.Error) {
+	invitation = &models.Invitation{}
+	err = invitation.FindOne(c, bson.M{"token": token, "status": models.InvitationStatus_PENDING})
+	if err != nil {
+		if err.IsNotFound() {
+			return nil, errors.InvalidInvitationError(err.Error())
+		}
+		return nil, err
+	}
+	if invitation.ExpiresAt.AsTime().Before(time.Now()) {
+		return nil, errors.InvalidInvitationError(nil)
+	}
+	return
+}
+
+// adds the user to the organization with the invited role and marks the invitation accepted.
+// The membership is inserted first so that a failed insert leaves the invitation pending.
+func acceptInvitation(c *gin.Context, invitation *models.Invitation, user *models.User) (membership *models.Membership, err *errors.Error) {
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, errors.InvitationEmailMismatchError()
+	}
+	membership = &models.Membership{
+		OrganizationId: invitation.OrganizationId,
+		UserId:         user.Id,
+		Role:           invitation.Role,
+	}
+	if err = membership.Insert(c); err != nil {
+		return nil, err
+	}
+	err = invitation.Update(c, bson.M{"_id": invitation.Id, "status": models.InvitationStatus_PENDING}, bson.M{
+		"status":      models.InvitationStatus_ACCEPTED,
+		"accepted_at": timestamppb.Now(),
+	})
+	if err != nil {
+		// the invitation was accepted or revoked meanwhile
+		if e := membership.Delete(c); e != nil {
+			utils.GetContextLogger(c).Error("Error while removing membership of invitation not accepted", zap.Error(e.Error()))
+		}
+		if err.IsNotFound() {
+			return nil, errors.InvalidInvitationError(err.Error())
+		}
+		return nil, err
+	}
+	return
+}
+
+func sendOrganizationInvitationEmail(c *gin.Context, invitation *models.Invitation, organization *models.Organization, inviter *models.User) {
+	mailBody := prepareMailBodyForOrganizationInvitation(c, invitation, organization, inviter)
+
+	// creating a duplicate context as the current context would die when the response ends
+	dupCtx := utils.CreateDuplicateContext(c)
+	// spawn a go routine to send the email as we do not want to add to the latency of the request
+	go serviceRegistry.GetMailerClient().SendMail(dupCtx, invitation.Email, fmt.Sprintf("You have been invited to join %s", organization.Name), mailBody)
+}
+
+func prepareMailBodyForOrganizationInvitation(ctx *gin.Context, invitation *models.Invitation, organization *models.Organization, inviter *models.User) (mailBody string) {
+	acceptLink := fmt.Sprintf("%s/invitations/accept?token=%s", serviceRegistry.GetConfig().App.BaseUrl, invitation.Token)
+	mailBody = fmt.Sprintf("Hello,\n\n%s %s has invited you to join %s.\n\nAccept the invitation: %s\n\nThe invitation expires in %d days.\n\n%s", inviter.GivenName, inviter.FamilyName, organization.Name, acceptLink, int(serviceRegistry.GetConfig().Tokens.InvitationTtl.Hours()/24), constants.MailSignature)
+	return
+}
+
+func generateInvitationToken() string {
+	return strings.Replace(uuid.New().String(), "-", "", -1)
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultPageSize = 50
+
+// parsePagination reads the limit and skip query params of a list endpoint
+func parsePagination(c *gin.Context, maxLimit int64) (limit int64, skip int64, err *errors.Error) {
+	limit, e := strconv.ParseInt(c.DefaultQuery("limit", strconv.Itoa(defaultPageSize)), 10, 64)
+	if e != nil || limit < 1 || limit > maxLimit {
+		return 0, 0, errors.BadRequestError("limit must be between 1 and " + strconv.FormatInt(maxLimit, 10))
+	}
+	skip, e = strconv.ParseInt(c.DefaultQuery("skip", "0"), 10, 64)
+	if e != nil || skip < 0 {
+		return 0, 0, errors.BadRequestError("skip must not be negative")
+	}
+	return limit, skip, nil
+}
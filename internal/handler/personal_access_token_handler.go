@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreatePersonalAccessToken issues a token scripts and CI jobs of the user authenticate with,
+// the token is only returned here
+func CreatePersonalAccessToken(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	user := utils.GetContextUser(c)
+
+	var req requests.CreatePersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to personal access token struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	if req.Name == "" || len(req.Scopes) == 0 {
+		utils.RespondWithError(c, errors.MissingFieldsError("name", "scopes"))
+		return
+	}
+	ttl, e := checkPersonalAccessTokenRequest(&req, user, utils.GetContextScopes(c), serviceRegistry.GetConfig().Tokens.PersonalAccessTokenMaxTtl)
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		logger.Error("Error while generating personal access token", zap.Error(err))
+		utils.RespondWithError(c, errors.InternalServerError(err))
+		return
+	}
+	token := constants.PersonalAccessTokenPrefix + hex.EncodeToString(secret)
+	pat := models.PersonalAccessToken{
+		UserId: user.Id,
+		Name:   req.Name,
+		// enough of the token to recognize it in the list, not enough to guess it
+		TokenPrefix: token[:len(constants.PersonalAccessTokenPrefix)+8],
+		TokenHash:   hashPersonalAccessToken(token),
+		Scopes:      req.Scopes,
+	}
+	if ttl > 0 {
+		pat.ExpiresAt = timestamppb.New(time.Now().Add(ttl))
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		if e := pat.Insert(c); e != nil {
+			logger.Error("Error while inserting personal access token into database", zap.Error(e.Error()))
+			return e
+		}
+		event := events.PersonalAccessTokenCreated{User: events.NewUser(user), TokenId: pat.Id, TokenName: pat.Name, Scopes: pat.Scopes}
+		if e := events.Publish(c, event); e != nil {
+			logger.Error("Error while publishing personal access token created event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Personal access token created", "token": token, "personal_access_token": &pat})
+}
+
+func ListPersonalAccessTokens(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	tokens, e := models.FindUserPersonalAccessTokens(c, utils.GetContextUser(c).Id)
+	if e != nil {
+		logger.Error("Error while fetching personal access tokens from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"personal_access_tokens": tokens})
+}
+
+// DeletePersonalAccessToken revokes the token, requests with it are refused right away
+func DeletePersonalAccessToken(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	user := utils.GetContextUser(c)
+
+	var pat models.PersonalAccessToken
+	e := pat.FindOne(c, bson.M{"_id": c.Param("token_id"), "user_id": user.Id})
+	if e != nil {
+		logger.Error("Error while fetching personal access token from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		if e := pat.Delete(c); e != nil {
+			logger.Error("Error while deleting personal access token", zap.Error(e.Error()))
+			return e
+		}
+		event := events.PersonalAccessTokenDeleted{User: events.NewUser(user), TokenId: pat.Id, TokenName: pat.Name}
+		if e := events.Publish(c, event); e != nil {
+			logger.Error("Error while publishing personal access token deleted event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Personal access token deleted"})
+}
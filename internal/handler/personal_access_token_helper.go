@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// how long the last use of a token may lag behind, so that busy scripts do not write on every request
+const personalAccessTokenUseInterval = time.Minute
+
+// authorizePersonalAccessToken loads the user of the token into user and its scopes into the context, expired
+// tokens and tokens of deleted or secured accounts are refused
+func authorizePersonalAccessToken(c *gin.Context, token string, user *models.User) *errors.Error {
+	logger := utils.GetContextLogger(c)
+
+	var pat models.PersonalAccessToken
+	if e := pat.FindOne(c, bson.M{"token_hash": hashPersonalAccessToken(token)}); e != nil {
+		if e.IsNotFound() {
+			logger.Info("Unknown personal access token")
+			return errors.InvalidAuthTokenError(e.Error())
+		}
+		logger.Error("Error while fetching personal access token from database", zap.Error(e.Error()))
+		return e
+	}
+	logger = utils.AddKeyToContextLogger(c, "token_id", pat.Id)
+	if pat.ExpiresAt != nil && pat.ExpiresAt.AsTime().Before(time.Now()) {
+		logger.Info("Expired personal access token")
+		return errors.TokenExpiredError()
+	}
+	if e := user.FindOne(c, bson.M{"_id": pat.UserId}); e != nil {
+		logger.Error("Error while finding user of personal access token", zap.Error(e.Error()))
+		return e
+	}
+	if user.Status == models.UserStatus_DELETED {
+		logger.Info("Personal access token of a deleted user")
+		return errors.InvalidAuthTokenError(nil)
+	}
+	// a secured account is taken over until the password is changed, its tokens may have leaked with it
+	if user.PasswordResetRequired {
+		logger.Info("Personal access token of a secured account")
+		return errors.PasswordResetRequiredError()
+	}
+	utils.SetContextScopes(c, pat.Scopes)
+	if pat.LastUsedAt == nil || time.Since(pat.LastUsedAt.AsTime()) > personalAccessTokenUseInterval {
+		if e := pat.RecordUse(c, c.ClientIP()); e != nil {
+			logger.Error("Error while recording use of personal access token", zap.Error(e.Error()))
+		}
+	}
+	return nil
+}
+
+// checkPersonalAccessTokenRequest returns the lifetime of the requested token, 0 when it does not expire.
+// A token can not create a token that can do more than itself, the scopes are those of the request's token,
+// and the admin scope only makes sense for platform admins
+func checkPersonalAccessTokenRequest(req *requests.CreatePersonalAccessTokenRequest, user *models.User, scopes []string, maxTtl time.Duration) (time.Duration, *errors.Error) {
+	for _, scope := range req.Scopes {
+		_, ok := constants.ScopeDescriptions[scope]
+		if !ok || !contains(scopes, scope) || (scope == constants.ScopeAdmin && user.Role != models.UserRole_PLATFORM_ADMIN) {
+			return 0, errors.TokenInvalidScopeError(scope)
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		return 0, errors.TokenInvalidExpiryError()
+	}
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	if maxTtl > 0 && (ttl == 0 || ttl > maxTtl) {
+		return 0, errors.TokenExpiryTooLongError(maxTtl)
+	}
+	return ttl, nil
+}
+
+// only the hash of a token is stored, the token is random enough for a fast hash
+func hashPersonalAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
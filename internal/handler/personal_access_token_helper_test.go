@@ -0,0 +1,65 @@
+package handler_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+)
+
+func TestHashPersonalAccessToken(t *testing.T) {
+	token := constants.PersonalAccessTokenPrefix + "0123456789abcdef"
+	hash := handler.HashPersonalAccessToken(token)
+	if !regexp.MustCompile(`^[0-9a-f]{64}$`).MatchString(hash) {
+		t.Errorf("hash %q is not a hex sha256", hash)
+	}
+	if hash != handler.HashPersonalAccessToken(token) {
+		t.Error("the same token hashes differently, it could not be looked up")
+	}
+	if hash == handler.HashPersonalAccessToken(token+"0") {
+		t.Error("different tokens have the same hash")
+	}
+}
+
+func TestCheckPersonalAccessTokenRequest(t *testing.T) {
+	user := &models.User{Role: models.UserRole_USER}
+	admin := &models.User{Role: models.UserRole_PLATFORM_ADMIN}
+	login := []string{constants.ScopeProfileRead, constants.ScopeProfileWrite, constants.ScopeAccountWrite, constants.ScopeAdmin}
+	day := 24 * time.Hour
+	tests := []struct {
+		name    string
+		req     *requests.CreatePersonalAccessTokenRequest
+		user    *models.User
+		scopes  []string
+		maxTtl  time.Duration
+		wantTtl time.Duration
+		wantErr string
+	}{
+		{"no expiry", &requests.CreatePersonalAccessTokenRequest{Scopes: []string{constants.ScopeProfileRead}}, user, login, 0, 0, ""},
+		{"expiry", &requests.CreatePersonalAccessTokenRequest{Scopes: []string{constants.ScopeProfileRead}, ExpiresInDays: 30}, user, login, 0, 30 * day, ""},
+		{"expiry within the maximum", &requests.CreatePersonalAccessTokenRequest{Scopes: []string{constants.ScopeProfileRead}, ExpiresInDays: 90}, user, login, 90 * day, 90 * day, ""},
+		{"expiry beyond the maximum", &requests.CreatePersonalAccessTokenRequest{Scopes: []string{constants.ScopeProfileRead}, ExpiresInDays: 91}, user, login, 90 * day, 0, errors.CodeTokenExpiryTooLong},
+		{"no expiry with a maximum", &requests.CreatePersonalAccessTokenRequest{Scopes: []string{constants.ScopeProfileRead}}, user, login, 90 * day, 0, errors.CodeTokenExpiryTooLong},
+		{"negative expiry", &requests.CreatePersonalAccessTokenRequest{Scopes: []string{constants.ScopeProfileRead}, ExpiresInDays: -1}, user, login, 0, 0, errors.CodeTokenInvalidExpiry},
+		{"unknown scope", &requests.CreatePersonalAccessTokenRequest{Scopes: []string{"everything"}}, user, login, 0, 0, errors.CodeTokenInvalidScope},
+		{"subset of the token", &requests.CreatePersonalAccessTokenRequest{Scopes: []string{constants.ScopeProfileRead}}, user, []string{constants.ScopeProfileRead, constants.ScopeAccountWrite}, 0, 0, ""},
+		{"beyond the token", &requests.CreatePersonalAccessTokenRequest{Scopes: []string{constants.ScopeProfileRead, constants.ScopeProfileWrite}}, user, []string{constants.ScopeProfileRead, constants.ScopeAccountWrite}, 0, 0, errors.CodeTokenInvalidScope},
+		{"admin scope of a user", &requests.CreatePersonalAccessTokenRequest{Scopes: []string{constants.ScopeAdmin}}, user, login, 0, 0, errors.CodeTokenInvalidScope},
+		{"admin scope of an admin", &requests.CreatePersonalAccessTokenRequest{Scopes: []string{constants.ScopeAdmin}}, admin, login, 0, 0, ""},
+	}
+	for _, test := range tests {
+		ttl, e := handler.CheckPersonalAccessTokenRequest(test.req, test.user, test.scopes, test.maxTtl)
+		got := ""
+		if e != nil {
+			got = e.UserErrorCode()
+		}
+		if got != test.wantErr || ttl != test.wantTtl {
+			t.Errorf("%s: checkPersonalAccessTokenRequest = %s, %q, want %s, %q", test.name, ttl, got, test.wantTtl, test.wantErr)
+		}
+	}
+}
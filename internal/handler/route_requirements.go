@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const describeRouteKey = "describe_route"
+
+// RouteRequirements is what the decorators wrapping a route handler require of a request, the OpenAPI test
+// compares it with the document
+type RouteRequirements struct {
+	// wrapped with IsAuthorized
+	Authorized bool
+	// declared with RequireScope
+	Scope string
+	// wrapped with RequireRecentAuth
+	RecentAuth bool
+	// wrapped with RequireAdmin
+	Admin bool
+	// wrapped with RequireOrganizationRole
+	OrganizationRole bool
+	// wrapped with RequireScimToken
+	ScimToken bool
+}
+
+// the code of the closures returned by the decorators
+var decorators sync.Map
+
+// DescribeRoute collects the requirements of the decorators wrapping the route handler, the handler itself is not run
+func DescribeRoute(fn gin.HandlerFunc) RouteRequirements {
+	requirements := &RouteRequirements{}
+	if isDecorator(fn) {
+		c := utils.NewContext(context.Background(), nil)
+		c.Set(describeRouteKey, requirements)
+		fn(c)
+	}
+	return *requirements
+}
+
+// describeRoute records the requirement of a decorator run by DescribeRoute and goes on with the decorators it
+// wraps, it reports whether the decorator is being described and must return right away
+func describeRoute(c *gin.Context, fn gin.HandlerFunc, record func(requirements *RouteRequirements)) bool {
+	value, ok := c.Get(describeRouteKey)
+	if !ok {
+		return false
+	}
+	record(value.(*RouteRequirements))
+	if isDecorator(fn) {
+		fn(c)
+	}
+	return true
+}
+
+// decorator remembers the code of a closure returned by a decorator, DescribeRoute only runs those
+func decorator(fn gin.HandlerFunc) gin.HandlerFunc {
+	decorators.Store(reflect.ValueOf(fn).Pointer(), true)
+	return fn
+}
+
+func isDecorator(fn gin.HandlerFunc) bool {
+	if fn == nil {
+		return false
+	}
+	_, ok := decorators.Load(reflect.ValueOf(fn).Pointer())
+	return ok
+}
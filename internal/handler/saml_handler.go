@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/saml"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// GetSamlConnection returns the identity provider the organization set up, if any, with the service provider
+// details the identity provider needs
+func GetSamlConnection(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	organization := utils.GetContextOrganization(c)
+
+	if !saml.Enabled() {
+		utils.RespondWithError(c, errors.SamlNotConfiguredError())
+		return
+	}
+	var connection *models.SamlConnection
+	found := &models.SamlConnection{}
+	e := found.FindOne(c, bson.M{"organization_id": organization.Id})
+	if e == nil {
+		connection = found
+	} else if !e.IsNotFound() {
+		logger.Error("Error while fetching saml connection from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{
+		"connection": connection,
+		"service_provider": gin.H{
+			"entity_id":    saml.EntityId(organization.Id),
+			"acs_url":      saml.AcsUrl(organization.Id),
+			"metadata_url": saml.EntityId(organization.Id),
+			"login_url":    saml.LoginUrl(organization.Id),
+		},
+	})
+}
+
+// SaveSamlConnection sets up the identity provider of the organization from its metadata, or replaces it
+func SaveSamlConnection(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.SamlConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to saml connection struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	if !saml.Enabled() {
+		utils.RespondWithError(c, errors.SamlNotConfiguredError())
+		return
+	}
+	if req.IdpMetadata == "" {
+		utils.RespondWithError(c, errors.MissingFieldsError("idp_metadata"))
+		return
+	}
+	descriptor, e := saml.ParseIdpMetadata([]byte(req.IdpMetadata))
+	if e != nil {
+		logger.Info("Invalid identity provider metadata", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+
+	organization := utils.GetContextOrganization(c)
+	connection := models.SamlConnection{
+		OrganizationId:      organization.Id,
+		IdpEntityId:         descriptor.EntityID,
+		IdpMetadata:         req.IdpMetadata,
+		EmailAttribute:      req.EmailAttribute,
+		GivenNameAttribute:  req.GivenNameAttribute,
+		FamilyNameAttribute: req.FamilyNameAttribute,
+		UpdatedBy:           utils.GetContextUser(c).Id,
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		if e := connection.Save(c); e != nil {
+			logger.Error("Error while saving saml connection into database", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.SamlConnectionSaved{OrganizationId: organization.Id, IdpEntityId: connection.IdpEntityId}); e != nil {
+			logger.Error("Error while publishing saml connection saved event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "SAML connection saved", "connection": &connection})
+}
+
+// DeleteSamlConnection turns off SAML single sign-on for the organization, the users it provisioned stay
+func DeleteSamlConnection(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+	organization := utils.GetContextOrganization(c)
+
+	var connection models.SamlConnection
+	if e := connection.FindOne(c, bson.M{"organization_id": organization.Id}); e != nil {
+		if e.IsNotFound() {
+			e = errors.SamlNotConfiguredError()
+		} else {
+			logger.Error("Error while fetching saml connection from database", zap.Error(e.Error()))
+		}
+		utils.RespondWithError(c, e)
+		return
+	}
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := connection.Delete(c); e != nil {
+			logger.Error("Error while deleting saml connection", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.SamlConnectionDeleted{OrganizationId: organization.Id, IdpEntityId: connection.IdpEntityId}); e != nil {
+			logger.Error("Error while publishing saml connection deleted event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "SAML connection deleted"})
+}
+
+// SamlMetadata serves the service provider metadata of the organization, its url is also the entity id
+func SamlMetadata(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	organizationId := c.Param("organization_id")
+	var organization models.Organization
+	if e := organization.FindOne(c, bson.M{"_id": organizationId}); e != nil {
+		if !e.IsNotFound() {
+			logger.Error("Error while fetching organization from database", zap.Error(e.Error()))
+		}
+		utils.RespondWithError(c, e)
+		return
+	}
+	metadata, e := saml.Metadata(organization.Id)
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// StartSamlLogin redirects the browser to the identity provider of the organization with a signed
+// authentication request. Like the social login state, the relay state cookie ties the response to this browser.
+// With the link_token of LinkIdentity the response links the name id to that user instead of logging in.
+func StartSamlLogin(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	organizationId := c.Param("organization_id")
+	logger = utils.AddKeyToContextLogger(c, "organization_id", organizationId)
+	provider, e := samlServiceProvider(c, organizationId)
+	if e != nil {
+		logger.Info("SAML login for an organization without a usable connection", zap.Error(e.Error()))
+		redirectToSamlLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	request, e := provider.NewAuthnRequest()
+	if e != nil {
+		logger.Error("Error while creating SAML authentication request", zap.Error(e.Error()))
+		redirectToSamlLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	samlRequest := datatypes.SamlRequest{OrganizationId: organizationId, RequestId: request.Id}
+	if linkToken := c.Query("link_token"); linkToken != "" {
+		samlRequest.LinkUserId, e = serviceRegistry.GetRedisClient().ConsumeSocialLinkToken(c, linkToken)
+		if e != nil {
+			if e.IsNotFound() {
+				logger.Info("SAML login with an unknown or expired link token")
+				e = errors.SamlInvalidStateError()
+			} else {
+				logger.Error("Error while fetching link token", zap.Error(e.Error()))
+			}
+			redirectToSamlLoginResult(c, "error", e.UserErrorCode())
+			return
+		}
+	}
+	relayState, e := serviceRegistry.GetRedisClient().SetSamlRequest(c, samlRequest)
+	if e != nil {
+		logger.Error("Error while storing SAML request", zap.Error(e.Error()))
+		redirectToSamlLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	redirectUrl, e := request.RedirectUrl(relayState)
+	if e != nil {
+		logger.Error("Error while signing SAML authentication request", zap.Error(e.Error()))
+		redirectToSamlLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	setSamlRequestCookie(c, relayState)
+	c.Redirect(http.StatusFound, redirectUrl)
+}
+
+// SamlAssertionConsumerService takes the response the identity provider posts. It verifies the response answers
+// the request started in this browser, that it was not used before and hands the frontend a single use login code
+// for ExchangeSamlLoginCode.
+func SamlAssertionConsumerService(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	organizationId := c.Param("organization_id")
+	logger = utils.AddKeyToContextLogger(c, "organization_id", organizationId)
+	relayState := c.PostForm("RelayState")
+	cookie, _ := c.Cookie(constants.SamlRequestCookie)
+	clearSamlRequestCookie(c)
+	// identity provider initiated logins have no request to answer and are not accepted
+	if relayState == "" || subtle.ConstantTimeCompare([]byte(relayState), []byte(cookie)) != 1 {
+		logger.Info("SAML response without a matching relay state cookie")
+		redirectToSamlLoginResult(c, "error", errors.SamlInvalidStateError().UserErrorCode())
+		return
+	}
+	redisClient := serviceRegistry.GetRedisClient()
+	request, e := redisClient.ConsumeSamlRequest(c, relayState)
+	if e != nil {
+		if e.IsNotFound() {
+			logger.Info("SAML response with an unknown or expired relay state")
+			e = errors.SamlInvalidStateError()
+		} else {
+			logger.Error("Error while fetching SAML request", zap.Error(e.Error()))
+		}
+		redirectToSamlLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	if request.OrganizationId != organizationId {
+		logger.Info("SAML response posted to the assertion consumer service of another organization")
+		redirectToSamlLoginResult(c, "error", errors.SamlInvalidStateError().UserErrorCode())
+		return
+	}
+	provider, e := samlServiceProvider(c, organizationId)
+	if e != nil {
+		logger.Info("SAML connection was removed during the login", zap.Error(e.Error()))
+		redirectToSamlLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	identity, e := provider.ParseResponse(c.PostForm("SAMLResponse"), request.RequestId)
+	if e != nil {
+		logger.Info("Invalid SAML response", zap.Error(e.Error()))
+		redirectToSamlLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	used, e := redisClient.MarkSamlAssertionConsumed(c, organizationId, identity.AssertionId, identity.ExpiresAt)
+	if e != nil {
+		logger.Error("Error while recording SAML assertion", zap.Error(e.Error()))
+		redirectToSamlLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	if used {
+		logger.Info("Replayed SAML assertion", zap.String("assertion_id", identity.AssertionId))
+		redirectToSamlLoginResult(c, "error", errors.SamlAssertionReplayedError().UserErrorCode())
+		return
+	}
+	if request.LinkUserId != "" {
+		if e = linkSamlIdentityToUser(c, organizationId, request.LinkUserId, identity); e != nil {
+			redirectToSamlLoginResult(c, "error", e.UserErrorCode())
+			return
+		}
+		redirectToSamlLoginResult(c, "linked", samlIdentityProvider(organizationId))
+		return
+	}
+	code, e := completeSamlLogin(c, organizationId, identity)
+	if e != nil {
+		redirectToSamlLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	redirectToSamlLoginResult(c, "code", code)
+}
+
+// ExchangeSamlLoginCode logs in with the login code of a SAML response
+func ExchangeSamlLoginCode(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.SamlLoginExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to saml login exchange struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	res, e := exchangeSamlLoginCode(c, &req)
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, res)
+}
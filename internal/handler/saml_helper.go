@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/oidc"
+	"github.com/MitP1997/golang-user-management/internal/saml"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// samlServiceProvider returns the service provider of the organization with the identity provider it set up
+func samlServiceProvider(c *gin.Context, organizationId string) (*saml.ServiceProvider, *errors.Error) {
+	if !saml.Enabled() {
+		return nil, errors.SamlNotConfiguredError()
+	}
+	var connection models.SamlConnection
+	if e := connection.FindOne(c, bson.M{"organization_id": organizationId}); e != nil {
+		if e.IsNotFound() {
+			return nil, errors.SamlNotConfiguredError()
+		}
+		return nil, e
+	}
+	mapping := saml.AttributeMapping{
+		Email:      connection.EmailAttribute,
+		GivenName:  connection.GivenNameAttribute,
+		FamilyName: connection.FamilyNameAttribute,
+	}
+	return saml.NewServiceProvider(organizationId, []byte(connection.IdpMetadata), mapping)
+}
+
+// the provider of the linked identities of an organization's identity provider
+func samlIdentityProvider(organizationId string) string {
+	return "saml:" + organizationId
+}
+
+// the identity provider posts the response from its own site, a lax cookie would not come along then,
+// so over https it is sent cross site. Plain http deployments only work with an identity provider on the same site.
+func setSamlRequestCookie(c *gin.Context, relayState string) {
+	maxAge := int(serviceRegistry.GetConfig().Tokens.SamlRequestTtl.Seconds())
+	secure := strings.HasPrefix(oidc.Issuer(), "https://")
+	c.SetSameSite(samlCookieSameSite(secure))
+	c.SetCookie(constants.SamlRequestCookie, relayState, maxAge, constants.SamlPathPrefix, "", secure, true)
+}
+
+func clearSamlRequestCookie(c *gin.Context) {
+	secure := strings.HasPrefix(oidc.Issuer(), "https://")
+	c.SetSameSite(samlCookieSameSite(secure))
+	c.SetCookie(constants.SamlRequestCookie, "", -1, constants.SamlPathPrefix, "", secure, true)
+}
+
+func samlCookieSameSite(secure bool) http.SameSite {
+	if secure {
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteLaxMode
+}
+
+// the browser arrives at the login and assertion consumer service endpoints by navigation, so they answer by
+// sending it to the frontend
+func redirectToSamlLoginResult(c *gin.Context, key string, value string) {
+	c.Redirect(http.StatusFound, serviceRegistry.GetConfig().App.BaseUrl+constants.SamlLoginResultPath+"?"+url.Values{key: {value}}.Encode())
+}
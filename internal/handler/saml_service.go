@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/responses"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/suspensions"
+	"github.com/MitP1997/golang-user-management/internal/tracing"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// completeSamlLogin finds or provisions the user of a verified assertion and returns the login code
+// the frontend exchanges for an auth token
+func completeSamlLogin(c *gin.Context, organizationId string, identity *datatypes.SamlIdentity) (code string, err *errors.Error) {
+	end := tracing.StartSpan(c, "completeSamlLogin")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	// the id stays empty when no user was found or created
+	var user *models.User
+	defer func() {
+		if err != nil {
+			var userId string
+			if user != nil {
+				userId = user.Id
+			}
+			publishAndLog(c, events.LoginFailed{Email: identity.Email, UserId: userId, Reason: err.UserErrorCode()})
+		}
+	}()
+
+	user, e := findOrProvisionSamlUser(c, organizationId, identity)
+	if e != nil {
+		return "", e
+	}
+	if user.Status == models.UserStatus_DELETED {
+		logger.Info("SAML login for a deleted user")
+		return "", errors.InvalidCredentialsError()
+	}
+	if user.PasswordResetRequired {
+		logger.Info("SAML login for a secured account that needs a password reset")
+		return "", errors.PasswordResetRequiredError()
+	}
+	if e := suspensions.Refuse(user); e != nil {
+		logger.Info("SAML login for a suspended or banned user", zap.String("status", user.Status.String()))
+		return "", e
+	}
+	code, e = serviceRegistry.GetRedisClient().SetSamlLoginCode(c, datatypes.SamlLogin{UserId: user.Id, OrganizationId: organizationId})
+	if e != nil {
+		logger.Error("Error while storing SAML login code", zap.Error(e.Error()))
+		return "", e
+	}
+	return code, nil
+}
+
+// findOrProvisionSamlUser returns the user the name id is linked to. A name id that is not linked yet is linked
+// to the user of the organization with its email, or provisions a new member. Anyone else has to link the identity
+// provider from their account, an organization admin controls its identity provider and could assert any email.
+func findOrProvisionSamlUser(c *gin.Context, organizationId string, identity *datatypes.SamlIdentity) (*models.User, *errors.Error) {
+	logger := utils.GetContextLogger(c)
+
+	var linkedIdentity models.LinkedIdentity
+	e := linkedIdentity.FindOne(c, bson.M{"provider": samlIdentityProvider(organizationId), "subject": identity.Subject})
+	if e == nil {
+		user := &models.User{}
+		if e = user.FindOne(c, bson.M{"_id": linkedIdentity.UserId}); e != nil {
+			logger.Error("Error while fetching user of linked identity", zap.Error(e.Error()))
+			return nil, e
+		}
+		if e = linkedIdentity.RecordLogin(c, identity.Email); e != nil {
+			logger.Error("Error while recording login of linked identity", zap.Error(e.Error()))
+			return nil, e
+		}
+		return user, nil
+	}
+	if !e.IsNotFound() {
+		logger.Error("Error while fetching linked identity from database", zap.Error(e.Error()))
+		return nil, e
+	}
+
+	if identity.Email == "" {
+		logger.Info("SAML assertion without an email")
+		return nil, errors.SamlEmailRequiredError()
+	}
+	filter := bson.M{"email": identity.Email}
+	if models.IsEmailUniquePerOrganization() {
+		filter["organization_id"] = organizationId
+	}
+	user := &models.User{}
+	e = user.FindOne(c, filter)
+	if e == nil {
+		return user, linkSamlIdentity(c, organizationId, user, identity)
+	}
+	if !e.IsNotFound() {
+		logger.Error("Error while fetching user from database", zap.Error(e.Error()))
+		return nil, e
+	}
+	return provisionSamlUser(c, organizationId, identity)
+}
+
+// linkSamlIdentity links the name id to the existing user with its email, who has to belong to the organization.
+// Members from elsewhere and platform admins link it themselves with linkSamlIdentityToUser.
+func linkSamlIdentity(c *gin.Context, organizationId string, user *models.User, identity *datatypes.SamlIdentity) *errors.Error {
+	logger := utils.GetContextLogger(c)
+	// the caller refuses the login
+	if user.Status == models.UserStatus_DELETED {
+		return nil
+	}
+	if user.OrganizationId != organizationId || user.Role == models.UserRole_PLATFORM_ADMIN {
+		logger.Info("Not linking SAML identity by email to a user the organization does not own", zap.String("user_id", user.Id))
+		return errors.SamlEmailInUseError()
+	}
+	var membership models.Membership
+	e := membership.FindOne(c, bson.M{"organization_id": organizationId, "user_id": user.Id})
+	if e != nil && !e.IsNotFound() {
+		logger.Error("Error while fetching membership from database", zap.Error(e.Error()))
+		return e
+	}
+	isMember := e == nil
+	return utils.RunInTransaction(c, func() *errors.Error {
+		if !isMember {
+			membership = models.Membership{OrganizationId: organizationId, UserId: user.Id, Role: models.MembershipRole_MEMBER}
+			if e := membership.Insert(c); e != nil {
+				logger.Error("Error while inserting membership into database", zap.Error(e.Error()))
+				return e
+			}
+		}
+		return insertSamlIdentity(c, organizationId, user, identity)
+	})
+}
+
+// provisionSamlUser creates the user the identity provider vouched for just in time, as a member of the organization.
+// The organization decides who its identity provider lets in, so the signup mode does not apply.
+func provisionSamlUser(c *gin.Context, organizationId string, identity *datatypes.SamlIdentity) (*models.User, *errors.Error) {
+	logger := utils.GetContextLogger(c)
+
+	user := &models.User{
+		GivenName:      identity.GivenName,
+		FamilyName:     identity.FamilyName,
+		Email:          identity.Email,
+		OrganizationId: organizationId,
+		Status:         models.UserStatus_VERIFIED,
+		VerifiedAt:     timestamppb.Now(),
+	}
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := user.Insert(c); e != nil {
+			logger.Error("Error while inserting user into database", zap.Error(e.Error()))
+			return e
+		}
+		membership := models.Membership{OrganizationId: organizationId, UserId: user.Id, Role: models.MembershipRole_MEMBER}
+		if e := membership.Insert(c); e != nil {
+			logger.Error("Error while inserting membership into database", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.UserSignedUp{User: events.NewUser(user)}); e != nil {
+			logger.Error("Error while publishing user signed up event", zap.Error(e.Error()))
+			return e
+		}
+		return insertSamlIdentity(c, organizationId, user, identity)
+	})
+	if e != nil {
+		return nil, e
+	}
+	return user, nil
+}
+
+// linkSamlIdentityToUser links the name id to the logged in user who started the SAML login from
+// POST /user/me/identities, the user has to be a member of the organization
+func linkSamlIdentityToUser(c *gin.Context, organizationId string, userId string, identity *datatypes.SamlIdentity) (err *errors.Error) {
+	end := tracing.StartSpan(c, "linkSamlIdentityToUser")
+	defer func() { end(err) }()
+	logger := utils.AddKeyToContextLogger(c, "user_id", userId)
+
+	user := &models.User{}
+	if e := user.FindOne(c, bson.M{"_id": userId}); e != nil {
+		logger.Error("Error while fetching user linking a SAML identity", zap.Error(e.Error()))
+		return e
+	}
+	if user.Status == models.UserStatus_DELETED {
+		logger.Info("Linking SAML identity to a deleted user")
+		return errors.InvalidCredentialsError()
+	}
+	var membership models.Membership
+	if e := membership.FindOne(c, bson.M{"organization_id": organizationId, "user_id": user.Id}); e != nil {
+		if e.IsNotFound() {
+			logger.Info("Linking SAML identity of an organization the user is not a member of")
+			return errors.NotOrganizationMemberError()
+		}
+		logger.Error("Error while fetching membership from database", zap.Error(e.Error()))
+		return e
+	}
+	var linkedIdentity models.LinkedIdentity
+	e := linkedIdentity.FindOne(c, bson.M{"provider": samlIdentityProvider(organizationId), "subject": identity.Subject})
+	if e == nil {
+		if linkedIdentity.UserId != user.Id {
+			logger.Info("SAML identity is already linked to another user")
+			return errors.SocialIdentityAlreadyLinkedError()
+		}
+		return nil
+	}
+	if !e.IsNotFound() {
+		logger.Error("Error while fetching linked identity from database", zap.Error(e.Error()))
+		return e
+	}
+	return utils.RunInTransaction(c, func() *errors.Error {
+		return insertSamlIdentity(c, organizationId, user, identity)
+	})
+}
+
+func insertSamlIdentity(c *gin.Context, organizationId string, user *models.User, identity *datatypes.SamlIdentity) *errors.Error {
+	return insertLinkedIdentity(c, user, &datatypes.SocialIdentity{
+		Provider: samlIdentityProvider(organizationId),
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+}
+
+// exchangeSamlLoginCode logs in the user of the login code in the organization of the identity provider
+func exchangeSamlLoginCode(c *gin.Context, req *requests.SamlLoginExchangeRequest) (res *responses.AuthTokenResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "exchangeSamlLoginCode")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	if req.Code == "" {
+		logger.Error("Login code not present in request")
+		return nil, errors.MissingFieldsError("code")
+	}
+	redisClient := serviceRegistry.GetRedisClient()
+	login, e := redisClient.ConsumeSamlLoginCode(c, req.Code)
+	if e != nil {
+		if e.IsNotFound() {
+			logger.Info("Unknown SAML login code")
+			return nil, errors.SamlInvalidLoginCodeError(e.Error())
+		}
+		logger.Error("Error while fetching SAML login code", zap.Error(e.Error()))
+		return nil, e
+	}
+	logger = utils.AddKeyToContextLogger(c, "user_id", login.UserId)
+	user := &models.User{}
+	if e = user.FindOne(c, bson.M{"_id": login.UserId}); e != nil {
+		logger.Error("Error while fetching user of SAML login", zap.Error(e.Error()))
+		return nil, e
+	}
+	if user.Status == models.UserStatus_DELETED {
+		logger.Info("SAML login code of a user deleted since")
+		return nil, errors.InvalidCredentialsError()
+	}
+	if e = suspensions.Refuse(user); e != nil {
+		logger.Info("SAML login code of a user suspended since", zap.String("status", user.Status.String()))
+		return nil, e
+	}
+	// the identity provider only vouches for the user within its organization
+	token, e := startOrganizationSession(c, user, constants.AuthMethodSaml, login.OrganizationId)
+	if e != nil {
+		logger.Error("Error while generating user auth token", zap.Error(e.Error()))
+		return nil, e
+	}
+	// the login happened through the organization, so it is the one the token works in
+	if e = redisClient.SetAuthTokenOrganization(c, token, login.OrganizationId); e != nil {
+		logger.Error("Error while setting organization claim on auth token", zap.Error(e.Error()))
+		return nil, e
+	}
+	publishAndLog(c, events.UserLoggedIn{User: events.NewUser(user), Ip: c.ClientIP(), UserAgent: c.Request.UserAgent(), At: time.Now()})
+	return &responses.AuthTokenResponse{Message: "User login successful", Token: token}, nil
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/scim"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetScimServiceProviderConfig tells the identity provider which parts of SCIM are supported
+func GetScimServiceProviderConfig(c *gin.Context) {
+	respondWithScim(c, http.StatusOK, &scim.ServiceProviderConfig{
+		Schemas: []string{constants.ScimSchemaServiceProviderConfig},
+		Patch:   scim.Supported{Supported: true},
+		Filter:  scim.Filter{Supported: true, MaxResults: constants.ScimMaxCount},
+		AuthenticationSchemes: []scim.AuthenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "Bearer token",
+			Description: "A SCIM token of the organization, issued by its admins",
+		}},
+	})
+}
+
+// ListScimUsers returns a page of the users of the organization matching the filter
+func ListScimUsers(c *gin.Context) {
+	res, e := listScimUsers(c)
+	if e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	respondWithScim(c, http.StatusOK, res)
+}
+
+func GetScimUser(c *gin.Context) {
+	user, e := findScimUser(c, c.Param("id"))
+	if e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	respondWithScim(c, http.StatusOK, newScimUser(user))
+}
+
+func CreateScimUser(c *gin.Context) {
+	var resource scim.User
+	if e := bindScimBody(c, &resource); e != nil {
+		utils.GetContextLogger(c).Info("Invalid SCIM user in request body", zap.Error(e.Error()))
+		respondWithScimError(c, e)
+		return
+	}
+	user, e := createScimUser(c, &resource)
+	if e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	respondWithScim(c, http.StatusCreated, newScimUser(user))
+}
+
+// ReplaceScimUser replaces the userName, name and active of the user, attributes missing in the body are cleared
+func ReplaceScimUser(c *gin.Context) {
+	var resource scim.User
+	if e := bindScimBody(c, &resource); e != nil {
+		utils.GetContextLogger(c).Info("Invalid SCIM user in request body", zap.Error(e.Error()))
+		respondWithScimError(c, e)
+		return
+	}
+	user, e := findScimUser(c, c.Param("id"))
+	if e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	if e = replaceScimUser(c, user, &resource); e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	respondWithScim(c, http.StatusOK, newScimUser(user))
+}
+
+// PatchScimUser applies the patch operations to the user, setting active to false deletes the user and
+// setting it to true reactivates them
+func PatchScimUser(c *gin.Context) {
+	var req scim.PatchRequest
+	if e := bindScimBody(c, &req); e != nil {
+		utils.GetContextLogger(c).Info("Invalid SCIM patch in request body", zap.Error(e.Error()))
+		respondWithScimError(c, e)
+		return
+	}
+	user, e := findScimUser(c, c.Param("id"))
+	if e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	resource := newScimUser(user)
+	if e = scim.ApplyUserPatch(resource, req.Operations); e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	if e = replaceScimUser(c, user, resource); e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	respondWithScim(c, http.StatusOK, newScimUser(user))
+}
+
+// DeleteScimUser soft deletes the user, the user stays visible as inactive so that it can be reactivated
+func DeleteScimUser(c *gin.Context) {
+	user, e := findScimUser(c, c.Param("id"))
+	if e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	if e = deleteScimUser(c, user); e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListScimGroups returns a page of the groups of the organization matching the filter
+func ListScimGroups(c *gin.Context) {
+	res, e := listScimGroups(c)
+	if e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	respondWithScim(c, http.StatusOK, res)
+}
+
+func GetScimGroup(c *gin.Context) {
+	group, e := findScimGroup(c, c.Param("id"))
+	if e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	respondWithScim(c, http.StatusOK, newScimGroup(group))
+}
+
+func CreateScimGroup(c *gin.Context) {
+	var resource scim.Group
+	if e := bindScimBody(c, &resource); e != nil {
+		utils.GetContextLogger(c).Info("Invalid SCIM group in request body", zap.Error(e.Error()))
+		respondWithScimError(c, e)
+		return
+	}
+	group := &models.ScimGroup{}
+	if e := saveScimGroup(c, group, &resource); e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	respondWithScim(c, http.StatusCreated, newScimGroup(group))
+}
+
+func ReplaceScimGroup(c *gin.Context) {
+	var resource scim.Group
+	if e := bindScimBody(c, &resource); e != nil {
+		utils.GetContextLogger(c).Info("Invalid SCIM group in request body", zap.Error(e.Error()))
+		respondWithScimError(c, e)
+		return
+	}
+	group, e := findScimGroup(c, c.Param("id"))
+	if e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	if e = saveScimGroup(c, group, &resource); e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	respondWithScim(c, http.StatusOK, newScimGroup(group))
+}
+
+// PatchScimGroup applies the patch operations to the group, identity providers use it to add and remove members
+func PatchScimGroup(c *gin.Context) {
+	var req scim.PatchRequest
+	if e := bindScimBody(c, &req); e != nil {
+		utils.GetContextLogger(c).Info("Invalid SCIM patch in request body", zap.Error(e.Error()))
+		respondWithScimError(c, e)
+		return
+	}
+	group, e := findScimGroup(c, c.Param("id"))
+	if e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	resource := newScimGroup(group)
+	if e = scim.ApplyGroupPatch(resource, req.Operations); e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	if e = saveScimGroup(c, group, resource); e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	respondWithScim(c, http.StatusOK, newScimGroup(group))
+}
+
+func DeleteScimGroup(c *gin.Context) {
+	group, e := findScimGroup(c, c.Param("id"))
+	if e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	if e = deleteScimGroup(c, group); e != nil {
+		respondWithScimError(c, e)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
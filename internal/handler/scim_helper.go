@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/oidc"
+	"github.com/MitP1997/golang-user-management/internal/scim"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// scimTypes picks the scimType of the error responses from the error code
+var scimTypes = map[string]string{
+	errors.CodeScimInvalidFilter:       constants.ScimTypeInvalidFilter,
+	errors.CodeScimInvalidValue:        constants.ScimTypeInvalidValue,
+	errors.CodeRequestValidationFailed: constants.ScimTypeInvalidValue,
+	errors.CodeScimInvalidPath:         constants.ScimTypeInvalidPath,
+	errors.CodeScimInvalidSyntax:       constants.ScimTypeInvalidSyntax,
+	errors.CodeScimUniqueness:          constants.ScimTypeUniqueness,
+}
+
+// respondWithScimError answers with the error format of RFC 7644 section 3.12 instead of the error envelope,
+// identity providers only understand that one
+func respondWithScimError(c *gin.Context, e *errors.Error) {
+	status := e.UserStatusError()
+	scimType := scimTypes[e.Code]
+	switch {
+	case e.Code == errors.CodeScimInvalidToken:
+		status = http.StatusUnauthorized
+	case e.Code == errors.CodeScimUniqueness || strings.Contains(e.Code, "_DUPLICATE_"):
+		status, scimType = http.StatusConflict, constants.ScimTypeUniqueness
+	}
+	c.Abort()
+	respondWithScim(c, status, &scim.Error{
+		Schemas:  []string{constants.ScimSchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   e.UserErrorString(),
+	})
+}
+
+func respondWithScim(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", constants.ScimContentType)
+	c.IndentedJSON(status, body)
+}
+
+// authorizeScimRequest resolves the organization of the SCIM token in the Authorization header, the identity
+// provider acts for the organization without a user
+func authorizeScimRequest(c *gin.Context) *errors.Error {
+	logger := utils.GetContextLogger(c)
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || !strings.HasPrefix(token, constants.ScimTokenPrefix) {
+		logger.Info("SCIM request without a SCIM bearer token")
+		return errors.ScimInvalidTokenError()
+	}
+	var scimToken models.ScimToken
+	if e := scimToken.FindOne(c, bson.M{"token_hash": hashScimToken(token)}); e != nil {
+		if e.IsNotFound() {
+			logger.Info("SCIM request with an unknown token")
+			return errors.ScimInvalidTokenError()
+		}
+		logger.Error("Error while fetching scim token from database", zap.Error(e.Error()))
+		return e
+	}
+	organization := &models.Organization{}
+	if e := organization.FindOne(c, bson.M{"_id": scimToken.OrganizationId}); e != nil {
+		logger.Error("Error while fetching organization of scim token", zap.Error(e.Error()))
+		return e
+	}
+	utils.SetContextOrganization(c, organization, nil)
+	utils.SetContextActorType(c, constants.AuditActorScim)
+	utils.AddKeyToContextLogger(c, "scim_token_id", scimToken.Id)
+	if e := scimToken.RecordUse(c); e != nil {
+		logger.Error("Error while recording use of scim token", zap.Error(e.Error()))
+	}
+	return nil
+}
+
+// only the hash of a token is stored, the token is random enough for a fast hash
+func hashScimToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// bindScimBody decodes the SCIM resource or message of the request body
+func bindScimBody(c *gin.Context, v interface{}) *errors.Error {
+	if err := json.NewDecoder(c.Request.Body).Decode(v); err != nil {
+		return errors.ScimInvalidSyntaxError(err)
+	}
+	return nil
+}
+
+// parseScimPagination reads the 1-based startIndex and the count of a list request, see RFC 7644 section 3.4.2.4
+func parseScimPagination(c *gin.Context) (startIndex int64, count int64, err *errors.Error) {
+	startIndex, e := strconv.ParseInt(c.DefaultQuery("startIndex", "1"), 10, 64)
+	if e != nil {
+		return 0, 0, errors.ScimInvalidValueError("startIndex must be a number")
+	}
+	// values below 1 are interpreted as 1
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count, e = strconv.ParseInt(c.DefaultQuery("count", strconv.Itoa(constants.ScimDefaultCount)), 10, 64)
+	if e != nil {
+		return 0, 0, errors.ScimInvalidValueError("count must be a number")
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > constants.ScimMaxCount {
+		count = constants.ScimMaxCount
+	}
+	return startIndex, count, nil
+}
+
+// scimQuery combines the filter of a list request with the scope of the organization
+func scimQuery(c *gin.Context, scope bson.M, attributes map[string]scim.Attribute) (bson.M, *errors.Error) {
+	filter := c.Query("filter")
+	if filter == "" {
+		return scope, nil
+	}
+	query, e := scim.ParseFilter(filter, attributes)
+	if e != nil {
+		return nil, e
+	}
+	return bson.M{"$and": bson.A{scope, query}}, nil
+}
+
+func scimLocation(resourceType string, id string) string {
+	return strings.TrimSuffix(oidc.Issuer(), "/") + constants.ScimPathPrefix + "/" + resourceType + "/" + id
+}
+
+func newScimUser(user *models.User) *scim.User {
+	active := user.Status != models.UserStatus_DELETED
+	resource := &scim.User{
+		Schemas:  []string{constants.ScimSchemaUser},
+		Id:       user.Id,
+		UserName: user.Email,
+		Name:     &scim.Name{GivenName: user.GivenName, FamilyName: user.FamilyName},
+		Emails:   []scim.Email{{Value: user.Email, Type: "work", Primary: true}},
+		Active:   &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      user.CreatedAt.AsTime(),
+			LastModified: user.UpdatedAt.AsTime(),
+			Location:     scimLocation("Users", user.Id),
+		},
+	}
+	return resource
+}
+
+func newScimGroup(group *models.ScimGroup) *scim.Group {
+	members := make([]scim.Member, 0, len(group.MemberIds))
+	for _, memberId := range group.MemberIds {
+		members = append(members, scim.Member{Value: memberId, Ref: scimLocation("Users", memberId)})
+	}
+	return &scim.Group{
+		Schemas:     []string{constants.ScimSchemaGroup},
+		Id:          group.Id,
+		ExternalId:  group.ExternalId,
+		DisplayName: group.DisplayName,
+		Members:     members,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt.AsTime(),
+			LastModified: group.UpdatedAt.AsTime(),
+			Location:     scimLocation("Groups", group.Id),
+		},
+	}
+}
+
+func newScimListResponse(total int64, startIndex int64, resources interface{}, count int) *scim.ListResponse {
+	return &scim.ListResponse{
+		Schemas:      []string{constants.ScimSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: count,
+		Resources:    resources,
+	}
+}
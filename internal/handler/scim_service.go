@@ -0,0 +1,383 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/scim"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/tracing"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// the users an identity provider manages are the ones belonging to its organization, deleted ones included
+// so that they can be reactivated
+func scimUserScope(c *gin.Context) bson.M {
+	return bson.M{"organization_id": utils.GetContextOrganization(c).Id}
+}
+
+func scimGroupScope(c *gin.Context) bson.M {
+	return bson.M{"organization_id": utils.GetContextOrganization(c).Id}
+}
+
+func listScimUsers(c *gin.Context) (res *scim.ListResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "listScimUsers")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	startIndex, count, e := parseScimPagination(c)
+	if e != nil {
+		return nil, e
+	}
+	query, e := scimQuery(c, scimUserScope(c), scim.UserAttributes)
+	if e != nil {
+		return nil, e
+	}
+	total, e := models.CountUsers(c, query)
+	if e != nil {
+		logger.Error("Error while counting users", zap.Error(e.Error()))
+		return nil, e
+	}
+	users := []*models.User{}
+	// a count of 0 only asks for the total
+	if count > 0 {
+		opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(startIndex - 1).SetLimit(count)
+		if users, e = models.FindUsers(c, query, opts); e != nil {
+			logger.Error("Error while fetching users from database", zap.Error(e.Error()))
+			return nil, e
+		}
+	}
+	resources := make([]*scim.User, 0, len(users))
+	for _, user := range users {
+		resources = append(resources, newScimUser(user))
+	}
+	return newScimListResponse(total, startIndex, resources, len(resources)), nil
+}
+
+func findScimUser(c *gin.Context, id string) (*models.User, *errors.Error) {
+	user := &models.User{}
+	filter := scimUserScope(c)
+	filter["_id"] = id
+	if e := user.FindOne(c, filter); e != nil {
+		// an id that is not an object id can not be the id of a user either
+		if e.Code == errors.CodeRequestInvalidId {
+			return nil, errors.NoDocumentsError(e.Error(), "user")
+		}
+		return nil, e
+	}
+	return user, nil
+}
+
+// createScimUser provisions a verified member of the organization without a password, the users log in through
+// single sign-on or reset the password. A deleted user of the organization with the email is reactivated instead.
+func createScimUser(c *gin.Context, resource *scim.User) (user *models.User, err *errors.Error) {
+	end := tracing.StartSpan(c, "createScimUser")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+	organization := utils.GetContextOrganization(c)
+
+	email := strings.ToLower(strings.TrimSpace(resource.UserName))
+	if email == "" {
+		return nil, errors.ScimInvalidValueError("userName is required")
+	}
+	filter := bson.M{"email": email}
+	if models.IsEmailUniquePerOrganization() {
+		filter["organization_id"] = organization.Id
+	}
+	existing := &models.User{}
+	e := existing.FindOne(c, filter)
+	if e == nil {
+		if existing.OrganizationId == organization.Id && existing.Status == models.UserStatus_DELETED {
+			logger.Info("Reactivating deleted user for SCIM create", zap.String("user_id", existing.Id))
+			return existing, replaceScimUser(c, existing, resource)
+		}
+		logger.Info("SCIM create for an email that is taken", zap.String("user_id", existing.Id))
+		return nil, errors.ScimUniquenessError("A user with the userName already exists")
+	}
+	if !e.IsNotFound() {
+		logger.Error("Error while fetching user from database", zap.Error(e.Error()))
+		return nil, e
+	}
+
+	user = &models.User{
+		Email:          email,
+		OrganizationId: organization.Id,
+		Status:         models.UserStatus_VERIFIED,
+		VerifiedAt:     timestamppb.Now(),
+	}
+	if resource.Name != nil {
+		user.GivenName, user.FamilyName = resource.Name.GivenName, resource.Name.FamilyName
+	}
+	if !resource.IsActive() {
+		user.Status, user.DeletedAt = models.UserStatus_DELETED, timestamppb.Now()
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		if e := user.Insert(c); e != nil {
+			logger.Error("Error while inserting user into database", zap.Error(e.Error()))
+			return e
+		}
+		membership := models.Membership{OrganizationId: organization.Id, UserId: user.Id, Role: models.MembershipRole_MEMBER}
+		if e := membership.Insert(c); e != nil {
+			logger.Error("Error while inserting membership into database", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.UserSignedUp{User: events.NewUser(user)}); e != nil {
+			logger.Error("Error while publishing user signed up event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return user, nil
+}
+
+// replaceScimUser stores the userName and name of the resource on the user, and deletes or reactivates the user
+// when active changed
+func replaceScimUser(c *gin.Context, user *models.User, resource *scim.User) (err *errors.Error) {
+	end := tracing.StartSpan(c, "replaceScimUser")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	email := strings.ToLower(strings.TrimSpace(resource.UserName))
+	if email == "" {
+		return errors.ScimInvalidValueError("userName is required")
+	}
+	var givenName, familyName string
+	if resource.Name != nil {
+		givenName, familyName = resource.Name.GivenName, resource.Name.FamilyName
+	}
+	set := bson.M{}
+	if email != user.Email {
+		set["email"] = email
+	}
+	if givenName != user.GivenName {
+		set["given_name"] = givenName
+	}
+	if familyName != user.FamilyName {
+		set["family_name"] = familyName
+	}
+	wasActive := user.Status != models.UserStatus_DELETED
+	active := resource.IsActive()
+
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if len(set) > 0 {
+			set["updated_at"] = timestamppb.Now()
+			if e := user.Update(c, bson.M{"_id": user.Id}, set); e != nil {
+				logger.Error("Error while updating user", zap.Error(e.Error()))
+				return e
+			}
+			if e := events.Publish(c, events.UserProfileUpdated{User: events.NewUser(user)}); e != nil {
+				logger.Error("Error while publishing user profile updated event", zap.Error(e.Error()))
+				return e
+			}
+		}
+		if wasActive && !active {
+			return markUserDeleted(c, user)
+		}
+		if !wasActive && active {
+			return reactivateScimUser(c, user)
+		}
+		return nil
+	})
+	if e != nil {
+		return e
+	}
+	if wasActive && !active {
+		if e = serviceRegistry.GetRedisClient().RevokeUserSessions(c, user.Id); e != nil {
+			logger.Error("Error while revoking sessions of deactivated user", zap.Error(e.Error()))
+			return e
+		}
+	}
+	return nil
+}
+
+// reactivateScimUser undoes the deletion, the identity provider vouches for the user so it is verified
+func reactivateScimUser(c *gin.Context, user *models.User) *errors.Error {
+	logger := utils.GetContextLogger(c)
+	previousStatus := user.Status
+	set := bson.M{"status": models.UserStatus_VERIFIED, "deleted_at": nil, "updated_at": timestamppb.Now()}
+	if user.VerifiedAt == nil {
+		set["verified_at"] = timestamppb.Now()
+	}
+	if e := user.Update(c, bson.M{"_id": user.Id}, set); e != nil {
+		logger.Error("Error while reactivating user", zap.Error(e.Error()))
+		return e
+	}
+	if e := events.Publish(c, events.StatusChanged{User: events.NewUser(user), PreviousStatus: previousStatus.String()}); e != nil {
+		logger.Error("Error while publishing status changed event", zap.Error(e.Error()))
+		return e
+	}
+	return nil
+}
+
+// deleteScimUser soft deletes the user and takes them out of the groups of the organization
+func deleteScimUser(c *gin.Context, user *models.User) (err *errors.Error) {
+	end := tracing.StartSpan(c, "deleteScimUser")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	wasActive := user.Status != models.UserStatus_DELETED
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := models.RemoveScimGroupMember(c, user.OrganizationId, user.Id); e != nil {
+			logger.Error("Error while removing user from scim groups", zap.Error(e.Error()))
+			return e
+		}
+		if wasActive {
+			return markUserDeleted(c, user)
+		}
+		return nil
+	})
+	if e != nil {
+		return e
+	}
+	if e = serviceRegistry.GetRedisClient().RevokeUserSessions(c, user.Id); e != nil {
+		logger.Error("Error while revoking sessions of deleted user", zap.Error(e.Error()))
+		return e
+	}
+	return nil
+}
+
+func listScimGroups(c *gin.Context) (res *scim.ListResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "listScimGroups")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	startIndex, count, e := parseScimPagination(c)
+	if e != nil {
+		return nil, e
+	}
+	query, e := scimQuery(c, scimGroupScope(c), scim.GroupAttributes)
+	if e != nil {
+		return nil, e
+	}
+	total, e := models.CountScimGroups(c, query)
+	if e != nil {
+		logger.Error("Error while counting scim groups", zap.Error(e.Error()))
+		return nil, e
+	}
+	groups := []*models.ScimGroup{}
+	if count > 0 {
+		if groups, e = models.FindScimGroups(c, query, startIndex-1, count); e != nil {
+			logger.Error("Error while fetching scim groups from database", zap.Error(e.Error()))
+			return nil, e
+		}
+	}
+	resources := make([]*scim.Group, 0, len(groups))
+	for _, group := range groups {
+		resources = append(resources, newScimGroup(group))
+	}
+	return newScimListResponse(total, startIndex, resources, len(resources)), nil
+}
+
+func findScimGroup(c *gin.Context, id string) (*models.ScimGroup, *errors.Error) {
+	group := &models.ScimGroup{}
+	filter := scimGroupScope(c)
+	filter["_id"] = id
+	if e := group.FindOne(c, filter); e != nil {
+		if e.Code == errors.CodeRequestInvalidId {
+			return nil, errors.NoDocumentsError(e.Error(), "scim group")
+		}
+		return nil, e
+	}
+	return group, nil
+}
+
+// saveScimGroup creates the group, or replaces it when it has an id, with the display name, external id and
+// members of the resource
+func saveScimGroup(c *gin.Context, group *models.ScimGroup, resource *scim.Group) (err *errors.Error) {
+	end := tracing.StartSpan(c, "saveScimGroup")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	if strings.TrimSpace(resource.DisplayName) == "" {
+		return errors.ScimInvalidValueError("displayName is required")
+	}
+	memberIds, e := scimGroupMemberIds(c, resource.Members)
+	if e != nil {
+		return e
+	}
+	group.OrganizationId = utils.GetContextOrganization(c).Id
+	group.DisplayName = resource.DisplayName
+	group.ExternalId = resource.ExternalId
+	group.MemberIds = memberIds
+
+	return utils.RunInTransaction(c, func() *errors.Error {
+		var e *errors.Error
+		if group.Id == "" {
+			e = group.Insert(c)
+		} else {
+			e = group.Replace(c)
+		}
+		if e != nil {
+			logger.Error("Error while saving scim group into database", zap.Error(e.Error()))
+			return e
+		}
+		event := events.ScimGroupSaved{OrganizationId: group.OrganizationId, GroupId: group.Id, DisplayName: group.DisplayName, MemberCount: len(group.MemberIds)}
+		if e = events.Publish(c, event); e != nil {
+			logger.Error("Error while publishing scim group saved event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+}
+
+// scimGroupMemberIds returns the user ids of the members, which must be users of the organization
+func scimGroupMemberIds(c *gin.Context, members []scim.Member) ([]string, *errors.Error) {
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.Value)
+	}
+	ids = dedupe(ids)
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	objectIds := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, errors.ScimInvalidValueError(fmt.Sprintf("The member %s is not a user of the organization", id))
+		}
+		objectIds = append(objectIds, oid)
+	}
+	filter := scimUserScope(c)
+	filter["_id"] = bson.M{"$in": objectIds}
+	count, e := models.CountUsers(c, filter)
+	if e != nil {
+		utils.GetContextLogger(c).Error("Error while counting group members", zap.Error(e.Error()))
+		return nil, e
+	}
+	if count != int64(len(ids)) {
+		return nil, errors.ScimInvalidValueError("Every member must be a user of the organization")
+	}
+	return ids, nil
+}
+
+func deleteScimGroup(c *gin.Context, group *models.ScimGroup) (err *errors.Error) {
+	end := tracing.StartSpan(c, "deleteScimGroup")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	return utils.RunInTransaction(c, func() *errors.Error {
+		if e := group.Delete(c); e != nil {
+			logger.Error("Error while deleting scim group from database", zap.Error(e.Error()))
+			return e
+		}
+		event := events.ScimGroupDeleted{OrganizationId: group.OrganizationId, GroupId: group.Id, DisplayName: group.DisplayName}
+		if e := events.Publish(c, event); e != nil {
+			logger.Error("Error while publishing scim group deleted event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// CreateScimToken issues a token the identity provider of the organization provisions its users with,
+// the token is only returned here
+func CreateScimToken(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.CreateScimTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to scim token struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	if req.Name == "" {
+		utils.RespondWithError(c, errors.MissingFieldsError("name"))
+		return
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		logger.Error("Error while generating scim token", zap.Error(err))
+		utils.RespondWithError(c, errors.InternalServerError(err))
+		return
+	}
+	token := constants.ScimTokenPrefix + hex.EncodeToString(secret)
+	scimToken := models.ScimToken{
+		OrganizationId: utils.GetContextOrganization(c).Id,
+		Name:           req.Name,
+		TokenHash:      hashScimToken(token),
+		CreatedBy:      utils.GetContextUser(c).Id,
+	}
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := scimToken.Insert(c); e != nil {
+			logger.Error("Error while inserting scim token into database", zap.Error(e.Error()))
+			return e
+		}
+		event := events.ScimTokenCreated{OrganizationId: scimToken.OrganizationId, TokenId: scimToken.Id, TokenName: scimToken.Name}
+		if e := events.Publish(c, event); e != nil {
+			logger.Error("Error while publishing scim token created event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "SCIM token created", "token": token, "scim_token": &scimToken})
+}
+
+func ListScimTokens(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	tokens, e := models.FindScimTokens(c, bson.M{"organization_id": utils.GetContextOrganization(c).Id})
+	if e != nil {
+		logger.Error("Error while fetching scim tokens from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"scim_tokens": tokens})
+}
+
+// DeleteScimToken revokes the token, the identity provider can not provision with it anymore
+func DeleteScimToken(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var scimToken models.ScimToken
+	e := scimToken.FindOne(c, bson.M{"_id": c.Param("token_id"), "organization_id": utils.GetContextOrganization(c).Id})
+	if e != nil {
+		logger.Error("Error while fetching scim token from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		if e := scimToken.Delete(c); e != nil {
+			logger.Error("Error while deleting scim token", zap.Error(e.Error()))
+			return e
+		}
+		event := events.ScimTokenDeleted{OrganizationId: scimToken.OrganizationId, TokenId: scimToken.Id, TokenName: scimToken.Name}
+		if e := events.Publish(c, event); e != nil {
+			logger.Error("Error while publishing scim token deleted event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "SCIM token deleted"})
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/signup"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// checks the signup against the configured signup mode and blocked email domains
+// in invite_only mode a pending organization invitation is as good as an invitation code,
+// otherwise one use of the invitation code is consumed and returned so that it can be released if the signup fails later
+func enforceSignupPolicy(c *gin.Context, req *requests.SignupRequest, invitation *models.Invitation) (invitationCode *models.InvitationCode, err *errors.Error) {
+	mode := signup.GetMode()
+	if mode == constants.SignupModeClosed {
+		return nil, errors.SignupClosedError()
+	}
+	if err = signup.ValidateEmailDomain(req.Email); err != nil {
+		return nil, err
+	}
+	if mode != constants.SignupModeInviteOnly || invitation != nil {
+		return nil, nil
+	}
+	if req.InvitationCode == "" {
+		return nil, errors.InvitationCodeRequiredError()
+	}
+	invitationCode = &models.InvitationCode{}
+	if err = invitationCode.Redeem(c, req.InvitationCode); err != nil {
+		if err.IsNotFound() {
+			return nil, errors.InvalidInvitationCodeError(err.Error())
+		}
+		return nil, err
+	}
+	return
+}
+
+func releaseInvitationCode(c *gin.Context, invitationCode *models.InvitationCode) {
+	if invitationCode == nil {
+		return
+	}
+	if e := invitationCode.Release(c); e != nil {
+		logger := utils.GetContextLogger(c)
+		logger.Error("Error while releasing invitation code", zap.Error(e.Error()), zap.String("invitation_code_id", invitationCode.Id))
+	}
+}
+
+// fields of the signup form that are only shown when the signup mode requires them
+func isSignupOptionalFormField(formField string) bool {
+	return formField == "invitation_code"
+}
+
+func getSignupExtraRequiredFields(mode datatypes.SignupMode) []string {
+	if mode == constants.SignupModeInviteOnly {
+		return []string{"invitation_code"}
+	}
+	return []string{}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package handler_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/signup"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+)
+
+// the invitation code itself is redeemed in mongo, see the invitation code tests of models
+func TestEnforceSignupPolicy(t *testing.T) {
+	invitation := &models.Invitation{Email: "jane@example.org"}
+	tests := []struct {
+		name       string
+		mode       string
+		req        *requests.SignupRequest
+		invitation *models.Invitation
+		want       string
+	}{
+		{"open", string(constants.SignupModeOpen), &requests.SignupRequest{Email: "jane@example.org"}, nil, ""},
+		{"open disposable", string(constants.SignupModeOpen), &requests.SignupRequest{Email: "jane@mailinator.com"}, nil, errors.CodeSignupDisposableEmail},
+		{"closed", string(constants.SignupModeClosed), &requests.SignupRequest{Email: "jane@example.com"}, nil, errors.CodeSignupClosed},
+		{"closed with invitation", string(constants.SignupModeClosed), &requests.SignupRequest{Email: "jane@example.org"}, invitation, errors.CodeSignupClosed},
+		{"allowlist allowed", string(constants.SignupModeDomainAllowlist), &requests.SignupRequest{Email: "jane@example.com"}, nil, ""},
+		{"allowlist not allowed", string(constants.SignupModeDomainAllowlist), &requests.SignupRequest{Email: "jane@example.org"}, nil, errors.CodeSignupEmailDomainNotAllowed},
+		{"allowlist not allowed with invitation", string(constants.SignupModeDomainAllowlist), &requests.SignupRequest{Email: "jane@example.org"}, invitation, errors.CodeSignupEmailDomainNotAllowed},
+		{"invite only without code", string(constants.SignupModeInviteOnly), &requests.SignupRequest{Email: "jane@example.org"}, nil, errors.CodeSignupInvitationCodeRequired},
+		{"invite only with invitation", string(constants.SignupModeInviteOnly), &requests.SignupRequest{Email: "jane@example.org"}, invitation, ""},
+		{"invite only disposable with invitation", string(constants.SignupModeInviteOnly), &requests.SignupRequest{Email: "jane@mailinator.com"}, invitation, errors.CodeSignupDisposableEmail},
+	}
+	for _, test := range tests {
+		if err := signup.InitSignupPolicy(config.SignupConfig{Mode: test.mode, AllowedDomains: []string{"example.com"}}); err != nil {
+			t.Fatal(err)
+		}
+		c := utils.NewContext(context.Background(), nil)
+		invitationCode, e := handler.EnforceSignupPolicy(c, test.req, test.invitation)
+		got := ""
+		if e != nil {
+			got = e.UserErrorCode()
+		}
+		if got != test.want {
+			t.Errorf("%s: enforceSignupPolicy = %q, want %q", test.name, got, test.want)
+		}
+		if invitationCode != nil {
+			t.Errorf("%s: an invitation code was redeemed", test.name)
+		}
+	}
+}
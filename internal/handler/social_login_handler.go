@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/social"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListSocialLoginProviders lists the providers the login page offers, with the url that starts the login
+func ListSocialLoginProviders(c *gin.Context) {
+	providers := []gin.H{}
+	for _, provider := range social.GetProviders() {
+		providers = append(providers, gin.H{
+			"name":         provider.Name,
+			"display_name": provider.DisplayName,
+			"login_url":    socialLoginStartUrl(provider.Name),
+		})
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"providers": providers})
+}
+
+// StartSocialLogin redirects the browser to the provider, the state cookie ties the callback to this browser
+// so nobody can complete a login of their own in someone else's browser. With the link_token of LinkIdentity
+// the callback links the identity to that user instead of logging in.
+func StartSocialLogin(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	provider, e := social.GetProvider(c.Param("provider"))
+	if e != nil {
+		logger.Info("Social login with an unknown provider", zap.String("provider", c.Param("provider")))
+		redirectToSocialLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	login := provider.NewLoginState()
+	if linkToken := c.Query("link_token"); linkToken != "" {
+		login.LinkUserId, e = serviceRegistry.GetRedisClient().ConsumeSocialLinkToken(c, linkToken)
+		if e != nil {
+			if e.IsNotFound() {
+				logger.Info("Social login with an unknown or expired link token")
+				e = errors.SocialInvalidStateError()
+			} else {
+				logger.Error("Error while fetching social link token", zap.Error(e.Error()))
+			}
+			redirectToSocialLoginResult(c, "error", e.UserErrorCode())
+			return
+		}
+	}
+	state, e := serviceRegistry.GetRedisClient().SetSocialLoginState(c, login)
+	if e != nil {
+		logger.Error("Error while storing social login state", zap.Error(e.Error()))
+		redirectToSocialLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	authUrl, e := provider.AuthCodeUrl(c, state, login)
+	if e != nil {
+		logger.Error("Error while building social login authorization url", zap.String("provider", provider.Name), zap.Error(e.Error()))
+		redirectToSocialLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	setSocialLoginStateCookie(c, state)
+	c.Redirect(http.StatusFound, authUrl)
+}
+
+// SocialLoginCallback is the redirect uri registered at the providers. It verifies the login and hands the
+// frontend a single use login code for ExchangeSocialLoginCode, the frontend request then does the actual login
+// with the organization header and the client details of a regular login. Logins started to link an identity
+// link it right away and redirect with ?linked=<provider>.
+func SocialLoginCallback(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	state := c.Query("state")
+	cookie, _ := c.Cookie(constants.SocialLoginStateCookie)
+	clearSocialLoginStateCookie(c)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookie)) != 1 {
+		logger.Info("Social login callback without a matching state cookie")
+		redirectToSocialLoginResult(c, "error", errors.SocialInvalidStateError().UserErrorCode())
+		return
+	}
+	login, e := serviceRegistry.GetRedisClient().ConsumeSocialLoginState(c, state)
+	if e != nil {
+		if e.IsNotFound() {
+			logger.Info("Social login callback with an unknown or expired state")
+			e = errors.SocialInvalidStateError()
+		} else {
+			logger.Error("Error while fetching social login state", zap.Error(e.Error()))
+		}
+		redirectToSocialLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	logger = utils.AddKeyToContextLogger(c, "provider", login.Provider)
+	provider, e := social.GetProvider(login.Provider)
+	if e != nil {
+		logger.Error("Social login provider was removed during the login")
+		redirectToSocialLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	if providerError := c.Query("error"); providerError != "" {
+		e = errors.SocialProviderError(fmt.Errorf("%s: %s", providerError, c.Query("error_description")))
+		logger.Info("Social login provider returned an error", zap.Error(e.Error()))
+		redirectToSocialLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	// RFC 9207, providers that send the issuer protect against mixing up the responses of two providers
+	if iss := c.Query("iss"); iss != "" && iss != provider.Issuer {
+		e = errors.SocialProviderError(fmt.Errorf("callback issuer %s does not match", iss))
+		logger.Info("Social login callback from another issuer", zap.Error(e.Error()))
+		redirectToSocialLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	identity, e := provider.Exchange(c, c.Query("code"), *login)
+	if e != nil {
+		logger.Info("Social login code exchange failed", zap.Error(e.Error()))
+		redirectToSocialLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	if login.LinkUserId != "" {
+		if e = linkIdentityToUser(c, login.LinkUserId, identity); e != nil {
+			redirectToSocialLoginResult(c, "error", e.UserErrorCode())
+			return
+		}
+		redirectToSocialLoginResult(c, "linked", provider.Name)
+		return
+	}
+	code, e := serviceRegistry.GetRedisClient().SetSocialLoginCode(c, *identity)
+	if e != nil {
+		logger.Error("Error while storing social login code", zap.Error(e.Error()))
+		redirectToSocialLoginResult(c, "error", e.UserErrorCode())
+		return
+	}
+	redirectToSocialLoginResult(c, "code", code)
+}
+
+// ExchangeSocialLoginCode logs in with the login code of a social login callback
+func ExchangeSocialLoginCode(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.SocialLoginExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to social login exchange struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	res, e := exchangeSocialLoginCode(c, &req)
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, res)
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/oidc"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/gin-gonic/gin"
+)
+
+func socialLoginStartUrl(provider string) string {
+	return oidc.Issuer() + "/api/v1/social/providers/" + url.PathEscape(provider) + "/login"
+}
+
+// the browser sends the cookie along on the redirect back from the provider, which is a top level navigation
+func setSocialLoginStateCookie(c *gin.Context, state string) {
+	maxAge := int(serviceRegistry.GetConfig().Tokens.SocialLoginStateTtl.Seconds())
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(constants.SocialLoginStateCookie, state, maxAge, constants.SocialLoginCallbackPath, "", strings.HasPrefix(oidc.Issuer(), "https://"), true)
+}
+
+func clearSocialLoginStateCookie(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(constants.SocialLoginStateCookie, "", -1, constants.SocialLoginCallbackPath, "", strings.HasPrefix(oidc.Issuer(), "https://"), true)
+}
+
+// the browser arrives at the start and callback endpoints by navigation, so they answer by sending it to the frontend
+func redirectToSocialLoginResult(c *gin.Context, key string, value string) {
+	c.Redirect(http.StatusFound, serviceRegistry.GetConfig().App.BaseUrl+constants.SocialLoginResultPath+"?"+url.Values{key: {value}}.Encode())
+}
@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/responses"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/signup"
+	"github.com/MitP1997/golang-user-management/internal/suspensions"
+	"github.com/MitP1997/golang-user-management/internal/tracing"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// exchangeSocialLoginCode logs in the user of the identity the callback stored behind the login code,
+// linking the identity to the user with the same email or signing up a new user when needed
+func exchangeSocialLoginCode(c *gin.Context, req *requests.SocialLoginExchangeRequest) (res *responses.AuthTokenResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "exchangeSocialLoginCode")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	if req.Code == "" {
+		logger.Error("Login code not present in request")
+		return nil, errors.MissingFieldsError("code")
+	}
+	redisClient := serviceRegistry.GetRedisClient()
+	identity, e := redisClient.ConsumeSocialLoginCode(c, req.Code)
+	if e != nil {
+		if e.IsNotFound() {
+			logger.Info("Unknown social login code")
+			return nil, errors.SocialInvalidLoginCodeError(e.Error())
+		}
+		logger.Error("Error while fetching social login code", zap.Error(e.Error()))
+		return nil, e
+	}
+	logger = utils.AddKeyToContextLogger(c, "provider", identity.Provider)
+	// the id stays empty when no user was found or created
+	var user *models.User
+	defer func() {
+		if err != nil {
+			var userId string
+			if user != nil {
+				userId = user.Id
+			}
+			publishAndLog(c, events.LoginFailed{Email: identity.Email, UserId: userId, Reason: err.UserErrorCode()})
+		}
+	}()
+
+	user, e = findOrCreateSocialLoginUser(c, identity, req)
+	if e != nil {
+		return nil, e
+	}
+	if user.Status == models.UserStatus_DELETED {
+		logger.Info("Social login for a deleted user")
+		return nil, errors.InvalidCredentialsError()
+	}
+	if user.PasswordResetRequired {
+		logger.Info("Social login for a secured account that needs a password reset")
+		return nil, errors.PasswordResetRequiredError()
+	}
+	if e := suspensions.Refuse(user); e != nil {
+		logger.Info("Social login for a suspended or banned user", zap.String("status", user.Status.String()))
+		return nil, e
+	}
+	logger = utils.AddKeyToContextLogger(c, "user_id", user.Id)
+	token, e := startSession(c, user, constants.AuthMethodSocial)
+	if e != nil {
+		logger.Error("Error while generating user auth token", zap.Error(e.Error()))
+		return nil, e
+	}
+	if e = setAuthTokenOrganizationClaim(c, user, token); e != nil {
+		logger.Error("Error while setting organization claim on auth token", zap.Error(e.Error()))
+		return nil, e
+	}
+	publishAndLog(c, events.UserLoggedIn{User: events.NewUser(user), Ip: c.ClientIP(), UserAgent: c.Request.UserAgent(), At: time.Now()})
+	return &responses.AuthTokenResponse{Message: "User login successful", Token: token}, nil
+}
+
+// findOrCreateSocialLoginUser returns the user the identity is linked to. An identity that is not linked yet
+// is linked to the user with its email when the provider verified the email, or signs up a new user.
+func findOrCreateSocialLoginUser(c *gin.Context, identity *datatypes.SocialIdentity, req *requests.SocialLoginExchangeRequest) (*models.User, *errors.Error) {
+	logger := utils.GetContextLogger(c)
+
+	var linkedIdentity models.LinkedIdentity
+	e := linkedIdentity.FindOne(c, bson.M{"provider": identity.Provider, "subject": identity.Subject})
+	if e == nil {
+		user := &models.User{}
+		if e = user.FindOne(c, bson.M{"_id": linkedIdentity.UserId}); e != nil {
+			logger.Error("Error while fetching user of linked identity", zap.Error(e.Error()))
+			return nil, e
+		}
+		if e = linkedIdentity.RecordLogin(c, identity.Email); e != nil {
+			logger.Error("Error while recording login of linked identity", zap.Error(e.Error()))
+			return nil, e
+		}
+		return user, nil
+	}
+	if !e.IsNotFound() {
+		logger.Error("Error while fetching linked identity from database", zap.Error(e.Error()))
+		return nil, e
+	}
+
+	if identity.Email == "" {
+		logger.Info("Social login provider did not share an email")
+		return nil, errors.SocialEmailRequiredError()
+	}
+	user := &models.User{}
+	e = user.FindOne(c, tenantScopedFilter(c, bson.M{"email": identity.Email}))
+	if e == nil {
+		return user, linkSocialIdentity(c, user, identity)
+	}
+	if !e.IsNotFound() {
+		logger.Error("Error while fetching user from database", zap.Error(e.Error()))
+		return nil, e
+	}
+	return signupSocialLoginUser(c, identity, req)
+}
+
+// linkSocialIdentity links the identity to the existing user with its email. Without the provider verifying
+// the email anyone could link an account there to the user. A user whose own email is not verified yet loses
+// the password and sessions, whoever set them never proved owning the email.
+func linkSocialIdentity(c *gin.Context, user *models.User, identity *datatypes.SocialIdentity) *errors.Error {
+	logger := utils.GetContextLogger(c)
+	// the caller refuses the login, verifying the email would also lift a suspension
+	if user.Status == models.UserStatus_DELETED || user.Status == models.UserStatus_SUSPENDED || user.Status == models.UserStatus_BANNED {
+		return nil
+	}
+	if !identity.EmailVerified {
+		logger.Info("Not linking social identity without a verified email to an existing user", zap.String("user_id", user.Id))
+		return errors.SocialEmailNotVerifiedError()
+	}
+	wasUnverified := user.VerifiedAt == nil
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if wasUnverified {
+			e := user.Update(c, bson.M{"_id": user.Id}, bson.M{"status": models.UserStatus_VERIFIED, "verified_at": timestamppb.Now(), "password": ""})
+			if e != nil {
+				logger.Error("Error while marking user email verified", zap.Error(e.Error()))
+				return e
+			}
+			if e = events.Publish(c, events.EmailVerified{User: events.NewUser(user)}); e != nil {
+				logger.Error("Error while publishing email verified event", zap.Error(e.Error()))
+				return e
+			}
+		}
+		return insertLinkedIdentity(c, user, identity)
+	})
+	if e != nil {
+		return e
+	}
+	if wasUnverified {
+		if e = serviceRegistry.GetRedisClient().RevokeUserSessions(c, user.Id); e != nil {
+			logger.Error("Error while revoking sessions of user with unverified email", zap.Error(e.Error()))
+			return e
+		}
+	}
+	return nil
+}
+
+// signupSocialLoginUser signs up a user for the identity under the same signup policy as the signup form,
+// the email counts as verified when the provider verified it
+func signupSocialLoginUser(c *gin.Context, identity *datatypes.SocialIdentity, req *requests.SocialLoginExchangeRequest) (*models.User, *errors.Error) {
+	logger := utils.GetContextLogger(c)
+
+	var invitation *models.Invitation
+	if req.InvitationToken != "" {
+		var e *errors.Error
+		invitation, e = findPendingInvitation(c, req.InvitationToken)
+		if e != nil {
+			logger.Error("Error while fetching invitation", zap.Error(e.Error()))
+			return nil, e
+		}
+		if !strings.EqualFold(invitation.Email, identity.Email) {
+			logger.Error("Social login email does not match the invitation")
+			return nil, errors.InvitationEmailMismatchError()
+		}
+	}
+	signupRequest := &requests.SignupRequest{Email: identity.Email, InvitationCode: req.InvitationCode}
+	invitationCode, e := enforceSignupPolicy(c, signupRequest, invitation)
+	if e != nil {
+		logger.Error("Social signup rejected by signup policy", zap.Error(e.Error()), zap.String("mode", string(signup.GetMode())))
+		return nil, e
+	}
+	organizationId, e := getSignupOrganizationId(c, invitation)
+	if e != nil {
+		logger.Error("Error while resolving signup organization", zap.Error(e.Error()))
+		releaseInvitationCode(c, invitationCode)
+		return nil, e
+	}
+
+	user := &models.User{
+		GivenName:      identity.GivenName,
+		FamilyName:     identity.FamilyName,
+		Email:          identity.Email,
+		OrganizationId: organizationId,
+	}
+	if identity.EmailVerified {
+		user.Status = models.UserStatus_VERIFIED
+		user.VerifiedAt = timestamppb.Now()
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		e := user.Insert(c)
+		if e != nil {
+			logger.Error("Error while inserting user into database", zap.Error(e.Error()))
+			return e
+		}
+		if invitation != nil {
+			if _, e = acceptInvitation(c, invitation, user); e != nil {
+				logger.Error("Error while accepting invitation", zap.Error(e.Error()))
+				return e
+			}
+		}
+		// sends the email verification otp unless the provider verified the email
+		if e = events.Publish(c, events.UserSignedUp{User: events.NewUser(user)}); e != nil {
+			logger.Error("Error while publishing user signed up event", zap.Error(e.Error()))
+			return e
+		}
+		return insertLinkedIdentity(c, user, identity)
+	})
+	if e != nil {
+		// the use of the code was consumed outside the transaction
+		releaseInvitationCode(c, invitationCode)
+		return nil, e
+	}
+	return user, nil
+}
+
+// linkIdentityToUser links the identity to the user that confirmed the password in LinkIdentity. The user logged
+// in at the provider, so unlike linking by email the provider does not need to verify the email.
+func linkIdentityToUser(c *gin.Context, userId string, identity *datatypes.SocialIdentity) (err *errors.Error) {
+	end := tracing.StartSpan(c, "linkIdentityToUser")
+	defer func() { end(err) }()
+	logger := utils.AddKeyToContextLogger(c, "user_id", userId)
+
+	user := &models.User{}
+	if e := user.FindOne(c, bson.M{"_id": userId}); e != nil {
+		logger.Error("Error while fetching user linking an identity", zap.Error(e.Error()))
+		return e
+	}
+	if user.Status == models.UserStatus_DELETED {
+		logger.Info("Linking identity to a deleted user")
+		return errors.InvalidCredentialsError()
+	}
+	var linkedIdentity models.LinkedIdentity
+	e := linkedIdentity.FindOne(c, bson.M{"provider": identity.Provider, "subject": identity.Subject})
+	if e == nil {
+		if linkedIdentity.UserId != user.Id {
+			logger.Info("Social identity is already linked to another user")
+			return errors.SocialIdentityAlreadyLinkedError()
+		}
+		if e = linkedIdentity.RecordLogin(c, identity.Email); e != nil {
+			logger.Error("Error while recording login of linked identity", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	}
+	if !e.IsNotFound() {
+		logger.Error("Error while fetching linked identity from database", zap.Error(e.Error()))
+		return e
+	}
+	return utils.RunInTransaction(c, func() *errors.Error {
+		return insertLinkedIdentity(c, user, identity)
+	})
+}
+
+func insertLinkedIdentity(c *gin.Context, user *models.User, identity *datatypes.SocialIdentity) *errors.Error {
+	logger := utils.GetContextLogger(c)
+	linkedIdentity := models.LinkedIdentity{
+		UserId:   user.Id,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	if e := linkedIdentity.Insert(c); e != nil {
+		logger.Error("Error while inserting linked identity into database", zap.Error(e.Error()))
+		return e
+	}
+	if e := events.Publish(c, events.IdentityLinked{User: events.NewUser(user), Provider: identity.Provider, Subject: identity.Subject}); e != nil {
+		logger.Error("Error while publishing identity linked event", zap.Error(e.Error()))
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/suspensions"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// SuspendUser refuses the user at login and on every request until an admin reinstates them or the suspension expires
+func SuspendUser(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to suspend user struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	if req.Reason == "" {
+		utils.RespondWithError(c, errors.MissingFieldsError("reason"))
+		return
+	}
+	if req.ExpiresInHours < 0 {
+		utils.RespondWithError(c, errors.SuspensionInvalidExpiryError())
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInHours > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &t
+	}
+	suspendUser(c, models.UserStatus_SUSPENDED, req.Reason, expiresAt)
+}
+
+// BanUser refuses the user at login and on every request until an admin reinstates them
+func BanUser(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.BanUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to ban user struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	if req.Reason == "" {
+		utils.RespondWithError(c, errors.MissingFieldsError("reason"))
+		return
+	}
+	suspendUser(c, models.UserStatus_BANNED, req.Reason, nil)
+}
+
+// shared by SuspendUser and BanUser
+func suspendUser(c *gin.Context, status models.UserStatus, reason string, expiresAt *time.Time) {
+	logger := utils.GetContextLogger(c)
+	admin := utils.GetContextUser(c)
+
+	var user models.User
+	if e := user.FindOne(c, bson.M{"_id": c.Param("user_id")}); e != nil {
+		logger.Error("Error while fetching user to suspend from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	if user.Id == admin.Id || user.Status == models.UserStatus_DELETED {
+		logger.Info("Suspension of a user who can not be suspended", zap.String("target_user_id", user.Id))
+		utils.RespondWithError(c, errors.SuspensionTargetNotAllowedError())
+		return
+	}
+	if e := suspensions.Suspend(c, &user, status, reason, admin.Id, expiresAt); e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	message := "User suspended"
+	if status == models.UserStatus_BANNED {
+		message = "User banned"
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": message, "status": user.Status.String(), "expires_at": expiresAt})
+}
+
+// ReinstateUser lifts the suspension or ban of the user, who gets back the status they had before
+func ReinstateUser(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var user models.User
+	if e := user.FindOne(c, bson.M{"_id": c.Param("user_id")}); e != nil {
+		logger.Error("Error while fetching user to reinstate from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	if user.Status != models.UserStatus_SUSPENDED && user.Status != models.UserStatus_BANNED {
+		utils.RespondWithError(c, errors.SuspensionNotActiveError())
+		return
+	}
+	if e := suspensions.Lift(c, &user, false); e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "User reinstated", "status": user.Status.String()})
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/responses"
+	"github.com/MitP1997/golang-user-management/internal/services"
+)
+
+// UserServiceServer serves the user account APIs over gRPC with the same logic as the gin handlers
+// it must be registered with UnaryServerInterceptor, which sets up the context the logic relies on
+type UserServiceServer struct {
+	services.UnimplementedUserServiceServer
+}
+
+func NewUserServiceServer() *UserServiceServer {
+	return &UserServiceServer{}
+}
+
+func (s *UserServiceServer) Signup(ctx context.Context, req *requests.SignupRequest) (*responses.AuthTokenResponse, error) {
+	c := grpcGinContext(ctx)
+	res, e := signupUser(c, req)
+	if e != nil {
+		return nil, grpcStatusError(c, e)
+	}
+	return res, nil
+}
+
+func (s *UserServiceServer) Login(ctx context.Context, req *requests.LoginRequest) (*responses.AuthTokenResponse, error) {
+	c := grpcGinContext(ctx)
+	res, e := loginUser(c, req)
+	if e != nil {
+		return nil, grpcStatusError(c, e)
+	}
+	return res, nil
+}
+
+func (s *UserServiceServer) VerifyEmail(ctx context.Context, req *requests.VerifyEmailRequest) (*responses.MessageResponse, error) {
+	c := grpcGinContext(ctx)
+	res, e := verifyEmail(c, req)
+	if e != nil {
+		return nil, grpcStatusError(c, e)
+	}
+	return res, nil
+}
+
+func (s *UserServiceServer) ResendEmailVerificationOtp(ctx context.Context, req *requests.ResendEmailVerificationOtpRequest) (*responses.MessageResponse, error) {
+	c := grpcGinContext(ctx)
+	res, e := resendEmailVerificationOtp(c)
+	if e != nil {
+		return nil, grpcStatusError(c, e)
+	}
+	return res, nil
+}
+
+func (s *UserServiceServer) ChangePasswordInitiate(ctx context.Context, req *requests.ChangePasswordInitiateRequest) (*responses.MessageResponse, error) {
+	c := grpcGinContext(ctx)
+	res, e := initiateChangePassword(c)
+	if e != nil {
+		return nil, grpcStatusError(c, e)
+	}
+	return res, nil
+}
+
+func (s *UserServiceServer) ChangePassword(ctx context.Context, req *requests.ChangePasswordRequest) (*responses.MessageResponse, error) {
+	c := grpcGinContext(ctx)
+	res, e := changePassword(c, req)
+	if e != nil {
+		return nil, grpcStatusError(c, e)
+	}
+	return res, nil
+}
+
+func (s *UserServiceServer) ResetPasswordInitiate(ctx context.Context, req *requests.ResetPasswordInitiateRequest) (*responses.MessageResponse, error) {
+	c := grpcGinContext(ctx)
+	res, e := initiatePasswordReset(c, req)
+	if e != nil {
+		return nil, grpcStatusError(c, e)
+	}
+	return res, nil
+}
+
+func (s *UserServiceServer) ResetPassword(ctx context.Context, req *requests.ResetPasswordRequest) (*responses.MessageResponse, error) {
+	c := grpcGinContext(ctx)
+	res, e := resetPassword(c, req)
+	if e != nil {
+		return nil, grpcStatusError(c, e)
+	}
+	return res, nil
+}
+
+func (s *UserServiceServer) GetPendingRequirements(ctx context.Context, req *requests.GetPendingRequirementsRequest) (*responses.PendingRequirementsResponse, error) {
+	return getPendingRequirements(grpcGinContext(ctx)), nil
+}
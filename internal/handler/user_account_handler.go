@@ -6,39 +6,44 @@ import (
 
 	"github.com/MitP1997/golang-user-management/internal/constants"
 	"github.com/MitP1997/golang-user-management/internal/errors"
-	"github.com/MitP1997/golang-user-management/internal/models"
 	"github.com/MitP1997/golang-user-management/internal/requests"
-	"github.com/MitP1997/golang-user-management/internal/requirements"
-	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/signup"
 	"github.com/MitP1997/golang-user-management/internal/utils"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func GetUserSignupFormFields(c *gin.Context) {
-	signupRequest := requests.SignupRequest{}
-	value := reflect.ValueOf(signupRequest) // nolint:govet
+	mode := signup.GetMode()
+	// the type is enough to read the tags and avoids copying the lock inside the proto message
+	structType := reflect.TypeOf(requests.SignupRequest{})
 	fields := map[string]interface{}{}
-	numFields := value.NumField()
-	structType := value.Type()
+	numFields := structType.NumField()
+	extraRequiredFields := getSignupExtraRequiredFields(mode)
 
 	for i := 0; i < numFields; i++ {
 		field := structType.Field(i)
 
+		formField := field.Tag.Get("form_field")
 		formFieldType := field.Tag.Get("form_field_type")
 		displayName := field.Tag.Get("display_name")
 		if formFieldType != "" {
+			if isSignupOptionalFormField(formField) && !contains(extraRequiredFields, formField) {
+				continue
+			}
 			formFieldDetails := map[string]string{
 				"form_field_type": formFieldType,
 				"display_name":    displayName,
 			}
-			fields[field.Tag.Get("form_field")] = formFieldDetails
+			fields[formField] = formFieldDetails
 		}
 	}
 
-	c.IndentedJSON(http.StatusOK, gin.H{"fields": fields})
+	response := gin.H{"mode": mode, "fields": fields, "required_fields": extraRequiredFields}
+	if mode == constants.SignupModeDomainAllowlist {
+		response["allowed_domains"] = signup.GetAllowedDomains()
+	}
+	c.IndentedJSON(http.StatusOK, response)
 }
 
 func Signup(c *gin.Context) {
@@ -47,47 +52,15 @@ func Signup(c *gin.Context) {
 	var req requests.SignupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Error("Error while binding request body to user struct", zap.Error(err))
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	if len(req.Password) < 8 {
-		logger.Error("Password length less than 8 characters")
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Password length less than 8 characters"})
-		return
-	}
-
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		logger.Error("Error while hashing password", zap.Error(err))
-	}
-	user := models.User{
-		GivenName:  req.GivenName,
-		FamilyName: req.FamilyName,
-		Email:      req.Email,
-		Password:   string(hashedPassword),
-	}
-	e := user.Insert(c)
-	if e != nil {
-		logger.Error("Error while inserting user into database", zap.Error(err))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
-		return
-	}
-	e = sendEmailVerificationOtp(c, &user)
-	if e != nil {
-		logger.Error("Error while sending email verification otp", zap.Error(err))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
+		utils.RespondWithError(c, errors.RequestBindingError(err))
 		return
 	}
-	logger = utils.AddKeyToContextLogger(c, "user_id", user.Id)
-	redisClient := serviceRegistry.GetRedisClient()
-	_, token, e := redisClient.GetOrCreateAndSetExpiryAuthToken(c, user.Id, "")
+	res, e := signupUser(c, &req)
 	if e != nil {
-		logger.Error("Error while generating user auth token", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
+		utils.RespondWithError(c, e)
 		return
 	}
-	c.IndentedJSON(http.StatusOK, gin.H{"message": "User login successful", "token": token})
+	c.IndentedJSON(http.StatusOK, res)
 }
 
 func Login(c *gin.Context) {
@@ -96,62 +69,24 @@ func Login(c *gin.Context) {
 	var req requests.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Error("Error while binding request body to user struct", zap.Error(err))
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.RespondWithError(c, errors.RequestBindingError(err))
 		return
 	}
-
-	filter := bson.M{"email": req.Email}
-	var user models.User
-	e := user.FindOne(c, filter)
+	res, e := loginUser(c, &req)
 	if e != nil {
-		logger.Error("Error while fetching user from database", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
+		utils.RespondWithError(c, e)
 		return
 	}
-
-	if verifyUserPassword(user.Password, req.Password) {
-		logger = utils.AddKeyToContextLogger(c, "user_id", user.Id)
-		redisClient := serviceRegistry.GetRedisClient()
-		_, token, e := redisClient.GetOrCreateAndSetExpiryAuthToken(c, user.Id, "")
-		if e != nil {
-			logger.Error("Error while generating user auth token", zap.Error(e.Error()))
-			c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
-			return
-		}
-		c.IndentedJSON(http.StatusOK, gin.H{"message": "User login successful", "token": token})
-		return
-	}
-	c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "User login password verification failed"})
+	c.IndentedJSON(http.StatusOK, res)
 }
 
 func ResendEmailVerificationOtp(c *gin.Context) {
-	logger := utils.GetContextLogger(c)
-
-	user := utils.GetContextUser(c)
-	if user.VerifiedAt != nil {
-		logger.Error("User email already verified")
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "User email already verified"})
-		return
-	}
-	redisClient := serviceRegistry.GetRedisClient()
-	ttl, e := redisClient.GetOtpTtl(c, user.Id)
+	res, e := resendEmailVerificationOtp(c)
 	if e != nil {
-		logger.Error("Error while fetching otp ttl", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
+		utils.RespondWithError(c, e)
 		return
 	}
-	if !allowResendEmailOtp(int32(ttl.Seconds())) {
-		logger.Error("Resend email otp not allowed")
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Resend email otp not allowed"})
-		return
-	}
-	e = sendEmailVerificationOtp(c, utils.GetContextUser(c))
-	if e != nil {
-		logger.Error("Error while sending email verification otp", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
-		return
-	}
-	c.IndentedJSON(http.StatusOK, gin.H{"message": "Email verification otp resent"})
+	c.IndentedJSON(http.StatusOK, res)
 }
 
 func VerifyEmail(c *gin.Context) {
@@ -160,119 +95,130 @@ func VerifyEmail(c *gin.Context) {
 	var req requests.VerifyEmailRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Error("Error while binding request body to user struct", zap.Error(err))
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	if req.Otp == "" {
-		logger.Error("OTP not present in request")
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "OTP not present in request"})
+		utils.RespondWithError(c, errors.RequestBindingError(err))
 		return
 	}
-	user := utils.GetContextUser(c)
-	if user == nil {
-		err := errors.UnauthedUserError(nil)
-		logger.Error("Error while verifying email otp", zap.Error(err.Error()))
-		c.IndentedJSON(err.UserStatusError(), gin.H{"error": err.UserErrorString()})
-		return
-	}
-	e := verifyEmailOtp(c, req.Otp, constants.RedisUserEmailVerificationScope, user)
+	res, e := verifyEmail(c, &req)
 	if e != nil {
-		logger.Error("Error while verifying email otp", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
-		return
-	}
-	if e := updateUserToMarkEmailVerified(c); e != nil {
-		logger.Error("Error while updating user to mark email verified", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
+		utils.RespondWithError(c, e)
 		return
 	}
-	c.IndentedJSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+	c.IndentedJSON(http.StatusOK, res)
 }
 
 func GetPendingRequirements(c *gin.Context) {
-	logger := utils.GetContextLogger(c)
+	c.IndentedJSON(http.StatusOK, getPendingRequirements(c))
+}
 
-	pendingRequirements := requirements.GetPendingRequirements(c)
-	logger.Info("Pending requirements", zap.Any("pending_requirements", pendingRequirements))
-	c.IndentedJSON(http.StatusOK, gin.H{"requirements": pendingRequirements})
+// GetMe returns the logged in user, the impersonation flags tell the client to show a banner
+func GetMe(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, getMe(c))
 }
 
+// ChangePasswordInitiate emails the otp to change the password to the logged in user
 func ChangePasswordInitiate(c *gin.Context) {
+	res, e := initiateChangePassword(c)
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, res)
+}
+
+// ChangePassword changes the password of the logged in user with the emailed otp
+func ChangePassword(c *gin.Context) {
 	logger := utils.GetContextLogger(c)
 
-	var req requests.ChangePasswordInitiateRequest
+	var req requests.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Error("Error while binding request body to user struct", zap.Error(err))
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		logger.Error("Error while binding request body to change password struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
 		return
 	}
-
-	if req.Email == "" {
-		logger.Error("Email not present in request")
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Email not provided"})
+	res, e := changePassword(c, &req)
+	if e != nil {
+		utils.RespondWithError(c, e)
 		return
 	}
+	c.IndentedJSON(http.StatusOK, res)
+}
 
-	filter := bson.M{"email": req.Email}
-	var user models.User
-	e := user.FindOne(c, filter)
-	if e != nil {
-		logger.Error("Error while fetching user from database", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
+// ResetPasswordInitiate emails the otp to change the password without a token, for a forgotten password
+func ResetPasswordInitiate(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.ResetPasswordInitiateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to user struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
 		return
 	}
-	e = sendEmailChangePasswordOtp(c, &user)
+	res, e := initiatePasswordReset(c, &req)
 	if e != nil {
-		logger.Error("Error while sending change password otp", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
+		utils.RespondWithError(c, e)
 		return
 	}
+	c.IndentedJSON(http.StatusOK, res)
 }
 
-func ChangePassword(c *gin.Context) {
+// ResetPassword changes the password of the user of the email with the emailed otp, without a token
+func ResetPassword(c *gin.Context) {
 	logger := utils.GetContextLogger(c)
 
-	var req requests.ChangePasswordRequest
+	var req requests.ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Error("Error while binding request body to user struct", zap.Error(err))
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.RespondWithError(c, errors.RequestBindingError(err))
 		return
 	}
-
-	if req.Email == "" || req.Password == "" || req.Otp == "" {
-		logger.Error("Email or Password or OTP not provided")
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Email or Password or OTP not provided"})
+	res, e := resetPassword(c, &req)
+	if e != nil {
+		utils.RespondWithError(c, e)
 		return
 	}
+	c.IndentedJSON(http.StatusOK, res)
+}
 
-	filter := bson.M{"email": req.Email}
-	var user models.User
-	e := user.FindOne(c, filter)
+// Reauthenticate confirms the password before a sensitive action, see RequireRecentAuth
+func Reauthenticate(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.ReauthenticateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to reauthenticate struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	res, e := reauthenticate(c, &req)
 	if e != nil {
-		logger.Error("Error while fetching user from database", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
+		utils.RespondWithError(c, e)
 		return
 	}
+	c.IndentedJSON(http.StatusOK, res)
+}
 
-	e = verifyEmailOtp(c, req.Otp, constants.RedisUserChangePasswordScope, &user)
+func DeleteAccount(c *gin.Context) {
+	res, e := deleteAccount(c)
 	if e != nil {
-		logger.Error("Error while verifying email otp", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
+		utils.RespondWithError(c, e)
 		return
 	}
+	c.IndentedJSON(http.StatusOK, res)
+}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		logger.Error("Error while hashing password", zap.Error(err))
-	}
+func SecureAccount(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
 
-	user.Password = string(hashedPassword)
-	e = user.Update(c, bson.M{"_id": user.Id}, bson.M{"password": user.Password})
+	var req requests.SecureAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to secure account struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	res, e := secureAccount(c, &req)
 	if e != nil {
-		logger.Error("Error while updating user password", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": e.UserErrorString()})
+		utils.RespondWithError(c, e)
 		return
 	}
-	c.IndentedJSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+	c.IndentedJSON(http.StatusOK, res)
 }
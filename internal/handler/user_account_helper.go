@@ -2,10 +2,13 @@ package handler
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/MitP1997/golang-user-management/internal/constants"
 	"github.com/MitP1997/golang-user-management/internal/datatypes"
 	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
 	"github.com/MitP1997/golang-user-management/internal/models"
 	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
 	"github.com/MitP1997/golang-user-management/internal/utils"
@@ -19,11 +22,14 @@ import (
 func sendEmailVerificationOtp(c *gin.Context, user *models.User) (err *errors.Error) {
 	logger := utils.GetContextLogger(c)
 	redisClient := serviceRegistry.GetRedisClient()
-	otp, e := redisClient.SetUserTokenForScope(c, user.Id, constants.RedisUserEmailVerificationScope, constants.TokenType6DigitOtp)
+	otp, e := redisClient.SetUserTokenForScope(c, user.Id, constants.RedisUserEmailVerificationScope, constants.TokenTypeOtp)
 	if e != nil {
 		logger.Error("Error while generating user email verification token", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": err.Error()})
-		return
+		return e
+	}
+	if e = events.Publish(c, events.OtpSent{User: events.NewUser(user), Scope: string(constants.RedisUserEmailVerificationScope)}); e != nil {
+		logger.Error("Error while publishing otp sent event", zap.Error(e.Error()))
+		return e
 	}
 	mailBody := prepareMailBodyForEmailVerification(c, otp, user)
 
@@ -38,11 +44,14 @@ func sendEmailVerificationOtp(c *gin.Context, user *models.User) (err *errors.Er
 func sendEmailChangePasswordOtp(c *gin.Context, user *models.User) (err *errors.Error) {
 	logger := utils.GetContextLogger(c)
 	redisClient := serviceRegistry.GetRedisClient()
-	otp, e := redisClient.SetUserTokenForScope(c, user.Id, constants.RedisUserChangePasswordScope, constants.TokenType6DigitOtp)
+	otp, e := redisClient.SetUserTokenForScope(c, user.Id, constants.RedisUserChangePasswordScope, constants.TokenTypeOtp)
 	if e != nil {
 		logger.Error("Error while generating user change password token", zap.Error(e.Error()))
-		c.IndentedJSON(e.UserStatusError(), gin.H{"error": err.Error()})
-		return
+		return e
+	}
+	if e = events.Publish(c, events.OtpSent{User: events.NewUser(user), Scope: string(constants.RedisUserChangePasswordScope)}); e != nil {
+		logger.Error("Error while publishing otp sent event", zap.Error(e.Error()))
+		return e
 	}
 	mailBody := prepareMailBodyForChangePassword(c, otp, user)
 
@@ -54,6 +63,47 @@ func sendEmailChangePasswordOtp(c *gin.Context, user *models.User) (err *errors.
 	return
 }
 
+// markUserDeleted soft deletes the user, the record is kept with the deleted status. Callers run it in a transaction
+// and revoke the sessions of the user afterwards.
+func markUserDeleted(c *gin.Context, user *models.User) *errors.Error {
+	logger := utils.GetContextLogger(c)
+	e := user.Update(c, bson.M{"_id": user.Id}, bson.M{"status": models.UserStatus_DELETED, "deleted_at": timestamppb.Now()})
+	if e != nil {
+		logger.Error("Error while marking user deleted", zap.Error(e.Error()))
+		return e
+	}
+	if e = events.Publish(c, events.UserDeleted{User: events.NewUser(user)}); e != nil {
+		logger.Error("Error while publishing user deleted event", zap.Error(e.Error()))
+		return e
+	}
+	return nil
+}
+
+// startSession returns the auth token of the user and records how and when they authenticated with it,
+// a login of a user who is logged in elsewhere reuses the token
+func startSession(c *gin.Context, user *models.User, method string) (token string, err *errors.Error) {
+	return startOrganizationSession(c, user, method, "")
+}
+
+// startOrganizationSession starts a session limited to the organization, organizationId is empty for a session
+// of the whole account
+func startOrganizationSession(c *gin.Context, user *models.User, method string, organizationId string) (token string, err *errors.Error) {
+	redisClient := serviceRegistry.GetRedisClient()
+	_, token, err = redisClient.GetOrCreateAndSetExpiryAuthToken(c, user.Id, "")
+	if err != nil {
+		return "", err
+	}
+	if err = recordAuthentication(c, token, method, organizationId); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func recordAuthentication(c *gin.Context, token string, method string, organizationId string) *errors.Error {
+	authentication := datatypes.SessionAuthentication{Method: method, AuthTime: time.Now().Unix(), OrganizationId: organizationId}
+	return serviceRegistry.GetRedisClient().SetAuthTokenAuthentication(c, token, authentication)
+}
+
 func verifyUserPassword(hashedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
@@ -70,17 +120,21 @@ func prepareMailBodyForChangePassword(ctx *gin.Context, otp string, user *models
 }
 
 func verifyEmailOtp(ctx *gin.Context, otp string, scope datatypes.RedisScope, user *models.User) (err *errors.Error) {
-	redisClient := serviceRegistry.GetRedisClient()
-	token, e := redisClient.GetUserTokenForScope(ctx, user.Id, scope)
+	defer func() {
+		if err != nil {
+			publishAndLog(ctx, events.OtpVerificationFailed{User: events.NewUser(user), Scope: string(scope), Reason: err.UserErrorCode()})
+			return
+		}
+		publishAndLog(ctx, events.OtpVerified{User: events.NewUser(user), Scope: string(scope)})
+	}()
+	// the otp is deleted once it matches so it can not be replayed until it expires
+	e := serviceRegistry.GetRedisClient().ConsumeUserTokenForScope(ctx, user.Id, scope, otp)
 	if e != nil {
 		if e.IsNotFound() {
 			return errors.InvalidOtpError(nil)
 		}
 		return e
 	}
-	if otp != token {
-		return errors.InvalidOtpError(nil)
-	}
 	return
 }
 
@@ -94,5 +148,17 @@ func updateUserToMarkEmailVerified(ctx *gin.Context) (err *errors.Error) {
 }
 
 func allowResendEmailOtp(ttl int32) bool {
-	return ttl < (constants.RedisScopeTtl[constants.RedisUserEmailVerificationScope] - constants.RedisResendEmailAllowedAfter)
+	tokenConfig := serviceRegistry.GetConfig().Tokens
+	return ttl < int32((tokenConfig.EmailVerificationOtpTtl - tokenConfig.OtpResendAfter).Seconds())
+}
+
+// names of the fields with an empty value, in a stable order for the error details
+func missingFields(fields map[string]string) (missing []string) {
+	for field, value := range fields {
+		if value == "" {
+			missing = append(missing, field)
+		}
+	}
+	sort.Strings(missing)
+	return
 }
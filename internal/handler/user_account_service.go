@@ -0,0 +1,489 @@
+package handler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/requirements"
+	"github.com/MitP1997/golang-user-management/internal/responses"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/signup"
+	"github.com/MitP1997/golang-user-management/internal/suspensions"
+	"github.com/MitP1997/golang-user-management/internal/tracing"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The functions below hold the user account logic shared by the gin handlers and the gRPC server.
+// They log their own errors and leave writing the response to the caller.
+
+func signupUser(c *gin.Context, req *requests.SignupRequest) (res *responses.AuthTokenResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "signupUser")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+	defer func() {
+		if err != nil {
+			publishAndLog(c, events.SignupFailed{Email: req.Email, Reason: err.UserErrorCode()})
+		}
+	}()
+
+	if len(req.Password) < 8 {
+		logger.Error("Password length less than 8 characters")
+		return nil, errors.PasswordTooShortError()
+	}
+
+	var invitation *models.Invitation
+	if req.InvitationToken != "" {
+		var e *errors.Error
+		invitation, e = findPendingInvitation(c, req.InvitationToken)
+		if e != nil {
+			logger.Error("Error while fetching invitation", zap.Error(e.Error()))
+			return nil, e
+		}
+		if !strings.EqualFold(invitation.Email, req.Email) {
+			logger.Error("Signup email does not match the invitation")
+			return nil, errors.InvitationEmailMismatchError()
+		}
+	}
+	invitationCode, e := enforceSignupPolicy(c, req, invitation)
+	if e != nil {
+		logger.Error("Signup rejected by signup policy", zap.Error(e.Error()), zap.String("mode", string(signup.GetMode())))
+		return nil, e
+	}
+	organizationId, e := getSignupOrganizationId(c, invitation)
+	if e != nil {
+		logger.Error("Error while resolving signup organization", zap.Error(e.Error()))
+		releaseInvitationCode(c, invitationCode)
+		return nil, e
+	}
+
+	hashedPassword, hashErr := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if hashErr != nil {
+		logger.Error("Error while hashing password", zap.Error(hashErr))
+	}
+	user := models.User{
+		GivenName:      req.GivenName,
+		FamilyName:     req.FamilyName,
+		Email:          req.Email,
+		Password:       string(hashedPassword),
+		OrganizationId: organizationId,
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		e := user.Insert(c)
+		if e != nil {
+			logger.Error("Error while inserting user into database", zap.Error(e.Error()))
+			return e
+		}
+		if invitation != nil {
+			if _, e = acceptInvitation(c, invitation, &user); e != nil {
+				logger.Error("Error while accepting invitation", zap.Error(e.Error()))
+				return e
+			}
+		}
+		// sends the email verification otp
+		if e = events.Publish(c, events.UserSignedUp{User: events.NewUser(&user)}); e != nil {
+			logger.Error("Error while publishing user signed up event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		// the use of the code was consumed outside the transaction
+		releaseInvitationCode(c, invitationCode)
+		return nil, e
+	}
+	logger = utils.AddKeyToContextLogger(c, "user_id", user.Id)
+	token, e := startSession(c, &user, constants.AuthMethodPassword)
+	if e != nil {
+		logger.Error("Error while generating user auth token", zap.Error(e.Error()))
+		return nil, e
+	}
+	return &responses.AuthTokenResponse{Message: "User login successful", Token: token}, nil
+}
+
+func loginUser(c *gin.Context, req *requests.LoginRequest) (res *responses.AuthTokenResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "loginUser")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+	// the id stays empty when the email does not match a user
+	var user models.User
+	defer func() {
+		if err != nil {
+			publishAndLog(c, events.LoginFailed{Email: req.Email, UserId: user.Id, Reason: err.UserErrorCode()})
+		}
+	}()
+
+	filter := tenantScopedFilter(c, bson.M{"email": req.Email})
+	e := user.FindOne(c, filter)
+	if e != nil {
+		// unknown emails are reported like a wrong password so the endpoint does not reveal which accounts exist
+		if e.IsNotFound() {
+			logger.Info("Login attempt for an unknown email")
+			return nil, errors.InvalidCredentialsError()
+		}
+		logger.Error("Error while fetching user from database", zap.Error(e.Error()))
+		return nil, e
+	}
+
+	// deleted accounts are reported like a wrong password so the endpoint does not reveal them
+	if user.Status == models.UserStatus_DELETED {
+		logger.Info("Login attempt for a deleted user")
+		return nil, errors.InvalidCredentialsError()
+	}
+	if !verifyUserPassword(user.Password, req.Password) {
+		logger.Info("User login password verification failed")
+		return nil, errors.InvalidCredentialsError()
+	}
+	// checked after the password so the error does not tell anything to someone without it
+	if user.PasswordResetRequired {
+		logger.Info("Login attempt for a secured account that needs a password reset")
+		return nil, errors.PasswordResetRequiredError()
+	}
+	if e := suspensions.Refuse(&user); e != nil {
+		logger.Info("Login attempt for a suspended or banned user", zap.String("status", user.Status.String()))
+		return nil, e
+	}
+	logger = utils.AddKeyToContextLogger(c, "user_id", user.Id)
+	token, e := startSession(c, &user, constants.AuthMethodPassword)
+	if e != nil {
+		logger.Error("Error while generating user auth token", zap.Error(e.Error()))
+		return nil, e
+	}
+	if e = setAuthTokenOrganizationClaim(c, &user, token); e != nil {
+		logger.Error("Error while setting organization claim on auth token", zap.Error(e.Error()))
+		return nil, e
+	}
+	publishAndLog(c, events.UserLoggedIn{User: events.NewUser(&user), Ip: c.ClientIP(), UserAgent: c.Request.UserAgent(), At: time.Now()})
+	return &responses.AuthTokenResponse{Message: "User login successful", Token: token}, nil
+}
+
+func resendEmailVerificationOtp(c *gin.Context) (res *responses.MessageResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "resendEmailVerificationOtp")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	user := utils.GetContextUser(c)
+	if user.VerifiedAt != nil {
+		logger.Error("User email already verified")
+		return nil, errors.EmailAlreadyVerifiedError()
+	}
+	redisClient := serviceRegistry.GetRedisClient()
+	ttl, e := redisClient.GetOtpTtl(c, user.Id)
+	if e != nil {
+		logger.Error("Error while fetching otp ttl", zap.Error(e.Error()))
+		return nil, e
+	}
+	if !allowResendEmailOtp(int32(ttl.Seconds())) {
+		logger.Error("Resend email otp not allowed")
+		return nil, errors.OtpResendTooSoonError()
+	}
+	e = sendEmailVerificationOtp(c, user)
+	if e != nil {
+		logger.Error("Error while sending email verification otp", zap.Error(e.Error()))
+		return nil, e
+	}
+	return &responses.MessageResponse{Message: "Email verification otp resent"}, nil
+}
+
+func verifyEmail(c *gin.Context, req *requests.VerifyEmailRequest) (res *responses.MessageResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "verifyEmail")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	if req.Otp == "" {
+		logger.Error("OTP not present in request")
+		return nil, errors.MissingFieldsError("otp")
+	}
+	user := utils.GetContextUser(c)
+	if user == nil {
+		err := errors.UnauthedUserError(nil)
+		logger.Error("Error while verifying email otp", zap.Error(err.Error()))
+		return nil, err
+	}
+	e := verifyEmailOtp(c, req.Otp, constants.RedisUserEmailVerificationScope, user)
+	if e != nil {
+		logger.Error("Error while verifying email otp", zap.Error(e.Error()))
+		return nil, e
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		if e := updateUserToMarkEmailVerified(c); e != nil {
+			logger.Error("Error while updating user to mark email verified", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.EmailVerified{User: events.NewUser(user)}); e != nil {
+			logger.Error("Error while publishing email verified event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return &responses.MessageResponse{Message: "Email verified successfully"}, nil
+}
+
+func getPendingRequirements(c *gin.Context) *responses.PendingRequirementsResponse {
+	defer tracing.StartSpan(c, "getPendingRequirements")(nil)
+	logger := utils.GetContextLogger(c)
+
+	pendingRequirements := requirements.GetPendingRequirements(c)
+	logger.Info("Pending requirements", zap.Any("pending_requirements", pendingRequirements))
+	return &responses.PendingRequirementsResponse{Requirements: pendingRequirements}
+}
+
+// getMe describes the context user without the password hash, with the admin when the request impersonates them
+func getMe(c *gin.Context) *responses.MeResponse {
+	defer tracing.StartSpan(c, "getMe")(nil)
+
+	user := utils.GetContextUser(c)
+	res := &responses.MeResponse{
+		Id:             user.Id,
+		GivenName:      user.GivenName,
+		FamilyName:     user.FamilyName,
+		Email:          user.Email,
+		Status:         user.Status.String(),
+		Role:           user.Role.String(),
+		EmailVerified:  user.VerifiedAt != nil,
+		OrganizationId: user.OrganizationId,
+	}
+	if impersonator := utils.GetContextImpersonator(c); impersonator != nil {
+		res.Impersonated = true
+		res.Impersonation = &responses.Impersonation{
+			ImpersonatorId:    impersonator.Id,
+			ImpersonatorEmail: impersonator.Email,
+			ExpiresAt:         timestamppb.New(time.Unix(utils.GetContextImpersonation(c).ExpiresAt, 0)),
+		}
+	}
+	return res
+}
+
+// initiateChangePassword emails the change password otp to the user of the request
+func initiateChangePassword(c *gin.Context) (res *responses.MessageResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "initiateChangePassword")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	e := sendEmailChangePasswordOtp(c, utils.GetContextUser(c))
+	if e != nil {
+		logger.Error("Error while sending change password otp", zap.Error(e.Error()))
+		return nil, e
+	}
+	return &responses.MessageResponse{Message: "Change password otp sent"}, nil
+}
+
+// changePassword changes the password of the user of the request with the emailed otp
+func changePassword(c *gin.Context, req *requests.ChangePasswordRequest) (res *responses.MessageResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "changePassword")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	if req.Password == "" || req.Otp == "" {
+		logger.Error("Password or OTP not provided")
+		return nil, errors.MissingFieldsError(missingFields(map[string]string{"password": req.Password, "otp": req.Otp})...)
+	}
+	if e := updatePassword(c, utils.GetContextUser(c), req.Otp, req.Password); e != nil {
+		return nil, e
+	}
+	return &responses.MessageResponse{Message: "Password changed successfully"}, nil
+}
+
+// initiatePasswordReset emails the change password otp to the user of the email, for a forgotten password or one
+// that has to be reset, so it works without a token
+func initiatePasswordReset(c *gin.Context, req *requests.ResetPasswordInitiateRequest) (res *responses.MessageResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "initiatePasswordReset")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	if req.Email == "" {
+		logger.Error("Email not present in request")
+		return nil, errors.MissingFieldsError("email")
+	}
+
+	filter := tenantScopedFilter(c, bson.M{"email": req.Email})
+	var user models.User
+	e := user.FindOne(c, filter)
+	if e != nil {
+		logger.Error("Error while fetching user from database", zap.Error(e.Error()))
+		return nil, e
+	}
+	e = sendEmailChangePasswordOtp(c, &user)
+	if e != nil {
+		logger.Error("Error while sending change password otp", zap.Error(e.Error()))
+		return nil, e
+	}
+	return &responses.MessageResponse{Message: "Change password otp sent"}, nil
+}
+
+// resetPassword changes the password of the user of the email, the emailed otp proves control of the account
+func resetPassword(c *gin.Context, req *requests.ResetPasswordRequest) (res *responses.MessageResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "resetPassword")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	if req.Email == "" || req.Password == "" || req.Otp == "" {
+		logger.Error("Email or Password or OTP not provided")
+		return nil, errors.MissingFieldsError(missingFields(map[string]string{"email": req.Email, "password": req.Password, "otp": req.Otp})...)
+	}
+
+	filter := tenantScopedFilter(c, bson.M{"email": req.Email})
+	var user models.User
+	e := user.FindOne(c, filter)
+	if e != nil {
+		logger.Error("Error while fetching user from database", zap.Error(e.Error()))
+		return nil, e
+	}
+	if e = updatePassword(c, &user, req.Otp, req.Password); e != nil {
+		return nil, e
+	}
+	return &responses.MessageResponse{Message: "Password changed successfully"}, nil
+}
+
+// updatePassword verifies the change password otp of the user and stores the new password
+func updatePassword(c *gin.Context, user *models.User, otp string, password string) *errors.Error {
+	logger := utils.GetContextLogger(c)
+	e := verifyEmailOtp(c, otp, constants.RedisUserChangePasswordScope, user)
+	if e != nil {
+		logger.Error("Error while verifying email otp", zap.Error(e.Error()))
+		return e
+	}
+
+	hashedPassword, hashErr := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if hashErr != nil {
+		logger.Error("Error while hashing password", zap.Error(hashErr))
+		return errors.InternalServerError(hashErr)
+	}
+
+	user.Password = string(hashedPassword)
+	return utils.RunInTransaction(c, func() *errors.Error {
+		e := user.Update(c, bson.M{"_id": user.Id}, bson.M{"password": user.Password, "password_reset_required": false})
+		if e != nil {
+			logger.Error("Error while updating user password", zap.Error(e.Error()))
+			return e
+		}
+		if e = events.Publish(c, events.PasswordChanged{User: events.NewUser(user)}); e != nil {
+			logger.Error("Error while publishing password changed event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+}
+
+// deleteAccount soft deletes the user of the request, the record is kept with the deleted status
+func deleteAccount(c *gin.Context) (res *responses.MessageResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "deleteAccount")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	user := utils.GetContextUser(c)
+	if user == nil {
+		err := errors.UnauthedUserError(nil)
+		logger.Error("Error while deleting account", zap.Error(err.Error()))
+		return nil, err
+	}
+	if e := utils.RunInTransaction(c, func() *errors.Error { return markUserDeleted(c, user) }); e != nil {
+		return nil, e
+	}
+	if e := serviceRegistry.GetRedisClient().RevokeUserSessions(c, user.Id); e != nil {
+		logger.Error("Error while revoking sessions of deleted user", zap.Error(e.Error()))
+		return nil, e
+	}
+	return &responses.MessageResponse{Message: "Account deleted"}, nil
+}
+
+// reauthenticate confirms the password of the logged in user, the session counts as freshly authenticated for the
+// routes wrapped with RequireRecentAuth
+func reauthenticate(c *gin.Context, req *requests.ReauthenticateRequest) (res *responses.MessageResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "reauthenticate")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	user := utils.GetContextUser(c)
+	if utils.GetContextAuthentication(c) == nil {
+		logger.Info("Reauthentication with a token instead of a login")
+		return nil, errors.SessionRequiredError()
+	}
+	// a password-less user logs in again through their provider instead
+	if user.Password == "" {
+		logger.Info("Reauthentication of a user without a password")
+		return nil, errors.ReauthenticationRequiredError()
+	}
+	if req.Password == "" {
+		return nil, errors.MissingFieldsError("password")
+	}
+	if !verifyUserPassword(user.Password, req.Password) {
+		logger.Info("Reauthentication with a wrong password")
+		publishAndLog(c, events.LoginFailed{Email: user.Email, UserId: user.Id, Reason: errors.CodeAuthInvalidCredentials})
+		return nil, errors.InvalidCredentialsError()
+	}
+	// auth tokens of a login are sent as is, and only logins get here. A session limited to an organization stays so.
+	if e := recordAuthentication(c, c.GetHeader("Authorization"), constants.AuthMethodPassword, utils.GetContextAuthentication(c).OrganizationId); e != nil {
+		logger.Error("Error while recording reauthentication", zap.Error(e.Error()))
+		return nil, e
+	}
+	publishAndLog(c, events.UserReauthenticated{User: events.NewUser(user), Method: constants.AuthMethodPassword})
+	return &responses.MessageResponse{Message: "Reauthenticated"}, nil
+}
+
+// secureAccount handles the "this wasn't me" link of a login alert: the sessions are revoked, the device is forgotten
+// and login is refused until the password is changed with the otp emailed here
+func secureAccount(c *gin.Context, req *requests.SecureAccountRequest) (res *responses.MessageResponse, err *errors.Error) {
+	end := tracing.StartSpan(c, "secureAccount")
+	defer func() { end(err) }()
+	logger := utils.GetContextLogger(c)
+
+	if req.Token == "" {
+		logger.Error("Token not present in request")
+		return nil, errors.MissingFieldsError("token")
+	}
+	redisClient := serviceRegistry.GetRedisClient()
+	userId, deviceId, e := redisClient.ConsumeSecureAccountToken(c, req.Token)
+	if e != nil {
+		if e.IsNotFound() {
+			logger.Info("Unknown secure account token")
+			return nil, errors.InvalidSecureAccountLinkError()
+		}
+		logger.Error("Error while reading secure account token", zap.Error(e.Error()))
+		return nil, e
+	}
+	logger = utils.AddKeyToContextLogger(c, "user_id", userId)
+
+	var user models.User
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		e := user.Update(c, bson.M{"_id": userId}, bson.M{"password_reset_required": true})
+		if e != nil {
+			logger.Error("Error while marking user password reset required", zap.Error(e.Error()))
+			return e
+		}
+		device := models.Device{Id: deviceId}
+		if e = device.Delete(c); e != nil {
+			logger.Error("Error while forgetting device", zap.Error(e.Error()))
+			return e
+		}
+		if e = events.Publish(c, events.AccountSecured{User: events.NewUser(&user), DeviceId: deviceId}); e != nil {
+			logger.Error("Error while publishing account secured event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	if e = redisClient.RevokeUserSessions(c, user.Id); e != nil {
+		logger.Error("Error while revoking sessions of secured user", zap.Error(e.Error()))
+		return nil, e
+	}
+	if e = sendEmailChangePasswordOtp(c, &user); e != nil {
+		logger.Error("Error while sending change password otp", zap.Error(e.Error()))
+		return nil, e
+	}
+	return &responses.MessageResponse{Message: "Account secured, reset the password with the otp sent by email"}, nil
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/MitP1997/golang-user-management/internal/webhooks"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const maxWebhookDeliveriesPageSize = 200
+
+func CreateWebhookEndpoint(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var req requests.CreateWebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Error while binding request body to webhook endpoint struct", zap.Error(err))
+		utils.RespondWithError(c, errors.RequestBindingError(err))
+		return
+	}
+	endpointUrl, err := url.Parse(req.Url)
+	if err != nil || (endpointUrl.Scheme != "http" && endpointUrl.Scheme != "https") || endpointUrl.Host == "" {
+		logger.Info("Invalid webhook url", zap.String("url", req.Url))
+		utils.RespondWithError(c, errors.WebhookInvalidUrlError())
+		return
+	}
+	for _, eventType := range req.EventTypes {
+		if !contains(constants.WebhookEventTypes, eventType) {
+			logger.Info("Unknown webhook event type", zap.String("event_type", eventType))
+			utils.RespondWithError(c, errors.WebhookUnknownEventTypeError(eventType))
+			return
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		logger.Error("Error while generating webhook secret", zap.Error(err))
+		utils.RespondWithError(c, errors.InternalServerError(err))
+		return
+	}
+	endpoint := models.WebhookEndpoint{
+		Url:         req.Url,
+		Secret:      "whsec_" + hex.EncodeToString(secret),
+		EventTypes:  req.EventTypes,
+		Description: req.Description,
+		CreatedBy:   utils.GetContextUser(c).Id,
+	}
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := endpoint.Insert(c); e != nil {
+			logger.Error("Error while inserting webhook endpoint into database", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.WebhookEndpointCreated{EndpointId: endpoint.Id, Url: endpoint.Url, EventTypes: endpoint.EventTypes}); e != nil {
+			logger.Error("Error while publishing webhook endpoint created event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	// the secret is not stored anywhere the admin can read it again
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Webhook endpoint created", "endpoint": &endpoint, "secret": endpoint.Secret})
+}
+
+func ListWebhookEndpoints(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	endpoints, e := models.FindWebhookEndpoints(c, bson.M{})
+	if e != nil {
+		logger.Error("Error while fetching webhook endpoints from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"endpoints": endpoints})
+}
+
+// DeleteWebhookEndpoint removes the endpoint, its queued deliveries are marked failed when they come up
+func DeleteWebhookEndpoint(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var endpoint models.WebhookEndpoint
+	e := endpoint.FindOne(c, bson.M{"_id": c.Param("endpoint_id")})
+	if e != nil {
+		logger.Error("Error while fetching webhook endpoint from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		if e := endpoint.Delete(c); e != nil {
+			logger.Error("Error while deleting webhook endpoint", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.WebhookEndpointDeleted{EndpointId: endpoint.Id, Url: endpoint.Url}); e != nil {
+			logger.Error("Error while publishing webhook endpoint deleted event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Webhook endpoint deleted"})
+}
+
+// ListWebhookDeliveries lists the newest deliveries first, filtered by the status, endpoint_id and event_type query params
+func ListWebhookDeliveries(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	filter := bson.M{}
+	if status := c.Query("status"); status != "" {
+		value, ok := models.WebhookDeliveryStatus_value[strings.ToUpper(status)]
+		if !ok {
+			logger.Info("Invalid webhook delivery status", zap.String("status", status))
+			utils.RespondWithError(c, errors.BadRequestError("status must be one of queued, succeeded, failed"))
+			return
+		}
+		filter["status"] = models.WebhookDeliveryStatus(value)
+	}
+	if endpointId := c.Query("endpoint_id"); endpointId != "" {
+		filter["endpoint_id"] = endpointId
+	}
+	if eventType := c.Query("event_type"); eventType != "" {
+		filter["event_type"] = eventType
+	}
+	limit, skip, e := parsePagination(c, maxWebhookDeliveriesPageSize)
+	if e != nil {
+		logger.Info("Invalid pagination of webhook deliveries", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at.seconds", Value: -1}}).SetSkip(skip).SetLimit(limit)
+	deliveries, e := models.FindWebhookDeliveries(c, filter, opts)
+	if e != nil {
+		logger.Error("Error while fetching webhook deliveries from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// ReplayWebhookDelivery sends a delivery again whatever its status, with the same payload and event id
+func ReplayWebhookDelivery(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var delivery models.WebhookDelivery
+	e := delivery.FindOne(c, bson.M{"_id": c.Param("delivery_id")})
+	if e != nil {
+		logger.Error("Error while fetching webhook delivery from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	e = utils.RunInTransaction(c, func() *errors.Error {
+		if _, e := models.ReplayWebhookDeliveries(c, bson.M{"_id": delivery.Id}); e != nil {
+			logger.Error("Error while queueing webhook delivery again", zap.Error(e.Error()))
+			return e
+		}
+		if e := events.Publish(c, events.WebhookDeliveriesReplayed{EndpointId: delivery.EndpointId, DeliveryId: delivery.Id, Count: 1}); e != nil {
+			logger.Error("Error while publishing webhook deliveries replayed event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	webhooks.Wake()
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Webhook delivery queued"})
+}
+
+// ReplayFailedWebhookDeliveries queues every failed delivery of the endpoint again, e.g. after the receiver had an outage
+func ReplayFailedWebhookDeliveries(c *gin.Context) {
+	logger := utils.GetContextLogger(c)
+
+	var endpoint models.WebhookEndpoint
+	e := endpoint.FindOne(c, bson.M{"_id": c.Param("endpoint_id")})
+	if e != nil {
+		logger.Error("Error while fetching webhook endpoint from database", zap.Error(e.Error()))
+		utils.RespondWithError(c, e)
+		return
+	}
+	var count int64
+	e = utils.RunInTransaction(c, func() (e *errors.Error) {
+		count, e = models.ReplayWebhookDeliveries(c, bson.M{"endpoint_id": endpoint.Id, "status": models.WebhookDeliveryStatus_FAILED})
+		if e != nil {
+			logger.Error("Error while queueing failed webhook deliveries again", zap.Error(e.Error()))
+			return e
+		}
+		if e = events.Publish(c, events.WebhookDeliveriesReplayed{EndpointId: endpoint.Id, Count: count}); e != nil {
+			logger.Error("Error while publishing webhook deliveries replayed event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		utils.RespondWithError(c, e)
+		return
+	}
+	webhooks.Wake()
+	c.IndentedJSON(http.StatusOK, gin.H{"message": "Failed webhook deliveries queued", "replayed": count})
+}
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp           = "up"
+	StatusDown         = "down"
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+type Check struct {
+	Name string
+	Ping func(ctx context.Context) error
+}
+
+type Result struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+var shuttingDown atomic.Bool
+
+// MarkShuttingDown makes the readiness probe fail so the orchestrator stops routing traffic before the server drains
+func MarkShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+func IsShuttingDown() bool {
+	return shuttingDown.Load()
+}
+
+// Run pings every dependency concurrently, each bounded by the timeout, and reports whether all of them are up
+func Run(ctx context.Context, timeout time.Duration, checks []Check) (results map[string]Result, ok bool) {
+	results = make(map[string]Result, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			err := check.Ping(checkCtx)
+			result := Result{Status: StatusUp, LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	ok = true
+	for _, result := range results {
+		if result.Status != StatusUp {
+			ok = false
+		}
+	}
+	return
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "user_management"
+
+// result label values shared by the collectors below
+const (
+	ResultOk    = "ok"
+	ResultError = "error"
+)
+
+var HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "http_request_duration_seconds",
+	Help:      "Latency of HTTP requests by route template, method and status code.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+var SignupsTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "signups_total",
+	Help:      "Users that signed up successfully.",
+})
+
+var SignupFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "signup_failures_total",
+	Help:      "Rejected or failed signups by error code.",
+}, []string{"reason"})
+
+var LoginsTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "logins_total",
+	Help:      "Successful logins.",
+})
+
+var LoginFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "login_failures_total",
+	Help:      "Failed logins by error code.",
+}, []string{"reason"})
+
+var OtpsIssuedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "otps_issued_total",
+	Help:      "OTPs generated by scope.",
+}, []string{"scope"})
+
+var OtpVerificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "otp_verifications_total",
+	Help:      "OTP checks by scope and result (verified or failed).",
+}, []string{"scope", "result"})
+
+var EmailsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "emails_total",
+	Help:      "Emails handed to the SMTP server by result (sent or failed).",
+}, []string{"result"})
+
+var WebhookDeliveryAttemptsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "webhook_delivery_attempts_total",
+	Help:      "Webhook delivery attempts by event type and result (ok or error).",
+}, []string{"event_type", "result"})
+
+var RedisCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "redis_command_duration_seconds",
+	Help:      "Latency of Redis commands by command and result.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+}, []string{"command", "result"})
+
+var MongoCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "mongo_command_duration_seconds",
+	Help:      "Latency of Mongo commands by command and result.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"command", "result"})
+
+// OTP verification results
+const (
+	OtpVerified = "verified"
+	OtpFailed   = "failed"
+)
+
+// email results
+const (
+	EmailSent   = "sent"
+	EmailFailed = "failed"
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
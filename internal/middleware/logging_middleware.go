@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/tracing"
 	"github.com/MitP1997/golang-user-management/internal/utils"
 	ginzap "github.com/gin-contrib/zap"
 	"github.com/gin-gonic/gin"
@@ -17,8 +20,11 @@ func IntroduceLoggingContextMiddleware(logger *zap.Logger) gin.HandlerFunc {
 		// request id as a uuid
 		requestId := uuid.New().String()
 		requestIdCoreField := zapcore.Field{Key: "request_id", Type: zapcore.StringType, String: requestId}
-		logger := logger.With(requestIdCoreField)
+		// the trace ids come from the tracing middleware that runs before this one
+		logger := logger.With(append([]zap.Field{requestIdCoreField}, tracing.LogFields(c.Request.Context())...)...)
 		utils.SetContextLogger(c, logger)
+		utils.SetContextRequestId(c, requestId)
+		c.Header("X-Request-Id", requestId)
 		c.Next()
 	}
 }
@@ -33,6 +39,8 @@ func GinzapLoggerMiddleware() gin.HandlerFunc {
 func RecoveryWithZapMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger := utils.GetContextLogger(c)
-		ginzap.RecoveryWithZap(logger, true)(c)
+		ginzap.CustomRecoveryWithZap(logger, true, func(c *gin.Context, recovered interface{}) {
+			utils.RespondWithError(c, errors.InternalServerError(fmt.Errorf("panic: %v", recovered)))
+		})(c)
 	}
 }
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		// the route template keeps the label cardinality bounded, unmatched paths share one label
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HttpRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Observe(time.Since(start).Seconds())
+	}
+}
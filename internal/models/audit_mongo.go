@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// the ttl index needs a date, which the proto timestamps are not, so the entries carry it in an extra field
+const auditRecordedAtField = "recorded_at"
+
+const auditRecordedAtIndex = "recorded_at_1"
+
+// Insert appends the entry to the audit log, there is no way to update or delete an entry
+func (a *AuditEntry) Insert(ctx context.Context) (err *errors.Error) {
+	now := time.Now()
+	a.CreatedAt = timestamppb.New(now)
+
+	e := validator.Struct(a)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	raw, e := bson.Marshal(a)
+	if e != nil {
+		return errors.InternalServerError(e)
+	}
+	var document bson.D
+	if e = bson.Unmarshal(raw, &document); e != nil {
+		return errors.InternalServerError(e)
+	}
+	document = append(document, bson.E{Key: auditRecordedAtField, Value: primitive.NewDateTimeFromTime(now)})
+
+	res, e := auditEntryCollection.InsertOne(ctx, document)
+	if e != nil {
+		return getErrorToReturn(e, "audit entry")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		a.Id = oid.Hex()
+	}
+	return nil
+}
+
+// FindAuditEntries returns the matching entries, newest first
+func FindAuditEntries(ctx context.Context, filter bson.M, skip int64, limit int64) (entries []*AuditEntry, err *errors.Error) {
+	opts := options.Find().SetSort(bson.D{{Key: auditRecordedAtField, Value: -1}}).SetSkip(skip).SetLimit(limit)
+	cursor, e := auditEntryCollection.Find(ctx, filter, opts)
+	if e != nil {
+		return nil, getErrorToReturn(e, "audit entry")
+	}
+	entries = []*AuditEntry{}
+	if e = cursor.All(ctx, &entries); e != nil {
+		return nil, getErrorToReturn(e, "audit entry")
+	}
+	return
+}
+
+// AuditTimeRangeFilter limits a query to the entries recorded in [from, to), a zero time leaves that side open
+func AuditTimeRangeFilter(filter bson.M, from time.Time, to time.Time) bson.M {
+	recordedAt := bson.M{}
+	if !from.IsZero() {
+		recordedAt["$gte"] = primitive.NewDateTimeFromTime(from)
+	}
+	if !to.IsZero() {
+		recordedAt["$lt"] = primitive.NewDateTimeFromTime(to)
+	}
+	if len(recordedAt) > 0 {
+		filter[auditRecordedAtField] = recordedAt
+	}
+	return filter
+}
+
+// createAuditRetentionIndex indexes the entries by recording time, with the retention as ttl when it is set.
+// An index left by another retention is replaced, mongo refuses to create one with different options.
+func createAuditRetentionIndex(retention time.Duration) error {
+	indexOptions := options.Index().SetName(auditRecordedAtIndex)
+	if retention > 0 {
+		indexOptions.SetExpireAfterSeconds(int32(retention.Seconds()))
+	}
+	index := mongo.IndexModel{Keys: bson.D{{Key: auditRecordedAtField, Value: 1}}, Options: indexOptions}
+
+	_, err := auditEntryCollection.Indexes().CreateOne(context.Background(), index)
+	// 85: IndexOptionsConflict
+	if cmdErr, ok := err.(mongo.CommandError); ok && cmdErr.Code == 85 {
+		if _, err = auditEntryCollection.Indexes().DropOne(context.Background(), auditRecordedAtIndex); err != nil {
+			return err
+		}
+		_, err = auditEntryCollection.Indexes().CreateOne(context.Background(), index)
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (d *Device) Insert(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	d.FirstSeenAt = now
+	d.LastSeenAt = now
+
+	e := validator.Struct(d)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := deviceCollection.InsertOne(ctx, d)
+	if e != nil {
+		return getErrorToReturn(e, "device")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		d.Id = oid.Hex()
+	}
+	return nil
+}
+
+func (d *Device) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := deviceCollection.FindOne(ctx, filter).Decode(d)
+	if e != nil {
+		return getErrorToReturn(e, "device")
+	}
+	return
+}
+
+// MarkSeen records a new login from the device
+func (d *Device) MarkSeen(ctx context.Context, ip string) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": d.Id})
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$set": bson.M{"last_ip": ip, "last_seen_at": timestamppb.Now()}}
+	e := deviceCollection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(d)
+	if e != nil {
+		return getErrorToReturn(e, "device")
+	}
+	return
+}
+
+// Delete forgets the device, the next login from it is reported again
+func (d *Device) Delete(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": d.Id})
+	if err != nil {
+		return err
+	}
+	if _, e := deviceCollection.DeleteOne(ctx, filter); e != nil {
+		return getErrorToReturn(e, "device")
+	}
+	return
+}
+
+// FindUserDevices returns the devices of the user, most recently seen first
+func FindUserDevices(ctx context.Context, userId string) (devices []*Device, err *errors.Error) {
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at.seconds", Value: -1}})
+	cursor, e := deviceCollection.Find(ctx, bson.M{"user_id": userId}, opts)
+	if e != nil {
+		return nil, getErrorToReturn(e, "device")
+	}
+	devices = []*Device{}
+	if e = cursor.All(ctx, &devices); e != nil {
+		return nil, getErrorToReturn(e, "device")
+	}
+	return
+}
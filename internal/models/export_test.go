@@ -0,0 +1,6 @@
+package models
+
+// the unexported helpers under test
+var (
+	RedeemableInvitationCodeFilter = redeemableInvitationCodeFilter
+)
@@ -9,9 +9,9 @@ func getErrorToReturn(e error, collection string) *errors.Error {
 	if e == mongo.ErrNoDocuments {
 		return errors.NoDocumentsError(e, collection)
 	}
-	writeException := e.(mongo.WriteException)
-	if writeException.HasErrorCode(11000) {
-		return errors.DuplicateKeyError(writeException)
+	writeException, ok := e.(mongo.WriteException)
+	if ok && writeException.HasErrorCode(11000) {
+		return errors.DuplicateKeyError(writeException, collection)
 	}
 	return errors.DatabaseError(e)
 }
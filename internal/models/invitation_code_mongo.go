@@ -0,0 +1,74 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (i *InvitationCode) Insert(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	i.CreatedAt = now
+	i.UpdatedAt = now
+
+	e := validator.Struct(i)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := invitationCodeCollection.InsertOne(ctx, i)
+	if e != nil {
+		return getErrorToReturn(e, "invitation code")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		i.Id = oid.Hex()
+	}
+	return nil
+}
+
+// Redeem consumes one use of the code in a single update so that concurrent signups cannot exceed max_uses
+// a code that is unknown, expired or used up is reported as not found
+func (i *InvitationCode) Redeem(ctx context.Context, code string) (err *errors.Error) {
+	update := bson.M{"$inc": bson.M{"uses": 1}, "$set": bson.M{"updated_at": timestamppb.Now()}}
+	e := invitationCodeCollection.FindOneAndUpdate(ctx, redeemableInvitationCodeFilter(code, time.Now()), update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(i)
+	if e != nil {
+		return getErrorToReturn(e, "invitation code")
+	}
+	return
+}
+
+// matches the code while it has uses left and has not expired at now
+func redeemableInvitationCodeFilter(code string, now time.Time) bson.M {
+	return bson.M{
+		"code": code,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"max_uses": 0},
+				bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"expires_at": nil},
+				bson.M{"expires_at.seconds": bson.M{"$gt": now.Unix()}},
+			}},
+		},
+	}
+}
+
+// Release gives back a use consumed by Redeem, e.g. when the signup failed afterwards
+func (i *InvitationCode) Release(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": i.Id})
+	if err != nil {
+		return err
+	}
+	_, e := invitationCodeCollection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"uses": -1}})
+	if e != nil {
+		return getErrorToReturn(e, "invitation code")
+	}
+	i.Uses--
+	return
+}
@@ -0,0 +1,39 @@
+package models_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Redeem updates the code only while the filter matches, so the use limit and the expiry are checked by the
+// same write that consumes the use and concurrent signups cannot exceed max_uses
+func TestRedeemableInvitationCodeFilter(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	filter := models.RedeemableInvitationCodeFilter("welcome", now)
+
+	if filter["code"] != "welcome" {
+		t.Errorf("code = %v, want welcome", filter["code"])
+	}
+	and, ok := filter["$and"].(bson.A)
+	if !ok || len(and) != 2 {
+		t.Fatalf("$and = %v, want the use limit and the expiry", filter["$and"])
+	}
+	uses := bson.A{
+		bson.M{"max_uses": 0},
+		bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}}},
+	}
+	if got := and[0].(bson.M)["$or"]; !reflect.DeepEqual(got, uses) {
+		t.Errorf("use limit = %v, want unlimited or uses below max_uses", got)
+	}
+	expiry := bson.A{
+		bson.M{"expires_at": nil},
+		bson.M{"expires_at.seconds": bson.M{"$gt": now.Unix()}},
+	}
+	if got := and[1].(bson.M)["$or"]; !reflect.DeepEqual(got, expiry) {
+		t.Errorf("expiry = %v, want no expiry or an expiry after now", got)
+	}
+}
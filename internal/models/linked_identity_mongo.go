@@ -0,0 +1,83 @@
+package models
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (l *LinkedIdentity) Insert(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	l.CreatedAt = now
+	l.LastLoginAt = now
+
+	e := validator.Struct(l)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := linkedIdentityCollection.InsertOne(ctx, l)
+	if e != nil {
+		return getErrorToReturn(e, "linked identity")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		l.Id = oid.Hex()
+	}
+	return nil
+}
+
+func (l *LinkedIdentity) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := linkedIdentityCollection.FindOne(ctx, filter).Decode(l)
+	if e != nil {
+		return getErrorToReturn(e, "linked identity")
+	}
+	return
+}
+
+// RecordLogin remembers the login and the email the provider asserted with it
+func (l *LinkedIdentity) RecordLogin(ctx context.Context, email string) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": l.Id})
+	if err != nil {
+		return err
+	}
+	l.Email = email
+	l.LastLoginAt = timestamppb.Now()
+	_, e := linkedIdentityCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"email": l.Email, "last_login_at": l.LastLoginAt}})
+	if e != nil {
+		return getErrorToReturn(e, "linked identity")
+	}
+	return
+}
+
+func (l *LinkedIdentity) Delete(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": l.Id})
+	if err != nil {
+		return err
+	}
+	if _, e := linkedIdentityCollection.DeleteOne(ctx, filter); e != nil {
+		return getErrorToReturn(e, "linked identity")
+	}
+	return
+}
+
+// FindUserLinkedIdentities returns the identities linked to the user, oldest first
+func FindUserLinkedIdentities(ctx context.Context, userId string) (identities []*LinkedIdentity, err *errors.Error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at.seconds", Value: 1}})
+	cursor, e := linkedIdentityCollection.Find(ctx, bson.M{"user_id": userId}, opts)
+	if e != nil {
+		return nil, getErrorToReturn(e, "linked identity")
+	}
+	identities = []*LinkedIdentity{}
+	if e = cursor.All(ctx, &identities); e != nil {
+		return nil, getErrorToReturn(e, "linked identity")
+	}
+	return
+}
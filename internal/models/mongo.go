@@ -3,8 +3,13 @@ package models
 import (
 	"context"
 	"fmt"
-	"os"
+	"net"
 	reflect "reflect"
+	"strconv"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/constants"
 
 	validator10 "github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,26 +18,47 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.uber.org/zap"
 )
 
 var dbClient *mongo.Database
 var userCollection *mongo.Collection
+var organizationCollection *mongo.Collection
+var membershipCollection *mongo.Collection
+var invitationCollection *mongo.Collection
+var invitationCodeCollection *mongo.Collection
+var webhookEndpointCollection *mongo.Collection
+var webhookDeliveryCollection *mongo.Collection
+var outboxEventCollection *mongo.Collection
+var auditEntryCollection *mongo.Collection
+var deviceCollection *mongo.Collection
+var oauthClientCollection *mongo.Collection
+var oauthConsentCollection *mongo.Collection
+var signingKeyCollection *mongo.Collection
+var linkedIdentityCollection *mongo.Collection
+var samlConnectionCollection *mongo.Collection
+var scimTokenCollection *mongo.Collection
+var scimGroupCollection *mongo.Collection
+var personalAccessTokenCollection *mongo.Collection
 
 var collectionObjectMap = make(map[*mongo.Collection]interface{})
 
 var validator *validator10.Validate
 
-var host, port, db, uri string
+var db, uri string
+
+// decides whether user emails are unique across the deployment or only within an organization
+var emailUniqueness string
 
-func InitMongoConnection() (err error) {
-	host = os.Getenv("DATABASE_HOST")
-	port = os.Getenv("DATABASE_PORT")
-	db = os.Getenv("DATABASE_NAME")
-	uri = fmt.Sprintf("mongodb://%s:%s/%s?retryWrites=true&w=majority", host, port, db)
+func InitMongoConnection(logger *zap.Logger, databaseConfig config.DatabaseConfig, tenancyConfig config.TenancyConfig, auditConfig config.AuditConfig) (err error) {
+	db = databaseConfig.Name
+	uri = fmt.Sprintf("mongodb://%s/%s?retryWrites=true&w=majority", net.JoinHostPort(databaseConfig.Host, strconv.Itoa(databaseConfig.Port)), db)
+	emailUniqueness = tenancyConfig.EmailUniqueness
 
 	// Use the SetServerAPIOptions() method to set the Stable API version to 1
 	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(uri).SetServerAPIOptions(serverAPI)
+	opts := options.Client().ApplyURI(uri).SetServerAPIOptions(serverAPI).SetMonitor(newCommandMonitor())
 
 	// Create a new client and connect to the server
 	client, err := mongo.Connect(context.Background(), opts)
@@ -47,21 +73,35 @@ func InitMongoConnection() (err error) {
 	}
 
 	initServerVarsPostMongoConnection(client)
-	createIndicesForAllCollections()
-	fmt.Println("Pinged your deployment. You successfully connected to MongoDB!")
+	createIndicesForAllCollections(logger)
+	if err = createAuditRetentionIndex(auditConfig.Retention); err != nil {
+		return
+	}
+	transactionsSupported, err = detectTransactionSupport(client)
 	return
 }
 
+// PingMongo checks the connection to the deployment for the readiness probe
+func PingMongo(ctx context.Context) error {
+	return dbClient.Client().Ping(ctx, readpref.Primary())
+}
+
 func CloseMongoConnection() (err error) {
 	err = dbClient.Client().Disconnect(context.Background())
 	if err != nil {
 		return err
 	}
-	fmt.Println("Disconnected from MongoDB!")
 	return
 }
 
-func createIndicesForAllCollections() {
+// IsEmailUniquePerOrganization reports whether the same email can be registered once per organization
+func IsEmailUniquePerOrganization() bool {
+	return emailUniqueness == constants.EmailUniquenessOrganization
+}
+
+func createIndicesForAllCollections(logger *zap.Logger) {
+	dropStaleTenantIndices(logger)
+	backfillUserOrganizations(logger)
 	for coll, obj := range collectionObjectMap {
 		indices := getIndices(obj)
 		err := createIndices(coll, indices)
@@ -77,6 +117,40 @@ func initServerVarsPostMongoConnection(client *mongo.Client) {
 	userCollectionOpts := options.Collection().SetRegistry(objectIdDecodingRegistry())
 	userCollection = dbClient.Collection("users", userCollectionOpts)
 	collectionObjectMap[userCollection] = &User{}
+	organizationCollection = dbClient.Collection("organizations", userCollectionOpts)
+	collectionObjectMap[organizationCollection] = &Organization{}
+	membershipCollection = dbClient.Collection("memberships", userCollectionOpts)
+	collectionObjectMap[membershipCollection] = &Membership{}
+	invitationCollection = dbClient.Collection("invitations", userCollectionOpts)
+	collectionObjectMap[invitationCollection] = &Invitation{}
+	invitationCodeCollection = dbClient.Collection("invitation_codes", userCollectionOpts)
+	collectionObjectMap[invitationCodeCollection] = &InvitationCode{}
+	webhookEndpointCollection = dbClient.Collection("webhook_endpoints", userCollectionOpts)
+	collectionObjectMap[webhookEndpointCollection] = &WebhookEndpoint{}
+	webhookDeliveryCollection = dbClient.Collection("webhook_deliveries", userCollectionOpts)
+	collectionObjectMap[webhookDeliveryCollection] = &WebhookDelivery{}
+	outboxEventCollection = dbClient.Collection("event_outbox", userCollectionOpts)
+	collectionObjectMap[outboxEventCollection] = &OutboxEvent{}
+	auditEntryCollection = dbClient.Collection("audit_log", userCollectionOpts)
+	collectionObjectMap[auditEntryCollection] = &AuditEntry{}
+	deviceCollection = dbClient.Collection("devices", userCollectionOpts)
+	collectionObjectMap[deviceCollection] = &Device{}
+	oauthClientCollection = dbClient.Collection("oauth_clients", userCollectionOpts)
+	collectionObjectMap[oauthClientCollection] = &OAuthClient{}
+	oauthConsentCollection = dbClient.Collection("oauth_consents", userCollectionOpts)
+	collectionObjectMap[oauthConsentCollection] = &OAuthConsent{}
+	signingKeyCollection = dbClient.Collection("signing_keys", userCollectionOpts)
+	collectionObjectMap[signingKeyCollection] = &SigningKey{}
+	linkedIdentityCollection = dbClient.Collection("linked_identities", userCollectionOpts)
+	collectionObjectMap[linkedIdentityCollection] = &LinkedIdentity{}
+	samlConnectionCollection = dbClient.Collection("saml_connections", userCollectionOpts)
+	collectionObjectMap[samlConnectionCollection] = &SamlConnection{}
+	scimTokenCollection = dbClient.Collection("scim_tokens", userCollectionOpts)
+	collectionObjectMap[scimTokenCollection] = &ScimToken{}
+	scimGroupCollection = dbClient.Collection("scim_groups", userCollectionOpts)
+	collectionObjectMap[scimGroupCollection] = &ScimGroup{}
+	personalAccessTokenCollection = dbClient.Collection("personal_access_tokens", userCollectionOpts)
+	collectionObjectMap[personalAccessTokenCollection] = &PersonalAccessToken{}
 	validator = validator10.New()
 }
 
@@ -90,8 +164,14 @@ func getIndices(obj interface{}) (indices []mongo.IndexModel) {
 
 		index := field.Tag.Get("index")
 		if index != "" {
+			// index_scope prefixes the index with other fields, e.g. to make a value unique per organization
+			keys := bson.D{}
+			for _, scopeKey := range resolveIndexScope(field.Tag.Get("index_scope")) {
+				keys = append(keys, bson.E{Key: scopeKey, Value: 1})
+			}
+			keys = append(keys, bson.E{Key: field.Tag.Get("bson"), Value: 1})
 			indexModel := mongo.IndexModel{
-				Keys: bson.D{{Key: field.Tag.Get("bson"), Value: 1}},
+				Keys: keys,
 			}
 			if index == "unique" {
 				indexModel.Options = options.Index().SetUnique(true)
@@ -102,6 +182,52 @@ func getIndices(obj interface{}) (indices []mongo.IndexModel) {
 	return
 }
 
+func resolveIndexScope(scope string) []string {
+	if scope == "" {
+		return nil
+	}
+	if scope == "tenant" {
+		if IsEmailUniquePerOrganization() {
+			return []string{"organization_id"}
+		}
+		return nil
+	}
+	return strings.Split(scope, ",")
+}
+
+// switching TENANT_EMAIL_UNIQUENESS leaves the index of the other mode behind, which would keep enforcing it
+func dropStaleTenantIndices(logger *zap.Logger) {
+	staleIndex := "organization_id_1_email_1"
+	if IsEmailUniquePerOrganization() {
+		staleIndex = "email_1"
+	}
+	_, err := userCollection.Indexes().DropOne(context.Background(), staleIndex)
+	if err != nil {
+		// 26: NamespaceNotFound, 27: IndexNotFound
+		if cmdErr, ok := err.(mongo.CommandError); ok && (cmdErr.Code == 26 || cmdErr.Code == 27) {
+			return
+		}
+		logger.Error("Error while dropping stale tenant index", zap.String("index", staleIndex), zap.Error(err))
+	}
+}
+
+// users created before emails were unique per organization may lack organization_id, while a lookup without
+// X-Organization-Id matches organization_id "", so they get the empty organization to keep logging in
+func backfillUserOrganizations(logger *zap.Logger) {
+	if !IsEmailUniquePerOrganization() {
+		return
+	}
+	result, err := userCollection.UpdateMany(context.Background(),
+		bson.M{"organization_id": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"organization_id": ""}})
+	if err != nil {
+		panic(err)
+	}
+	if result.ModifiedCount > 0 {
+		logger.Info("Backfilled the organization of users without one", zap.Int64("count", result.ModifiedCount))
+	}
+}
+
 func objectIdDecodingRegistry() *bsoncodec.Registry {
 	// we need to generate a new object id and get its type to be used in the decoder
 	// as primitive.ObjectID is not allowed to be used directly because of the error "not an expression"
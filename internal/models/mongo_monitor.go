@@ -0,0 +1,49 @@
+package models
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/metrics"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+)
+
+// the driver accepts a single command monitor, so the metrics and tracing monitors are fanned out from one
+func newCommandMonitor() *event.CommandMonitor {
+	monitors := []*event.CommandMonitor{newMetricsCommandMonitor(), otelmongo.NewMonitor()}
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Started != nil {
+					monitor.Started(ctx, evt)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			for _, monitor := range monitors {
+				if monitor.Succeeded != nil {
+					monitor.Succeeded(ctx, evt)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Failed != nil {
+					monitor.Failed(ctx, evt)
+				}
+			}
+		},
+	}
+}
+
+// records the latency of every command the driver sends to the deployment
+func newMetricsCommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			metrics.MongoCommandDuration.WithLabelValues(evt.CommandName, metrics.ResultOk).Observe(evt.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			metrics.MongoCommandDuration.WithLabelValues(evt.CommandName, metrics.ResultError).Observe(evt.Duration.Seconds())
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (o *OAuthClient) Insert(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	o.CreatedAt = now
+	o.UpdatedAt = now
+
+	e := validator.Struct(o)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := oauthClientCollection.InsertOne(ctx, o)
+	if e != nil {
+		return getErrorToReturn(e, "oauth client")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		o.Id = oid.Hex()
+	}
+	return nil
+}
+
+func (o *OAuthClient) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := oauthClientCollection.FindOne(ctx, filter).Decode(o)
+	if e != nil {
+		return getErrorToReturn(e, "oauth client")
+	}
+	return
+}
+
+// Delete removes the client together with the consents users granted it
+func (o *OAuthClient) Delete(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": o.Id})
+	if err != nil {
+		return err
+	}
+	_, e := oauthClientCollection.DeleteOne(ctx, filter)
+	if e != nil {
+		return getErrorToReturn(e, "oauth client")
+	}
+	_, e = oauthConsentCollection.DeleteMany(ctx, bson.M{"client_id": o.ClientId})
+	if e != nil {
+		return getErrorToReturn(e, "oauth consent")
+	}
+	return
+}
+
+func FindOAuthClients(ctx context.Context, filter bson.M) (clients []*OAuthClient, err *errors.Error) {
+	cursor, e := oauthClientCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at.seconds", Value: 1}}))
+	if e != nil {
+		return nil, getErrorToReturn(e, "oauth client")
+	}
+	clients = []*OAuthClient{}
+	if e = cursor.All(ctx, &clients); e != nil {
+		return nil, getErrorToReturn(e, "oauth client")
+	}
+	return
+}
+
+func (o *OAuthConsent) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := oauthConsentCollection.FindOne(ctx, filter).Decode(o)
+	if e != nil {
+		return getErrorToReturn(e, "oauth consent")
+	}
+	return
+}
+
+// GrantOAuthConsent adds the scopes to the consent of the user for the client, creating it on the first grant
+func GrantOAuthConsent(ctx context.Context, userId string, clientId string, scopes []string) (err *errors.Error) {
+	now := timestamppb.Now()
+	filter := bson.M{"user_id": userId, "client_id": clientId}
+	update := bson.M{
+		"$addToSet":    bson.M{"scopes": bson.M{"$each": scopes}},
+		"$set":         bson.M{"updated_at": now},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	_, e := oauthConsentCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if e != nil {
+		return getErrorToReturn(e, "oauth consent")
+	}
+	return
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (o *Organization) Insert(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	o.CreatedAt = now
+	o.UpdatedAt = now
+
+	e := validator.Struct(o)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := organizationCollection.InsertOne(ctx, o)
+	if e != nil {
+		return getErrorToReturn(e, "organization")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		o.Id = oid.Hex()
+	}
+	return nil
+}
+
+func (o *Organization) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := organizationCollection.FindOne(ctx, filter).Decode(o)
+	if e != nil {
+		return getErrorToReturn(e, "organization")
+	}
+	return
+}
+
+func (m *Membership) Insert(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	m.CreatedAt = now
+	m.UpdatedAt = now
+
+	e := validator.Struct(m)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := membershipCollection.InsertOne(ctx, m)
+	if e != nil {
+		return getErrorToReturn(e, "membership")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		m.Id = oid.Hex()
+	}
+	return nil
+}
+
+func (m *Membership) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := membershipCollection.FindOne(ctx, filter).Decode(m)
+	if e != nil {
+		return getErrorToReturn(e, "membership")
+	}
+	return
+}
+
+func (m *Membership) Delete(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": m.Id})
+	if err != nil {
+		return err
+	}
+	_, e := membershipCollection.DeleteOne(ctx, filter)
+	if e != nil {
+		return getErrorToReturn(e, "membership")
+	}
+	return
+}
+
+func FindMemberships(ctx context.Context, filter bson.M) (memberships []*Membership, err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return nil, err
+	}
+	cursor, e := membershipCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at.seconds", Value: 1}}))
+	if e != nil {
+		return nil, getErrorToReturn(e, "membership")
+	}
+	memberships = []*Membership{}
+	if e = cursor.All(ctx, &memberships); e != nil {
+		return nil, getErrorToReturn(e, "membership")
+	}
+	return
+}
+
+func CountMemberships(ctx context.Context, filter bson.M) (count int64, err *errors.Error) {
+	count, e := membershipCollection.CountDocuments(ctx, filter)
+	if e != nil {
+		return 0, getErrorToReturn(e, "membership")
+	}
+	return
+}
+
+func (i *Invitation) Insert(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	i.CreatedAt = now
+	i.UpdatedAt = now
+
+	e := validator.Struct(i)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := invitationCollection.InsertOne(ctx, i)
+	if e != nil {
+		return getErrorToReturn(e, "invitation")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		i.Id = oid.Hex()
+	}
+	return nil
+}
+
+func (i *Invitation) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := invitationCollection.FindOne(ctx, filter).Decode(i)
+	if e != nil {
+		return getErrorToReturn(e, "invitation")
+	}
+	return
+}
+
+func (i *Invitation) Update(ctx context.Context, filter bson.M, set bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	set["updated_at"] = timestamppb.Now()
+	set = bson.M{"$set": set}
+	e := invitationCollection.FindOneAndUpdate(ctx, filter, set, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(i)
+	if e != nil {
+		return getErrorToReturn(e, "invitation")
+	}
+	return
+}
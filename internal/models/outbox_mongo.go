@@ -0,0 +1,84 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (o *OutboxEvent) Insert(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	o.CreatedAt = now
+	o.UpdatedAt = now
+	if o.NextAttemptAt == nil {
+		o.NextAttemptAt = now
+	}
+
+	e := validator.Struct(o)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := outboxEventCollection.InsertOne(ctx, o)
+	if e != nil {
+		return getErrorToReturn(e, "outbox event")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		o.Id = oid.Hex()
+	}
+	return nil
+}
+
+// Delete removes the event once every subscriber handled it
+func (o *OutboxEvent) Delete(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": o.Id})
+	if err != nil {
+		return err
+	}
+	_, e := outboxEventCollection.DeleteOne(ctx, filter)
+	if e != nil {
+		return getErrorToReturn(e, "outbox event")
+	}
+	return
+}
+
+// RecordFailure keeps the subscribers that still have to handle the event and when to try them again
+func (o *OutboxEvent) RecordFailure(ctx context.Context, pendingSubscribers []string, lastError string, nextAttemptAt time.Time) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": o.Id})
+	if err != nil {
+		return err
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"pending_subscribers": pendingSubscribers,
+			"last_error":          lastError,
+			"next_attempt_at":     timestamppb.New(nextAttemptAt),
+			"updated_at":          timestamppb.Now(),
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	e := outboxEventCollection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(o)
+	if e != nil {
+		return getErrorToReturn(e, "outbox event")
+	}
+	return
+}
+
+// ClaimOutboxEvent picks the event that is due the longest and leases it until leaseUntil,
+// when nothing is due the not found error is returned
+func ClaimOutboxEvent(ctx context.Context, leaseUntil time.Time) (event *OutboxEvent, err *errors.Error) {
+	filter := bson.M{"next_attempt_at.seconds": bson.M{"$lte": time.Now().Unix()}}
+	update := bson.M{"$set": bson.M{"next_attempt_at": timestamppb.New(leaseUntil)}}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "next_attempt_at.seconds", Value: 1}}).SetReturnDocument(options.After)
+	event = &OutboxEvent{}
+	e := outboxEventCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(event)
+	if e != nil {
+		return nil, getErrorToReturn(e, "outbox event")
+	}
+	return
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (p *PersonalAccessToken) Insert(ctx context.Context) (err *errors.Error) {
+	p.CreatedAt = timestamppb.Now()
+
+	e := validator.Struct(p)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := personalAccessTokenCollection.InsertOne(ctx, p)
+	if e != nil {
+		return getErrorToReturn(e, "personal access token")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		p.Id = oid.Hex()
+	}
+	return nil
+}
+
+func (p *PersonalAccessToken) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := personalAccessTokenCollection.FindOne(ctx, filter).Decode(p)
+	if e != nil {
+		return getErrorToReturn(e, "personal access token")
+	}
+	return
+}
+
+// RecordUse remembers when and from where the token was last used
+func (p *PersonalAccessToken) RecordUse(ctx context.Context, ip string) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": p.Id})
+	if err != nil {
+		return err
+	}
+	p.LastUsedAt, p.LastUsedIp = timestamppb.Now(), ip
+	set := bson.M{"last_used_at": p.LastUsedAt, "last_used_ip": p.LastUsedIp}
+	if _, e := personalAccessTokenCollection.UpdateOne(ctx, filter, bson.M{"$set": set}); e != nil {
+		return getErrorToReturn(e, "personal access token")
+	}
+	return
+}
+
+func (p *PersonalAccessToken) Delete(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": p.Id})
+	if err != nil {
+		return err
+	}
+	if _, e := personalAccessTokenCollection.DeleteOne(ctx, filter); e != nil {
+		return getErrorToReturn(e, "personal access token")
+	}
+	return
+}
+
+// FindUserPersonalAccessTokens returns the tokens of the user, oldest first
+func FindUserPersonalAccessTokens(ctx context.Context, userId string) (tokens []*PersonalAccessToken, err *errors.Error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at.seconds", Value: 1}})
+	cursor, e := personalAccessTokenCollection.Find(ctx, bson.M{"user_id": userId}, opts)
+	if e != nil {
+		return nil, getErrorToReturn(e, "personal access token")
+	}
+	tokens = []*PersonalAccessToken{}
+	if e = cursor.All(ctx, &tokens); e != nil {
+		return nil, getErrorToReturn(e, "personal access token")
+	}
+	return
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Save creates the connection of the organization or replaces the settings of the existing one
+func (s *SamlConnection) Save(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	s.UpdatedAt = now
+
+	e := validator.Struct(s)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"idp_entity_id":         s.IdpEntityId,
+			"idp_metadata":          s.IdpMetadata,
+			"email_attribute":       s.EmailAttribute,
+			"given_name_attribute":  s.GivenNameAttribute,
+			"family_name_attribute": s.FamilyNameAttribute,
+			"updated_by":            s.UpdatedBy,
+			"updated_at":            now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	e = samlConnectionCollection.FindOneAndUpdate(ctx, bson.M{"organization_id": s.OrganizationId}, update, opts).Decode(s)
+	if e != nil {
+		return getErrorToReturn(e, "saml connection")
+	}
+	return nil
+}
+
+func (s *SamlConnection) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := samlConnectionCollection.FindOne(ctx, filter).Decode(s)
+	if e != nil {
+		return getErrorToReturn(e, "saml connection")
+	}
+	return
+}
+
+func (s *SamlConnection) Delete(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": s.Id})
+	if err != nil {
+		return err
+	}
+	if _, e := samlConnectionCollection.DeleteOne(ctx, filter); e != nil {
+		return getErrorToReturn(e, "saml connection")
+	}
+	return
+}
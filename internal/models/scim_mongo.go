@@ -0,0 +1,171 @@
+package models
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *ScimToken) Insert(ctx context.Context) (err *errors.Error) {
+	s.CreatedAt = timestamppb.Now()
+
+	e := validator.Struct(s)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := scimTokenCollection.InsertOne(ctx, s)
+	if e != nil {
+		return getErrorToReturn(e, "scim token")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		s.Id = oid.Hex()
+	}
+	return nil
+}
+
+func (s *ScimToken) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := scimTokenCollection.FindOne(ctx, filter).Decode(s)
+	if e != nil {
+		return getErrorToReturn(e, "scim token")
+	}
+	return
+}
+
+// RecordUse remembers when the identity provider last called with the token
+func (s *ScimToken) RecordUse(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": s.Id})
+	if err != nil {
+		return err
+	}
+	s.LastUsedAt = timestamppb.Now()
+	if _, e := scimTokenCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"last_used_at": s.LastUsedAt}}); e != nil {
+		return getErrorToReturn(e, "scim token")
+	}
+	return
+}
+
+func (s *ScimToken) Delete(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": s.Id})
+	if err != nil {
+		return err
+	}
+	if _, e := scimTokenCollection.DeleteOne(ctx, filter); e != nil {
+		return getErrorToReturn(e, "scim token")
+	}
+	return
+}
+
+func FindScimTokens(ctx context.Context, filter bson.M) (tokens []*ScimToken, err *errors.Error) {
+	cursor, e := scimTokenCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at.seconds", Value: 1}}))
+	if e != nil {
+		return nil, getErrorToReturn(e, "scim token")
+	}
+	tokens = []*ScimToken{}
+	if e = cursor.All(ctx, &tokens); e != nil {
+		return nil, getErrorToReturn(e, "scim token")
+	}
+	return
+}
+
+func (g *ScimGroup) Insert(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	g.CreatedAt = now
+	g.UpdatedAt = now
+
+	e := validator.Struct(g)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := scimGroupCollection.InsertOne(ctx, g)
+	if e != nil {
+		return getErrorToReturn(e, "scim group")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		g.Id = oid.Hex()
+	}
+	return nil
+}
+
+func (g *ScimGroup) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := scimGroupCollection.FindOne(ctx, filter).Decode(g)
+	if e != nil {
+		return getErrorToReturn(e, "scim group")
+	}
+	return
+}
+
+// Replace stores the name, external id and members of the group
+func (g *ScimGroup) Replace(ctx context.Context) (err *errors.Error) {
+	g.UpdatedAt = timestamppb.Now()
+
+	e := validator.Struct(g)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": g.Id})
+	if err != nil {
+		return err
+	}
+	set := bson.M{"display_name": g.DisplayName, "external_id": g.ExternalId, "member_ids": g.MemberIds, "updated_at": g.UpdatedAt}
+	if _, e = scimGroupCollection.UpdateOne(ctx, filter, bson.M{"$set": set}); e != nil {
+		return getErrorToReturn(e, "scim group")
+	}
+	return
+}
+
+func (g *ScimGroup) Delete(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": g.Id})
+	if err != nil {
+		return err
+	}
+	if _, e := scimGroupCollection.DeleteOne(ctx, filter); e != nil {
+		return getErrorToReturn(e, "scim group")
+	}
+	return
+}
+
+// FindScimGroups returns a page of the matching groups, oldest first so pages stay stable while groups are added
+func FindScimGroups(ctx context.Context, filter bson.M, skip int64, limit int64) (groups []*ScimGroup, err *errors.Error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(skip).SetLimit(limit)
+	cursor, e := scimGroupCollection.Find(ctx, filter, opts)
+	if e != nil {
+		return nil, getErrorToReturn(e, "scim group")
+	}
+	groups = []*ScimGroup{}
+	if e = cursor.All(ctx, &groups); e != nil {
+		return nil, getErrorToReturn(e, "scim group")
+	}
+	return
+}
+
+func CountScimGroups(ctx context.Context, filter bson.M) (count int64, err *errors.Error) {
+	count, e := scimGroupCollection.CountDocuments(ctx, filter)
+	if e != nil {
+		return 0, getErrorToReturn(e, "scim group")
+	}
+	return count, nil
+}
+
+// RemoveScimGroupMember takes the user out of the groups of the organization
+func RemoveScimGroupMember(ctx context.Context, organizationId string, userId string) (err *errors.Error) {
+	update := bson.M{"$pull": bson.M{"member_ids": userId}, "$set": bson.M{"updated_at": timestamppb.Now()}}
+	if _, e := scimGroupCollection.UpdateMany(ctx, bson.M{"organization_id": organizationId, "member_ids": userId}, update); e != nil {
+		return getErrorToReturn(e, "scim group")
+	}
+	return
+}
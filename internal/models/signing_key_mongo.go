@@ -0,0 +1,50 @@
+package models
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (k *SigningKey) Insert(ctx context.Context) (err *errors.Error) {
+	k.CreatedAt = timestamppb.Now()
+
+	e := validator.Struct(k)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := signingKeyCollection.InsertOne(ctx, k)
+	if e != nil {
+		return getErrorToReturn(e, "signing key")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		k.Id = oid.Hex()
+	}
+	return nil
+}
+
+// FindSigningKeys returns the signing keys, newest first
+func FindSigningKeys(ctx context.Context) (keys []*SigningKey, err *errors.Error) {
+	cursor, e := signingKeyCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at.seconds", Value: -1}}))
+	if e != nil {
+		return nil, getErrorToReturn(e, "signing key")
+	}
+	keys = []*SigningKey{}
+	if e = cursor.All(ctx, &keys); e != nil {
+		return nil, getErrorToReturn(e, "signing key")
+	}
+	return
+}
+
+func DeleteSigningKeys(ctx context.Context, kids []string) (err *errors.Error) {
+	_, e := signingKeyCollection.DeleteMany(ctx, bson.M{"kid": bson.M{"$in": kids}})
+	if e != nil {
+		return getErrorToReturn(e, "signing key")
+	}
+	return
+}
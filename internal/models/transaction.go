@@ -0,0 +1,52 @@
+package models
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// transactions need a replica set or a sharded cluster, a standalone server rejects them
+var transactionsSupported bool
+
+func detectTransactionSupport(client *mongo.Client) (bool, error) {
+	var hello bson.M
+	if err := client.Database("admin").RunCommand(context.Background(), bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false, err
+	}
+	_, replicaSet := hello["setName"]
+	return replicaSet || hello["msg"] == "isdbgrid", nil
+}
+
+// SupportsTransactions reports whether RunInTransaction really runs a transaction on this deployment
+func SupportsTransactions() bool {
+	return transactionsSupported
+}
+
+// RunInTransaction calls fn with a context that carries a transaction, the models called with it join the transaction,
+// which is committed when fn succeeds and aborted otherwise
+// on a deployment without transactions fn is called with ctx as is and every write stands on its own
+func RunInTransaction(ctx context.Context, fn func(ctx context.Context) *errors.Error) (err *errors.Error) {
+	if !transactionsSupported {
+		return fn(ctx)
+	}
+	session, e := dbClient.Client().StartSession()
+	if e != nil {
+		return errors.DatabaseError(e)
+	}
+	defer session.EndSession(context.Background())
+	if e = session.StartTransaction(); e != nil {
+		return errors.DatabaseError(e)
+	}
+	sessionCtx := mongo.NewSessionContext(ctx, session)
+	if err = fn(sessionCtx); err != nil {
+		_ = session.AbortTransaction(context.Background())
+		return err
+	}
+	if e = session.CommitTransaction(sessionCtx); e != nil {
+		return errors.DatabaseError(e)
+	}
+	return nil
+}
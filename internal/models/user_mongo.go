@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"time"
 
 	"github.com/MitP1997/golang-user-management/internal/errors"
 	"go.mongodb.org/mongo-driver/bson"
@@ -67,3 +68,64 @@ func updateFilterIdToObjectIdIfExists(filter bson.M) (bson.M, *errors.Error) {
 	}
 	return filter, nil
 }
+
+func FindUsers(ctx context.Context, filter bson.M, opts ...*options.FindOptions) (users []*User, err *errors.Error) {
+	cursor, e := userCollection.Find(ctx, filter, opts...)
+	if e != nil {
+		return nil, getErrorToReturn(e, "user")
+	}
+	users = []*User{}
+	if e = cursor.All(ctx, &users); e != nil {
+		return nil, getErrorToReturn(e, "user")
+	}
+	return
+}
+
+func CountUsers(ctx context.Context, filter bson.M) (count int64, err *errors.Error) {
+	count, e := userCollection.CountDocuments(ctx, filter)
+	if e != nil {
+		return 0, getErrorToReturn(e, "user")
+	}
+	return count, nil
+}
+
+func FindUsersByIds(ctx context.Context, ids []string) (users []*User, err *errors.Error) {
+	objectIds := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		oid, e := primitive.ObjectIDFromHex(id)
+		if e != nil {
+			return nil, errors.IncorrectIdFormatError(e)
+		}
+		objectIds = append(objectIds, oid)
+	}
+	return FindUsers(ctx, bson.M{"_id": bson.M{"$in": objectIds}})
+}
+
+// EachUser streams the users matching the filter to fn without loading them all in memory, it stops at the first error of fn
+func EachUser(ctx context.Context, filter bson.M, fn func(user *User) error) (err *errors.Error) {
+	cursor, e := userCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if e != nil {
+		return getErrorToReturn(e, "user")
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var user User
+		if e = cursor.Decode(&user); e != nil {
+			return getErrorToReturn(e, "user")
+		}
+		if e = fn(&user); e != nil {
+			return errors.InternalServerError(e)
+		}
+	}
+	if e = cursor.Err(); e != nil {
+		return getErrorToReturn(e, "user")
+	}
+	return
+}
+
+// FindExpiredSuspensions returns up to limit suspended users whose suspension expired, oldest expiry first
+func FindExpiredSuspensions(ctx context.Context, limit int64) (users []*User, err *errors.Error) {
+	filter := bson.M{"status": UserStatus_SUSPENDED, "suspension.expires_at.seconds": bson.M{"$lte": time.Now().Unix()}}
+	opts := options.Find().SetSort(bson.D{{Key: "suspension.expires_at.seconds", Value: 1}}).SetLimit(limit)
+	return FindUsers(ctx, filter, opts)
+}
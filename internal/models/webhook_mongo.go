@@ -0,0 +1,178 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (w *WebhookEndpoint) Insert(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	w.CreatedAt = now
+	w.UpdatedAt = now
+
+	e := validator.Struct(w)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := webhookEndpointCollection.InsertOne(ctx, w)
+	if e != nil {
+		return getErrorToReturn(e, "webhook endpoint")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		w.Id = oid.Hex()
+	}
+	return nil
+}
+
+func (w *WebhookEndpoint) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := webhookEndpointCollection.FindOne(ctx, filter).Decode(w)
+	if e != nil {
+		return getErrorToReturn(e, "webhook endpoint")
+	}
+	return
+}
+
+func (w *WebhookEndpoint) Delete(ctx context.Context) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": w.Id})
+	if err != nil {
+		return err
+	}
+	_, e := webhookEndpointCollection.DeleteOne(ctx, filter)
+	if e != nil {
+		return getErrorToReturn(e, "webhook endpoint")
+	}
+	return
+}
+
+func FindWebhookEndpoints(ctx context.Context, filter bson.M) (endpoints []*WebhookEndpoint, err *errors.Error) {
+	cursor, e := webhookEndpointCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at.seconds", Value: 1}}))
+	if e != nil {
+		return nil, getErrorToReturn(e, "webhook endpoint")
+	}
+	endpoints = []*WebhookEndpoint{}
+	if e = cursor.All(ctx, &endpoints); e != nil {
+		return nil, getErrorToReturn(e, "webhook endpoint")
+	}
+	return
+}
+
+func (d *WebhookDelivery) Insert(ctx context.Context) (err *errors.Error) {
+	now := timestamppb.Now()
+	d.CreatedAt = now
+	d.UpdatedAt = now
+
+	e := validator.Struct(d)
+	if e != nil {
+		return errors.ValidationError(e)
+	}
+
+	res, e := webhookDeliveryCollection.InsertOne(ctx, d)
+	if e != nil {
+		return getErrorToReturn(e, "webhook delivery")
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		d.Id = oid.Hex()
+	}
+	return nil
+}
+
+func (d *WebhookDelivery) FindOne(ctx context.Context, filter bson.M) (err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return err
+	}
+	e := webhookDeliveryCollection.FindOne(ctx, filter).Decode(d)
+	if e != nil {
+		return getErrorToReturn(e, "webhook delivery")
+	}
+	return
+}
+
+// RecordAttempt stores the outcome of an attempt and when the delivery is due next
+func (d *WebhookDelivery) RecordAttempt(ctx context.Context, attempt *WebhookAttempt, status WebhookDeliveryStatus, nextAttemptAt time.Time) (err *errors.Error) {
+	filter, err := updateFilterIdToObjectIdIfExists(bson.M{"_id": d.Id})
+	if err != nil {
+		return err
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":           status,
+			"next_attempt_at":  timestamppb.New(nextAttemptAt),
+			"last_status_code": attempt.StatusCode,
+			"last_error":       attempt.Error,
+			"updated_at":       timestamppb.Now(),
+		},
+		"$inc":  bson.M{"attempts": 1},
+		"$push": bson.M{"attempt_history": attempt},
+	}
+	e := webhookDeliveryCollection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(d)
+	if e != nil {
+		return getErrorToReturn(e, "webhook delivery")
+	}
+	return
+}
+
+func FindWebhookDeliveries(ctx context.Context, filter bson.M, opts ...*options.FindOptions) (deliveries []*WebhookDelivery, err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return nil, err
+	}
+	cursor, e := webhookDeliveryCollection.Find(ctx, filter, opts...)
+	if e != nil {
+		return nil, getErrorToReturn(e, "webhook delivery")
+	}
+	deliveries = []*WebhookDelivery{}
+	if e = cursor.All(ctx, &deliveries); e != nil {
+		return nil, getErrorToReturn(e, "webhook delivery")
+	}
+	return
+}
+
+// ClaimWebhookDelivery picks the queued delivery that is due the longest and leases it until leaseUntil
+// by moving its next_attempt_at, so a delivery is sent by a single worker and picked up again if that worker dies
+// when nothing is due the not found error is returned
+func ClaimWebhookDelivery(ctx context.Context, leaseUntil time.Time) (delivery *WebhookDelivery, err *errors.Error) {
+	filter := bson.M{
+		"status":                  WebhookDeliveryStatus_QUEUED,
+		"next_attempt_at.seconds": bson.M{"$lte": time.Now().Unix()},
+	}
+	update := bson.M{"$set": bson.M{"next_attempt_at": timestamppb.New(leaseUntil)}}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "next_attempt_at.seconds", Value: 1}}).SetReturnDocument(options.After)
+	delivery = &WebhookDelivery{}
+	e := webhookDeliveryCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(delivery)
+	if e != nil {
+		return nil, getErrorToReturn(e, "webhook delivery")
+	}
+	return
+}
+
+// ReplayWebhookDeliveries queues the matching deliveries again with a fresh attempt budget, the attempt history is kept
+func ReplayWebhookDeliveries(ctx context.Context, filter bson.M) (count int64, err *errors.Error) {
+	filter, err = updateFilterIdToObjectIdIfExists(filter)
+	if err != nil {
+		return 0, err
+	}
+	now := timestamppb.Now()
+	update := bson.M{"$set": bson.M{
+		"status":          WebhookDeliveryStatus_QUEUED,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"updated_at":      now,
+	}}
+	res, e := webhookDeliveryCollection.UpdateMany(ctx, filter, update)
+	if e != nil {
+		return 0, getErrorToReturn(e, "webhook delivery")
+	}
+	return res.ModifiedCount, nil
+}
@@ -0,0 +1,8 @@
+package oidc
+
+// the unexported helpers under test
+var (
+	SigningKeyIndex   = signingKeyIndex
+	KeyReloadInterval = keyReloadInterval
+	Load              = load
+)
@@ -0,0 +1,168 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	goerrors "errors"
+	"sync"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/go-jose/go-jose/v3"
+	"go.uber.org/zap"
+)
+
+var errNoSigningKey = goerrors.New("no oidc signing key loaded")
+
+var (
+	stop chan struct{}
+	wg   sync.WaitGroup
+)
+
+// Start loads the signing keys, creating the first one on a fresh deployment, and rotates and reloads them
+// in the background until Shutdown
+func Start(logger *zap.Logger) error {
+	if _, err := RotateSigningKeys(context.Background(), false); err != nil {
+		return err.Error()
+	}
+	logger = logger.With(zap.String("source", "oidc"))
+	stop = make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(keyReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := RotateSigningKeys(context.Background(), false); err != nil {
+					logger.Error("Error while rotating oidc signing keys", zap.Error(err.Error()))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func Shutdown(ctx context.Context) error {
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RotateSigningKeys creates a signing key when there is none, the newest is older than the rotation interval
+// or force is set, deletes the keys no unexpired id token can be signed with and loads the rest.
+// It returns the key created, if any.
+func RotateSigningKeys(ctx context.Context, force bool) (created *models.SigningKey, err *errors.Error) {
+	keys, err := models.FindSigningKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if force || len(keys) == 0 || time.Since(keys[0].CreatedAt.AsTime()) >= oidcConfig.KeyRotationInterval {
+		if created, err = newSigningKey(ctx); err != nil {
+			return nil, err
+		}
+		keys = append([]*models.SigningKey{created}, keys...)
+	}
+
+	// a key is kept while the tokens it signed can be unexpired: it signs until its successor was published for
+	// one reload interval and the other instances take up to another interval to reload the keys
+	retention := oidcConfig.IdTokenTtl + 2*keyReloadInterval
+	kept, expired := keys[:1], []string{}
+	for i := 1; i < len(keys); i++ {
+		if time.Since(keys[i-1].CreatedAt.AsTime()) < retention {
+			kept = append(kept, keys[i])
+		} else {
+			expired = append(expired, keys[i].Kid)
+		}
+	}
+	if len(expired) > 0 {
+		if err = models.DeleteSigningKeys(ctx, expired); err != nil {
+			return nil, err
+		}
+	}
+	return created, load(kept, signingKeyIndex(kept, time.Now()))
+}
+
+func newSigningKey(ctx context.Context) (*models.SigningKey, *errors.Error) {
+	privateKey, e := rsa.GenerateKey(rand.Reader, keyBits)
+	if e != nil {
+		return nil, errors.InternalServerError(e)
+	}
+	der, e := x509.MarshalPKCS8PrivateKey(privateKey)
+	if e != nil {
+		return nil, errors.InternalServerError(e)
+	}
+	kid := make([]byte, 8)
+	if _, e = rand.Read(kid); e != nil {
+		return nil, errors.InternalServerError(e)
+	}
+	key := &models.SigningKey{
+		Kid:        hex.EncodeToString(kid),
+		Algorithm:  SigningAlgorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	}
+	if err := key.Insert(ctx); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// signingKeyIndex picks the newest key published for at least one reload interval, so that every instance serves
+// it in the jwks before tokens signed with it reach the relying parties. The first key of a deployment signs right
+// away as there is no other one.
+func signingKeyIndex(keys []*models.SigningKey, now time.Time) int {
+	for i, key := range keys {
+		if now.Sub(key.CreatedAt.AsTime()) >= keyReloadInterval {
+			return i
+		}
+	}
+	return len(keys) - 1
+}
+
+// load makes the key at signingIndex the one signing and publishes all of them
+func load(keys []*models.SigningKey, signingIndex int) *errors.Error {
+	jwks := jose.JSONWebKeySet{}
+	var signing *jose.JSONWebKey
+	for i, key := range keys {
+		block, _ := pem.Decode([]byte(key.PrivateKey))
+		if block == nil {
+			return errors.InternalServerError(goerrors.New("signing key " + key.Kid + " is not pem encoded"))
+		}
+		parsed, e := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if e != nil {
+			return errors.InternalServerError(e)
+		}
+		privateKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return errors.InternalServerError(goerrors.New("signing key " + key.Kid + " is not an rsa key"))
+		}
+		if i == signingIndex {
+			signing = &jose.JSONWebKey{Key: privateKey, KeyID: key.Kid, Algorithm: key.Algorithm, Use: "sig"}
+		}
+		jwks.Keys = append(jwks.Keys, jose.JSONWebKey{Key: &privateKey.PublicKey, KeyID: key.Kid, Algorithm: key.Algorithm, Use: "sig"})
+	}
+	mu.Lock()
+	signingKey, publicKeys = signing, jwks
+	mu.Unlock()
+	return nil
+}
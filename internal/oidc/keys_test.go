@@ -0,0 +1,94 @@
+package oidc_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/oidc"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSigningKeyIndex(t *testing.T) {
+	now := time.Now()
+	key := func(age time.Duration) *models.SigningKey {
+		return &models.SigningKey{CreatedAt: timestamppb.New(now.Add(-age))}
+	}
+	tests := []struct {
+		name string
+		keys []*models.SigningKey
+		want int
+	}{
+		{"first key of a deployment", []*models.SigningKey{key(0)}, 0},
+		{"published for a reload interval", []*models.SigningKey{key(oidc.KeyReloadInterval), key(time.Hour)}, 0},
+		{"new key not published long enough", []*models.SigningKey{key(oidc.KeyReloadInterval / 2), key(time.Hour)}, 1},
+		{"two keys not published long enough", []*models.SigningKey{key(0), key(oidc.KeyReloadInterval / 2), key(time.Hour)}, 2},
+		{"no key published long enough", []*models.SigningKey{key(0), key(oidc.KeyReloadInterval / 2)}, 1},
+	}
+	for _, test := range tests {
+		if got := oidc.SigningKeyIndex(test.keys, now); got != test.want {
+			t.Errorf("%s: signingKeyIndex = %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSignIdToken(t *testing.T) {
+	oidc.Configure(config.OidcConfig{Issuer: "https://auth.example.com", IdTokenTtl: time.Hour}, "https://app.example.com")
+	now := time.Now()
+	keys := []*models.SigningKey{newSigningKey(t, "new", now), newSigningKey(t, "current", now.Add(-time.Hour))}
+	if e := oidc.Load(keys, oidc.SigningKeyIndex(keys, now)); e != nil {
+		t.Fatal(e.Error())
+	}
+	jwks := oidc.PublicKeys()
+	if len(jwks.Keys) != 2 || len(jwks.Key("new")) != 1 || len(jwks.Key("current")) != 1 {
+		t.Fatalf("jwks publishes %v, want both keys", jwks.Keys)
+	}
+	for _, key := range jwks.Keys {
+		if !key.IsPublic() {
+			t.Fatalf("jwks publishes the private key %s", key.KeyID)
+		}
+	}
+
+	claims := oidc.IdTokenClaims{Issuer: oidc.Issuer(), Subject: "user-1", Audience: "client-1", IssuedAt: now.Unix(), Expiry: now.Add(time.Hour).Unix(), Nonce: "n-0S6"}
+	signed, err := oidc.SignIdToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.ParseSigned(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := token.Headers[0].KeyID; kid != "current" {
+		t.Fatalf("signed with %s, want the key published for a reload interval", kid)
+	}
+	var got oidc.IdTokenClaims
+	if err = token.Claims(jwks.Key("current")[0].Key, &got); err != nil {
+		t.Fatalf("id token does not verify with the published key: %s", err)
+	}
+	if got != claims {
+		t.Errorf("claims = %+v, want %+v", got, claims)
+	}
+}
+
+func newSigningKey(t *testing.T, kid string, createdAt time.Time) *models.SigningKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.SigningKey{
+		Kid:        kid,
+		Algorithm:  oidc.SigningAlgorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		CreatedAt:  timestamppb.New(createdAt),
+	}
+}
@@ -0,0 +1,105 @@
+// Package oidc is the OpenID Connect layer on top of the oauth authorization server. It signs the id tokens
+// with RSA keys kept in Mongo, so every instance signs with the same newest key and publishes the same jwks,
+// and rotates the keys in the background.
+package oidc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+const (
+	SigningAlgorithm = string(jose.RS256)
+	keyBits          = 2048
+	// how often the instances reload the keys to pick up the rotations of the others
+	keyReloadInterval = time.Minute
+)
+
+var (
+	oidcConfig config.OidcConfig
+	appBaseUrl string
+
+	mu         sync.RWMutex
+	signingKey *jose.JSONWebKey
+	publicKeys jose.JSONWebKeySet
+)
+
+// Configure sets the issuer and key lifetimes, Start must run before id tokens can be signed
+func Configure(cfg config.OidcConfig, baseUrl string) {
+	oidcConfig = cfg
+	appBaseUrl = baseUrl
+}
+
+// Issuer is the iss of the id tokens
+func Issuer() string {
+	return oidcConfig.Issuer
+}
+
+func IdTokenTtl() time.Duration {
+	return oidcConfig.IdTokenTtl
+}
+
+// IdTokenClaims are the claims of an id token, the profile and email ones are only set with their scope
+type IdTokenClaims struct {
+	Issuer        string `json:"iss"`
+	Subject       string `json:"sub"`
+	Audience      string `json:"aud"`
+	Expiry        int64  `json:"exp"`
+	IssuedAt      int64  `json:"iat"`
+	Nonce         string `json:"nonce,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+}
+
+// SignIdToken signs the claims with the newest signing key
+func SignIdToken(claims IdTokenClaims) (string, error) {
+	mu.RLock()
+	key := signingKey
+	mu.RUnlock()
+	if key == nil {
+		return "", errNoSigningKey
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+	return jwt.Signed(signer).Claims(claims).CompactSerialize()
+}
+
+// PublicKeys is the jwks of the keys id tokens may still be signed with
+func PublicKeys() jose.JSONWebKeySet {
+	mu.RLock()
+	defer mu.RUnlock()
+	return publicKeys
+}
+
+// Discovery is the document served at /.well-known/openid-configuration
+func Discovery(scopes []string, claims []string) map[string]interface{} {
+	issuer := Issuer()
+	return map[string]interface{}{
+		"issuer": issuer,
+		// the frontend shows the consent screen, see GetOAuthAuthorization
+		"authorization_endpoint":                appBaseUrl + "/oauth/authorize",
+		"token_endpoint":                        issuer + "/api/v1/oauth/token",
+		"userinfo_endpoint":                     issuer + "/api/v1/oauth/userinfo",
+		"introspection_endpoint":                issuer + "/api/v1/oauth/introspect",
+		"revocation_endpoint":                   issuer + "/api/v1/oauth/revoke",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"scopes_supported":                      scopes,
+		"claims_supported":                      claims,
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{SigningAlgorithm},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		// the redirect of the authorization response carries iss, see RFC 9207
+		"authorization_response_iss_parameter_supported": true,
+	}
+}
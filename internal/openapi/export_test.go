@@ -0,0 +1,28 @@
+package openapi
+
+// Requirements is what the document declares a route requires of a request
+type Requirements struct {
+	Authorized   bool
+	Scope        string
+	RecentAuth   bool
+	Admin        bool
+	Organization bool
+	Scim         bool
+}
+
+// DocumentedRequirements finds the route of the gin route in routes
+func DocumentedRequirements(method string, ginPath string) (Requirements, bool) {
+	for _, r := range routes {
+		if r.method == method && r.fullPath() == ginPath {
+			return Requirements{
+				Authorized:   r.authorized,
+				Scope:        r.scope,
+				RecentAuth:   r.recentAuth,
+				Admin:        r.admin,
+				Organization: r.organization,
+				Scim:         r.scim,
+			}, true
+		}
+	}
+	return Requirements{}, false
+}
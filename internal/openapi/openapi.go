@@ -0,0 +1,740 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/requests"
+	"github.com/MitP1997/golang-user-management/internal/responses"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	basePath          = "/api/v1"
+	authSecurityName  = "authToken"
+	oauthSecurityName = "oauthAccessToken"
+	errorEnvelopeName = "ErrorEnvelope"
+	oauthErrorName    = "OAuthError"
+	scimSecurityName  = "scimToken"
+	scimErrorName     = "ScimError"
+	scimUserName      = "ScimUser"
+	scimGroupName     = "ScimGroup"
+)
+
+// route describes one gin route of the API
+// every route registered in the router must be listed here with the requirements of its decorators, openapi_test.go
+// enforces it
+type route struct {
+	method  string
+	path    string
+	tag     string
+	summary string
+	// added in front of the requirements in the description
+	description string
+	// wrapped with IsAuthorized
+	authorized bool
+	// acts on the organization picked with the organization header
+	organization bool
+	// the scope the token needs, declared with RequireScope
+	scope string
+	// wrapped with RequireRecentAuth
+	recentAuth bool
+	// limited to platform admins with RequireAdmin
+	admin   bool
+	query   []Parameter
+	request proto.Message
+	// a form encoded request body, for the oauth endpoints
+	requestForm *Schema
+	// a proto.Message or a *Schema
+	response    interface{}
+	contentType string
+	// errors are answered with the error body of RFC 6749 instead of the envelope
+	oauthErrors bool
+	// needs an oauth access token instead of the auth token
+	oauthBearer bool
+	// a SCIM endpoint: authenticated with a SCIM token, bodies are application/scim+json and errors are answered
+	// as in RFC 7644
+	scim bool
+	// a json request body that is no proto message, for the SCIM endpoints
+	requestSchema *Schema
+	// the status of a success when it is not 200, 204 has no body
+	status int
+	// the browser navigates to the route and is redirected, also on errors, this describes where to
+	redirect string
+	// served at the root of the server and not below the versioned api
+	root bool
+}
+
+var routes = []route{
+	{method: http.MethodGet, path: "/openapi.json", tag: "docs", summary: "This OpenAPI document", response: &Schema{Type: "object"}},
+	{method: http.MethodGet, path: "/docs", tag: "docs", summary: "Swagger UI for this OpenAPI document", response: stringSchema(), contentType: "text/html"},
+
+	{method: http.MethodGet, path: "/user/signup-form-fields", tag: "user", summary: "Fields of the signup form for the configured signup mode", response: object(map[string]*Schema{
+		"mode": {Type: "string", Enum: []interface{}{
+			string(constants.SignupModeOpen), string(constants.SignupModeInviteOnly), string(constants.SignupModeDomainAllowlist), string(constants.SignupModeClosed),
+		}},
+		"fields": {Type: "object", AdditionalProperties: object(map[string]*Schema{
+			"form_field_type": stringSchema(),
+			"display_name":    stringSchema(),
+		})},
+		"required_fields": arrayOf(stringSchema()),
+		"allowed_domains": arrayOf(stringSchema()),
+	})},
+	{method: http.MethodPost, path: "/user/signup", tag: "user", summary: "Sign up and log in", organization: true, request: &requests.SignupRequest{}, response: &responses.AuthTokenResponse{}},
+	{method: http.MethodPost, path: "/user/login", tag: "user", summary: "Log in with email and password", organization: true, request: &requests.LoginRequest{}, response: &responses.AuthTokenResponse{}},
+	{method: http.MethodPost, path: "/user/verify-email", tag: "user", summary: "Verify the email with the emailed OTP", authorized: true, scope: constants.ScopeProfileWrite, request: &requests.VerifyEmailRequest{}, response: &responses.MessageResponse{}},
+	{method: http.MethodPost, path: "/user/resend-email-verification-otp", tag: "user", summary: "Send the email verification OTP again", authorized: true, scope: constants.ScopeProfileWrite, response: &responses.MessageResponse{}},
+	{method: http.MethodGet, path: "/user/pending-requirements", tag: "user", summary: "Steps the user still has to complete", authorized: true, scope: constants.ScopeProfileRead, response: &responses.PendingRequirementsResponse{}},
+	{method: http.MethodPost, path: "/user/change-password-initiate", tag: "user", summary: "Email an OTP to change the password of the logged in user", authorized: true, scope: constants.ScopeAccountWrite, response: &responses.MessageResponse{}},
+	{method: http.MethodPost, path: "/user/change-password", tag: "user", summary: "Change the password of the logged in user with the emailed OTP", authorized: true, scope: constants.ScopeAccountWrite, request: &requests.ChangePasswordRequest{}, response: &responses.MessageResponse{}},
+	{method: http.MethodPost, path: "/user/reset-password-initiate", tag: "user", summary: "Email an OTP to reset a forgotten password, without a token", description: resetPasswordDescription, organization: true, request: &requests.ResetPasswordInitiateRequest{}, response: &responses.MessageResponse{}},
+	{method: http.MethodPost, path: "/user/reset-password", tag: "user", summary: "Reset the password with the emailed OTP, without a token", description: resetPasswordDescription, organization: true, request: &requests.ResetPasswordRequest{}, response: &responses.MessageResponse{}},
+	{method: http.MethodGet, path: "/user/me", tag: "user", summary: "The logged in user, with the admin and expiry while an admin impersonates them", authorized: true, scope: constants.ScopeProfileRead, response: &responses.MeResponse{}},
+	{method: http.MethodDelete, path: "/user/impersonation", tag: "user", summary: "End the impersonation of the impersonation token of the request", authorized: true, scope: constants.ScopeProfileRead, response: messageSchema()},
+	{method: http.MethodPost, path: "/user/reauthenticate", tag: "user", summary: "Confirm the password of the logged in user before a sensitive action", authorized: true, scope: constants.ScopeAccountWrite, request: &requests.ReauthenticateRequest{}, response: &responses.MessageResponse{}},
+	{method: http.MethodDelete, path: "/user/me", tag: "user", summary: "Delete the account and log out everywhere", authorized: true, scope: constants.ScopeAccountWrite, recentAuth: true, response: &responses.MessageResponse{}},
+	{method: http.MethodGet, path: "/user/me/devices", tag: "user", summary: "Devices the user logged in from, most recently seen first", authorized: true, scope: constants.ScopeProfileRead, response: object(map[string]*Schema{
+		"devices": arrayOf(&Schema{Ref: schemaRefPrefix + "Device"}),
+	})},
+	{method: http.MethodDelete, path: "/user/me/devices/:device_id", tag: "user", summary: "Forget a device, the next login from it sends a login alert", authorized: true, scope: constants.ScopeAccountWrite, response: messageSchema()},
+	{method: http.MethodGet, path: "/user/me/identities", tag: "social", summary: "Accounts at social login providers linked to the user, and whether the user has a password", authorized: true, scope: constants.ScopeProfileRead, response: object(map[string]*Schema{
+		"identities":   arrayOf(&Schema{Ref: schemaRefPrefix + "LinkedIdentity"}),
+		"has_password": {Type: "boolean"},
+	})},
+	{method: http.MethodPost, path: "/user/me/identities", tag: "social", summary: "Get the url that links an account at a provider, or with the saml provider the identity provider of the selected organization. The callback redirects to the frontend with ?linked=<provider>", authorized: true, scope: constants.ScopeAccountWrite, recentAuth: true, request: &requests.LinkIdentityRequest{}, response: object(map[string]*Schema{
+		"redirect_to": stringFormat("uri"),
+	})},
+	{method: http.MethodDelete, path: "/user/me/identities/:identity_id", tag: "social", summary: "Unlink an account at a provider, refused when it is the only way to log in", authorized: true, scope: constants.ScopeAccountWrite, recentAuth: true, response: messageSchema()},
+	{method: http.MethodPost, path: "/user/tokens", tag: "user", summary: "Create a personal access token for scripts and CI jobs, the token is only returned here", authorized: true, scope: constants.ScopeAccountWrite, recentAuth: true, request: &requests.CreatePersonalAccessTokenRequest{}, response: object(map[string]*Schema{
+		"message":               stringSchema(),
+		"token":                 stringSchema(),
+		"personal_access_token": {Ref: schemaRefPrefix + "PersonalAccessToken"},
+	})},
+	{method: http.MethodGet, path: "/user/tokens", tag: "user", summary: "The personal access tokens of the user, oldest first", authorized: true, scope: constants.ScopeProfileRead, response: object(map[string]*Schema{
+		"personal_access_tokens": arrayOf(&Schema{Ref: schemaRefPrefix + "PersonalAccessToken"}),
+	})},
+	{method: http.MethodDelete, path: "/user/tokens/:token_id", tag: "user", summary: "Revoke a personal access token", authorized: true, scope: constants.ScopeAccountWrite, response: messageSchema()},
+	{method: http.MethodPost, path: "/user/secure-account", tag: "user", summary: "Secure the account from the \"this wasn't me\" link of a login alert: log out everywhere and require a password reset", request: &requests.SecureAccountRequest{}, response: &responses.MessageResponse{}},
+	{method: http.MethodGet, path: "/user/me/activity", tag: "user", summary: "Security activity on the account, newest first", authorized: true, scope: constants.ScopeProfileRead, query: paginationParameters(200), response: object(map[string]*Schema{
+		"entries": arrayOf(&Schema{Ref: schemaRefPrefix + "AuditEntry"}),
+	})},
+
+	{method: http.MethodPost, path: "/org", tag: "organization", summary: "Create an organization owned by the user", authorized: true, scope: constants.ScopeOrganizationWrite, request: &requests.CreateOrganizationRequest{}, response: object(map[string]*Schema{
+		"message":      stringSchema(),
+		"organization": {Ref: schemaRefPrefix + "Organization"},
+	})},
+	{method: http.MethodGet, path: "/org/members", tag: "organization", summary: "List the members of the organization", authorized: true, scope: constants.ScopeOrganizationRead, organization: true, response: object(map[string]*Schema{
+		"members": arrayOf(object(map[string]*Schema{
+			"user_id":     stringSchema(),
+			"email":       stringFormat("email"),
+			"given_name":  stringSchema(),
+			"family_name": stringSchema(),
+			"role":        {Type: "string", Enum: []interface{}{"MEMBER", "ADMIN", "OWNER"}},
+			"joined_at":   stringFormat("date-time"),
+		})),
+	})},
+	{method: http.MethodDelete, path: "/org/members/:user_id", tag: "organization", summary: "Remove a member, or leave the organization", authorized: true, scope: constants.ScopeOrganizationWrite, organization: true, response: messageSchema()},
+	{method: http.MethodPost, path: "/org/invitations", tag: "organization", summary: "Invite someone to the organization by email", authorized: true, scope: constants.ScopeOrganizationWrite, organization: true, request: &requests.InviteOrganizationMemberRequest{}, response: object(map[string]*Schema{
+		"message":       stringSchema(),
+		"invitation_id": stringSchema(),
+	})},
+	{method: http.MethodPost, path: "/org/invitations/accept", tag: "organization", summary: "Accept an invitation sent to the user's email", authorized: true, scope: constants.ScopeOrganizationWrite, request: &requests.AcceptOrganizationInvitationRequest{}, response: object(map[string]*Schema{
+		"message":         stringSchema(),
+		"organization_id": stringSchema(),
+	})},
+	{method: http.MethodGet, path: "/org/saml", tag: "saml", summary: "The SAML identity provider of the organization, for admins, null when none is set up, and the service provider details to register at it", authorized: true, scope: constants.ScopeOrganizationRead, organization: true, response: object(map[string]*Schema{
+		"connection": {Ref: schemaRefPrefix + "SamlConnection"},
+		"service_provider": object(map[string]*Schema{
+			"entity_id":    stringFormat("uri"),
+			"acs_url":      stringFormat("uri"),
+			"metadata_url": stringFormat("uri"),
+			"login_url":    {Type: "string", Format: "uri", Description: "Navigate the browser here to log in through the identity provider"},
+		}),
+	})},
+	{method: http.MethodPut, path: "/org/saml", tag: "saml", summary: "Set up or replace the SAML identity provider of the organization from its metadata, for admins", authorized: true, scope: constants.ScopeOrganizationWrite, organization: true, request: &requests.SamlConnectionRequest{}, response: object(map[string]*Schema{
+		"message":    stringSchema(),
+		"connection": {Ref: schemaRefPrefix + "SamlConnection"},
+	})},
+	{method: http.MethodDelete, path: "/org/saml", tag: "saml", summary: "Turn off SAML single sign-on for the organization, for admins, the users it provisioned stay", authorized: true, scope: constants.ScopeOrganizationWrite, organization: true, response: messageSchema()},
+	{method: http.MethodPost, path: "/org/scim/tokens", tag: "scim", summary: "Issue a SCIM token for the identity provider of the organization, for admins, the token is only returned here", authorized: true, scope: constants.ScopeOrganizationWrite, recentAuth: true, organization: true, request: &requests.CreateScimTokenRequest{}, response: object(map[string]*Schema{
+		"message":    stringSchema(),
+		"token":      stringSchema(),
+		"scim_token": {Ref: schemaRefPrefix + "ScimToken"},
+	})},
+	{method: http.MethodGet, path: "/org/scim/tokens", tag: "scim", summary: "The SCIM tokens of the organization, for admins", authorized: true, scope: constants.ScopeOrganizationRead, organization: true, response: object(map[string]*Schema{
+		"scim_tokens": arrayOf(&Schema{Ref: schemaRefPrefix + "ScimToken"}),
+	})},
+	{method: http.MethodDelete, path: "/org/scim/tokens/:token_id", tag: "scim", summary: "Revoke a SCIM token of the organization, for admins", authorized: true, scope: constants.ScopeOrganizationWrite, organization: true, response: messageSchema()},
+
+	{method: http.MethodGet, path: "/admin/audit", tag: "admin", summary: "Query the audit log, newest first", authorized: true, scope: constants.ScopeAdmin, admin: true, query: append([]Parameter{
+		{Name: "actor_id", In: "query", Schema: stringSchema()},
+		{Name: "target_user_id", In: "query", Schema: stringSchema()},
+		{Name: "action", In: "query", Description: "the event name, e.g. user.login_failed", Schema: stringSchema()},
+		{Name: "outcome", In: "query", Schema: &Schema{Type: "string", Enum: []interface{}{constants.AuditOutcomeSuccess, constants.AuditOutcomeFailure}}},
+		{Name: "from", In: "query", Description: "entries recorded at or after this time", Schema: stringFormat("date-time")},
+		{Name: "to", In: "query", Description: "entries recorded before this time", Schema: stringFormat("date-time")},
+	}, paginationParameters(200)...), response: object(map[string]*Schema{
+		"entries": arrayOf(&Schema{Ref: schemaRefPrefix + "AuditEntry"}),
+	})},
+	{method: http.MethodPost, path: "/admin/users/:user_id/impersonate", tag: "admin", summary: "Get a token that acts as the user for support, it can only read and every request with it is audited", authorized: true, scope: constants.ScopeAdmin, recentAuth: true, admin: true, request: &requests.ImpersonateUserRequest{}, response: object(map[string]*Schema{
+		"message":    stringSchema(),
+		"token":      stringSchema(),
+		"expires_at": stringFormat("date-time"),
+	})},
+	{method: http.MethodPost, path: "/admin/users/:user_id/suspend", tag: "admin", summary: "Suspend the user and log them out everywhere, until reinstated or for expires_in_hours", authorized: true, scope: constants.ScopeAdmin, recentAuth: true, admin: true, request: &requests.SuspendUserRequest{}, response: object(map[string]*Schema{
+		"message":    stringSchema(),
+		"status":     stringSchema(),
+		"expires_at": stringFormat("date-time"),
+	})},
+	{method: http.MethodPost, path: "/admin/users/:user_id/ban", tag: "admin", summary: "Ban the user and log them out everywhere, until reinstated", authorized: true, scope: constants.ScopeAdmin, recentAuth: true, admin: true, request: &requests.BanUserRequest{}, response: object(map[string]*Schema{
+		"message": stringSchema(),
+		"status":  stringSchema(),
+	})},
+	{method: http.MethodPost, path: "/admin/users/:user_id/reinstate", tag: "admin", summary: "Lift the suspension or ban of the user, who gets back the status they had before", authorized: true, scope: constants.ScopeAdmin, recentAuth: true, admin: true, response: object(map[string]*Schema{
+		"message": stringSchema(),
+		"status":  stringSchema(),
+	})},
+	{method: http.MethodPost, path: "/admin/invitation-codes", tag: "admin", summary: "Create a code that lets people sign up in invite_only mode, generated when code is empty", authorized: true, scope: constants.ScopeAdmin, admin: true, request: &requests.CreateInvitationCodeRequest{}, response: object(map[string]*Schema{
+		"message":         stringSchema(),
+		"invitation_code": {Ref: schemaRefPrefix + "InvitationCode"},
+	})},
+	{method: http.MethodPost, path: "/admin/webhooks", tag: "admin", summary: "Register a webhook endpoint, the signing secret is only returned here", authorized: true, scope: constants.ScopeAdmin, admin: true, request: &requests.CreateWebhookEndpointRequest{}, response: object(map[string]*Schema{
+		"message":  stringSchema(),
+		"endpoint": webhookEndpointSchema(),
+		"secret":   stringSchema(),
+	})},
+	{method: http.MethodGet, path: "/admin/webhooks", tag: "admin", summary: "List the webhook endpoints", authorized: true, scope: constants.ScopeAdmin, admin: true, response: object(map[string]*Schema{
+		"endpoints": arrayOf(webhookEndpointSchema()),
+	})},
+	{method: http.MethodDelete, path: "/admin/webhooks/:endpoint_id", tag: "admin", summary: "Delete a webhook endpoint", authorized: true, scope: constants.ScopeAdmin, admin: true, response: messageSchema()},
+	{method: http.MethodPost, path: "/admin/webhooks/:endpoint_id/replay-failed", tag: "admin", summary: "Queue the failed deliveries of an endpoint again", authorized: true, scope: constants.ScopeAdmin, admin: true, response: object(map[string]*Schema{
+		"message":  stringSchema(),
+		"replayed": {Type: "integer", Format: "int64"},
+	})},
+	{method: http.MethodGet, path: "/admin/webhooks/deliveries", tag: "admin", summary: "List webhook deliveries with their attempts, newest first", authorized: true, scope: constants.ScopeAdmin, admin: true, query: append([]Parameter{
+		{Name: "status", In: "query", Schema: &Schema{Type: "string", Enum: []interface{}{"queued", "succeeded", "failed"}}},
+		{Name: "endpoint_id", In: "query", Schema: stringSchema()},
+		{Name: "event_type", In: "query", Schema: &Schema{Type: "string", Enum: stringValues(constants.WebhookEventTypes)}},
+	}, paginationParameters(200)...), response: object(map[string]*Schema{
+		"deliveries": arrayOf(&Schema{Ref: schemaRefPrefix + "WebhookDelivery"}),
+	})},
+	{method: http.MethodPost, path: "/admin/webhooks/deliveries/:delivery_id/replay", tag: "admin", summary: "Send a delivery again with the same payload", authorized: true, scope: constants.ScopeAdmin, admin: true, response: messageSchema()},
+	{method: http.MethodPost, path: "/admin/oauth/clients", tag: "admin", summary: "Register an oauth client, the secret of a confidential client is only returned here", authorized: true, scope: constants.ScopeAdmin, admin: true, request: &requests.CreateOAuthClientRequest{}, response: object(map[string]*Schema{
+		"message":       stringSchema(),
+		"client":        oauthClientSchema(),
+		"client_secret": stringSchema(),
+	})},
+	{method: http.MethodGet, path: "/admin/oauth/clients", tag: "admin", summary: "List the oauth clients", authorized: true, scope: constants.ScopeAdmin, admin: true, response: object(map[string]*Schema{
+		"clients": arrayOf(oauthClientSchema()),
+	})},
+	{method: http.MethodDelete, path: "/admin/oauth/clients/:client_id", tag: "admin", summary: "Delete an oauth client and the consents granted to it", authorized: true, scope: constants.ScopeAdmin, admin: true, response: messageSchema()},
+
+	{method: http.MethodGet, path: "/oauth/authorize", tag: "oauth", summary: "Validate an authorization request and get what the consent screen shows", authorized: true, scope: constants.ScopeAccountWrite, query: []Parameter{
+		{Name: "response_type", In: "query", Required: true, Schema: &Schema{Type: "string", Enum: []interface{}{constants.OAuthResponseTypeCode}}},
+		{Name: "client_id", In: "query", Required: true, Schema: stringSchema()},
+		{Name: "redirect_uri", In: "query", Required: true, Description: "one of the redirect uris of the client, compared exactly", Schema: stringFormat("uri")},
+		{Name: "scope", In: "query", Description: "space separated, all the scopes of the client by default", Schema: stringSchema()},
+		{Name: "state", In: "query", Schema: stringSchema()},
+		{Name: "code_challenge", In: "query", Required: true, Schema: stringSchema()},
+		{Name: "code_challenge_method", In: "query", Required: true, Schema: &Schema{Type: "string", Enum: []interface{}{constants.OAuthCodeChallengeMethodS256}}},
+		{Name: "nonce", In: "query", Description: "copied into the id token", Schema: stringSchema()},
+	}, response: object(map[string]*Schema{
+		"client": object(map[string]*Schema{
+			"client_id":   stringSchema(),
+			"name":        stringSchema(),
+			"first_party": {Type: "boolean"},
+		}),
+		"scopes": arrayOf(object(map[string]*Schema{
+			"name":        stringSchema(),
+			"description": stringSchema(),
+		})),
+		"consent_required": {Type: "boolean", Description: "false when the client is first party or the user already granted the scopes"},
+	})},
+	{method: http.MethodPost, path: "/oauth/authorize", tag: "oauth", summary: "Approve or deny an authorization request and get the redirect back to the client", authorized: true, scope: constants.ScopeAccountWrite, request: &requests.OAuthAuthorizeRequest{}, response: object(map[string]*Schema{
+		"redirect_to": stringFormat("uri"),
+	})},
+	{method: http.MethodPost, path: "/oauth/token", tag: "oauth", summary: "Exchange an authorization code or a refresh token for tokens", oauthErrors: true, requestForm: object(map[string]*Schema{
+		"grant_type":    {Type: "string", Enum: []interface{}{constants.OAuthGrantTypeAuthorizationCode, constants.OAuthGrantTypeRefreshToken}},
+		"code":          stringSchema(),
+		"code_verifier": stringSchema(),
+		"redirect_uri":  stringFormat("uri"),
+		"refresh_token": stringSchema(),
+		"scope":         {Type: "string", Description: "refresh only, fewer scopes than the refresh token for the access token, the new refresh token keeps all of them"},
+		"client_id":     {Type: "string", Description: "unless sent with basic auth"},
+		"client_secret": {Type: "string", Description: "confidential clients, unless sent with basic auth"},
+	}), response: object(map[string]*Schema{
+		"access_token":  stringSchema(),
+		"token_type":    stringSchema(),
+		"expires_in":    {Type: "integer"},
+		"refresh_token": {Type: "string", Description: "only with the offline_access scope"},
+		"id_token":      {Type: "string", Description: "only with the openid scope, verify it with the keys at /.well-known/jwks.json"},
+		"scope":         stringSchema(),
+	})},
+	{method: http.MethodPost, path: "/oauth/introspect", tag: "oauth", summary: "Introspect a token (RFC 7662), limited to confidential clients", oauthErrors: true, requestForm: oauthTokenFormSchema(), response: object(map[string]*Schema{
+		"active":     {Type: "boolean"},
+		"scope":      stringSchema(),
+		"client_id":  stringSchema(),
+		"sub":        stringSchema(),
+		"token_type": stringSchema(),
+		"iat":        {Type: "integer", Format: "int64"},
+		"exp":        {Type: "integer", Format: "int64"},
+	})},
+	{method: http.MethodGet, path: "/oauth/userinfo", tag: "oauth", summary: "Claims of the user of an access token with the openid scope", oauthErrors: true, oauthBearer: true, response: userInfoSchema()},
+	{method: http.MethodPost, path: "/oauth/userinfo", tag: "oauth", summary: "Claims of the user of an access token with the openid scope", oauthErrors: true, oauthBearer: true, response: userInfoSchema()},
+	{method: http.MethodPost, path: "/oauth/revoke", tag: "oauth", summary: "Revoke a token of the client (RFC 7009)", oauthErrors: true, requestForm: oauthTokenFormSchema(), response: messageSchema()},
+
+	{method: http.MethodGet, path: "/social/providers", tag: "social", summary: "List the social login providers", response: object(map[string]*Schema{
+		"providers": arrayOf(object(map[string]*Schema{
+			"name":         stringSchema(),
+			"display_name": stringSchema(),
+			"login_url":    {Type: "string", Format: "uri", Description: "Navigate the browser here to log in with the provider"},
+		})),
+	})},
+	{method: http.MethodGet, path: "/social/providers/:provider/login", tag: "social", summary: "Start a login with the provider",
+		query: []Parameter{
+			{Name: "link_token", In: "query", Description: "from POST /user/me/identities, links the account to that user instead of logging in", Schema: stringSchema()},
+		},
+		redirect: "To the provider, or to the social login page of the frontend with `error` set to the error code"},
+	{method: http.MethodGet, path: "/social/callback", tag: "social", summary: "Redirect uri of the providers, register it as the oidc issuer followed by " + constants.SocialLoginCallbackPath,
+		redirect: "To the social login page of the frontend with a single use `code` for /social/exchange, or with `error` set to the error code"},
+	{method: http.MethodPost, path: "/social/exchange", tag: "social", summary: "Log in with the code of the social login callback, signing up or linking the user when needed", organization: true, request: &requests.SocialLoginExchangeRequest{}, response: &responses.AuthTokenResponse{}},
+
+	{method: http.MethodGet, path: "/saml/:organization_id/metadata", tag: "saml", summary: "SAML service provider metadata of the organization, its url is the entity id", response: stringSchema(), contentType: "application/samlmetadata+xml"},
+	{method: http.MethodGet, path: "/saml/:organization_id/login", tag: "saml", summary: "Start a login through the SAML identity provider of the organization",
+		query: []Parameter{
+			{Name: "link_token", In: "query", Description: "from POST /user/me/identities with the saml provider, links the name id to that user instead of logging in", Schema: stringSchema()},
+		},
+		redirect: "To the identity provider with a signed authentication request, or to the SAML login page of the frontend with `error` set to the error code"},
+	{method: http.MethodPost, path: "/saml/:organization_id/acs", tag: "saml", summary: "Assertion consumer service the identity provider posts its response to", requestForm: object(map[string]*Schema{
+		"SAMLResponse": stringSchema(),
+		"RelayState":   stringSchema(),
+	}),
+		redirect: "To the SAML login page of the frontend with a single use `code` for /saml/exchange, with `linked` set to the provider after a link, or with `error` set to the error code"},
+	{method: http.MethodPost, path: "/saml/exchange", tag: "saml", summary: "Log in with the code of the assertion consumer service, in the organization of the identity provider", request: &requests.SamlLoginExchangeRequest{}, response: &responses.AuthTokenResponse{}},
+
+	{method: http.MethodGet, path: "/scim/v2/ServiceProviderConfig", tag: "scim", summary: "The SCIM features supported", scim: true, response: object(map[string]*Schema{
+		"schemas":               arrayOf(stringSchema()),
+		"patch":                 object(map[string]*Schema{"supported": {Type: "boolean"}}),
+		"bulk":                  object(map[string]*Schema{"supported": {Type: "boolean"}, "maxOperations": {Type: "integer"}, "maxPayloadSize": {Type: "integer"}}),
+		"filter":                object(map[string]*Schema{"supported": {Type: "boolean"}, "maxResults": {Type: "integer"}}),
+		"changePassword":        object(map[string]*Schema{"supported": {Type: "boolean"}}),
+		"sort":                  object(map[string]*Schema{"supported": {Type: "boolean"}}),
+		"etag":                  object(map[string]*Schema{"supported": {Type: "boolean"}}),
+		"authenticationSchemes": arrayOf(object(map[string]*Schema{"type": stringSchema(), "name": stringSchema(), "description": stringSchema()})),
+	})},
+	{method: http.MethodGet, path: "/scim/v2/Users", tag: "scim", summary: "Users of the organization matching the filter, deleted ones are inactive", scim: true,
+		query: scimListParameters("userName, emails.value, name.givenName, name.familyName and id, e.g. userName eq \"jane@example.com\""), response: scimListSchema(scimUserName)},
+	{method: http.MethodPost, path: "/scim/v2/Users", tag: "scim", summary: "Provision a verified member without a password, a deleted user of the organization with the userName is reactivated", scim: true, status: http.StatusCreated,
+		requestSchema: &Schema{Ref: schemaRefPrefix + scimUserName}, response: &Schema{Ref: schemaRefPrefix + scimUserName}},
+	{method: http.MethodGet, path: "/scim/v2/Users/:id", tag: "scim", summary: "A user of the organization", scim: true, response: &Schema{Ref: schemaRefPrefix + scimUserName}},
+	{method: http.MethodPut, path: "/scim/v2/Users/:id", tag: "scim", summary: "Replace the userName, name and active of the user, active false deletes the user", scim: true,
+		requestSchema: &Schema{Ref: schemaRefPrefix + scimUserName}, response: &Schema{Ref: schemaRefPrefix + scimUserName}},
+	{method: http.MethodPatch, path: "/scim/v2/Users/:id", tag: "scim", summary: "Patch the user, active false deletes the user and active true reactivates them", scim: true,
+		requestSchema: scimPatchSchema(), response: &Schema{Ref: schemaRefPrefix + scimUserName}},
+	{method: http.MethodDelete, path: "/scim/v2/Users/:id", tag: "scim", summary: "Delete the user, the record is kept as inactive and leaves its groups", scim: true, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/scim/v2/Groups", tag: "scim", summary: "Groups of the organization matching the filter", scim: true,
+		query: scimListParameters("displayName, externalId, members.value and id, e.g. displayName eq \"Engineering\""), response: scimListSchema(scimGroupName)},
+	{method: http.MethodPost, path: "/scim/v2/Groups", tag: "scim", summary: "Create a group of users of the organization", scim: true, status: http.StatusCreated,
+		requestSchema: &Schema{Ref: schemaRefPrefix + scimGroupName}, response: &Schema{Ref: schemaRefPrefix + scimGroupName}},
+	{method: http.MethodGet, path: "/scim/v2/Groups/:id", tag: "scim", summary: "A group of the organization", scim: true, response: &Schema{Ref: schemaRefPrefix + scimGroupName}},
+	{method: http.MethodPut, path: "/scim/v2/Groups/:id", tag: "scim", summary: "Replace the displayName, externalId and members of the group", scim: true,
+		requestSchema: &Schema{Ref: schemaRefPrefix + scimGroupName}, response: &Schema{Ref: schemaRefPrefix + scimGroupName}},
+	{method: http.MethodPatch, path: "/scim/v2/Groups/:id", tag: "scim", summary: "Patch the group, e.g. add or remove members", scim: true,
+		requestSchema: scimPatchSchema(), response: &Schema{Ref: schemaRefPrefix + scimGroupName}},
+	{method: http.MethodDelete, path: "/scim/v2/Groups/:id", tag: "scim", summary: "Delete the group, its members stay", scim: true, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/.well-known/openid-configuration", tag: "oauth", summary: "OpenID Connect discovery document, its urls start with the issuer", root: true, response: &Schema{Type: "object"}},
+	{method: http.MethodGet, path: "/.well-known/jwks.json", tag: "oauth", summary: "Public keys id tokens are signed with, a new key is listed before it signs", root: true, response: object(map[string]*Schema{
+		"keys": arrayOf(&Schema{Type: "object"}),
+	})},
+
+	{method: http.MethodGet, path: "/healthz", tag: "operations", summary: "Liveness probe, answers as long as the process serves requests", root: true, response: object(map[string]*Schema{
+		"status": stringSchema(),
+	})},
+	{method: http.MethodGet, path: "/readyz", tag: "operations", summary: "Readiness probe, pings the dependencies and answers 503 when one is down or the server shuts down", root: true, response: object(map[string]*Schema{
+		"status": stringSchema(),
+		"checks": {Type: "object", AdditionalProperties: object(map[string]*Schema{
+			"status":     stringSchema(),
+			"latency_ms": {Type: "integer"},
+			"error":      stringSchema(),
+		})},
+	})},
+	{method: http.MethodGet, path: "/metrics", tag: "operations", summary: "Prometheus metrics, keep it off the public load balancer", root: true, response: stringSchema(), contentType: "text/plain"},
+}
+
+// the reset routes are deliberately left out of the token scopes
+const resetPasswordDescription = "Deliberately not authenticated, the emailed OTP proves control of the account. So a user who forgot the password or has to reset it (AUTH_PASSWORD_RESET_REQUIRED) can change it. Logged in users change it with /user/change-password."
+
+var ginPathParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+var nonAlphanumeric = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Generate builds the OpenAPI document of the REST API
+func Generate() *Document {
+	components := schemas{}
+	components.ref(&models.Organization{})
+	components.ref(&models.WebhookDelivery{})
+	components.ref(&models.AuditEntry{})
+	components.ref(&models.Device{})
+	components.ref(&models.LinkedIdentity{})
+	components.ref(&models.SamlConnection{})
+	components.ref(&models.ScimToken{})
+	components.ref(&models.PersonalAccessToken{})
+	components.ref(&models.InvitationCode{})
+	components[errorEnvelopeName] = errorEnvelopeSchema()
+	components[oauthErrorName] = oauthErrorSchema()
+	components[scimErrorName] = scimErrorSchema()
+	components[scimUserName] = scimUserSchema()
+	components[scimGroupName] = scimGroupSchema()
+
+	paths := map[string]PathItem{}
+	for _, r := range routes {
+		path := ginPathParam.ReplaceAllString(r.fullPath(), "{$1}")
+		if paths[path] == nil {
+			paths[path] = PathItem{}
+		}
+		paths[path][strings.ToLower(r.method)] = r.operation(components)
+	}
+
+	return &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "User Management API",
+			Description: "REST APIs for user management. Errors are returned in the ErrorEnvelope, switch on its code.",
+			Version:     "1.0.0",
+		},
+		Servers: []Server{{Url: "/"}},
+		Tags: []Tag{
+			{Name: "user", Description: "Signup, login and account management"},
+			{Name: "organization", Description: "Organizations, members and invitations"},
+			{Name: "admin", Description: "Platform administration, limited to platform admins"},
+			{Name: "oauth", Description: "OAuth 2.1 authorization server, the token endpoints answer errors as in RFC 6749"},
+			{Name: "social", Description: "Login with accounts of OpenID Connect providers"},
+			{Name: "saml", Description: "SAML 2.0 single sign-on through the identity provider of an organization"},
+			{Name: "scim", Description: "SCIM 2.0 provisioning of the users and groups of an organization by its identity provider, errors are answered as in RFC 7644"},
+			{Name: "docs", Description: "API documentation"},
+			{Name: "operations", Description: "Probes and metrics for the orchestrator, served outside the versioned api"},
+		},
+		Paths: paths,
+		Components: Components{
+			Schemas: components,
+			SecuritySchemes: map[string]SecurityScheme{
+				authSecurityName:  {Type: "apiKey", In: "header", Name: "Authorization", Description: "Token returned by signup or login, sent as is without a scheme, an impersonation token starting with imp_, a personal access token starting with pat_, optionally after Bearer, or an oauth access token with api scopes after Bearer. The token needs the scope of the route"},
+				oauthSecurityName: {Type: "http", Scheme: "bearer", Description: "Access token returned by the oauth token endpoint"},
+				scimSecurityName:  {Type: "http", Scheme: "bearer", Description: "SCIM token issued by an organization admin"},
+			},
+		},
+	}
+}
+
+// HasOperation reports whether the document describes the gin route
+func (d *Document) HasOperation(method string, ginPath string) bool {
+	item, ok := d.Paths[ginPathParam.ReplaceAllString(ginPath, "{$1}")]
+	if !ok {
+		return false
+	}
+	_, ok = item[strings.ToLower(method)]
+	return ok
+}
+
+func (r route) operation(components schemas) *Operation {
+	operation := &Operation{
+		Tags:        []string{r.tag},
+		Summary:     r.summary,
+		OperationId: operationId(r.method, r.path),
+		Description: r.description,
+	}
+	if r.redirect != "" {
+		operation.Responses = map[string]Response{"302": {Description: r.redirect}}
+	} else if r.status == http.StatusNoContent {
+		operation.Responses = map[string]Response{"204": {Description: "Success"}}
+	} else {
+		operation.Responses = map[string]Response{
+			r.successStatus(): {Description: "Success", Content: map[string]MediaType{r.responseContentType(): {Schema: r.responseSchema(components)}}},
+			"default": {Description: "Error, see docs/errors.md for the codes", Content: map[string]MediaType{
+				"application/json": {Schema: &Schema{Ref: schemaRefPrefix + errorEnvelopeName}},
+			}},
+		}
+	}
+	for _, match := range ginPathParam.FindAllStringSubmatch(r.path, -1) {
+		operation.Parameters = append(operation.Parameters, Parameter{Name: match[1], In: "path", Required: true, Schema: stringSchema()})
+	}
+	operation.Parameters = append(operation.Parameters, r.query...)
+	if r.organization {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name:        constants.OrganizationHeader,
+			In:          "header",
+			Description: "Organization the request acts on, defaults to the one selected at login",
+			Schema:      stringSchema(),
+		})
+	}
+	if r.authorized {
+		operation.Security = []map[string][]string{{authSecurityName: {}}}
+	}
+	if r.oauthBearer {
+		operation.Security = []map[string][]string{{oauthSecurityName: {}}}
+	}
+	if r.scim {
+		operation.Security = []map[string][]string{{scimSecurityName: {}}}
+	}
+	if r.scope != "" {
+		operation.Description = strings.TrimSpace(operation.Description + " Requires the " + r.scope + " scope, other tokens get AUTH_INSUFFICIENT_SCOPE.")
+	}
+	if r.recentAuth {
+		operation.Description = strings.TrimSpace(operation.Description + " Requires a login or reauthentication within the last 5 minutes, other sessions get AUTH_RECENT_AUTHENTICATION_REQUIRED and tokens AUTH_SESSION_REQUIRED.")
+	}
+	if r.admin {
+		operation.Description = strings.TrimSpace(operation.Description + " Requires a platform admin, other users get ADMIN_REQUIRED.")
+	}
+	if r.request != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: components.ref(r.request)}},
+		}
+	}
+	if r.requestSchema != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{r.responseContentType(): {Schema: r.requestSchema}},
+		}
+	}
+	if r.requestForm != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/x-www-form-urlencoded": {Schema: r.requestForm}},
+		}
+	}
+	if r.oauthErrors {
+		operation.Responses["default"] = Response{Description: "Error, see RFC 6749 section 5.2 for the codes", Content: map[string]MediaType{
+			"application/json": {Schema: &Schema{Ref: schemaRefPrefix + oauthErrorName}},
+		}}
+	}
+	if r.scim {
+		operation.Responses["default"] = Response{Description: "Error, see RFC 7644 section 3.12 and docs/errors.md for the codes", Content: map[string]MediaType{
+			constants.ScimContentType: {Schema: &Schema{Ref: schemaRefPrefix + scimErrorName}},
+		}}
+	}
+	return operation
+}
+
+func (r route) fullPath() string {
+	if r.root {
+		return r.path
+	}
+	return basePath + r.path
+}
+
+func (r route) successStatus() string {
+	if r.status != 0 {
+		return strconv.Itoa(r.status)
+	}
+	return "200"
+}
+
+func (r route) responseContentType() string {
+	if r.contentType != "" {
+		return r.contentType
+	}
+	if r.scim {
+		return constants.ScimContentType
+	}
+	return "application/json"
+}
+
+func (r route) responseSchema(components schemas) *Schema {
+	if message, ok := r.response.(proto.Message); ok {
+		return components.ref(message)
+	}
+	return r.response.(*Schema)
+}
+
+// e.g. DELETE /org/members/:user_id gives deleteOrgMembersUserId
+func operationId(method string, path string) string {
+	id := strings.ToLower(method)
+	for _, part := range nonAlphanumeric.Split(path, -1) {
+		if part != "" {
+			id += strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return id
+}
+
+func errorEnvelopeSchema() *Schema {
+	return object(map[string]*Schema{
+		"error": {
+			Type:     "object",
+			Required: []string{"code", "status", "message"},
+			Properties: map[string]*Schema{
+				"code":       {Type: "string", Description: "Stable machine readable code, e.g. AUTH_INVALID_OTP"},
+				"status":     {Type: "integer", Description: "HTTP status of the response"},
+				"message":    {Type: "string", Description: "Human readable message"},
+				"request_id": {Type: "string", Description: "Id of the request, also in the X-Request-Id header"},
+				"details": arrayOf(object(map[string]*Schema{
+					"field": stringSchema(),
+					"issue": stringSchema(),
+				})),
+			},
+		},
+	})
+}
+
+// the secret of an endpoint is never serialized, so the proto message cannot be used as is
+func webhookEndpointSchema() *Schema {
+	return object(map[string]*Schema{
+		"id":          stringSchema(),
+		"url":         stringFormat("uri"),
+		"event_types": arrayOf(&Schema{Type: "string", Enum: stringValues(constants.WebhookEventTypes)}),
+		"description": stringSchema(),
+		"created_by":  stringSchema(),
+		"created_at":  {Ref: schemaRefPrefix + "Timestamp"},
+		"updated_at":  {Ref: schemaRefPrefix + "Timestamp"},
+	})
+}
+
+// the secret hash of a client is never serialized, so the proto message cannot be used as is
+func oauthClientSchema() *Schema {
+	return object(map[string]*Schema{
+		"id":            stringSchema(),
+		"client_id":     stringSchema(),
+		"name":          stringSchema(),
+		"type":          enumSchema((&models.OAuthClient{}).ProtoReflect().Descriptor().Fields().ByName("type").Enum()),
+		"redirect_uris": arrayOf(stringFormat("uri")),
+		"scopes":        arrayOf(stringSchema()),
+		"first_party":   {Type: "boolean"},
+		"created_by":    stringSchema(),
+		"created_at":    {Ref: schemaRefPrefix + "Timestamp"},
+		"updated_at":    {Ref: schemaRefPrefix + "Timestamp"},
+	})
+}
+
+// the body of the introspection and revocation requests, the client authenticates as at the token endpoint
+func oauthTokenFormSchema() *Schema {
+	return object(map[string]*Schema{
+		"token":           stringSchema(),
+		"token_type_hint": {Type: "string", Enum: []interface{}{constants.OAuthTokenTypeHintAccessToken, constants.OAuthTokenTypeHintRefreshToken}},
+		"client_id":       {Type: "string", Description: "unless sent with basic auth"},
+		"client_secret":   {Type: "string", Description: "confidential clients, unless sent with basic auth"},
+	})
+}
+
+// the profile claims need the profile scope, the email ones the email scope
+func userInfoSchema() *Schema {
+	return object(map[string]*Schema{
+		"sub":            stringSchema(),
+		"given_name":     stringSchema(),
+		"family_name":    stringSchema(),
+		"email":          stringFormat("email"),
+		"email_verified": {Type: "boolean"},
+	})
+}
+
+func oauthErrorSchema() *Schema {
+	return &Schema{
+		Type:     "object",
+		Required: []string{"error"},
+		Properties: map[string]*Schema{
+			"error":             {Type: "string", Description: "e.g. invalid_grant"},
+			"error_description": stringSchema(),
+		},
+	}
+}
+
+func scimErrorSchema() *Schema {
+	return &Schema{
+		Type:     "object",
+		Required: []string{"schemas", "status"},
+		Properties: map[string]*Schema{
+			"schemas":  arrayOf(stringSchema()),
+			"status":   {Type: "string", Description: "HTTP status of the response"},
+			"scimType": {Type: "string", Description: "e.g. uniqueness or invalidFilter"},
+			"detail":   stringSchema(),
+		},
+	}
+}
+
+func scimMetaSchema() *Schema {
+	return object(map[string]*Schema{
+		"resourceType": stringSchema(),
+		"created":      stringFormat("date-time"),
+		"lastModified": stringFormat("date-time"),
+		"location":     stringFormat("uri"),
+	})
+}
+
+// the userName is the email, emails is derived from it and ignored in requests
+func scimUserSchema() *Schema {
+	schema := object(map[string]*Schema{
+		"schemas":  arrayOf(stringSchema()),
+		"id":       stringSchema(),
+		"userName": stringFormat("email"),
+		"name":     object(map[string]*Schema{"givenName": stringSchema(), "familyName": stringSchema()}),
+		"emails":   arrayOf(object(map[string]*Schema{"value": stringFormat("email"), "type": stringSchema(), "primary": {Type: "boolean"}})),
+		"active":   {Type: "boolean", Description: "false when the user is deleted"},
+		"meta":     scimMetaSchema(),
+	})
+	schema.Required = []string{"userName"}
+	return schema
+}
+
+func scimGroupSchema() *Schema {
+	schema := object(map[string]*Schema{
+		"schemas":     arrayOf(stringSchema()),
+		"id":          stringSchema(),
+		"externalId":  stringSchema(),
+		"displayName": stringSchema(),
+		"members":     arrayOf(object(map[string]*Schema{"value": {Type: "string", Description: "id of a user of the organization"}, "$ref": stringFormat("uri")})),
+		"meta":        scimMetaSchema(),
+	})
+	schema.Required = []string{"displayName"}
+	return schema
+}
+
+func scimPatchSchema() *Schema {
+	return object(map[string]*Schema{
+		"schemas": arrayOf(stringSchema()),
+		"Operations": arrayOf(object(map[string]*Schema{
+			"op":    {Type: "string", Enum: []interface{}{"add", "replace", "remove"}},
+			"path":  stringSchema(),
+			"value": {Description: "the new value, an object of attributes without a path"},
+		})),
+	})
+}
+
+func scimListSchema(resourceName string) *Schema {
+	return object(map[string]*Schema{
+		"schemas":      arrayOf(stringSchema()),
+		"totalResults": {Type: "integer"},
+		"startIndex":   {Type: "integer"},
+		"itemsPerPage": {Type: "integer"},
+		"Resources":    arrayOf(&Schema{Ref: schemaRefPrefix + resourceName}),
+	})
+}
+
+// the filter and the 1-based pagination of the SCIM list endpoints, see parseScimPagination in the handler package
+func scimListParameters(filterAttributes string) []Parameter {
+	return []Parameter{
+		{Name: "filter", In: "query", Description: "RFC 7644 filter on " + filterAttributes, Schema: stringSchema()},
+		{Name: "startIndex", In: "query", Description: "1-based index of the first result", Schema: &Schema{Type: "integer"}},
+		{Name: "count", In: "query", Description: fmt.Sprintf("0 to %d, %d by default", constants.ScimMaxCount, constants.ScimDefaultCount), Schema: &Schema{Type: "integer"}},
+	}
+}
+
+// the limit and skip query params of the list endpoints, see parsePagination in the handler package
+func paginationParameters(maxLimit int) []Parameter {
+	return []Parameter{
+		{Name: "limit", In: "query", Description: fmt.Sprintf("1 to %d, 50 by default", maxLimit), Schema: &Schema{Type: "integer"}},
+		{Name: "skip", In: "query", Schema: &Schema{Type: "integer"}},
+	}
+}
+
+func stringValues(values []string) []interface{} {
+	enum := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		enum = append(enum, value)
+	}
+	return enum
+}
@@ -0,0 +1,104 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/MitP1997/golang-user-management/internal/openapi"
+	"github.com/MitP1997/golang-user-management/internal/router"
+	"github.com/gin-gonic/gin"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	document := openapi.Generate()
+	for _, route := range registeredRoutes() {
+		if !document.HasOperation(route.Method, route.Path) {
+			t.Errorf("route %s %s is missing from the OpenAPI document, add it to routes in internal/openapi/openapi.go", route.Method, route.Path)
+		}
+	}
+}
+
+func TestDocumentedRequirementsMatchTheRouter(t *testing.T) {
+	for _, route := range registeredRoutes() {
+		documented, ok := openapi.DocumentedRequirements(route.Method, route.Path)
+		if !ok {
+			continue
+		}
+		registered := handler.DescribeRoute(route.HandlerFunc)
+		if documented.Authorized != registered.Authorized {
+			t.Errorf("%s %s: documented authorized %v, the router wraps it with IsAuthorized %v", route.Method, route.Path, documented.Authorized, registered.Authorized)
+		}
+		if documented.Scope != registered.Scope {
+			t.Errorf("%s %s: documented scope %q, the router requires %q", route.Method, route.Path, documented.Scope, registered.Scope)
+		}
+		if documented.RecentAuth != registered.RecentAuth {
+			t.Errorf("%s %s: documented recentAuth %v, the router wraps it with RequireRecentAuth %v", route.Method, route.Path, documented.RecentAuth, registered.RecentAuth)
+		}
+		if documented.Admin != registered.Admin {
+			t.Errorf("%s %s: documented admin %v, the router wraps it with RequireAdmin %v", route.Method, route.Path, documented.Admin, registered.Admin)
+		}
+		if documented.Scim != registered.ScimToken {
+			t.Errorf("%s %s: documented scim %v, the router wraps it with RequireScimToken %v", route.Method, route.Path, documented.Scim, registered.ScimToken)
+		}
+		if registered.OrganizationRole && !documented.Organization {
+			t.Errorf("%s %s: the router wraps it with RequireOrganizationRole, document the organization header", route.Method, route.Path)
+		}
+	}
+}
+
+// the routes of the server, see server.New
+func registeredRoutes() gin.RoutesInfo {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	router.RegisterHealthRoutes(r)
+	router.RegisterMetricsRoutes(r)
+	router.RegisterWellKnownRoutes(r)
+	router.RegisterRoutes(r)
+	return r.Routes()
+}
+
+func TestDocumentReferencesExist(t *testing.T) {
+	document := openapi.Generate()
+	raw, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var refs []string
+	collectRefs(t, raw, &refs)
+	if len(refs) == 0 {
+		t.Fatal("expected the document to reference component schemas")
+	}
+	for _, ref := range refs {
+		name := ref[len("#/components/schemas/"):]
+		if _, ok := document.Components.Schemas[name]; !ok {
+			t.Errorf("%s is referenced but not defined in components", ref)
+		}
+	}
+}
+
+func collectRefs(t *testing.T, raw []byte, refs *[]string) {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		t.Fatal(err)
+	}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				if ref, ok := child.(string); ok && key == "$ref" {
+					*refs = append(*refs, ref)
+					continue
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(value)
+}
@@ -0,0 +1,101 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// schemas collects the component schemas referenced while building the operations
+type schemas map[string]*Schema
+
+// ref registers the schema of the proto message as a component and returns a reference to it
+// request and response bodies are bound with encoding/json, so properties use the proto field names
+// and enums are numbers
+func (s schemas) ref(message proto.Message) *Schema {
+	return s.refDescriptor(message.ProtoReflect().Descriptor())
+}
+
+func (s schemas) refDescriptor(md protoreflect.MessageDescriptor) *Schema {
+	name := string(md.Name())
+	if _, ok := s[name]; !ok {
+		// registered before the fields are walked so that recursive messages terminate
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		s[name] = schema
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			schema.Properties[string(field.Name())] = s.fieldSchema(field)
+		}
+	}
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+func (s schemas) fieldSchema(field protoreflect.FieldDescriptor) *Schema {
+	// maps are json objects, not lists of their generated entry messages
+	if field.IsMap() {
+		return &Schema{Type: "object", AdditionalProperties: s.fieldSchema(field.MapValue())}
+	}
+	var schema *Schema
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		schema = &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind, protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		schema = &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		schema = &Schema{Type: "integer", Format: "int64"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		schema = &Schema{Type: "number"}
+	case protoreflect.BytesKind:
+		schema = &Schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		schema = enumSchema(field.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		schema = s.refDescriptor(field.Message())
+	default:
+		schema = &Schema{Type: "string"}
+		if strings.HasSuffix(string(field.Name()), "email") {
+			schema.Format = "email"
+		}
+	}
+	if field.IsList() {
+		return &Schema{Type: "array", Items: schema}
+	}
+	return schema
+}
+
+func enumSchema(ed protoreflect.EnumDescriptor) *Schema {
+	values := ed.Values()
+	enum := make([]interface{}, 0, values.Len())
+	names := make([]string, 0, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		value := values.Get(i)
+		enum = append(enum, int32(value.Number()))
+		names = append(names, fmt.Sprintf("%d: %s", value.Number(), value.Name()))
+	}
+	return &Schema{Type: "integer", Format: "int32", Enum: enum, Description: strings.Join(names, ", ")}
+}
+
+func object(properties map[string]*Schema) *Schema {
+	return &Schema{Type: "object", Properties: properties}
+}
+
+func arrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+func stringSchema() *Schema {
+	return &Schema{Type: "string"}
+}
+
+func stringFormat(format string) *Schema {
+	return &Schema{Type: "string", Format: format}
+}
+
+func messageSchema() *Schema {
+	return object(map[string]*Schema{"message": stringSchema()})
+}
@@ -0,0 +1,8 @@
+package openapi
+
+import _ "embed"
+
+// SwaggerUi is a page rendering the document served next to it at openapi.json
+//
+//go:embed swagger_ui.html
+var SwaggerUi []byte
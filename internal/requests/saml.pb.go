@@ -0,0 +1,253 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        (unknown)
+// source: requests/saml.proto
+
+package requests
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SamlConnectionRequest sets up the SAML identity provider of the organization, the attributes
+// name the assertion attributes holding the user fields when they differ from the common names
+type SamlConnectionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	 
+	IdpMetadata string `protobuf:"bytes,1,opt,name=idp_metadata,json=idpMetadata,proto3" json:"idp_metadata,omitempty" form_field:"idp_metadata" form_field_type:"textarea" display_name:"Identity Provider Metadata XML"`
+	 
+	EmailAttribute string `protobuf:"bytes,2,opt,name=email_attribute,json=emailAttribute,proto3" json:"email_attribute,omitempty" form_field:"email_attribute" form_field_type:"text" display_name:"Email Attribute"`
+	 
+	GivenNameAttribute string `protobuf:"bytes,3,opt,name=given_name_attribute,json=givenNameAttribute,proto3" json:"given_name_attribute,omitempty" form_field:"given_name_attribute" form_field_type:"text" display_name:"Given Name Attribute"`
+	 
+	FamilyNameAttribute string `protobuf:"bytes,4,opt,name=family_name_attribute,json=familyNameAttribute,proto3" json:"family_name_attribute,omitempty" form_field:"family_name_attribute" form_field_type:"text" display_name:"Family Name Attribute"`
+}
+
+func (x *SamlConnectionRequest) Reset() {
+	*x = SamlConnectionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_requests_saml_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SamlConnectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SamlConnectionRequest) ProtoMessage() {}
+
+func (x *SamlConnectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_requests_saml_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SamlConnectionRequest.ProtoReflect.Descriptor instead.
+func (*SamlConnectionRequest) Descriptor() ([]byte, []int) {
+	return file_requests_saml_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SamlConnectionRequest) GetIdpMetadata() string {
+	if x != nil {
+		return x.IdpMetadata
+	}
+	return ""
+}
+
+func (x *SamlConnectionRequest) GetEmailAttribute() string {
+	if x != nil {
+		return x.EmailAttribute
+	}
+	return ""
+}
+
+func (x *SamlConnectionRequest) GetGivenNameAttribute() string {
+	if x != nil {
+		return x.GivenNameAttribute
+	}
+	return ""
+}
+
+func (x *SamlConnectionRequest) GetFamilyNameAttribute() string {
+	if x != nil {
+		return x.FamilyNameAttribute
+	}
+	return ""
+}
+
+// SamlLoginExchangeRequest trades the login code the SAML assertion consumer service redirected to the frontend with
+type SamlLoginExchangeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	 
+	Code string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty" form_field:"code" form_field_type:"hidden"`
+}
+
+func (x *SamlLoginExchangeRequest) Reset() {
+	*x = SamlLoginExchangeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_requests_saml_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SamlLoginExchangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SamlLoginExchangeRequest) ProtoMessage() {}
+
+func (x *SamlLoginExchangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_requests_saml_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SamlLoginExchangeRequest.ProtoReflect.Descriptor instead.
+func (*SamlLoginExchangeRequest) Descriptor() ([]byte, []int) {
+	return file_requests_saml_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SamlLoginExchangeRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+var File_requests_saml_proto protoreflect.FileDescriptor
+
+var file_requests_saml_proto_rawDesc = []byte{
+	0x0a, 0x13, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x2f, 0x73, 0x61, 0x6d, 0x6c, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1f, 0x67, 0x6f, 0x6c, 0x61, 0x6e, 0x67, 0x5f, 0x75, 0x73,
+	0x65, 0x72, 0x5f, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x22, 0xc9, 0x01, 0x0a, 0x15, 0x53, 0x61, 0x6d, 0x6c, 0x43,
+	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x21, 0x0a, 0x0c, 0x69, 0x64, 0x70, 0x5f, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x69, 0x64, 0x70, 0x4d, 0x65, 0x74, 0x61, 0x64,
+	0x61, 0x74, 0x61, 0x12, 0x27, 0x0a, 0x0f, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x5f, 0x61, 0x74, 0x74,
+	0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x65, 0x6d,
+	0x61, 0x69, 0x6c, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x12, 0x30, 0x0a, 0x14,
+	0x67, 0x69, 0x76, 0x65, 0x6e, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x67, 0x69, 0x76, 0x65,
+	0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x12, 0x32,
+	0x0a, 0x15, 0x66, 0x61, 0x6d, 0x69, 0x6c, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x61, 0x74,
+	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x66,
+	0x61, 0x6d, 0x69, 0x6c, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75,
+	0x74, 0x65, 0x22, 0x2e, 0x0a, 0x18, 0x53, 0x61, 0x6d, 0x6c, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x45,
+	0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f,
+	0x64, 0x65, 0x42, 0x47, 0x5a, 0x45, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x4d, 0x69, 0x74, 0x50, 0x31, 0x39, 0x39, 0x37, 0x2f, 0x67, 0x6f, 0x6c, 0x61, 0x6e, 0x67,
+	0x2d, 0x75, 0x73, 0x65, 0x72, 0x2d, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x73, 0x3b, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_requests_saml_proto_rawDescOnce sync.Once
+	file_requests_saml_proto_rawDescData = file_requests_saml_proto_rawDesc
+)
+
+func file_requests_saml_proto_rawDescGZIP() []byte {
+	file_requests_saml_proto_rawDescOnce.Do(func() {
+		file_requests_saml_proto_rawDescData = protoimpl.X.CompressGZIP(file_requests_saml_proto_rawDescData)
+	})
+	return file_requests_saml_proto_rawDescData
+}
+
+var file_requests_saml_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_requests_saml_proto_goTypes = []interface{}{
+	(*SamlConnectionRequest)(nil),    // 0: golang_user_management.requests.SamlConnectionRequest
+	(*SamlLoginExchangeRequest)(nil), // 1: golang_user_management.requests.SamlLoginExchangeRequest
+}
+var file_requests_saml_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_requests_saml_proto_init() }
+func file_requests_saml_proto_init() {
+	if File_requests_saml_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_requests_saml_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SamlConnectionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_requests_saml_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SamlLoginExchangeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_requests_saml_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_requests_saml_proto_goTypes,
+		DependencyIndexes: file_requests_saml_proto_depIdxs,
+		MessageInfos:      file_requests_saml_proto_msgTypes,
+	}.Build()
+	File_requests_saml_proto = out.File
+	file_requests_saml_proto_rawDesc = nil
+	file_requests_saml_proto_goTypes = nil
+	file_requests_saml_proto_depIdxs = nil
+}
@@ -0,0 +1,27 @@
+package router
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// admin routes are limited to platform admins, see the set-role command of usermgmt
+func RegisterAdminRoutes(r *gin.RouterGroup) {
+	adminRouterGroup := r.Group("/admin")
+	adminRouterGroup.GET("/audit", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.ListAuditEntries))))
+	adminRouterGroup.POST("/users/:user_id/impersonate", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.RequireRecentAuth(recentAuthMaxAge, handler.ImpersonateUser)))))
+	adminRouterGroup.POST("/users/:user_id/suspend", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.RequireRecentAuth(recentAuthMaxAge, handler.SuspendUser)))))
+	adminRouterGroup.POST("/users/:user_id/ban", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.RequireRecentAuth(recentAuthMaxAge, handler.BanUser)))))
+	adminRouterGroup.POST("/users/:user_id/reinstate", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.RequireRecentAuth(recentAuthMaxAge, handler.ReinstateUser)))))
+	adminRouterGroup.POST("/invitation-codes", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.CreateInvitationCode))))
+	adminRouterGroup.POST("/webhooks", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.CreateWebhookEndpoint))))
+	adminRouterGroup.GET("/webhooks", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.ListWebhookEndpoints))))
+	adminRouterGroup.DELETE("/webhooks/:endpoint_id", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.DeleteWebhookEndpoint))))
+	adminRouterGroup.POST("/webhooks/:endpoint_id/replay-failed", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.ReplayFailedWebhookDeliveries))))
+	adminRouterGroup.GET("/webhooks/deliveries", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.ListWebhookDeliveries))))
+	adminRouterGroup.POST("/webhooks/deliveries/:delivery_id/replay", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.ReplayWebhookDelivery))))
+	adminRouterGroup.POST("/oauth/clients", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.CreateOAuthClient))))
+	adminRouterGroup.GET("/oauth/clients", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.ListOAuthClients))))
+	adminRouterGroup.DELETE("/oauth/clients/:client_id", handler.IsAuthorized(handler.RequireScope(constants.ScopeAdmin, handler.RequireAdmin(handler.DeleteOAuthClient))))
+}
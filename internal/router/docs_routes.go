@@ -0,0 +1,11 @@
+package router
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterDocsRoutes(r *gin.RouterGroup) {
+	r.GET("/openapi.json", handler.GetOpenApiSpec)
+	r.GET("/docs", handler.GetApiDocs)
+}
@@ -0,0 +1,12 @@
+package router
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHealthRoutes registers the orchestrator probes, they are not part of the versioned api
+func RegisterHealthRoutes(r *gin.Engine) {
+	r.GET("/healthz", handler.Healthz)
+	r.GET("/readyz", handler.Readyz)
+}
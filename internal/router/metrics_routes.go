@@ -0,0 +1,11 @@
+package router
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMetricsRoutes registers the Prometheus metrics, they are not part of the versioned api
+func RegisterMetricsRoutes(r *gin.Engine) {
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+}
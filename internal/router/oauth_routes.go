@@ -0,0 +1,20 @@
+package router
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// the authorize routes are called by the frontend for the logged in user, the token, introspection and revocation
+// routes by the oauth clients with form encoded bodies and userinfo with an oauth access token
+func RegisterOAuthRoutes(r *gin.RouterGroup) {
+	oauthRouterGroup := r.Group("/oauth")
+	oauthRouterGroup.GET("/authorize", handler.IsAuthorized(handler.RequireScope(constants.ScopeAccountWrite, handler.GetOAuthAuthorization)))
+	oauthRouterGroup.POST("/authorize", handler.IsAuthorized(handler.RequireScope(constants.ScopeAccountWrite, handler.Authorize)))
+	oauthRouterGroup.POST("/token", handler.OAuthToken)
+	oauthRouterGroup.POST("/introspect", handler.IntrospectOAuthToken)
+	oauthRouterGroup.POST("/revoke", handler.RevokeOAuthToken)
+	oauthRouterGroup.GET("/userinfo", handler.UserInfo)
+	oauthRouterGroup.POST("/userinfo", handler.UserInfo)
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterOrganizationRoutes(r *gin.RouterGroup) {
+	organizationRouterGroup := r.Group("/org")
+	organizationRouterGroup.POST("", handler.IsAuthorized(handler.RequireScope(constants.ScopeOrganizationWrite, handler.CreateOrganization)))
+	organizationRouterGroup.GET("/members", handler.IsAuthorized(handler.RequireScope(constants.ScopeOrganizationRead, handler.RequireOrganizationRole(models.MembershipRole_MEMBER, handler.ListOrganizationMembers))))
+	organizationRouterGroup.DELETE("/members/:user_id", handler.IsAuthorized(handler.RequireScope(constants.ScopeOrganizationWrite, handler.RequireOrganizationRole(models.MembershipRole_MEMBER, handler.RemoveOrganizationMember))))
+	organizationRouterGroup.POST("/invitations", handler.IsAuthorized(handler.RequireScope(constants.ScopeOrganizationWrite, handler.RequireOrganizationRole(models.MembershipRole_ADMIN, handler.InviteOrganizationMember))))
+	organizationRouterGroup.POST("/invitations/accept", handler.IsAuthorized(handler.RequireScope(constants.ScopeOrganizationWrite, handler.AcceptOrganizationInvitation)))
+	organizationRouterGroup.GET("/saml", handler.IsAuthorized(handler.RequireScope(constants.ScopeOrganizationRead, handler.RequireOrganizationRole(models.MembershipRole_ADMIN, handler.GetSamlConnection))))
+	organizationRouterGroup.PUT("/saml", handler.IsAuthorized(handler.RequireScope(constants.ScopeOrganizationWrite, handler.RequireOrganizationRole(models.MembershipRole_ADMIN, handler.SaveSamlConnection))))
+	organizationRouterGroup.DELETE("/saml", handler.IsAuthorized(handler.RequireScope(constants.ScopeOrganizationWrite, handler.RequireOrganizationRole(models.MembershipRole_ADMIN, handler.DeleteSamlConnection))))
+	organizationRouterGroup.POST("/scim/tokens", handler.IsAuthorized(handler.RequireScope(constants.ScopeOrganizationWrite, handler.RequireOrganizationRole(models.MembershipRole_ADMIN, handler.RequireRecentAuth(recentAuthMaxAge, handler.CreateScimToken)))))
+	organizationRouterGroup.GET("/scim/tokens", handler.IsAuthorized(handler.RequireScope(constants.ScopeOrganizationRead, handler.RequireOrganizationRole(models.MembershipRole_ADMIN, handler.ListScimTokens))))
+	organizationRouterGroup.DELETE("/scim/tokens/:token_id", handler.IsAuthorized(handler.RequireScope(constants.ScopeOrganizationWrite, handler.RequireOrganizationRole(models.MembershipRole_ADMIN, handler.DeleteScimToken))))
+}
@@ -1,10 +1,29 @@
 package router
 
 import (
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
+// how long ago the user of a session must have logged in or reauthenticated for the sensitive routes wrapped with
+// RequireRecentAuth
+const recentAuthMaxAge = 5 * time.Minute
+
 func RegisterRoutes(r *gin.Engine) {
+	r.NoRoute(func(c *gin.Context) {
+		utils.RespondWithError(c, errors.RouteNotFoundError())
+	})
+
 	apiRouterGroup := r.Group("/api/v1")
 	RegisterUserRoutes(apiRouterGroup)
+	RegisterOrganizationRoutes(apiRouterGroup)
+	RegisterAdminRoutes(apiRouterGroup)
+	RegisterOAuthRoutes(apiRouterGroup)
+	RegisterSocialLoginRoutes(apiRouterGroup)
+	RegisterSamlRoutes(apiRouterGroup)
+	RegisterScimRoutes(apiRouterGroup)
+	RegisterDocsRoutes(apiRouterGroup)
 }
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// the identity providers and the browser talk to the routes of an organization, the exchange is called by the frontend
+func RegisterSamlRoutes(r *gin.RouterGroup) {
+	samlRouterGroup := r.Group("/saml")
+	samlRouterGroup.GET("/:organization_id/metadata", handler.SamlMetadata)
+	samlRouterGroup.GET("/:organization_id/login", handler.StartSamlLogin)
+	samlRouterGroup.POST("/:organization_id/acs", handler.SamlAssertionConsumerService)
+	samlRouterGroup.POST("/exchange", handler.ExchangeSamlLoginCode)
+}
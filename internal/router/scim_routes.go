@@ -0,0 +1,24 @@
+package router
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// the identity provider of an organization provisions its users and groups here with a SCIM token of the organization
+func RegisterScimRoutes(r *gin.RouterGroup) {
+	scimRouterGroup := r.Group("/scim/v2")
+	scimRouterGroup.GET("/ServiceProviderConfig", handler.RequireScimToken(handler.GetScimServiceProviderConfig))
+	scimRouterGroup.GET("/Users", handler.RequireScimToken(handler.ListScimUsers))
+	scimRouterGroup.POST("/Users", handler.RequireScimToken(handler.CreateScimUser))
+	scimRouterGroup.GET("/Users/:id", handler.RequireScimToken(handler.GetScimUser))
+	scimRouterGroup.PUT("/Users/:id", handler.RequireScimToken(handler.ReplaceScimUser))
+	scimRouterGroup.PATCH("/Users/:id", handler.RequireScimToken(handler.PatchScimUser))
+	scimRouterGroup.DELETE("/Users/:id", handler.RequireScimToken(handler.DeleteScimUser))
+	scimRouterGroup.GET("/Groups", handler.RequireScimToken(handler.ListScimGroups))
+	scimRouterGroup.POST("/Groups", handler.RequireScimToken(handler.CreateScimGroup))
+	scimRouterGroup.GET("/Groups/:id", handler.RequireScimToken(handler.GetScimGroup))
+	scimRouterGroup.PUT("/Groups/:id", handler.RequireScimToken(handler.ReplaceScimGroup))
+	scimRouterGroup.PATCH("/Groups/:id", handler.RequireScimToken(handler.PatchScimGroup))
+	scimRouterGroup.DELETE("/Groups/:id", handler.RequireScimToken(handler.DeleteScimGroup))
+}
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// the login and callback routes are navigated to by the browser and redirect, the others are called by the frontend
+func RegisterSocialLoginRoutes(r *gin.RouterGroup) {
+	socialRouterGroup := r.Group("/social")
+	socialRouterGroup.GET("/providers", handler.ListSocialLoginProviders)
+	socialRouterGroup.GET("/providers/:provider/login", handler.StartSocialLogin)
+	socialRouterGroup.GET("/callback", handler.SocialLoginCallback)
+	socialRouterGroup.POST("/exchange", handler.ExchangeSocialLoginCode)
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"github.com/MitP1997/golang-user-management/internal/constants"
 	"github.com/MitP1997/golang-user-management/internal/handler"
 	"github.com/gin-gonic/gin"
 )
@@ -10,9 +11,27 @@ func RegisterUserRoutes(r *gin.RouterGroup) {
 	userRouterGroup.GET("/signup-form-fields", handler.GetUserSignupFormFields)
 	userRouterGroup.POST("/signup", handler.Signup)
 	userRouterGroup.POST("/login", handler.Login)
-	userRouterGroup.POST("/verify-email", handler.IsAuthorized(handler.VerifyEmail))
-	userRouterGroup.POST("/resend-email-verification-otp", handler.IsAuthorized(handler.ResendEmailVerificationOtp))
-	userRouterGroup.GET("/pending-requirements", handler.IsAuthorized(handler.GetPendingRequirements))
-	userRouterGroup.POST("/change-password-initiate", handler.ChangePasswordInitiate)
-	userRouterGroup.POST("/change-password", handler.ChangePassword)
+	userRouterGroup.POST("/verify-email", handler.IsAuthorized(handler.RequireScope(constants.ScopeProfileWrite, handler.VerifyEmail)))
+	userRouterGroup.POST("/resend-email-verification-otp", handler.IsAuthorized(handler.RequireScope(constants.ScopeProfileWrite, handler.ResendEmailVerificationOtp)))
+	userRouterGroup.GET("/pending-requirements", handler.IsAuthorized(handler.RequireScope(constants.ScopeProfileRead, handler.GetPendingRequirements)))
+	userRouterGroup.POST("/reauthenticate", handler.IsAuthorized(handler.RequireScope(constants.ScopeAccountWrite, handler.Reauthenticate)))
+	userRouterGroup.GET("/me", handler.IsAuthorized(handler.RequireScope(constants.ScopeProfileRead, handler.GetMe)))
+	userRouterGroup.DELETE("/impersonation", handler.IsAuthorized(handler.RequireScope(constants.ScopeProfileRead, handler.EndImpersonation)))
+	userRouterGroup.DELETE("/me", handler.IsAuthorized(handler.RequireScope(constants.ScopeAccountWrite, handler.RequireRecentAuth(recentAuthMaxAge, handler.DeleteAccount))))
+	userRouterGroup.GET("/me/activity", handler.IsAuthorized(handler.RequireScope(constants.ScopeProfileRead, handler.ListMyActivity)))
+	userRouterGroup.GET("/me/devices", handler.IsAuthorized(handler.RequireScope(constants.ScopeProfileRead, handler.ListDevices)))
+	userRouterGroup.DELETE("/me/devices/:device_id", handler.IsAuthorized(handler.RequireScope(constants.ScopeAccountWrite, handler.ForgetDevice)))
+	userRouterGroup.GET("/me/identities", handler.IsAuthorized(handler.RequireScope(constants.ScopeProfileRead, handler.ListLinkedIdentities)))
+	userRouterGroup.POST("/me/identities", handler.IsAuthorized(handler.RequireScope(constants.ScopeAccountWrite, handler.RequireRecentAuth(recentAuthMaxAge, handler.LinkIdentity))))
+	userRouterGroup.DELETE("/me/identities/:identity_id", handler.IsAuthorized(handler.RequireScope(constants.ScopeAccountWrite, handler.RequireRecentAuth(recentAuthMaxAge, handler.UnlinkIdentity))))
+	userRouterGroup.POST("/tokens", handler.IsAuthorized(handler.RequireScope(constants.ScopeAccountWrite, handler.RequireRecentAuth(recentAuthMaxAge, handler.CreatePersonalAccessToken))))
+	userRouterGroup.GET("/tokens", handler.IsAuthorized(handler.RequireScope(constants.ScopeProfileRead, handler.ListPersonalAccessTokens)))
+	userRouterGroup.DELETE("/tokens/:token_id", handler.IsAuthorized(handler.RequireScope(constants.ScopeAccountWrite, handler.DeletePersonalAccessToken)))
+	userRouterGroup.POST("/secure-account", handler.SecureAccount)
+	userRouterGroup.POST("/change-password-initiate", handler.IsAuthorized(handler.RequireScope(constants.ScopeAccountWrite, handler.ChangePasswordInitiate)))
+	userRouterGroup.POST("/change-password", handler.IsAuthorized(handler.RequireScope(constants.ScopeAccountWrite, handler.ChangePassword)))
+	// the emailed otp proves control of the account, so a forgotten password or one that has to be reset works
+	// without a token
+	userRouterGroup.POST("/reset-password-initiate", handler.ResetPasswordInitiate)
+	userRouterGroup.POST("/reset-password", handler.ResetPassword)
 }
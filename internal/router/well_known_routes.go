@@ -0,0 +1,12 @@
+package router
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterWellKnownRoutes registers the OpenID Connect discovery, it lives below the issuer and not in the versioned api
+func RegisterWellKnownRoutes(r *gin.Engine) {
+	r.GET("/.well-known/openid-configuration", handler.OpenIdConfiguration)
+	r.GET("/.well-known/jwks.json", handler.Jwks)
+}
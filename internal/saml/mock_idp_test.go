@@ -0,0 +1,146 @@
+package saml_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/xml"
+	"html"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/saml"
+	gosaml "github.com/crewjam/saml"
+	"github.com/crewjam/saml/logger"
+)
+
+// mockIdp is a local identity provider: it serves its metadata and answers authentication requests of the
+// service providers of this package with a signed response for session, like a real one after the user logged in
+type mockIdp struct {
+	t       *testing.T
+	server  *httptest.Server
+	idp     *gosaml.IdentityProvider
+	session *gosaml.Session
+}
+
+func newMockIdp(t *testing.T) *mockIdp {
+	key, certificate := newCertificate(t, "mock idp")
+	m := &mockIdp{
+		t: t,
+		session: &gosaml.Session{
+			ID:            "session-1",
+			NameID:        "user-1",
+			NameIDFormat:  string(gosaml.PersistentNameIDFormat),
+			UserGivenName: "Jane",
+			UserSurname:   "Doe",
+			UserEmail:     "jane.doe@idp.example.com",
+			CustomAttributes: []gosaml.Attribute{
+				{Name: "email", Values: []gosaml.AttributeValue{{Type: "xs:string", Value: "Jane@Example.com"}}},
+			},
+		},
+	}
+	m.server = httptest.NewServer(nil)
+	t.Cleanup(m.server.Close)
+	metadataUrl, _ := url.Parse(m.server.URL + "/metadata")
+	ssoUrl, _ := url.Parse(m.server.URL + "/sso")
+	m.idp = &gosaml.IdentityProvider{
+		Key:                     key,
+		Certificate:             certificate,
+		Logger:                  logger.DefaultLogger,
+		MetadataURL:             *metadataUrl,
+		SSOURL:                  *ssoUrl,
+		ServiceProviderProvider: m,
+		SessionProvider:         m,
+	}
+	m.server.Config.Handler = m.idp.Handler()
+	return m
+}
+
+// metadata is what the organization uploads
+func (m *mockIdp) metadata() []byte {
+	res, err := http.Get(m.idp.MetadataURL.String())
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	metadata, err := io.ReadAll(res.Body)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return metadata
+}
+
+// login follows the redirect to the identity provider and returns the form it makes the browser post to the
+// assertion consumer service
+func (m *mockIdp) login(redirectUrl string) (samlResponse string, relayState string) {
+	res, err := http.Get(redirectUrl)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		m.t.Fatalf("identity provider refused the request with %d: %s", res.StatusCode, body)
+	}
+	return formValue(m.t, body, "SAMLResponse"), formValue(m.t, body, "RelayState")
+}
+
+// GetServiceProvider knows the service providers of all organizations, their metadata url is the entity id
+func (m *mockIdp) GetServiceProvider(r *http.Request, serviceProviderId string) (*gosaml.EntityDescriptor, error) {
+	organizationId := regexp.MustCompile(`/saml/([^/]+)/metadata$`).FindStringSubmatch(serviceProviderId)
+	if organizationId == nil {
+		return nil, os.ErrNotExist
+	}
+	metadata, e := saml.Metadata(organizationId[1])
+	if e != nil {
+		return nil, e.Error()
+	}
+	descriptor := &gosaml.EntityDescriptor{}
+	if err := xml.Unmarshal(metadata, descriptor); err != nil {
+		return nil, err
+	}
+	return descriptor, nil
+}
+
+// GetSession stands in for the login page, the user is always logged in
+func (m *mockIdp) GetSession(w http.ResponseWriter, r *http.Request, req *gosaml.IdpAuthnRequest) *gosaml.Session {
+	return m.session
+}
+
+func formValue(t *testing.T, body []byte, name string) string {
+	match := regexp.MustCompile(`name="` + name + `" value="([^"]*)"`).FindSubmatch(body)
+	if match == nil {
+		t.Fatalf("no %s in the form of the identity provider: %s", name, body)
+	}
+	return html.UnescapeString(string(match[1]))
+}
+
+func newCertificate(t *testing.T, commonName string) (*rsa.PrivateKey, *x509.Certificate) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, certificate
+}
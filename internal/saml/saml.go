@@ -0,0 +1,195 @@
+// Package saml is the service provider side of SAML 2.0 single sign-on for organizations. Every organization is
+// its own service provider, with the entity id, login and assertion consumer service below
+// /api/v1/saml/<organization id>/, and trusts the identity provider of the metadata it uploaded. Authentication
+// requests are signed with the configured certificate and only signed responses to them are accepted.
+// Provisioning the users is left to the handlers.
+package saml
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	gosaml "github.com/crewjam/saml"
+	xrv "github.com/mattermost/xml-roundtrip-validator"
+)
+
+var (
+	key         *rsa.PrivateKey
+	certificate *x509.Certificate
+	baseUrl     string
+)
+
+// InitSaml loads the certificate and key of the service provider, issuer is the public url of this service.
+// Without a certificate SAML single sign-on is disabled.
+func InitSaml(samlConfig config.SamlConfig, issuer string) (err error) {
+	key, certificate = nil, nil
+	baseUrl = strings.TrimSuffix(issuer, "/") + constants.SamlPathPrefix
+	if samlConfig.CertificateFile == "" {
+		return nil
+	}
+	cert, err := readCertificate(samlConfig.CertificateFile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", samlConfig.CertificateFile, err)
+	}
+	privateKey, err := readPrivateKey(samlConfig.KeyFile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", samlConfig.KeyFile, err)
+	}
+	if publicKey, ok := cert.PublicKey.(*rsa.PublicKey); !ok || !publicKey.Equal(&privateKey.PublicKey) {
+		return fmt.Errorf("%s: the certificate is not for the key in %s", samlConfig.CertificateFile, samlConfig.KeyFile)
+	}
+	key, certificate = privateKey, cert
+	return nil
+}
+
+// Enabled tells whether the service provider has a certificate to sign with
+func Enabled() bool {
+	return key != nil
+}
+
+// EntityId identifies the service provider of the organization, it is also the url of its metadata
+func EntityId(organizationId string) string {
+	return baseUrl + url.PathEscape(organizationId) + "/metadata"
+}
+
+// AcsUrl is the assertion consumer service the identity provider of the organization posts its responses to
+func AcsUrl(organizationId string) string {
+	return baseUrl + url.PathEscape(organizationId) + "/acs"
+}
+
+// LoginUrl starts a login through the identity provider of the organization
+func LoginUrl(organizationId string) string {
+	return baseUrl + url.PathEscape(organizationId) + "/login"
+}
+
+// Metadata is the service provider metadata of the organization, uploaded to its identity provider
+func Metadata(organizationId string) ([]byte, *errors.Error) {
+	if !Enabled() {
+		return nil, errors.SamlNotConfiguredError()
+	}
+	sp := newServiceProvider(organizationId, nil)
+	descriptor := sp.Metadata()
+	// responses are only taken with the post binding
+	for i := range descriptor.SPSSODescriptors {
+		spDescriptor := &descriptor.SPSSODescriptors[i]
+		services := spDescriptor.AssertionConsumerServices[:0]
+		for _, service := range spDescriptor.AssertionConsumerServices {
+			if service.Binding == gosaml.HTTPPostBinding {
+				services = append(services, service)
+			}
+		}
+		spDescriptor.AssertionConsumerServices = services
+	}
+	metadata, err := xml.MarshalIndent(descriptor, "", "  ")
+	if err != nil {
+		return nil, errors.InternalServerError(err)
+	}
+	return metadata, nil
+}
+
+// ParseIdpMetadata reads the metadata of an identity provider, which has to offer the redirect binding
+// for authentication requests and a certificate its responses are signed with
+func ParseIdpMetadata(data []byte) (*gosaml.EntityDescriptor, *errors.Error) {
+	if err := xrv.Validate(bytes.NewReader(data)); err != nil {
+		return nil, errors.SamlInvalidMetadataError(err)
+	}
+	descriptor := &gosaml.EntityDescriptor{}
+	if err := xml.Unmarshal(data, descriptor); err != nil {
+		// some identity providers wrap their entity in an EntitiesDescriptor
+		entities := &gosaml.EntitiesDescriptor{}
+		if xml.Unmarshal(data, entities) != nil {
+			return nil, errors.SamlInvalidMetadataError(err)
+		}
+		descriptor = nil
+		for i, entity := range entities.EntityDescriptors {
+			if len(entity.IDPSSODescriptors) > 0 {
+				descriptor = &entities.EntityDescriptors[i]
+				break
+			}
+		}
+		if descriptor == nil {
+			return nil, errors.SamlInvalidMetadataError(fmt.Errorf("no entity is an identity provider"))
+		}
+	}
+	if descriptor.EntityID == "" {
+		return nil, errors.SamlInvalidMetadataError(fmt.Errorf("the entityID is missing"))
+	}
+	if len(descriptor.IDPSSODescriptors) == 0 {
+		return nil, errors.SamlInvalidMetadataError(fmt.Errorf("there is no IDPSSODescriptor"))
+	}
+	sp := newServiceProvider("", descriptor)
+	if sp.GetSSOBindingLocation(gosaml.HTTPRedirectBinding) == "" {
+		return nil, errors.SamlInvalidMetadataError(fmt.Errorf("there is no SingleSignOnService with the HTTP-Redirect binding"))
+	}
+	if err := checkSigningCertificates(descriptor); err != nil {
+		return nil, errors.SamlInvalidMetadataError(err)
+	}
+	return descriptor, nil
+}
+
+// the certificates of the keys without a use or with the signing use, the ones responses are verified with
+func checkSigningCertificates(descriptor *gosaml.EntityDescriptor) error {
+	found := false
+	for _, idpDescriptor := range descriptor.IDPSSODescriptors {
+		for _, keyDescriptor := range idpDescriptor.KeyDescriptors {
+			if keyDescriptor.Use != "" && keyDescriptor.Use != "signing" {
+				continue
+			}
+			for _, cert := range keyDescriptor.KeyInfo.X509Data.X509Certificates {
+				if _, err := parseBase64Certificate(cert.Data); err != nil {
+					return fmt.Errorf("a signing certificate is invalid: %w", err)
+				}
+				found = true
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("there is no signing certificate")
+	}
+	return nil
+}
+
+func readCertificate(path string) (*x509.Certificate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no pem CERTIFICATE found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func readPrivateKey(path string) (*rsa.PrivateKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("no pem key found")
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("the key must be an rsa key")
+	}
+	return privateKey, nil
+}
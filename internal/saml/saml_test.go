@@ -0,0 +1,330 @@
+package saml_test
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/saml"
+	gosaml "github.com/crewjam/saml"
+)
+
+const issuer = "http://localhost:8080"
+
+// writes the certificate and key of the service provider and initializes the package with them
+func initSaml(t *testing.T) *x509.Certificate {
+	key, certificate := newCertificate(t, "service provider")
+	dir := t.TempDir()
+	certificateFile := filepath.Join(dir, "sp.crt")
+	keyFile := filepath.Join(dir, "sp.key")
+	writePem(t, certificateFile, "CERTIFICATE", certificate.Raw)
+	writePem(t, keyFile, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	if err := saml.InitSaml(config.SamlConfig{CertificateFile: certificateFile, KeyFile: keyFile}, issuer); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = saml.InitSaml(config.SamlConfig{}, issuer) })
+	return certificate
+}
+
+func writePem(t *testing.T, path string, blockType string, der []byte) {
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// the organization org-1 trusts the mock identity provider
+func setupServiceProvider(t *testing.T, mapping saml.AttributeMapping) (*mockIdp, *saml.ServiceProvider) {
+	initSaml(t)
+	mock := newMockIdp(t)
+	provider, e := saml.NewServiceProvider("org-1", mock.metadata(), mapping)
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	return mock, provider
+}
+
+// starts a login and returns the response of the identity provider with the id of the request it answers
+func login(t *testing.T, mock *mockIdp, provider *saml.ServiceProvider) (samlResponse string, requestId string) {
+	request, e := provider.NewAuthnRequest()
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	redirectUrl, e := request.RedirectUrl("relay-state")
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	samlResponse, relayState := mock.login(redirectUrl)
+	if relayState != "relay-state" {
+		t.Fatalf("expected the relay state back, got %q", relayState)
+	}
+	return samlResponse, request.Id
+}
+
+func TestRedirectUrlIsSigned(t *testing.T) {
+	mock, provider := setupServiceProvider(t, saml.AttributeMapping{})
+	request, e := provider.NewAuthnRequest()
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	redirectUrl, e := request.RedirectUrl("state/with+special=chars")
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+
+	parsed, err := url.Parse(redirectUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(redirectUrl, mock.idp.SSOURL.String()+"?") {
+		t.Errorf("expected the sso url of the identity provider, got %s", redirectUrl)
+	}
+	query := parsed.Query()
+	if query.Get("RelayState") != "state/with+special=chars" {
+		t.Errorf("relay state was not escaped, got %q", query.Get("RelayState"))
+	}
+	if query.Get("SigAlg") != "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256" {
+		t.Errorf("unexpected signature algorithm %q", query.Get("SigAlg"))
+	}
+	// the redirect binding signs the raw query up to the signature
+	signed := parsed.RawQuery[:strings.Index(parsed.RawQuery, "&Signature=")]
+	signature, err := base64.StdEncoding.DecodeString(query.Get("Signature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, e := saml.Metadata("org-1")
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	certificate := spCertificate(t, metadata)
+	digest := sha256.Sum256([]byte(signed))
+	if err = rsa.VerifyPKCS1v15(certificate.PublicKey.(*rsa.PublicKey), crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("signature does not verify with the certificate of the metadata: %v", err)
+	}
+}
+
+func TestMetadataDescribesTheOrganization(t *testing.T) {
+	initSaml(t)
+	metadata, e := saml.Metadata("org-1")
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	for _, expected := range []string{
+		`entityID="` + issuer + `/api/v1/saml/org-1/metadata"`,
+		`Location="` + issuer + `/api/v1/saml/org-1/acs"`,
+		`AuthnRequestsSigned="true"`,
+	} {
+		if !strings.Contains(string(metadata), expected) {
+			t.Errorf("metadata lacks %s:\n%s", expected, metadata)
+		}
+	}
+	if strings.Contains(string(metadata), gosaml.HTTPArtifactBinding) {
+		t.Errorf("metadata offers the artifact binding:\n%s", metadata)
+	}
+}
+
+func TestParseResponseReturnsIdentity(t *testing.T) {
+	mock, provider := setupServiceProvider(t, saml.AttributeMapping{})
+	samlResponse, requestId := login(t, mock, provider)
+
+	identity, e := provider.ParseResponse(samlResponse, requestId)
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	if identity.Subject != "user-1" {
+		t.Errorf("expected the name id as subject, got %q", identity.Subject)
+	}
+	if identity.Email != "jane@example.com" || identity.GivenName != "Jane" || identity.FamilyName != "Doe" {
+		t.Errorf("unexpected attributes %+v", identity)
+	}
+	if identity.AssertionId == "" || !identity.ExpiresAt.After(time.Now()) {
+		t.Errorf("expected the assertion id and a future expiry, got %+v", identity)
+	}
+}
+
+func TestParseResponseUsesAttributeMapping(t *testing.T) {
+	mock, provider := setupServiceProvider(t, saml.AttributeMapping{Email: "urn:oid:1.3.6.1.4.1.5923.1.1.1.6", GivenName: "sn", FamilyName: "givenName"})
+	samlResponse, requestId := login(t, mock, provider)
+
+	identity, e := provider.ParseResponse(samlResponse, requestId)
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	if identity.Email != "jane.doe@idp.example.com" || identity.GivenName != "Doe" || identity.FamilyName != "Jane" {
+		t.Errorf("mapping was not applied, got %+v", identity)
+	}
+}
+
+func TestParseResponseRejectsInvalidResponses(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(mock *mockIdp)
+		parse func(t *testing.T, mock *mockIdp, provider *saml.ServiceProvider, samlResponse string, requestId string) *errors.Error
+	}{
+		{
+			name: "answers another request",
+			parse: func(t *testing.T, mock *mockIdp, provider *saml.ServiceProvider, samlResponse string, requestId string) *errors.Error {
+				_, e := provider.ParseResponse(samlResponse, "id-other")
+				return e
+			},
+		},
+		{
+			name: "tampered with",
+			parse: func(t *testing.T, mock *mockIdp, provider *saml.ServiceProvider, samlResponse string, requestId string) *errors.Error {
+				_, e := provider.ParseResponse(tamper(t, samlResponse), requestId)
+				return e
+			},
+		},
+		{
+			name: "signed with a key missing from the metadata",
+			setup: func(mock *mockIdp) {
+				mock.idp.Key, mock.idp.Certificate = newCertificate(mock.t, "forged idp")
+			},
+		},
+		{
+			name: "meant for another organization",
+			parse: func(t *testing.T, mock *mockIdp, provider *saml.ServiceProvider, samlResponse string, requestId string) *errors.Error {
+				other, e := saml.NewServiceProvider("org-2", mock.metadata(), saml.AttributeMapping{})
+				if e != nil {
+					t.Fatal(e.Error())
+				}
+				_, e = other.ParseResponse(samlResponse, requestId)
+				return e
+			},
+		},
+		{
+			name: "expired",
+			parse: func(t *testing.T, mock *mockIdp, provider *saml.ServiceProvider, samlResponse string, requestId string) *errors.Error {
+				timeNow := gosaml.TimeNow
+				gosaml.TimeNow = func() time.Time { return time.Now().Add(time.Hour) }
+				defer func() { gosaml.TimeNow = timeNow }()
+				_, e := provider.ParseResponse(samlResponse, requestId)
+				return e
+			},
+		},
+		{
+			name: "with a transient name id",
+			setup: func(mock *mockIdp) {
+				mock.session.NameIDFormat = string(gosaml.TransientNameIDFormat)
+			},
+		},
+		{
+			name: "not base64",
+			parse: func(t *testing.T, mock *mockIdp, provider *saml.ServiceProvider, samlResponse string, requestId string) *errors.Error {
+				_, e := provider.ParseResponse("not base64!", requestId)
+				return e
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, provider := setupServiceProvider(t, saml.AttributeMapping{})
+			if tt.setup != nil {
+				tt.setup(mock)
+			}
+			samlResponse, requestId := login(t, mock, provider)
+			var e *errors.Error
+			if tt.parse != nil {
+				e = tt.parse(t, mock, provider, samlResponse, requestId)
+			} else {
+				_, e = provider.ParseResponse(samlResponse, requestId)
+			}
+			if e == nil || e.Code != errors.CodeSamlInvalidResponse {
+				t.Errorf("expected %s, got %v", errors.CodeSamlInvalidResponse, e)
+			}
+		})
+	}
+}
+
+// shifts the issue instant of the response by a second, which only its signature protects
+func tamper(t *testing.T, samlResponse string) string {
+	raw, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issueInstant := regexp.MustCompile(`IssueInstant="([^"]+)"`)
+	match := issueInstant.FindSubmatchIndex(raw)
+	instant, err := time.Parse(time.RFC3339Nano, string(raw[match[2]:match[3]]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := string(raw[:match[2]]) + instant.Add(-time.Second).Format(time.RFC3339Nano) + string(raw[match[3]:])
+	return base64.StdEncoding.EncodeToString([]byte(tampered))
+}
+
+func spCertificate(t *testing.T, metadata []byte) *x509.Certificate {
+	match := regexp.MustCompile(`<X509Certificate[^>]*>([^<]+)</X509Certificate>`).FindSubmatch(metadata)
+	if match == nil {
+		t.Fatalf("no certificate in the metadata: %s", metadata)
+	}
+	der, err := base64.StdEncoding.DecodeString(string(match[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate
+}
+
+func TestParseIdpMetadataRejectsUnusableMetadata(t *testing.T) {
+	initSaml(t)
+	valid := string(newMockIdp(t).metadata())
+	if _, e := saml.ParseIdpMetadata([]byte(valid)); e != nil {
+		t.Fatalf("valid metadata was rejected: %v", e.Error())
+	}
+	spMetadata, e := saml.Metadata("org-1")
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	keyDescriptors := regexp.MustCompile(`(?s)<KeyDescriptor.*</KeyDescriptor>`)
+	redirectBinding := regexp.MustCompile(`<SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"[^>]*></SingleSignOnService>`)
+
+	tests := map[string]string{
+		"not xml":                   "not xml",
+		"a service provider":        string(spMetadata),
+		"without signing key":       keyDescriptors.ReplaceAllString(valid, ""),
+		"without redirect binding":  redirectBinding.ReplaceAllString(valid, ""),
+		"without entity id":         strings.Replace(valid, `entityID="`, `entityID="" ignored="`, 1),
+		"with an invalid signature": regexp.MustCompile(`(<X509Certificate[^>]*>)`).ReplaceAllString(valid, "${1}AAAA"),
+	}
+	for name, metadata := range tests {
+		t.Run(name, func(t *testing.T) {
+			if metadata == valid {
+				t.Fatal("test metadata was not changed")
+			}
+			if _, e := saml.ParseIdpMetadata([]byte(metadata)); e == nil || e.Code != errors.CodeSamlInvalidMetadata {
+				t.Errorf("expected %s, got %v", errors.CodeSamlInvalidMetadata, e)
+			}
+		})
+	}
+}
+
+func TestInitSamlRejectsCertificateOfAnotherKey(t *testing.T) {
+	_, certificate := newCertificate(t, "service provider")
+	otherKey, _ := newCertificate(t, "other")
+	dir := t.TempDir()
+	certificateFile := filepath.Join(dir, "sp.crt")
+	keyFile := filepath.Join(dir, "sp.key")
+	writePem(t, certificateFile, "CERTIFICATE", certificate.Raw)
+	writePem(t, keyFile, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(otherKey))
+
+	if err := saml.InitSaml(config.SamlConfig{CertificateFile: certificateFile, KeyFile: keyFile}, issuer); err == nil {
+		t.Error("expected the mismatched key to be rejected")
+	}
+	if saml.Enabled() {
+		t.Error("expected SAML to stay disabled")
+	}
+}
@@ -0,0 +1,166 @@
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	gosaml "github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+// the attribute names identity providers commonly send the user fields under, tried in order
+var (
+	defaultEmailAttributes      = []string{"email", "mail", "emailAddress", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress", "urn:oid:0.9.2342.19200300.100.1.3"}
+	defaultGivenNameAttributes  = []string{"givenName", "firstName", "given_name", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname", "urn:oid:2.5.4.42"}
+	defaultFamilyNameAttributes = []string{"sn", "surname", "lastName", "family_name", "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/surname", "urn:oid:2.5.4.4"}
+)
+
+// AttributeMapping names the assertion attributes holding the user fields, empty ones use the common names
+type AttributeMapping struct {
+	Email      string
+	GivenName  string
+	FamilyName string
+}
+
+// ServiceProvider is the service provider of an organization talking to the identity provider of its metadata
+type ServiceProvider struct {
+	sp      *gosaml.ServiceProvider
+	mapping AttributeMapping
+}
+
+// NewServiceProvider returns the service provider of the organization for the identity provider metadata it uploaded
+func NewServiceProvider(organizationId string, idpMetadata []byte, mapping AttributeMapping) (*ServiceProvider, *errors.Error) {
+	if !Enabled() {
+		return nil, errors.SamlNotConfiguredError()
+	}
+	descriptor, e := ParseIdpMetadata(idpMetadata)
+	if e != nil {
+		return nil, e
+	}
+	return &ServiceProvider{sp: newServiceProvider(organizationId, descriptor), mapping: mapping}, nil
+}
+
+func newServiceProvider(organizationId string, idpMetadata *gosaml.EntityDescriptor) *gosaml.ServiceProvider {
+	metadataUrl, _ := url.Parse(EntityId(organizationId))
+	acsUrl, _ := url.Parse(AcsUrl(organizationId))
+	return &gosaml.ServiceProvider{
+		EntityID:    EntityId(organizationId),
+		Key:         key,
+		Certificate: certificate,
+		MetadataURL: *metadataUrl,
+		AcsURL:      *acsUrl,
+		IDPMetadata: idpMetadata,
+		// lets the identity provider send the name id format it is configured with
+		AuthnNameIDFormat: gosaml.UnspecifiedNameIDFormat,
+		SignatureMethod:   dsig.RSASHA256SignatureMethod,
+	}
+}
+
+// AuthnRequest is a signed authentication request, the response has to be in response to its id
+type AuthnRequest struct {
+	Id      string
+	request *gosaml.AuthnRequest
+	sp      *gosaml.ServiceProvider
+}
+
+// NewAuthnRequest creates an authentication request for the identity provider
+func (p *ServiceProvider) NewAuthnRequest() (*AuthnRequest, *errors.Error) {
+	request, e := p.sp.MakeAuthenticationRequest(p.sp.GetSSOBindingLocation(gosaml.HTTPRedirectBinding), gosaml.HTTPRedirectBinding, gosaml.HTTPPostBinding)
+	if e != nil {
+		return nil, errors.InternalServerError(e)
+	}
+	return &AuthnRequest{Id: request.ID, request: request, sp: p.sp}, nil
+}
+
+// RedirectUrl is the sso url of the identity provider with the request and the relay state, signed as the
+// redirect binding requires
+func (r *AuthnRequest) RedirectUrl(relayState string) (string, *errors.Error) {
+	// the relay state is added to the query unescaped
+	u, e := r.request.Redirect(url.QueryEscape(relayState), r.sp)
+	if e != nil {
+		return "", errors.InternalServerError(e)
+	}
+	return u.String(), nil
+}
+
+// ParseResponse verifies the SAMLResponse the identity provider posted: the signature against the certificates of
+// its metadata, the issuer, the destination and recipient, that it answers the request, the audience and the
+// validity window. It returns the user the assertion is about.
+func (p *ServiceProvider) ParseResponse(samlResponse string, requestId string) (*datatypes.SamlIdentity, *errors.Error) {
+	raw, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return nil, errors.SamlInvalidResponseError(fmt.Errorf("SAMLResponse is not base64: %w", err))
+	}
+	assertion, err := p.sp.ParseXMLResponse(raw, []string{requestId})
+	if err != nil {
+		// the error message of the library hides the reason
+		if invalid, ok := err.(*gosaml.InvalidResponseError); ok {
+			err = invalid.PrivateErr
+		}
+		return nil, errors.SamlInvalidResponseError(err)
+	}
+	// the library accepts assertions without an audience, they could be meant for any service provider
+	if assertion.Conditions == nil || len(assertion.Conditions.AudienceRestrictions) == 0 {
+		return nil, errors.SamlInvalidResponseError(fmt.Errorf("assertion has no audience restriction"))
+	}
+	if assertion.Subject == nil || assertion.Subject.NameID == nil || assertion.Subject.NameID.Value == "" {
+		return nil, errors.SamlInvalidResponseError(fmt.Errorf("assertion has no name id"))
+	}
+	if assertion.Subject.NameID.Format == string(gosaml.TransientNameIDFormat) {
+		return nil, errors.SamlInvalidResponseError(fmt.Errorf("a transient name id cannot identify the user, configure a persistent one"))
+	}
+
+	identity := &datatypes.SamlIdentity{
+		Subject:     assertion.Subject.NameID.Value,
+		Email:       strings.ToLower(p.attribute(assertion, p.mapping.Email, defaultEmailAttributes)),
+		GivenName:   p.attribute(assertion, p.mapping.GivenName, defaultGivenNameAttributes),
+		FamilyName:  p.attribute(assertion, p.mapping.FamilyName, defaultFamilyNameAttributes),
+		AssertionId: assertion.ID,
+		ExpiresAt:   expiresAt(assertion),
+	}
+	if identity.Email == "" && p.mapping.Email == "" && assertion.Subject.NameID.Format == string(gosaml.EmailAddressNameIDFormat) {
+		identity.Email = strings.ToLower(assertion.Subject.NameID.Value)
+	}
+	return identity, nil
+}
+
+// the first value of the mapped attribute, or of the first common name present
+func (p *ServiceProvider) attribute(assertion *gosaml.Assertion, mapped string, defaults []string) string {
+	names := defaults
+	if mapped != "" {
+		names = []string{mapped}
+	}
+	for _, name := range names {
+		for _, statement := range assertion.AttributeStatements {
+			for _, attribute := range statement.Attributes {
+				if (attribute.Name == name || attribute.FriendlyName == name) && len(attribute.Values) > 0 {
+					return strings.TrimSpace(attribute.Values[0].Value)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// the latest time the assertion is still accepted, replays have to be caught until then
+func expiresAt(assertion *gosaml.Assertion) time.Time {
+	expires := assertion.IssueInstant.Add(gosaml.MaxIssueDelay)
+	if notOnOrAfter := assertion.Conditions.NotOnOrAfter; !notOnOrAfter.IsZero() && notOnOrAfter.After(expires) {
+		expires = notOnOrAfter
+	}
+	return expires.Add(gosaml.MaxClockSkew)
+}
+
+func parseBase64Certificate(data string) (*x509.Certificate, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(data), ""))
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
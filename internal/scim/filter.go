@@ -0,0 +1,280 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AttributeKind decides how the values of a filter are compared with the stored field
+type AttributeKind int
+
+const (
+	// CaseIgnored strings match regardless of case, the default of RFC 7643
+	CaseIgnored AttributeKind = iota
+	CaseExact
+	// ObjectId is the id of the resource, it can only be compared for equality
+	ObjectId
+)
+
+// Attribute is the field an attribute of a filter queries
+type Attribute struct {
+	Field string
+	Kind  AttributeKind
+}
+
+// attributes the filters of the users can query, keyed by the lowercased attribute path
+var UserAttributes = map[string]Attribute{
+	"id":              {Field: "_id", Kind: ObjectId},
+	"username":        {Field: "email", Kind: CaseIgnored},
+	"emails":          {Field: "email", Kind: CaseIgnored},
+	"emails.value":    {Field: "email", Kind: CaseIgnored},
+	"name.givenname":  {Field: "given_name", Kind: CaseIgnored},
+	"name.familyname": {Field: "family_name", Kind: CaseIgnored},
+}
+
+// attributes the filters of the groups can query, keyed by the lowercased attribute path
+var GroupAttributes = map[string]Attribute{
+	"id":            {Field: "_id", Kind: ObjectId},
+	"displayname":   {Field: "display_name", Kind: CaseIgnored},
+	"externalid":    {Field: "external_id", Kind: CaseExact},
+	"members":       {Field: "member_ids", Kind: CaseExact},
+	"members.value": {Field: "member_ids", Kind: CaseExact},
+}
+
+// ParseFilter turns the filter of a query, see RFC 7644 section 3.4.2.2, into a mongo filter. Logical operators,
+// grouping and all comparison operators are supported on the given attributes, complex attribute filters are not.
+func ParseFilter(filter string, attributes map[string]Attribute) (bson.M, *errors.Error) {
+	tokens, e := tokenizeFilter(filter)
+	if e != nil {
+		return nil, e
+	}
+	p := &filterParser{tokens: tokens, attributes: attributes}
+	query, e := p.parseOr()
+	if e != nil {
+		return nil, e
+	}
+	if !p.done() {
+		return nil, errors.ScimInvalidFilterError(fmt.Sprintf("unexpected %s", p.peek().text))
+	}
+	return query, nil
+}
+
+type filterToken struct {
+	text string
+	// quoted tokens are string values, never keywords
+	quoted bool
+}
+
+func tokenizeFilter(filter string) ([]filterToken, *errors.Error) {
+	tokens := []filterToken{}
+	for i := 0; i < len(filter); {
+		switch ch := filter[i]; {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == '(' || ch == ')':
+			tokens = append(tokens, filterToken{text: string(ch)})
+			i++
+		case ch == '"':
+			end := i + 1
+			for ; end < len(filter) && filter[end] != '"'; end++ {
+				if filter[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(filter) {
+				return nil, errors.ScimInvalidFilterError("unterminated string")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(filter[i:end+1]), &value); err != nil {
+				return nil, errors.ScimInvalidFilterError(fmt.Sprintf("invalid string %s", filter[i:end+1]))
+			}
+			tokens = append(tokens, filterToken{text: value, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for ; end < len(filter) && !strings.ContainsRune(" \t()\"", rune(filter[end])); end++ {
+			}
+			word := filter[i:end]
+			if strings.ContainsAny(word, "[]") {
+				return nil, errors.ScimInvalidFilterError("complex attribute filters are not supported")
+			}
+			tokens = append(tokens, filterToken{text: word})
+			i = end
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, errors.ScimInvalidFilterError("empty filter")
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens     []filterToken
+	position   int
+	attributes map[string]Attribute
+}
+
+func (p *filterParser) done() bool {
+	return p.position >= len(p.tokens)
+}
+
+func (p *filterParser) peek() filterToken {
+	if p.done() {
+		return filterToken{}
+	}
+	return p.tokens[p.position]
+}
+
+func (p *filterParser) next() (filterToken, *errors.Error) {
+	if p.done() {
+		return filterToken{}, errors.ScimInvalidFilterError("unexpected end")
+	}
+	p.position++
+	return p.tokens[p.position-1], nil
+}
+
+// isKeyword tells whether the next token is the unquoted keyword, keywords are case-insensitive
+func (p *filterParser) isKeyword(keyword string) bool {
+	token := p.peek()
+	return !token.quoted && strings.EqualFold(token.text, keyword)
+}
+
+func (p *filterParser) parseOr() (bson.M, *errors.Error) {
+	return p.parseLogical("or", "$or", p.parseAnd)
+}
+
+func (p *filterParser) parseAnd() (bson.M, *errors.Error) {
+	return p.parseLogical("and", "$and", p.parseUnary)
+}
+
+func (p *filterParser) parseLogical(keyword string, operator string, parseOperand func() (bson.M, *errors.Error)) (bson.M, *errors.Error) {
+	operand, e := parseOperand()
+	if e != nil {
+		return nil, e
+	}
+	operands := bson.A{operand}
+	for p.isKeyword(keyword) {
+		p.position++
+		if operand, e = parseOperand(); e != nil {
+			return nil, e
+		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 1 {
+		return operand, nil
+	}
+	return bson.M{operator: operands}, nil
+}
+
+func (p *filterParser) parseUnary() (bson.M, *errors.Error) {
+	if p.isKeyword("not") {
+		p.position++
+		if token := p.peek(); token.quoted || token.text != "(" {
+			return nil, errors.ScimInvalidFilterError("not must be followed by a parenthesized filter")
+		}
+		query, e := p.parseUnary()
+		if e != nil {
+			return nil, e
+		}
+		return bson.M{"$nor": bson.A{query}}, nil
+	}
+	if token := p.peek(); !token.quoted && token.text == "(" {
+		p.position++
+		query, e := p.parseOr()
+		if e != nil {
+			return nil, e
+		}
+		if token, e = p.next(); e != nil || token.quoted || token.text != ")" {
+			return nil, errors.ScimInvalidFilterError("missing )")
+		}
+		return query, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (bson.M, *errors.Error) {
+	path, e := p.next()
+	if e != nil {
+		return nil, e
+	}
+	attribute, ok := p.attributes[strings.ToLower(path.text)]
+	if path.quoted || !ok {
+		return nil, errors.ScimInvalidFilterError(fmt.Sprintf("unsupported attribute %s", path.text))
+	}
+	operator, e := p.next()
+	if e != nil {
+		return nil, e
+	}
+	op := strings.ToLower(operator.text)
+	if operator.quoted {
+		return nil, errors.ScimInvalidFilterError(fmt.Sprintf("invalid operator %s", operator.text))
+	}
+	if op == "pr" {
+		return bson.M{attribute.Field: bson.M{"$exists": true, "$nin": bson.A{nil, ""}}}, nil
+	}
+	value, e := p.next()
+	if e != nil {
+		return nil, e
+	}
+	if !value.quoted {
+		return nil, errors.ScimInvalidFilterError(fmt.Sprintf("%s must be compared with a string", path.text))
+	}
+	return compare(attribute, path.text, op, value.text)
+}
+
+func compare(attribute Attribute, path string, op string, value string) (bson.M, *errors.Error) {
+	if attribute.Kind == ObjectId {
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, errors.ScimInvalidFilterError(fmt.Sprintf("%s is not an id", value))
+		}
+		switch op {
+		case "eq":
+			return bson.M{attribute.Field: id}, nil
+		case "ne":
+			return bson.M{attribute.Field: bson.M{"$ne": id}}, nil
+		}
+		return nil, errors.ScimInvalidFilterError(fmt.Sprintf("%s only supports eq and ne", path))
+	}
+
+	exact := attribute.Kind == CaseExact
+	switch op {
+	case "eq":
+		if exact {
+			return bson.M{attribute.Field: value}, nil
+		}
+		return matching(attribute.Field, "^"+regexp.QuoteMeta(value)+"$", false), nil
+	case "ne":
+		if exact {
+			return bson.M{attribute.Field: bson.M{"$ne": value}}, nil
+		}
+		return bson.M{attribute.Field: bson.M{"$not": matchingRegex("^"+regexp.QuoteMeta(value)+"$", false)}}, nil
+	case "co":
+		return matching(attribute.Field, regexp.QuoteMeta(value), exact), nil
+	case "sw":
+		return matching(attribute.Field, "^"+regexp.QuoteMeta(value), exact), nil
+	case "ew":
+		return matching(attribute.Field, regexp.QuoteMeta(value)+"$", exact), nil
+	case "gt", "ge", "lt", "le":
+		operators := map[string]string{"gt": "$gt", "ge": "$gte", "lt": "$lt", "le": "$lte"}
+		return bson.M{attribute.Field: bson.M{operators[op]: value}}, nil
+	}
+	return nil, errors.ScimInvalidFilterError(fmt.Sprintf("unknown operator %s", op))
+}
+
+func matching(field string, pattern string, exact bool) bson.M {
+	return bson.M{field: matchingRegex(pattern, exact)}
+}
+
+func matchingRegex(pattern string, exact bool) primitive.Regex {
+	if exact {
+		return primitive.Regex{Pattern: pattern}
+	}
+	return primitive.Regex{Pattern: pattern, Options: "i"}
+}
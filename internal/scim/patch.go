@@ -0,0 +1,256 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+)
+
+const (
+	opAdd     = "add"
+	opReplace = "replace"
+	opRemove  = "remove"
+)
+
+// a member selected by its value, the only value filter identity providers use in the paths of group patches
+var memberPathPattern = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+("(?:[^"\\]|\\.)*")\s*\]$`)
+
+// ApplyUserPatch applies the operations to the user, see RFC 7644 section 3.5.2. Attributes the service does not
+// store are ignored, the emails are derived from the userName.
+func ApplyUserPatch(user *User, operations []PatchOperation) *errors.Error {
+	for _, operation := range operations {
+		op, e := patchOp(operation)
+		if e != nil {
+			return e
+		}
+		if operation.Path == "" {
+			values, e := patchValues(operation)
+			if e != nil {
+				return e
+			}
+			for path, value := range values {
+				if e = setUserAttribute(user, path, value); e != nil {
+					return e
+				}
+			}
+			continue
+		}
+		var value json.RawMessage
+		if op != opRemove {
+			value = operation.Value
+		}
+		if e = setUserAttribute(user, operation.Path, value); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// setUserAttribute sets the attribute at the path, a nil value removes it
+func setUserAttribute(user *User, path string, value json.RawMessage) (e *errors.Error) {
+	switch strings.ToLower(trimSchema(path, constants.ScimSchemaUser)) {
+	case "username":
+		user.UserName, e = stringValue(path, value)
+	case "externalid":
+		user.ExternalId, e = stringValue(path, value)
+	case "active":
+		if value == nil {
+			return errors.ScimInvalidValueError("active can not be removed")
+		}
+		active, e := boolValue(path, value)
+		if e != nil {
+			return e
+		}
+		user.Active = &active
+	case "name":
+		if value == nil {
+			user.Name = nil
+			return nil
+		}
+		// the fields in the value replace the ones of the current name
+		name := &Name{}
+		if user.Name != nil {
+			*name = *user.Name
+		}
+		if err := json.Unmarshal(value, name); err != nil {
+			return errors.ScimInvalidValueError(fmt.Sprintf("%s must be a name", path))
+		}
+		user.Name = name
+	case "name.givenname":
+		if user.Name == nil {
+			user.Name = &Name{}
+		}
+		user.Name.GivenName, e = stringValue(path, value)
+	case "name.familyname":
+		if user.Name == nil {
+			user.Name = &Name{}
+		}
+		user.Name.FamilyName, e = stringValue(path, value)
+	}
+	return e
+}
+
+// ApplyGroupPatch applies the operations to the group, see RFC 7644 section 3.5.2. Members are added to and removed
+// from the current ones, or selected by value in the path for removal.
+func ApplyGroupPatch(group *Group, operations []PatchOperation) *errors.Error {
+	for _, operation := range operations {
+		op, e := patchOp(operation)
+		if e != nil {
+			return e
+		}
+		if operation.Path == "" {
+			values, e := patchValues(operation)
+			if e != nil {
+				return e
+			}
+			for path, value := range values {
+				if e = setGroupAttribute(group, op, path, value); e != nil {
+					return e
+				}
+			}
+			continue
+		}
+		if match := memberPathPattern.FindStringSubmatch(operation.Path); match != nil {
+			if op != opRemove {
+				return errors.ScimInvalidPathError(fmt.Sprintf("%s can only be removed", operation.Path))
+			}
+			var memberId string
+			if err := json.Unmarshal([]byte(match[1]), &memberId); err != nil {
+				return errors.ScimInvalidPathError(operation.Path)
+			}
+			group.Members = withoutMembers(group.Members, []Member{{Value: memberId}})
+			continue
+		}
+		if e = setGroupAttribute(group, op, operation.Path, operation.Value); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func setGroupAttribute(group *Group, op string, path string, value json.RawMessage) (e *errors.Error) {
+	attribute := strings.ToLower(trimSchema(path, constants.ScimSchemaGroup))
+	if op == opRemove && attribute != "members" {
+		value = nil
+	}
+	switch attribute {
+	case "displayname":
+		group.DisplayName, e = stringValue(path, value)
+	case "externalid":
+		group.ExternalId, e = stringValue(path, value)
+	case "members":
+		var members []Member
+		if value != nil {
+			if err := json.Unmarshal(value, &members); err != nil {
+				return errors.ScimInvalidValueError(fmt.Sprintf("%s must be a list of members", path))
+			}
+		}
+		switch {
+		case op == opAdd:
+			group.Members = withMembers(group.Members, members)
+		case op == opReplace:
+			group.Members = withMembers(nil, members)
+		// without a value all members are removed
+		case value == nil:
+			group.Members = []Member{}
+		default:
+			group.Members = withoutMembers(group.Members, members)
+		}
+	default:
+		if strings.HasPrefix(attribute, "members") {
+			return errors.ScimInvalidPathError(path)
+		}
+	}
+	return e
+}
+
+func patchOp(operation PatchOperation) (string, *errors.Error) {
+	op := strings.ToLower(operation.Op)
+	if op != opAdd && op != opReplace && op != opRemove {
+		return "", errors.ScimInvalidValueError(fmt.Sprintf("unknown op %s", operation.Op))
+	}
+	if op == opRemove && operation.Path == "" {
+		return "", errors.ScimInvalidPathError("remove needs a path")
+	}
+	return op, nil
+}
+
+// patchValues is the value of an operation without a path, an object of attributes
+func patchValues(operation PatchOperation) (map[string]json.RawMessage, *errors.Error) {
+	values := map[string]json.RawMessage{}
+	if err := json.Unmarshal(operation.Value, &values); err != nil {
+		return nil, errors.ScimInvalidValueError("the value of an operation without a path must be an object")
+	}
+	return values, nil
+}
+
+// trimSchema strips the schema urn identity providers may put in front of an attribute
+func trimSchema(path string, schema string) string {
+	if len(path) > len(schema) && strings.EqualFold(path[:len(schema)+1], schema+":") {
+		return path[len(schema)+1:]
+	}
+	return path
+}
+
+func stringValue(path string, value json.RawMessage) (string, *errors.Error) {
+	if value == nil {
+		return "", nil
+	}
+	var str *string
+	if err := json.Unmarshal(value, &str); err != nil {
+		return "", errors.ScimInvalidValueError(fmt.Sprintf("%s must be a string", path))
+	}
+	if str == nil {
+		return "", nil
+	}
+	return *str, nil
+}
+
+// boolValue also accepts booleans sent as strings, as some identity providers do
+func boolValue(path string, value json.RawMessage) (bool, *errors.Error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		if b, err := strconv.ParseBool(str); err == nil {
+			return b, nil
+		}
+	}
+	return false, errors.ScimInvalidValueError(fmt.Sprintf("%s must be a boolean", path))
+}
+
+func withMembers(members []Member, added []Member) []Member {
+	result := append([]Member{}, members...)
+	for _, member := range added {
+		if !hasMember(result, member.Value) {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+func withoutMembers(members []Member, removed []Member) []Member {
+	result := []Member{}
+	for _, member := range members {
+		if !hasMember(removed, member.Value) {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+func hasMember(members []Member, value string) bool {
+	for _, member := range members {
+		if member.Value == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/scim"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	tests := []struct {
+		filter string
+		want   bson.M
+	}{
+		{`userName eq "Jane@Example.com"`, bson.M{"email": primitive.Regex{Pattern: `^Jane@Example\.com$`, Options: "i"}}},
+		{`emails.value co "example.com"`, bson.M{"email": primitive.Regex{Pattern: `example\.com`, Options: "i"}}},
+		{`name.familyName sw "d.e"`, bson.M{"family_name": primitive.Regex{Pattern: `^d\.e`, Options: "i"}}},
+		{`name.givenName eq "Jane"`, bson.M{"given_name": primitive.Regex{Pattern: `^Jane$`, Options: "i"}}},
+		{`id eq "` + id.Hex() + `"`, bson.M{"_id": id}},
+		{`name.givenName pr`, bson.M{"given_name": bson.M{"$exists": true, "$nin": bson.A{nil, ""}}}},
+		{
+			`USERNAME EQ "a@b.c" OR (userName ew "@d.e" and not (name.givenName eq "x"))`,
+			bson.M{"$or": bson.A{
+				bson.M{"email": primitive.Regex{Pattern: `^a@b\.c$`, Options: "i"}},
+				bson.M{"$and": bson.A{
+					bson.M{"email": primitive.Regex{Pattern: `@d\.e$`, Options: "i"}},
+					bson.M{"$nor": bson.A{bson.M{"given_name": primitive.Regex{Pattern: `^x$`, Options: "i"}}}},
+				}},
+			}},
+		},
+		{`userName eq "quote\"d"`, bson.M{"email": primitive.Regex{Pattern: `^quote"d$`, Options: "i"}}},
+	}
+	for _, test := range tests {
+		got, e := scim.ParseFilter(test.filter, scim.UserAttributes)
+		if e != nil {
+			t.Errorf("%s: %s", test.filter, e.UserErrorString())
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.filter, got, test.want)
+		}
+	}
+}
+
+func TestParseGroupFilter(t *testing.T) {
+	got, e := scim.ParseFilter(`displayName eq "Engineering" and externalId sw "Ext" and members eq "u1"`, scim.GroupAttributes)
+	if e != nil {
+		t.Fatal(e.UserErrorString())
+	}
+	want := bson.M{"$and": bson.A{
+		bson.M{"display_name": primitive.Regex{Pattern: `^Engineering$`, Options: "i"}},
+		bson.M{"external_id": primitive.Regex{Pattern: `^Ext`}},
+		bson.M{"member_ids": "u1"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseFilterRejectsInvalidFilters(t *testing.T) {
+	for _, filter := range []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName eq jane`,
+		`password eq "secret"`,
+		`userName xx "a"`,
+		`(userName eq "a"`,
+		`userName eq "a" and`,
+		`userName eq "a" userName eq "b"`,
+		`emails[type eq "work"].value eq "a"`,
+		`id co "abc"`,
+		`id eq "not an id"`,
+		`userName eq "unterminated`,
+	} {
+		if _, e := scim.ParseFilter(filter, scim.UserAttributes); e == nil || e.Code != errors.CodeScimInvalidFilter {
+			t.Errorf("%q was not rejected as an invalid filter", filter)
+		}
+	}
+}
+
+func TestApplyUserPatch(t *testing.T) {
+	user := &scim.User{UserName: "jane@example.com", Name: &scim.Name{GivenName: "Jane", FamilyName: "Doe"}}
+	operations := []scim.PatchOperation{
+		{Op: "Replace", Path: "name.familyName", Value: json.RawMessage(`"Smith"`)},
+		{Op: "replace", Value: json.RawMessage(`{"active": "False", "userName": "jane.smith@example.com", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department": "x"}`)},
+		{Op: "remove", Path: "urn:ietf:params:scim:schemas:core:2.0:User:name.givenName"},
+		{Op: "add", Path: "emails[type eq \"work\"].value", Value: json.RawMessage(`"ignored@example.com"`)},
+	}
+	if e := scim.ApplyUserPatch(user, operations); e != nil {
+		t.Fatal(e.UserErrorString())
+	}
+	if user.UserName != "jane.smith@example.com" || user.Name.GivenName != "" || user.Name.FamilyName != "Smith" || user.IsActive() {
+		t.Errorf("unexpected user after patch: %+v %+v", user, user.Name)
+	}
+}
+
+func TestApplyUserPatchRejectsInvalidOperations(t *testing.T) {
+	for _, operation := range []scim.PatchOperation{
+		{Op: "move", Path: "userName", Value: json.RawMessage(`"a@b.c"`)},
+		{Op: "remove"},
+		{Op: "replace", Path: "active", Value: json.RawMessage(`"maybe"`)},
+		{Op: "replace", Path: "userName", Value: json.RawMessage(`1`)},
+		{Op: "remove", Path: "active"},
+		{Op: "replace", Value: json.RawMessage(`"not an object"`)},
+	} {
+		if e := scim.ApplyUserPatch(&scim.User{}, []scim.PatchOperation{operation}); e == nil {
+			t.Errorf("%+v was not rejected", operation)
+		}
+	}
+}
+
+func TestApplyGroupPatch(t *testing.T) {
+	group := &scim.Group{DisplayName: "Engineering", Members: []scim.Member{{Value: "1"}, {Value: "2"}}}
+	operations := []scim.PatchOperation{
+		{Op: "add", Path: "members", Value: json.RawMessage(`[{"value": "2"}, {"value": "3"}, {"value": "4"}]`)},
+		{Op: "remove", Path: `members[value eq "1"]`},
+		{Op: "remove", Path: "members", Value: json.RawMessage(`[{"value": "3"}]`)},
+		{Op: "replace", Value: json.RawMessage(`{"displayName": "Platform", "externalId": "ext-1"}`)},
+	}
+	if e := scim.ApplyGroupPatch(group, operations); e != nil {
+		t.Fatal(e.UserErrorString())
+	}
+	if group.DisplayName != "Platform" || group.ExternalId != "ext-1" {
+		t.Errorf("unexpected group after patch: %+v", group)
+	}
+	if want := []scim.Member{{Value: "2"}, {Value: "4"}}; !reflect.DeepEqual(group.Members, want) {
+		t.Errorf("got members %+v, want %+v", group.Members, want)
+	}
+
+	operations = []scim.PatchOperation{{Op: "replace", Path: "members", Value: json.RawMessage(`[{"value": "5"}]`)}}
+	if e := scim.ApplyGroupPatch(group, operations); e != nil {
+		t.Fatal(e.UserErrorString())
+	}
+	if want := []scim.Member{{Value: "5"}}; !reflect.DeepEqual(group.Members, want) {
+		t.Errorf("got members %+v, want %+v", group.Members, want)
+	}
+
+	operations = []scim.PatchOperation{{Op: "remove", Path: "members"}}
+	if e := scim.ApplyGroupPatch(group, operations); e != nil {
+		t.Fatal(e.UserErrorString())
+	}
+	if len(group.Members) != 0 {
+		t.Errorf("members were not removed: %+v", group.Members)
+	}
+}
+
+func TestApplyGroupPatchRejectsInvalidPaths(t *testing.T) {
+	for _, operation := range []scim.PatchOperation{
+		{Op: "replace", Path: `members[value eq "1"]`, Value: json.RawMessage(`{"display": "x"}`)},
+		{Op: "remove", Path: `members[display eq "x"]`},
+		{Op: "add", Path: "members", Value: json.RawMessage(`{"value": "1"}`)},
+	} {
+		if e := scim.ApplyGroupPatch(&scim.Group{}, []scim.PatchOperation{operation}); e == nil {
+			t.Errorf("%+v was not rejected", operation)
+		}
+	}
+}
@@ -1,20 +1,14 @@
 package server
 
 import (
-	"fmt"
-	"os"
-	"strings"
-
+	"github.com/MitP1997/golang-user-management/internal/constants"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-func getServerConfig() gin.HandlerFunc {
+func getServerConfig(allowOrigins []string) gin.HandlerFunc {
 	config := cors.DefaultConfig()
-	var allowOrigins []string
-	allowOrigins = append(allowOrigins, strings.Split(os.Getenv("SERVER_ALLOW_ORIGINS"), ",")...)
 	config.AllowOrigins = allowOrigins
-	fmt.Println(allowOrigins)
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", constants.OrganizationHeader}
 	return cors.New(config)
 }
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/handler"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/services"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+)
+
+// NewGrpcServer serves the gRPC APIs, it must be created after NewServer which sets up the clients and the database
+func NewGrpcServer() *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		// extracts the incoming traceparent and starts the server span before the request context is built
+		otelgrpc.UnaryServerInterceptor(),
+		handler.UnaryServerInterceptor(serviceRegistry.GetLogger()),
+	))
+	services.RegisterUserServiceServer(srv, handler.NewUserServiceServer())
+	return srv
+}
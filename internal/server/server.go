@@ -1,38 +1,83 @@
 package server
 
 import (
+	"fmt"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/devices"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/handler"
 	"github.com/MitP1997/golang-user-management/internal/middleware"
 	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/oidc"
 	"github.com/MitP1997/golang-user-management/internal/router"
+	"github.com/MitP1997/golang-user-management/internal/saml"
 	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/signup"
+	"github.com/MitP1997/golang-user-management/internal/social"
+	"github.com/MitP1997/golang-user-management/internal/suspensions"
+	"github.com/MitP1997/golang-user-management/internal/tracing"
+	"github.com/MitP1997/golang-user-management/internal/webhooks"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func NewServer() *gin.Engine {
-	err := loadEnv()
+func NewServer(cfg *config.Config) *gin.Engine {
+	serviceRegistry.InitServiceRegistry(cfg)
+	err := signup.InitSignupPolicy(cfg.Signup)
 	if err != nil {
 		panic(err)
 	}
-
-	serviceRegistry.InitServiceRegistry()
-	err = models.InitMongoConnection()
+	err = devices.InitLoginAlerts(cfg.LoginAlerts)
+	if err != nil {
+		panic(err)
+	}
+	err = models.InitMongoConnection(serviceRegistry.GetLogger(), cfg.Database, cfg.Tenancy, cfg.Audit)
 	if err != nil {
 		panic(err)
 	}
+	fmt.Println("Pinged your deployment. You successfully connected to MongoDB!")
+	err = tracing.InitTracerProvider(cfg.Tracing)
+	if err != nil {
+		panic(err)
+	}
+	events.Configure(cfg.Events)
+	handler.RegisterEventSubscribers()
+	events.StartRelay(serviceRegistry.GetLogger())
+	if cfg.Events.Outbox && !models.SupportsTransactions() {
+		fmt.Println("MongoDB is not a replica set, outbox events are written right after the changes instead of in the same transaction")
+	}
+	webhooks.Start(cfg.Webhooks, serviceRegistry.GetLogger())
+	oidc.Configure(cfg.Oidc, cfg.App.BaseUrl)
+	if err = oidc.Start(serviceRegistry.GetLogger()); err != nil {
+		panic(err)
+	}
+	suspensions.Start(serviceRegistry.GetLogger())
+	if err = social.InitSocialLogin(cfg.SocialLogin, cfg.Oidc.Issuer+constants.SocialLoginCallbackPath); err != nil {
+		panic(err)
+	}
+	if err = saml.InitSaml(cfg.Saml, cfg.Oidc.Issuer); err != nil {
+		panic(err)
+	}
 	r := gin.Default()
+	// lets the redis and mongo calls made with the gin context find the request span
+	r.ContextWithFallback = true
+	// registered before the middlewares below so frequent probes stay out of the request logs, traces and metrics
+	router.RegisterHealthRoutes(r)
 
-	r.Use(getServerConfig())
+	r.Use(getServerConfig(cfg.Server.AllowOrigins))
+	r.Use(otelgin.Middleware(tracing.ServiceName))
 
 	r.Use(middleware.IntroduceLoggingContextMiddleware(serviceRegistry.GetLogger()))
 	r.Use(middleware.GinzapLoggerMiddleware())
+	// outside the recovery middleware so requests that panic are still counted
+	r.Use(middleware.MetricsMiddleware())
 	r.Use(middleware.RecoveryWithZapMiddleware())
 
+	// operational endpoint for the scraper, kept outside the versioned api
+	router.RegisterMetricsRoutes(r)
+	router.RegisterWellKnownRoutes(r)
 	router.RegisterRoutes(r)
 	return r
 }
-
-func loadEnv() (err error) {
-	err = godotenv.Load()
-	return
-}
@@ -2,27 +2,34 @@ package server
 
 import (
 	"github.com/MitP1997/golang-user-management/internal/clients"
+	"github.com/MitP1997/golang-user-management/internal/config"
 	"go.uber.org/zap"
 )
 
 var serviceRegistry map[string]interface{}
 
 const (
+	keyConfig       = "key-config"
 	keyLogger       = "key-logger"
 	keyRedisClient  = "key-redis-client"
 	keyMailerClient = "key-mailer-client"
 )
 
-func InitServiceRegistry() {
+func InitServiceRegistry(cfg *config.Config) {
 	serviceRegistry = make(map[string]interface{})
 	logger, _ := zap.NewProduction()
-	redisClient := clients.NewRedisClient()
-	mailerClient := clients.NewMailerClient(logger)
+	redisClient := clients.NewRedisClient(cfg.Redis, cfg.Tokens)
+	mailerClient := clients.NewMailerClient(logger, cfg.Email)
+	addClientToServiceRegistry(keyConfig, cfg)
 	addClientToServiceRegistry(keyLogger, logger)
 	addClientToServiceRegistry(keyRedisClient, redisClient)
 	addClientToServiceRegistry(keyMailerClient, mailerClient)
 }
 
+func GetConfig() *config.Config {
+	return getClientFromServiceRegistry(keyConfig).(*config.Config)
+}
+
 func GetLogger() *zap.Logger {
 	return getClientFromServiceRegistry(keyLogger).(*zap.Logger)
 }
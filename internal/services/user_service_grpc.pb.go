@@ -0,0 +1,419 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: services/user_service.proto
+
+package services
+
+import (
+	context "context"
+	requests "github.com/MitP1997/golang-user-management/internal/requests"
+	responses "github.com/MitP1997/golang-user-management/internal/responses"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	UserService_Signup_FullMethodName                     = "/golang_user_management.services.UserService/Signup"
+	UserService_Login_FullMethodName                      = "/golang_user_management.services.UserService/Login"
+	UserService_VerifyEmail_FullMethodName                = "/golang_user_management.services.UserService/VerifyEmail"
+	UserService_ResendEmailVerificationOtp_FullMethodName = "/golang_user_management.services.UserService/ResendEmailVerificationOtp"
+	UserService_ChangePasswordInitiate_FullMethodName     = "/golang_user_management.services.UserService/ChangePasswordInitiate"
+	UserService_ChangePassword_FullMethodName             = "/golang_user_management.services.UserService/ChangePassword"
+	UserService_ResetPasswordInitiate_FullMethodName      = "/golang_user_management.services.UserService/ResetPasswordInitiate"
+	UserService_ResetPassword_FullMethodName              = "/golang_user_management.services.UserService/ResetPassword"
+	UserService_GetPendingRequirements_FullMethodName     = "/golang_user_management.services.UserService/GetPendingRequirements"
+)
+
+// UserServiceClient is the client API for UserService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UserServiceClient interface {
+	Signup(ctx context.Context, in *requests.SignupRequest, opts ...grpc.CallOption) (*responses.AuthTokenResponse, error)
+	Login(ctx context.Context, in *requests.LoginRequest, opts ...grpc.CallOption) (*responses.AuthTokenResponse, error)
+	// requires authorization
+	VerifyEmail(ctx context.Context, in *requests.VerifyEmailRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error)
+	// requires authorization
+	ResendEmailVerificationOtp(ctx context.Context, in *requests.ResendEmailVerificationOtpRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error)
+	// requires authorization
+	ChangePasswordInitiate(ctx context.Context, in *requests.ChangePasswordInitiateRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error)
+	// requires authorization
+	ChangePassword(ctx context.Context, in *requests.ChangePasswordRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error)
+	// for a forgotten password or one that has to be reset, the emailed otp proves control of the account
+	ResetPasswordInitiate(ctx context.Context, in *requests.ResetPasswordInitiateRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error)
+	ResetPassword(ctx context.Context, in *requests.ResetPasswordRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error)
+	// requires authorization
+	GetPendingRequirements(ctx context.Context, in *requests.GetPendingRequirementsRequest, opts ...grpc.CallOption) (*responses.PendingRequirementsResponse, error)
+}
+
+type userServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUserServiceClient(cc grpc.ClientConnInterface) UserServiceClient {
+	return &userServiceClient{cc}
+}
+
+func (c *userServiceClient) Signup(ctx context.Context, in *requests.SignupRequest, opts ...grpc.CallOption) (*responses.AuthTokenResponse, error) {
+	out := new(responses.AuthTokenResponse)
+	err := c.cc.Invoke(ctx, UserService_Signup_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) Login(ctx context.Context, in *requests.LoginRequest, opts ...grpc.CallOption) (*responses.AuthTokenResponse, error) {
+	out := new(responses.AuthTokenResponse)
+	err := c.cc.Invoke(ctx, UserService_Login_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) VerifyEmail(ctx context.Context, in *requests.VerifyEmailRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error) {
+	out := new(responses.MessageResponse)
+	err := c.cc.Invoke(ctx, UserService_VerifyEmail_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ResendEmailVerificationOtp(ctx context.Context, in *requests.ResendEmailVerificationOtpRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error) {
+	out := new(responses.MessageResponse)
+	err := c.cc.Invoke(ctx, UserService_ResendEmailVerificationOtp_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ChangePasswordInitiate(ctx context.Context, in *requests.ChangePasswordInitiateRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error) {
+	out := new(responses.MessageResponse)
+	err := c.cc.Invoke(ctx, UserService_ChangePasswordInitiate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ChangePassword(ctx context.Context, in *requests.ChangePasswordRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error) {
+	out := new(responses.MessageResponse)
+	err := c.cc.Invoke(ctx, UserService_ChangePassword_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ResetPasswordInitiate(ctx context.Context, in *requests.ResetPasswordInitiateRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error) {
+	out := new(responses.MessageResponse)
+	err := c.cc.Invoke(ctx, UserService_ResetPasswordInitiate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ResetPassword(ctx context.Context, in *requests.ResetPasswordRequest, opts ...grpc.CallOption) (*responses.MessageResponse, error) {
+	out := new(responses.MessageResponse)
+	err := c.cc.Invoke(ctx, UserService_ResetPassword_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetPendingRequirements(ctx context.Context, in *requests.GetPendingRequirementsRequest, opts ...grpc.CallOption) (*responses.PendingRequirementsResponse, error) {
+	out := new(responses.PendingRequirementsResponse)
+	err := c.cc.Invoke(ctx, UserService_GetPendingRequirements_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UserServiceServer is the server API for UserService service.
+// All implementations must embed UnimplementedUserServiceServer
+// for forward compatibility
+type UserServiceServer interface {
+	Signup(context.Context, *requests.SignupRequest) (*responses.AuthTokenResponse, error)
+	Login(context.Context, *requests.LoginRequest) (*responses.AuthTokenResponse, error)
+	// requires authorization
+	VerifyEmail(context.Context, *requests.VerifyEmailRequest) (*responses.MessageResponse, error)
+	// requires authorization
+	ResendEmailVerificationOtp(context.Context, *requests.ResendEmailVerificationOtpRequest) (*responses.MessageResponse, error)
+	// requires authorization
+	ChangePasswordInitiate(context.Context, *requests.ChangePasswordInitiateRequest) (*responses.MessageResponse, error)
+	// requires authorization
+	ChangePassword(context.Context, *requests.ChangePasswordRequest) (*responses.MessageResponse, error)
+	// for a forgotten password or one that has to be reset, the emailed otp proves control of the account
+	ResetPasswordInitiate(context.Context, *requests.ResetPasswordInitiateRequest) (*responses.MessageResponse, error)
+	ResetPassword(context.Context, *requests.ResetPasswordRequest) (*responses.MessageResponse, error)
+	// requires authorization
+	GetPendingRequirements(context.Context, *requests.GetPendingRequirementsRequest) (*responses.PendingRequirementsResponse, error)
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+// UnimplementedUserServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedUserServiceServer struct {
+}
+
+func (UnimplementedUserServiceServer) Signup(context.Context, *requests.SignupRequest) (*responses.AuthTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Signup not implemented")
+}
+func (UnimplementedUserServiceServer) Login(context.Context, *requests.LoginRequest) (*responses.AuthTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedUserServiceServer) VerifyEmail(context.Context, *requests.VerifyEmailRequest) (*responses.MessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyEmail not implemented")
+}
+func (UnimplementedUserServiceServer) ResendEmailVerificationOtp(context.Context, *requests.ResendEmailVerificationOtpRequest) (*responses.MessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResendEmailVerificationOtp not implemented")
+}
+func (UnimplementedUserServiceServer) ChangePasswordInitiate(context.Context, *requests.ChangePasswordInitiateRequest) (*responses.MessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePasswordInitiate not implemented")
+}
+func (UnimplementedUserServiceServer) ChangePassword(context.Context, *requests.ChangePasswordRequest) (*responses.MessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedUserServiceServer) ResetPasswordInitiate(context.Context, *requests.ResetPasswordInitiateRequest) (*responses.MessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetPasswordInitiate not implemented")
+}
+func (UnimplementedUserServiceServer) ResetPassword(context.Context, *requests.ResetPasswordRequest) (*responses.MessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetPassword not implemented")
+}
+func (UnimplementedUserServiceServer) GetPendingRequirements(context.Context, *requests.GetPendingRequirementsRequest) (*responses.PendingRequirementsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPendingRequirements not implemented")
+}
+func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
+
+// UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UserServiceServer will
+// result in compilation errors.
+type UnsafeUserServiceServer interface {
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+func RegisterUserServiceServer(s grpc.ServiceRegistrar, srv UserServiceServer) {
+	s.RegisterService(&UserService_ServiceDesc, srv)
+}
+
+func _UserService_Signup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(requests.SignupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).Signup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_Signup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).Signup(ctx, req.(*requests.SignupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(requests.LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).Login(ctx, req.(*requests.LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_VerifyEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(requests.VerifyEmailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).VerifyEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_VerifyEmail_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).VerifyEmail(ctx, req.(*requests.VerifyEmailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ResendEmailVerificationOtp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(requests.ResendEmailVerificationOtpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ResendEmailVerificationOtp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ResendEmailVerificationOtp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ResendEmailVerificationOtp(ctx, req.(*requests.ResendEmailVerificationOtpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ChangePasswordInitiate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(requests.ChangePasswordInitiateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ChangePasswordInitiate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ChangePasswordInitiate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ChangePasswordInitiate(ctx, req.(*requests.ChangePasswordInitiateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(requests.ChangePasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ChangePassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ChangePassword(ctx, req.(*requests.ChangePasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ResetPasswordInitiate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(requests.ResetPasswordInitiateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ResetPasswordInitiate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ResetPasswordInitiate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ResetPasswordInitiate(ctx, req.(*requests.ResetPasswordInitiateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ResetPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(requests.ResetPasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ResetPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ResetPassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ResetPassword(ctx, req.(*requests.ResetPasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetPendingRequirements_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(requests.GetPendingRequirementsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetPendingRequirements(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetPendingRequirements_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetPendingRequirements(ctx, req.(*requests.GetPendingRequirementsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "golang_user_management.services.UserService",
+	HandlerType: (*UserServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Signup",
+			Handler:    _UserService_Signup_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _UserService_Login_Handler,
+		},
+		{
+			MethodName: "VerifyEmail",
+			Handler:    _UserService_VerifyEmail_Handler,
+		},
+		{
+			MethodName: "ResendEmailVerificationOtp",
+			Handler:    _UserService_ResendEmailVerificationOtp_Handler,
+		},
+		{
+			MethodName: "ChangePasswordInitiate",
+			Handler:    _UserService_ChangePasswordInitiate_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _UserService_ChangePassword_Handler,
+		},
+		{
+			MethodName: "ResetPasswordInitiate",
+			Handler:    _UserService_ResetPasswordInitiate_Handler,
+		},
+		{
+			MethodName: "ResetPassword",
+			Handler:    _UserService_ResetPassword_Handler,
+		},
+		{
+			MethodName: "GetPendingRequirements",
+			Handler:    _UserService_GetPendingRequirements_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "services/user_service.proto",
+}
@@ -0,0 +1,105 @@
+package signup
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+)
+
+//go:embed disposable_domains.txt
+var bundledDisposableDomains string
+
+var mode datatypes.SignupMode
+var allowedDomains map[string]bool
+var disposableDomains map[string]bool
+
+// InitSignupPolicy sets up the signup mode, the domain allowlist and the disposable domain list,
+// the disposable domains file adds more domains to block on top of the bundled list
+func InitSignupPolicy(signupConfig config.SignupConfig) (err error) {
+	mode = datatypes.SignupMode(signupConfig.Mode)
+
+	allowedDomains = make(map[string]bool)
+	for _, domain := range signupConfig.AllowedDomains {
+		allowedDomains[strings.ToLower(domain)] = true
+	}
+
+	disposableDomains = make(map[string]bool)
+	addDomains(disposableDomains, strings.NewReader(bundledDisposableDomains))
+	if path := signupConfig.DisposableDomainsFile; path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		addDomains(disposableDomains, file)
+	}
+	return
+}
+
+func GetMode() datatypes.SignupMode {
+	return mode
+}
+
+// GetAllowedDomains lists the domains accepted in domain_allowlist mode
+func GetAllowedDomains() []string {
+	domains := make([]string, 0, len(allowedDomains))
+	for domain := range allowedDomains {
+		domains = append(domains, domain)
+	}
+	return domains
+}
+
+// ValidateEmailDomain rejects disposable email domains in every mode
+// and, in domain_allowlist mode, domains that are not in the allowlist
+func ValidateEmailDomain(email string) *errors.Error {
+	domain := emailDomain(email)
+	if IsDisposableDomain(domain) {
+		return errors.DisposableEmailError()
+	}
+	if mode == constants.SignupModeDomainAllowlist && !allowedDomains[domain] {
+		return errors.EmailDomainNotAllowedError()
+	}
+	return nil
+}
+
+// IsDisposableDomain also matches subdomains of listed domains
+func IsDisposableDomain(domain string) bool {
+	domain = strings.ToLower(domain)
+	for domain != "" {
+		if disposableDomains[domain] {
+			return true
+		}
+		i := strings.Index(domain, ".")
+		if i < 0 {
+			break
+		}
+		domain = domain[i+1:]
+	}
+	return false
+}
+
+func emailDomain(email string) string {
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return ""
+	}
+	return strings.ToLower(email[i+1:])
+}
+
+func addDomains(domains map[string]bool, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains[line] = true
+	}
+}
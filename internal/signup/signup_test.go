@@ -0,0 +1,65 @@
+package signup_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/signup"
+)
+
+func TestValidateEmailDomain(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  string
+		email string
+		want  string
+	}{
+		{"open", string(constants.SignupModeOpen), "jane@example.com", ""},
+		{"open disposable", string(constants.SignupModeOpen), "jane@mailinator.com", errors.CodeSignupDisposableEmail},
+		{"disposable subdomain", string(constants.SignupModeOpen), "jane@eu.mailinator.com", errors.CodeSignupDisposableEmail},
+		{"disposable upper case", string(constants.SignupModeOpen), "jane@MAILINATOR.COM", errors.CodeSignupDisposableEmail},
+		{"allowlist allowed", string(constants.SignupModeDomainAllowlist), "jane@example.com", ""},
+		{"allowlist allowed upper case", string(constants.SignupModeDomainAllowlist), "jane@Example.COM", ""},
+		{"allowlist subdomain", string(constants.SignupModeDomainAllowlist), "jane@eu.example.com", errors.CodeSignupEmailDomainNotAllowed},
+		{"allowlist not allowed", string(constants.SignupModeDomainAllowlist), "jane@example.org", errors.CodeSignupEmailDomainNotAllowed},
+		{"allowlist disposable", string(constants.SignupModeDomainAllowlist), "jane@mailinator.com", errors.CodeSignupDisposableEmail},
+		{"invite only", string(constants.SignupModeInviteOnly), "jane@example.org", ""},
+	}
+	for _, test := range tests {
+		if err := signup.InitSignupPolicy(config.SignupConfig{Mode: test.mode, AllowedDomains: []string{"Example.com"}}); err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if e := signup.ValidateEmailDomain(test.email); e != nil {
+			got = e.UserErrorCode()
+		}
+		if got != test.want {
+			t.Errorf("%s: ValidateEmailDomain(%q) = %q, want %q", test.name, test.email, got, test.want)
+		}
+	}
+}
+
+func TestDisposableDomainsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "domains.txt")
+	if err := os.WriteFile(path, []byte("# more domains\n\nThrowaway.test\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := signup.InitSignupPolicy(config.SignupConfig{Mode: string(constants.SignupModeOpen), DisposableDomainsFile: path}); err != nil {
+		t.Fatal(err)
+	}
+	for _, domain := range []string{"throwaway.test", "mx.throwaway.test", "mailinator.com"} {
+		if !signup.IsDisposableDomain(domain) {
+			t.Errorf("%s is not disposable", domain)
+		}
+	}
+	if signup.IsDisposableDomain("test") {
+		t.Error("the parent domain of a listed domain is disposable")
+	}
+	if err := signup.InitSignupPolicy(config.SignupConfig{DisposableDomainsFile: filepath.Join(t.TempDir(), "missing.txt")}); err == nil {
+		t.Error("missing disposable domains file accepted")
+	}
+}
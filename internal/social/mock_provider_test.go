@@ -0,0 +1,163 @@
+package social_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+// mockProvider is a minimal OpenID Connect provider: discovery, jwks, a token endpoint checking the client
+// secret and PKCE, and userinfo. authorize stands in for the login page and returns the code of a request.
+type mockProvider struct {
+	t            *testing.T
+	server       *httptest.Server
+	clientId     string
+	clientSecret string
+	key          *rsa.PrivateKey
+	kid          string
+
+	// the claims of the id tokens, mutate adjusts them per test
+	claims   map[string]interface{}
+	mutate   func(claims map[string]interface{})
+	signWith *rsa.PrivateKey
+	userInfo map[string]interface{}
+
+	mu    sync.Mutex
+	codes map[string]url.Values
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockProvider{
+		t:            t,
+		clientId:     "mock-client",
+		clientSecret: "mock-secret",
+		key:          key,
+		kid:          "key-1",
+		codes:        map[string]url.Values{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/jwks", m.jwks)
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/userinfo", m.userinfo)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	m.claims = map[string]interface{}{
+		"sub":            "subject-1",
+		"email":          "Jane@Example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+	}
+	return m
+}
+
+func (m *mockProvider) issuer() string {
+	return m.server.URL
+}
+
+// authorize accepts the authorization request and returns the code the provider redirects back with
+func (m *mockProvider) authorize(authUrl string) string {
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	code := base64.RawURLEncoding.EncodeToString([]byte(parsed.Query().Get("state")))
+	m.mu.Lock()
+	m.codes[code] = parsed.Query()
+	m.mu.Unlock()
+	return code
+}
+
+func (m *mockProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"issuer":                 m.issuer(),
+		"authorization_endpoint": m.issuer() + "/authorize",
+		"token_endpoint":         m.issuer() + "/token",
+		"userinfo_endpoint":      m.issuer() + "/userinfo",
+		"jwks_uri":               m.issuer() + "/jwks",
+	})
+}
+
+func (m *mockProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &m.key.PublicKey, KeyID: m.kid, Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientId, clientSecret, ok := r.BasicAuth()
+	if !ok || clientId != m.clientId || clientSecret != m.clientSecret {
+		writeJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	m.mu.Lock()
+	request, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || request.Get("redirect_uri") != r.PostForm.Get("redirect_uri") || request.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := map[string]interface{}{
+		"iss":   m.issuer(),
+		"aud":   m.clientId,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": request.Get("nonce"),
+	}
+	for name, value := range m.claims {
+		claims[name] = value
+	}
+	if m.mutate != nil {
+		m.mutate(claims)
+	}
+	signingKey := m.key
+	if m.signWith != nil {
+		signingKey = m.signWith
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: signingKey, KeyID: m.kid}}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	idToken, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{"access_token": "access-" + request.Get("state"), "token_type": "Bearer", "id_token": idToken})
+}
+
+func (m *mockProvider) userinfo(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" || m.userInfo == nil {
+		writeJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+	writeJson(w, http.StatusOK, m.userInfo)
+}
+
+func writeJson(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
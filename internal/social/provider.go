@@ -0,0 +1,329 @@
+package social
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+	// the discovery document is fetched again after this long to pick up changed endpoints
+	discoveryTtl = 24 * time.Hour
+	// an unknown kid refetches the jwks, at most this often so forged tokens cannot hammer the provider
+	jwksRefetchInterval = time.Minute
+	clockSkew           = time.Minute
+	maxResponseSize     = 1 << 20
+)
+
+var httpClient = &http.Client{Timeout: httpTimeout}
+
+// Provider is an OpenID Connect provider of the providers file, its endpoints are discovered from the issuer
+type Provider struct {
+	// used in the urls and stored on the linked identities, so it must not change once users logged in with it
+	Name        string `yaml:"name"`
+	DisplayName string `yaml:"display_name"`
+	Issuer      string `yaml:"issuer"`
+	ClientId    string `yaml:"client_id"`
+	// empty for public clients, $VARIABLES are expanded from the environment
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes"`
+
+	mu            sync.Mutex
+	discovery     *discoveryDocument
+	discoveredAt  time.Time
+	keys          jose.JSONWebKeySet
+	keysFetchedAt time.Time
+}
+
+type discoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksUri                           string   `json:"jwks_uri"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IdToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// the claims of the id token and the userinfo response besides the registered jwt ones
+type identityClaims struct {
+	Subject       string       `json:"sub"`
+	Nonce         string       `json:"nonce"`
+	AuthorizedBy  string       `json:"azp"`
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	GivenName     string       `json:"given_name"`
+	FamilyName    string       `json:"family_name"`
+}
+
+// flexibleBool also accepts "true" and "false", some providers send email_verified as a string
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	case "false", "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+// AuthCodeUrl is the authorization request the user is redirected to
+func (p *Provider) AuthCodeUrl(ctx context.Context, state string, login datatypes.SocialLoginState) (string, *errors.Error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", errors.SocialProviderError(err)
+	}
+	authUrl, err := url.Parse(doc.AuthorizationEndpoint)
+	if err != nil {
+		return "", errors.SocialProviderError(err)
+	}
+	query := authUrl.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.ClientId)
+	query.Set("redirect_uri", redirectUri)
+	query.Set("scope", strings.Join(p.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", login.Nonce)
+	query.Set("code_challenge", codeChallenge(login.CodeVerifier))
+	query.Set("code_challenge_method", "S256")
+	authUrl.RawQuery = query.Encode()
+	return authUrl.String(), nil
+}
+
+// Exchange redeems the code of the callback and returns the user the verified id token is about,
+// the userinfo endpoint fills in the email when the id token does not carry it
+func (p *Provider) Exchange(ctx context.Context, code string, login datatypes.SocialLoginState) (*datatypes.SocialIdentity, *errors.Error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, errors.SocialProviderError(err)
+	}
+	token, err := p.requestToken(ctx, doc, code, login.CodeVerifier)
+	if err != nil {
+		return nil, errors.SocialProviderError(err)
+	}
+	claims, err := p.verifyIdToken(ctx, doc, token.IdToken, login.Nonce)
+	if err != nil {
+		return nil, errors.SocialProviderError(err)
+	}
+	if claims.Email == "" && doc.UserinfoEndpoint != "" && token.AccessToken != "" {
+		if claims, err = p.userInfo(ctx, doc, token.AccessToken, claims); err != nil {
+			return nil, errors.SocialProviderError(err)
+		}
+	}
+	return &datatypes.SocialIdentity{
+		Provider:      p.Name,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: bool(claims.EmailVerified),
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil && time.Since(p.discoveredAt) < discoveryTtl {
+		return p.discovery, nil
+	}
+	var doc discoveryDocument
+	if err := getJson(ctx, strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, err
+	}
+	// a document of another issuer would let it sign tokens for this provider
+	if doc.Issuer != p.Issuer {
+		return nil, fmt.Errorf("discovery document of %s is for the issuer %s", p.Issuer, doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JwksUri == "" {
+		return nil, fmt.Errorf("discovery document of %s lacks an endpoint", p.Issuer)
+	}
+	p.discovery, p.discoveredAt = &doc, time.Now()
+	return p.discovery, nil
+}
+
+func (p *Provider) requestToken(ctx context.Context, doc *discoveryDocument, code string, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectUri},
+		"code_verifier": {codeVerifier},
+	}
+	// client_secret_basic is the default of the spec, client_secret_post only when it is all the provider takes
+	useBasicAuth := p.ClientSecret != "" && (len(doc.TokenEndpointAuthMethodsSupported) == 0 || contains(doc.TokenEndpointAuthMethodsSupported, "client_secret_basic"))
+	if !useBasicAuth {
+		form.Set("client_id", p.ClientId)
+		if p.ClientSecret != "" {
+			form.Set("client_secret", p.ClientSecret)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if useBasicAuth {
+		req.SetBasicAuth(url.QueryEscape(p.ClientId), url.QueryEscape(p.ClientSecret))
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var token tokenResponse
+	if err = json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("token endpoint answered %d: %w", res.StatusCode, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint answered %d: %s %s", res.StatusCode, token.Error, token.ErrorDescription)
+	}
+	if token.IdToken == "" {
+		return nil, goerrors.New("token endpoint did not return an id token")
+	}
+	return &token, nil
+}
+
+func (p *Provider) verifyIdToken(ctx context.Context, doc *discoveryDocument, idToken string, nonce string) (*identityClaims, error) {
+	token, err := jwt.ParseSigned(idToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(token.Headers) != 1 {
+		return nil, goerrors.New("id token must have exactly one signature")
+	}
+	key, err := p.signingKey(ctx, doc, token.Headers[0].KeyID, token.Headers[0].Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	var registered jwt.Claims
+	var claims identityClaims
+	if err = token.Claims(key, &registered, &claims); err != nil {
+		return nil, err
+	}
+	if registered.Subject == "" || registered.Expiry == nil {
+		return nil, goerrors.New("id token lacks sub or exp")
+	}
+	expected := jwt.Expected{Issuer: doc.Issuer, Audience: jwt.Audience{p.ClientId}, Time: time.Now()}
+	if err = registered.ValidateWithLeeway(expected, clockSkew); err != nil {
+		return nil, err
+	}
+	if len(registered.Audience) > 1 && claims.AuthorizedBy != p.ClientId {
+		return nil, goerrors.New("id token was issued to another client")
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, goerrors.New("id token nonce does not match")
+	}
+	return &claims, nil
+}
+
+// signingKey finds the key of the provider's jwks the id token claims to be signed with,
+// the jwks is fetched again when the kid is unknown as the provider may have rotated its keys
+func (p *Provider) signingKey(ctx context.Context, doc *discoveryDocument, kid string, algorithm string) (*jose.JSONWebKey, error) {
+	// the keys are public, a symmetric algorithm would let anyone sign
+	if algorithm == "" || algorithm == "none" || strings.HasPrefix(algorithm, "HS") {
+		return nil, fmt.Errorf("id token algorithm %q is not allowed", algorithm)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := findKey(p.keys, kid, algorithm)
+	if key == nil && time.Since(p.keysFetchedAt) >= jwksRefetchInterval {
+		var keys jose.JSONWebKeySet
+		if err := getJson(ctx, doc.JwksUri, &keys); err != nil {
+			return nil, err
+		}
+		p.keys, p.keysFetchedAt = keys, time.Now()
+		key = findKey(p.keys, kid, algorithm)
+	}
+	if key == nil {
+		return nil, fmt.Errorf("no signing key %q for %s in the jwks", kid, algorithm)
+	}
+	return key, nil
+}
+
+// without a kid the token must be verifiable with the only signing key of the algorithm
+func findKey(keys jose.JSONWebKeySet, kid string, algorithm string) *jose.JSONWebKey {
+	var found *jose.JSONWebKey
+	for i := range keys.Keys {
+		key := &keys.Keys[i]
+		if (key.Use != "" && key.Use != "sig") || (key.Algorithm != "" && key.Algorithm != algorithm) {
+			continue
+		}
+		if kid != "" && key.KeyID == kid {
+			return key
+		}
+		if kid == "" {
+			if found != nil {
+				return nil
+			}
+			found = key
+		}
+	}
+	return found
+}
+
+func (p *Provider) userInfo(ctx context.Context, doc *discoveryDocument, accessToken string, claims *identityClaims) (*identityClaims, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, doc.UserinfoEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	var info identityClaims
+	if err = doJson(req, &info); err != nil {
+		return nil, err
+	}
+	// the userinfo response could be about someone else when the access token got mixed up
+	if info.Subject != claims.Subject {
+		return nil, goerrors.New("userinfo is about another subject than the id token")
+	}
+	merged := *claims
+	merged.Email, merged.EmailVerified = info.Email, info.EmailVerified
+	if merged.GivenName == "" && merged.FamilyName == "" {
+		merged.GivenName, merged.FamilyName = info.GivenName, info.FamilyName
+	}
+	return &merged, nil
+}
+
+func getJson(ctx context.Context, rawUrl string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return err
+	}
+	return doJson(req, dest)
+}
+
+func doJson(req *http.Request, dest interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %d", req.URL.Redacted(), res.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(dest)
+}
@@ -0,0 +1,141 @@
+// Package social lets users log in with their account at another OpenID Connect provider, e.g. Google.
+// It is the relying party side of the authorization code flow: it sends the user to the provider with a state,
+// a nonce and a PKCE challenge, exchanges the code the provider returns and verifies the id token against the
+// keys the provider publishes. Linking the identity to a user is left to the handlers.
+package social
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var defaultScopes = []string{"openid", "email", "profile"}
+
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+var (
+	providers     map[string]*Provider
+	providerNames []string
+	redirectUri   string
+)
+
+type providersFile struct {
+	Providers []*Provider `yaml:"providers"`
+}
+
+// InitSocialLogin loads the providers of the providers file, callbackUrl is the redirect uri registered at every
+// provider, without a providers file social login is disabled
+func InitSocialLogin(socialLoginConfig config.SocialLoginConfig, callbackUrl string) (err error) {
+	providers = map[string]*Provider{}
+	providerNames = nil
+	redirectUri = callbackUrl
+	path := socialLoginConfig.ProvidersFile
+	if path == "" {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file providersFile
+	if err = yaml.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for i, provider := range file.Providers {
+		if err = provider.init(); err != nil {
+			return fmt.Errorf("%s: provider %d: %w", path, i+1, err)
+		}
+		if providers[provider.Name] != nil {
+			return fmt.Errorf("%s: provider %s is listed twice", path, provider.Name)
+		}
+		providers[provider.Name] = provider
+		providerNames = append(providerNames, provider.Name)
+	}
+	return nil
+}
+
+// GetProvider returns the configured provider with the name
+func GetProvider(name string) (*Provider, *errors.Error) {
+	provider, ok := providers[name]
+	if !ok {
+		return nil, errors.SocialUnknownProviderError(name)
+	}
+	return provider, nil
+}
+
+// GetProviders lists the configured providers in the order of the providers file
+func GetProviders() []*Provider {
+	list := make([]*Provider, 0, len(providerNames))
+	for _, name := range providerNames {
+		list = append(list, providers[name])
+	}
+	return list
+}
+
+// RedirectUri is the callback url the providers send the users back to
+func RedirectUri() string {
+	return redirectUri
+}
+
+// NewLoginState returns a fresh PKCE code verifier and id token nonce for a login with the provider
+func (p *Provider) NewLoginState() datatypes.SocialLoginState {
+	return datatypes.SocialLoginState{Provider: p.Name, CodeVerifier: randomString(), Nonce: randomString()}
+}
+
+// codeChallenge is the S256 PKCE challenge of the verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (p *Provider) init() error {
+	if !providerNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("name %q must be lowercase letters, digits, _ and -", p.Name)
+	}
+	issuer, err := url.Parse(p.Issuer)
+	if err != nil || (issuer.Scheme != "https" && issuer.Scheme != "http") || issuer.Host == "" {
+		return fmt.Errorf("issuer of %s must be an http or https url", p.Name)
+	}
+	if p.ClientId == "" {
+		return fmt.Errorf("client_id of %s is required", p.Name)
+	}
+	// lets the file reference the secret instead of containing it
+	p.ClientSecret = os.ExpandEnv(p.ClientSecret)
+	if p.DisplayName == "" {
+		p.DisplayName = p.Name
+	}
+	if len(p.Scopes) == 0 {
+		p.Scopes = defaultScopes
+	}
+	if !contains(p.Scopes, "openid") {
+		p.Scopes = append([]string{"openid"}, p.Scopes...)
+	}
+	return nil
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+package social_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/social"
+)
+
+const callbackUrl = "http://localhost:8080/api/v1/social/callback"
+
+func initProviders(t *testing.T, content string) error {
+	path := filepath.Join(t.TempDir(), "providers.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return social.InitSocialLogin(config.SocialLoginConfig{ProvidersFile: path}, callbackUrl)
+}
+
+// configures the mock as the provider "mock", the secret comes from the environment like in a deployment
+func setupMockProvider(t *testing.T) (*mockProvider, *social.Provider) {
+	mock := newMockProvider(t)
+	t.Setenv("MOCK_CLIENT_SECRET", mock.clientSecret)
+	err := initProviders(t, `
+providers:
+  - name: mock
+    display_name: Mock
+    issuer: `+mock.issuer()+`
+    client_id: `+mock.clientId+`
+    client_secret: ${MOCK_CLIENT_SECRET}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, e := social.GetProvider("mock")
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	return mock, provider
+}
+
+func TestAuthCodeUrlCarriesStateNonceAndPkce(t *testing.T) {
+	mock, provider := setupMockProvider(t)
+	login := provider.NewLoginState()
+
+	authUrl, e := provider.AuthCodeUrl(context.Background(), "state-1", login)
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.Scheme + "://" + parsed.Host + parsed.Path; got != mock.issuer()+"/authorize" {
+		t.Errorf("authorization endpoint = %s", got)
+	}
+	sum := sha256.Sum256([]byte(login.CodeVerifier))
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             mock.clientId,
+		"redirect_uri":          callbackUrl,
+		"scope":                 "openid email profile",
+		"state":                 "state-1",
+		"nonce":                 login.Nonce,
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(sum[:]),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := parsed.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if login.Nonce == login.CodeVerifier || len(login.CodeVerifier) < 43 {
+		t.Errorf("the verifier must be long enough and differ from the nonce, which is sent to the provider")
+	}
+}
+
+func TestExchangeReturnsVerifiedIdentity(t *testing.T) {
+	mock, provider := setupMockProvider(t)
+	login := provider.NewLoginState()
+	authUrl, e := provider.AuthCodeUrl(context.Background(), "state-1", login)
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+
+	identity, e := provider.Exchange(context.Background(), mock.authorize(authUrl), login)
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	if identity.Provider != "mock" || identity.Subject != "subject-1" {
+		t.Errorf("identity = %s/%s", identity.Provider, identity.Subject)
+	}
+	if identity.Email != "jane@example.com" || !identity.EmailVerified {
+		t.Errorf("email = %s, verified = %v", identity.Email, identity.EmailVerified)
+	}
+	if identity.GivenName != "Jane" || identity.FamilyName != "Doe" {
+		t.Errorf("name = %s %s", identity.GivenName, identity.FamilyName)
+	}
+}
+
+func TestExchangeFallsBackToUserInfo(t *testing.T) {
+	mock, provider := setupMockProvider(t)
+	mock.claims = map[string]interface{}{"sub": "subject-1"}
+	mock.userInfo = map[string]interface{}{"sub": "subject-1", "email": "jane@example.com", "email_verified": "true", "given_name": "Jane"}
+	login := provider.NewLoginState()
+	authUrl, _ := provider.AuthCodeUrl(context.Background(), "state-1", login)
+
+	identity, e := provider.Exchange(context.Background(), mock.authorize(authUrl), login)
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	if identity.Email != "jane@example.com" || !identity.EmailVerified || identity.GivenName != "Jane" {
+		t.Errorf("identity = %+v", identity)
+	}
+
+	// userinfo about someone else must not be trusted
+	mock.userInfo["sub"] = "subject-2"
+	login = provider.NewLoginState()
+	authUrl, _ = provider.AuthCodeUrl(context.Background(), "state-2", login)
+	if _, e = provider.Exchange(context.Background(), mock.authorize(authUrl), login); e == nil || e.Code != errors.CodeSocialProviderError {
+		t.Errorf("expected %s for userinfo of another subject, got %v", errors.CodeSocialProviderError, e)
+	}
+}
+
+func TestExchangeRejectsInvalidIdTokens(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		mutate   func(claims map[string]interface{})
+		signWith *rsa.PrivateKey
+	}{
+		{name: "nonce of another login", mutate: func(claims map[string]interface{}) { claims["nonce"] = "replayed" }},
+		{name: "missing nonce", mutate: func(claims map[string]interface{}) { delete(claims, "nonce") }},
+		{name: "another audience", mutate: func(claims map[string]interface{}) { claims["aud"] = "other-client" }},
+		{name: "another issuer", mutate: func(claims map[string]interface{}) { claims["iss"] = "https://evil.example.com" }},
+		{name: "expired", mutate: func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "missing expiry", mutate: func(claims map[string]interface{}) { delete(claims, "exp") }},
+		{name: "missing subject", mutate: func(claims map[string]interface{}) { delete(claims, "sub") }},
+		{name: "several audiences without azp", mutate: func(claims map[string]interface{}) { claims["aud"] = []string{"mock-client", "other-client"} }},
+		{name: "signed with another key", signWith: otherKey},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock, provider := setupMockProvider(t)
+			mock.mutate, mock.signWith = test.mutate, test.signWith
+			login := provider.NewLoginState()
+			authUrl, _ := provider.AuthCodeUrl(context.Background(), "state-1", login)
+
+			_, e := provider.Exchange(context.Background(), mock.authorize(authUrl), login)
+			if e == nil || e.Code != errors.CodeSocialProviderError {
+				t.Errorf("expected %s, got %v", errors.CodeSocialProviderError, e)
+			}
+		})
+	}
+}
+
+func TestExchangeRejectsWrongCodeVerifier(t *testing.T) {
+	mock, provider := setupMockProvider(t)
+	login := provider.NewLoginState()
+	authUrl, _ := provider.AuthCodeUrl(context.Background(), "state-1", login)
+	code := mock.authorize(authUrl)
+
+	stolen := provider.NewLoginState()
+	stolen.Nonce = login.Nonce
+	if _, e := provider.Exchange(context.Background(), code, stolen); e == nil || !strings.Contains(e.Error().Error(), "invalid_grant") {
+		t.Errorf("expected invalid_grant from the provider, got %v", e)
+	}
+}
+
+func TestInitSocialLoginValidatesProviders(t *testing.T) {
+	tests := map[string]string{
+		"duplicate name": `
+providers:
+  - {name: google, issuer: "https://accounts.google.com", client_id: a}
+  - {name: google, issuer: "https://accounts.google.com", client_id: b}
+`,
+		"missing client id": `
+providers:
+  - {name: google, issuer: "https://accounts.google.com"}
+`,
+		"invalid name": `
+providers:
+  - {name: "Google Accounts", issuer: "https://accounts.google.com", client_id: a}
+`,
+		"issuer without scheme": `
+providers:
+  - {name: google, issuer: accounts.google.com, client_id: a}
+`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := initProviders(t, content); err == nil {
+				t.Error("expected the providers file to be rejected")
+			}
+		})
+	}
+
+	if err := initProviders(t, "providers:\n  - {name: google, issuer: \"https://accounts.google.com\", client_id: a, scopes: [email]}\n"); err != nil {
+		t.Fatal(err)
+	}
+	provider, e := social.GetProvider("google")
+	if e != nil {
+		t.Fatal(e.Error())
+	}
+	if strings.Join(provider.Scopes, " ") != "openid email" || provider.DisplayName != "google" {
+		t.Errorf("scopes = %v, display name = %s", provider.Scopes, provider.DisplayName)
+	}
+	if _, e = social.GetProvider("github"); e == nil || e.Code != errors.CodeSocialUnknownProvider {
+		t.Errorf("expected %s, got %v", errors.CodeSocialUnknownProvider, e)
+	}
+}
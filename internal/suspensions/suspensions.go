@@ -0,0 +1,187 @@
+// Package suspensions suspends and bans users. A suspended or banned user is refused at login and on every
+// request, a suspension can expire and is then lifted by a background job.
+package suspensions
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/events"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	serviceRegistry "github.com/MitP1997/golang-user-management/internal/service_registry"
+	"github.com/MitP1997/golang-user-management/internal/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// how often the expired suspensions are lifted, the user is let in as soon as it expires regardless
+	liftInterval  = time.Minute
+	liftBatchSize = 100
+)
+
+var (
+	stop chan struct{}
+	wg   sync.WaitGroup
+)
+
+// InEffect reports whether the user is suspended or banned at now, an expired suspension is over even before
+// the job lifted it
+func InEffect(user *models.User, now time.Time) bool {
+	switch user.Status {
+	case models.UserStatus_BANNED:
+		return true
+	case models.UserStatus_SUSPENDED:
+		expiresAt := user.GetSuspension().GetExpiresAt()
+		return expiresAt == nil || now.Before(expiresAt.AsTime())
+	}
+	return false
+}
+
+// Refuse returns the error a suspended or banned user is refused with, nil when the user is neither
+func Refuse(user *models.User) *errors.Error {
+	if !InEffect(user, time.Now()) {
+		return nil
+	}
+	if user.Status == models.UserStatus_BANNED {
+		return errors.UserBannedError()
+	}
+	var expiresAt *time.Time
+	if user.GetSuspension().GetExpiresAt() != nil {
+		t := user.Suspension.ExpiresAt.AsTime()
+		expiresAt = &t
+	}
+	return errors.UserSuspendedError(expiresAt)
+}
+
+// Suspend suspends the user, or bans them with the BANNED status, and logs them out everywhere, including the
+// impersonations of a suspended admin.
+// expiresAt is nil for a suspension that lasts until the user is reinstated.
+func Suspend(c *gin.Context, user *models.User, status models.UserStatus, reason string, actorId string, expiresAt *time.Time) *errors.Error {
+	logger := utils.GetContextLogger(c)
+	previousStatus := user.Status
+	// suspending again, e.g. turning a suspension into a ban, keeps the status the user gets back
+	restoredStatus := previousStatus
+	if user.Suspension != nil && (previousStatus == models.UserStatus_SUSPENDED || previousStatus == models.UserStatus_BANNED) {
+		restoredStatus = user.Suspension.PreviousStatus
+	}
+	suspension := &models.Suspension{Reason: reason, ActorId: actorId, SuspendedAt: timestamppb.Now(), PreviousStatus: restoredStatus}
+	if expiresAt != nil {
+		suspension.ExpiresAt = timestamppb.New(*expiresAt)
+	}
+	e := utils.RunInTransaction(c, func() *errors.Error {
+		if e := user.Update(c, bson.M{"_id": user.Id}, bson.M{"status": status, "suspension": suspension, "updated_at": timestamppb.Now()}); e != nil {
+			logger.Error("Error while suspending user", zap.Error(e.Error()))
+			return e
+		}
+		event := events.UserSuspended{User: events.NewUser(user), PreviousStatus: previousStatus.String(), Reason: reason, ExpiresAt: expiresAt}
+		if e := events.Publish(c, event); e != nil {
+			logger.Error("Error while publishing user suspended event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+	if e != nil {
+		return e
+	}
+	redisClient := serviceRegistry.GetRedisClient()
+	if e = redisClient.RevokeUserSessions(c, user.Id); e != nil {
+		logger.Error("Error while revoking sessions of suspended user", zap.Error(e.Error()))
+		return e
+	}
+	if e = redisClient.RevokeImpersonatorTokens(c, user.Id); e != nil {
+		logger.Error("Error while revoking impersonation tokens of suspended user", zap.Error(e.Error()))
+		return e
+	}
+	return nil
+}
+
+// Lift gives the user back the status they had before the suspension or ban, expired tells the job lifted it.
+// It returns the not found error when the user is no longer in the status, e.g. another instance lifted it.
+func Lift(c *gin.Context, user *models.User, expired bool) *errors.Error {
+	logger := utils.GetContextLogger(c)
+	suspendedStatus, suspension := user.Status, user.GetSuspension()
+	if suspension == nil {
+		suspension = &models.Suspension{PreviousStatus: models.UserStatus_VERIFIED}
+	}
+	filter := bson.M{"_id": user.Id, "status": suspendedStatus}
+	if expired {
+		// an admin may have suspended the user again since
+		filter["suspension.expires_at.seconds"] = bson.M{"$lte": time.Now().Unix()}
+	}
+	return utils.RunInTransaction(c, func() *errors.Error {
+		set := bson.M{"status": suspension.PreviousStatus, "suspension": nil, "updated_at": timestamppb.Now()}
+		if e := user.Update(c, filter, set); e != nil {
+			if !e.IsNotFound() {
+				logger.Error("Error while lifting suspension of user", zap.Error(e.Error()))
+			}
+			return e
+		}
+		event := events.UserReinstated{User: events.NewUser(user), SuspendedStatus: suspendedStatus.String(), SuspensionReason: suspension.Reason, Expired: expired}
+		if e := events.Publish(c, event); e != nil {
+			logger.Error("Error while publishing user reinstated event", zap.Error(e.Error()))
+			return e
+		}
+		return nil
+	})
+}
+
+// Start lifts the expired suspensions in the background until Shutdown
+func Start(logger *zap.Logger) {
+	logger = logger.With(zap.String("source", "suspensions"))
+	stop = make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(liftInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				liftExpired(logger)
+			}
+		}
+	}()
+}
+
+func Shutdown(ctx context.Context) error {
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// the audit entries of the lifts are attributed to the system
+func liftExpired(logger *zap.Logger) {
+	c := utils.NewContext(context.Background(), nil)
+	utils.SetContextLogger(c, logger)
+	utils.SetContextActorType(c, constants.AuditActorSystem)
+	users, err := models.FindExpiredSuspensions(c, liftBatchSize)
+	if err != nil {
+		logger.Error("Error while fetching expired suspensions", zap.Error(err.Error()))
+		return
+	}
+	for _, user := range users {
+		if err = Lift(c, user, true); err != nil && !err.IsNotFound() {
+			logger.Error("Error while lifting expired suspension", zap.String("user_id", user.Id), zap.Error(err.Error()))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package suspensions_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/suspensions"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestInEffect(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		user *models.User
+		want bool
+	}{
+		{"verified", &models.User{Status: models.UserStatus_VERIFIED}, false},
+		{"deleted", &models.User{Status: models.UserStatus_DELETED}, false},
+		{"banned", &models.User{Status: models.UserStatus_BANNED, Suspension: &models.Suspension{}}, true},
+		{"suspended without expiry", &models.User{Status: models.UserStatus_SUSPENDED, Suspension: &models.Suspension{}}, true},
+		{"suspended without suspension", &models.User{Status: models.UserStatus_SUSPENDED}, true},
+		{"suspension not expired", &models.User{Status: models.UserStatus_SUSPENDED, Suspension: &models.Suspension{ExpiresAt: timestamppb.New(now.Add(time.Hour))}}, true},
+		{"suspension expired", &models.User{Status: models.UserStatus_SUSPENDED, Suspension: &models.Suspension{ExpiresAt: timestamppb.New(now.Add(-time.Second))}}, false},
+	}
+	for _, test := range tests {
+		if got := suspensions.InEffect(test.user, now); got != test.want {
+			t.Errorf("%s: InEffect = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRefuse(t *testing.T) {
+	if e := suspensions.Refuse(&models.User{Status: models.UserStatus_VERIFIED}); e != nil {
+		t.Errorf("verified user refused with %s", e.UserErrorCode())
+	}
+	if e := suspensions.Refuse(&models.User{Status: models.UserStatus_BANNED}); e == nil || e.UserErrorCode() != errors.CodeUserBanned {
+		t.Errorf("banned user not refused with %s", errors.CodeUserBanned)
+	}
+	suspended := &models.User{Status: models.UserStatus_SUSPENDED, Suspension: &models.Suspension{ExpiresAt: timestamppb.New(time.Now().Add(time.Hour))}}
+	if e := suspensions.Refuse(suspended); e == nil || e.UserErrorCode() != errors.CodeUserSuspended {
+		t.Errorf("suspended user not refused with %s", errors.CodeUserSuspended)
+	}
+}
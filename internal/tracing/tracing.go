@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const ServiceName = "golang-user-management"
+
+var tracerProvider *sdktrace.TracerProvider
+
+// InitTracerProvider installs the global tracer provider and the W3C trace context propagator.
+// With the none exporter spans are not recorded, but incoming trace ids are still propagated and logged.
+func InitTracerProvider(tracingConfig config.TracingConfig) (err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch tracingConfig.Exporter {
+	case constants.TracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case constants.TracingExporterOtlp:
+		// the endpoint and headers are read from the standard OTEL_EXPORTER_OTLP_* variables
+		exporter, err = otlptracegrpc.New(context.Background())
+	default:
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(tracingConfig.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName))),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	return
+}
+
+// Shutdown flushes the spans that are still buffered
+func Shutdown(ctx context.Context) error {
+	if tracerProvider == nil {
+		return nil
+	}
+	return tracerProvider.Shutdown(ctx)
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// StartSpan starts a child span of the one carried by the request and makes it the parent of the calls made with c
+// until the returned function is called with the outcome of the work.
+func StartSpan(c *gin.Context, name string) (end func(err *errors.Error)) {
+	parent := c.Request.Context()
+	ctx, span := Tracer().Start(parent, name)
+	c.Request = c.Request.WithContext(ctx)
+	return func(err *errors.Error) {
+		RecordError(span, err)
+		span.End()
+		c.Request = c.Request.WithContext(parent)
+	}
+}
+
+func RecordError(span trace.Span, err *errors.Error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err.Error())
+	span.SetStatus(codes.Error, err.UserErrorCode())
+}
+
+// LogFields returns the ids of the span in ctx to correlate logs with traces
+func LogFields(ctx context.Context) []zap.Field {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+	}
+}
@@ -1,6 +1,11 @@
 package utils
 
 import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/MitP1997/golang-user-management/internal/datatypes"
 	"github.com/MitP1997/golang-user-management/internal/models"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -18,6 +23,14 @@ func GetContextLogger(c *gin.Context) *zap.Logger {
 	return logger.(*zap.Logger)
 }
 
+func SetContextRequestId(c *gin.Context, requestId string) {
+	c.Set("request_id", requestId)
+}
+
+func GetContextRequestId(c *gin.Context) string {
+	return c.GetString("request_id")
+}
+
 func SetContextUser(c *gin.Context, user *models.User) {
 	c.Set("user", user)
 }
@@ -30,6 +43,85 @@ func GetContextUser(c *gin.Context) *models.User {
 	return user.(*models.User)
 }
 
+func SetContextOrganization(c *gin.Context, organization *models.Organization, membership *models.Membership) {
+	c.Set("organization", organization)
+	c.Set("membership", membership)
+}
+
+// GetContextOrganization returns the organization selected for the request, nil when the request is not scoped to one
+func GetContextOrganization(c *gin.Context) *models.Organization {
+	organization, ok := c.Get("organization")
+	if !ok {
+		return nil
+	}
+	return organization.(*models.Organization)
+}
+
+// GetContextMembership returns the membership of the context user in the context organization
+func GetContextMembership(c *gin.Context) *models.Membership {
+	membership, ok := c.Get("membership")
+	if !ok {
+		return nil
+	}
+	return membership.(*models.Membership)
+}
+
+// SetContextActorType marks who acts in a context without an authenticated user, e.g. the cli, for the audit log
+func SetContextActorType(c *gin.Context, actorType string) {
+	c.Set("actor_type", actorType)
+}
+
+func GetContextActorType(c *gin.Context) string {
+	return c.GetString("actor_type")
+}
+
+// SetContextScopes keeps the scopes the token of the request grants, routes declare the scope they need
+func SetContextScopes(c *gin.Context, scopes []string) {
+	c.Set("scopes", scopes)
+}
+
+func GetContextScopes(c *gin.Context) []string {
+	return c.GetStringSlice("scopes")
+}
+
+// SetContextAuthentication keeps how and when the user of a login session authenticated, requests with other tokens
+// have none
+func SetContextAuthentication(c *gin.Context, authentication *datatypes.SessionAuthentication) {
+	c.Set("authentication", authentication)
+}
+
+func GetContextAuthentication(c *gin.Context) *datatypes.SessionAuthentication {
+	authentication, ok := c.Get("authentication")
+	if !ok {
+		return nil
+	}
+	return authentication.(*datatypes.SessionAuthentication)
+}
+
+// SetContextImpersonation marks the request as made by an admin impersonating the context user, GetContextUser
+// is the impersonated user and GetContextImpersonator the admin
+func SetContextImpersonation(c *gin.Context, impersonator *models.User, impersonation *datatypes.Impersonation) {
+	c.Set("impersonator", impersonator)
+	c.Set("impersonation", impersonation)
+}
+
+// GetContextImpersonator returns the admin impersonating the context user, nil when the user makes the request
+func GetContextImpersonator(c *gin.Context) *models.User {
+	impersonator, ok := c.Get("impersonator")
+	if !ok {
+		return nil
+	}
+	return impersonator.(*models.User)
+}
+
+func GetContextImpersonation(c *gin.Context) *datatypes.Impersonation {
+	impersonation, ok := c.Get("impersonation")
+	if !ok {
+		return nil
+	}
+	return impersonation.(*datatypes.Impersonation)
+}
+
 // Ideally we should create a new context and copy the values that are required.
 // The reason for not taking the ideal approach is that the context.Context does not support Get and Set values directly,
 // which is being used above in SetContextLogger and GetContextLogger.
@@ -37,3 +129,24 @@ func CreateDuplicateContext(c *gin.Context) *gin.Context {
 	duplicateContext := c.Copy()
 	return duplicateContext
 }
+
+// NewContext creates a gin context for work that does not come through the gin router, e.g. gRPC calls,
+// so that the helpers relying on values stored on the gin context can be reused
+func NewContext(ctx context.Context, header http.Header) *gin.Context {
+	request, _ := http.NewRequestWithContext(ctx, "", "/", nil)
+	if header != nil {
+		request.Header = header
+	}
+	// bound to an engine with context fallback so ctx values like the trace span stay reachable through the gin context
+	contextEngineOnce.Do(func() {
+		contextEngine = gin.New()
+		contextEngine.ContextWithFallback = true
+	})
+	c := gin.CreateTestContextOnly(nil, contextEngine)
+	c.Request = request
+	return c
+}
+
+// created on first use so that importing utils has no side effects
+var contextEngine *gin.Engine
+var contextEngineOnce sync.Once
@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// RespondWithError writes the error envelope for err and stops the handler chain
+// every error response goes through here so that clients can always rely on the same shape
+func RespondWithError(c *gin.Context, err *errors.Error) {
+	c.Abort()
+	c.IndentedJSON(err.UserStatusError(), err.Envelope(GetContextRequestId(c)))
+}
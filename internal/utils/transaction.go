@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type afterCommitKey struct{}
+
+// RunInTransaction runs fn in a mongo transaction, see models.RunInTransaction
+// the transaction is carried by the request of c while fn runs, so every model called with c joins it
+// the functions passed to AfterCommit while fn runs are called once the transaction committed and dropped when it aborts
+func RunInTransaction(c *gin.Context, fn func() *errors.Error) *errors.Error {
+	parent := c.Request.Context()
+	var afterCommit []func()
+	err := models.RunInTransaction(parent, func(ctx context.Context) *errors.Error {
+		c.Request = c.Request.WithContext(context.WithValue(ctx, afterCommitKey{}, &afterCommit))
+		return fn()
+	})
+	c.Request = c.Request.WithContext(parent)
+	if err != nil {
+		return err
+	}
+	// a transaction run inside another one hands them on to the outer transaction
+	for _, f := range afterCommit {
+		AfterCommit(c, f)
+	}
+	return nil
+}
+
+// AfterCommit calls fn once the transaction c carries committed, without a transaction it calls fn right away
+func AfterCommit(c *gin.Context, fn func()) {
+	afterCommit, ok := c.Request.Context().Value(afterCommitKey{}).(*[]func())
+	if !ok {
+		fn()
+		return
+	}
+	*afterCommit = append(*afterCommit, fn)
+}
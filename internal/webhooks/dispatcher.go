@@ -0,0 +1,223 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/metrics"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/tracing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// only the start of a response body is read, receivers are expected to answer with a status code
+const maxResponseBytes = 64 << 10
+
+type dispatcher struct {
+	config config.WebhookConfig
+	logger *zap.Logger
+	client *http.Client
+	wake   chan struct{}
+	stop   chan struct{}
+	// cancels the requests still in flight when the shutdown times out
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var active *dispatcher
+
+// Start launches the workers that send the queued deliveries, deliveries queued while no server runs,
+// e.g. by the admin CLI, are picked up on the next poll
+func Start(webhookConfig config.WebhookConfig, logger *zap.Logger) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &dispatcher{
+		config: webhookConfig,
+		logger: logger.With(zap.String("source", "webhooks")),
+		client: &http.Client{Timeout: webhookConfig.RequestTimeout},
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < webhookConfig.Workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+	active = d
+}
+
+// Shutdown stops the workers after the deliveries they are sending, or aborts them when ctx is done
+func Shutdown(ctx context.Context) error {
+	d := active
+	if d == nil {
+		return nil
+	}
+	close(d.stop)
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	defer d.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wake tells an idle worker that new deliveries are queued, it never blocks
+func Wake() {
+	d := active
+	if d == nil {
+		return
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *dispatcher) work() {
+	defer d.wg.Done()
+	timer := time.NewTimer(d.config.PollInterval)
+	defer timer.Stop()
+	for {
+		for !d.stopped() && d.deliverNext() {
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(d.config.PollInterval)
+		select {
+		case <-d.stop:
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+func (d *dispatcher) stopped() bool {
+	select {
+	case <-d.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// deliverNext sends one due delivery and reports whether there was one
+func (d *dispatcher) deliverNext() bool {
+	// the lease outlives the request so another worker does not send the delivery twice
+	leaseUntil := time.Now().Add(2 * d.config.RequestTimeout)
+	delivery, e := models.ClaimWebhookDelivery(d.ctx, leaseUntil)
+	if e != nil {
+		if !e.IsNotFound() {
+			d.logger.Error("Error while claiming webhook delivery", zap.Error(e.Error()))
+		}
+		return false
+	}
+	logger := d.logger.With(zap.String("delivery_id", delivery.Id), zap.String("endpoint_id", delivery.EndpointId), zap.String("event_type", delivery.EventType))
+
+	var endpoint models.WebhookEndpoint
+	var attempt *models.WebhookAttempt
+	endpointDeleted := false
+	if e = endpoint.FindOne(d.ctx, bson.M{"_id": delivery.EndpointId}); e != nil {
+		if !e.IsNotFound() {
+			// the lease runs out and the delivery is claimed again
+			logger.Error("Error while fetching webhook endpoint", zap.Error(e.Error()))
+			return true
+		}
+		endpointDeleted = true
+		attempt = &models.WebhookAttempt{AttemptedAt: timestamppb.Now(), Error: "endpoint was deleted"}
+	} else {
+		attempt = d.send(&endpoint, delivery)
+	}
+
+	status, nextAttemptAt := d.nextStatus(delivery, attempt, endpointDeleted, time.Now())
+	result := metrics.ResultOk
+	if attempt.Error != "" {
+		result = metrics.ResultError
+		logger.Info("Webhook delivery attempt failed", zap.String("error", attempt.Error), zap.Int32("status_code", attempt.StatusCode), zap.String("status", status.String()))
+	}
+	metrics.WebhookDeliveryAttemptsTotal.WithLabelValues(delivery.EventType, result).Inc()
+	if e = delivery.RecordAttempt(d.ctx, attempt, status, nextAttemptAt); e != nil {
+		logger.Error("Error while recording webhook delivery attempt", zap.Error(e.Error()))
+	}
+	return true
+}
+
+// nextStatus is where the delivery goes after the attempt: succeeded, queued again after the backoff, or failed once
+// it ran out of attempts or its endpoint was deleted
+func (d *dispatcher) nextStatus(delivery *models.WebhookDelivery, attempt *models.WebhookAttempt, endpointDeleted bool, now time.Time) (models.WebhookDeliveryStatus, time.Time) {
+	if attempt.Error == "" {
+		return models.WebhookDeliveryStatus_SUCCEEDED, now
+	}
+	status := models.WebhookDeliveryStatus_QUEUED
+	if delivery.Attempts+1 >= int32(d.config.MaxAttempts) || endpointDeleted {
+		status = models.WebhookDeliveryStatus_FAILED
+	}
+	return status, now.Add(d.backoff(delivery.Attempts + 1))
+}
+
+// send posts the payload once, any response other than 2xx counts as a failure
+func (d *dispatcher) send(endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) *models.WebhookAttempt {
+	start := time.Now()
+	attempt := &models.WebhookAttempt{AttemptedAt: timestamppb.New(start)}
+	defer func() { attempt.DurationMs = time.Since(start).Milliseconds() }()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, endpoint.Url, strings.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", tracing.ServiceName+"-webhooks")
+	req.Header.Set(constants.WebhookIdHeader, delivery.EventId)
+	req.Header.Set(constants.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(constants.WebhookTimestampHeader, timestamp)
+	req.Header.Set(constants.WebhookSignatureHeader, Sign(endpoint.Secret, timestamp, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxResponseBytes))
+	attempt.StatusCode = int32(res.StatusCode)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", res.StatusCode)
+	}
+	return attempt
+}
+
+// backoff doubles the wait after every failed attempt, starting at the initial backoff and capped at the max backoff
+func (d *dispatcher) backoff(attempts int32) time.Duration {
+	wait := d.config.InitialBackoff
+	for i := int32(1); i < attempts && wait < d.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.config.MaxBackoff {
+		wait = d.config.MaxBackoff
+	}
+	return wait
+}
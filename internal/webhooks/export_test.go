@@ -0,0 +1,27 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/models"
+)
+
+// the unexported helpers under test run on a dispatcher without workers
+func newDispatcher(webhookConfig config.WebhookConfig) *dispatcher {
+	return &dispatcher{config: webhookConfig, client: &http.Client{Timeout: webhookConfig.RequestTimeout}, ctx: context.Background()}
+}
+
+func Backoff(webhookConfig config.WebhookConfig, attempts int32) time.Duration {
+	return newDispatcher(webhookConfig).backoff(attempts)
+}
+
+func Send(webhookConfig config.WebhookConfig, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) *models.WebhookAttempt {
+	return newDispatcher(webhookConfig).send(endpoint, delivery)
+}
+
+func NextStatus(webhookConfig config.WebhookConfig, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt, endpointDeleted bool, now time.Time) (models.WebhookDeliveryStatus, time.Time) {
+	return newDispatcher(webhookConfig).nextStatus(delivery, attempt, endpointDeleted, now)
+}
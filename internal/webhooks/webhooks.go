@@ -0,0 +1,65 @@
+// Package webhooks sends the user lifecycle events to the endpoints registered by the platform admins.
+// Emit stores one delivery per subscribed endpoint in Mongo and the dispatcher workers send them
+// in the background, so a slow or failing receiver never holds up the request that caused the event.
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/errors"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Event is the json body of every delivery
+type Event struct {
+	Id        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Emit queues the event for every endpoint subscribed to its type and wakes up the dispatcher
+func Emit(ctx context.Context, eventType string, data interface{}) (err *errors.Error) {
+	endpoints, err := models.FindWebhookEndpoints(ctx, bson.M{"event_types": eventType})
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+	event := Event{Id: uuid.New().String(), Type: eventType, CreatedAt: time.Now().UTC(), Data: data}
+	payload, e := json.Marshal(event)
+	if e != nil {
+		return errors.InternalServerError(e)
+	}
+	for _, endpoint := range endpoints {
+		delivery := models.WebhookDelivery{
+			EndpointId:    endpoint.Id,
+			EventId:       event.Id,
+			EventType:     eventType,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryStatus_QUEUED,
+			NextAttemptAt: timestamppb.Now(),
+		}
+		if err = delivery.Insert(ctx); err != nil {
+			return err
+		}
+	}
+	Wake()
+	return nil
+}
+
+// Sign computes the X-Webhook-Signature value, receivers recompute it over the raw body with their secret
+// and should reject timestamps that are too old to prevent replays
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
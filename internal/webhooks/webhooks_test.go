@@ -0,0 +1,115 @@
+package webhooks_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/MitP1997/golang-user-management/internal/config"
+	"github.com/MitP1997/golang-user-management/internal/constants"
+	"github.com/MitP1997/golang-user-management/internal/models"
+	"github.com/MitP1997/golang-user-management/internal/webhooks"
+)
+
+var webhookConfig = config.WebhookConfig{
+	MaxAttempts:    3,
+	InitialBackoff: 30 * time.Second,
+	MaxBackoff:     time.Hour,
+	RequestTimeout: time.Second,
+}
+
+func TestSign(t *testing.T) {
+	// HMAC-SHA256 of "1700000000." and the body with the key whsec_test, receivers recompute exactly this
+	got := webhooks.Sign("whsec_test", "1700000000", []byte(`{"id":"evt_1","type":"user.signed_up"}`))
+	want := "sha256=095b372ad92dfc9027038178db6e0858a8d00c8f19da7fe54c53cb1bb8241360"
+	if got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if other := webhooks.Sign("whsec_other", "1700000000", []byte(`{"id":"evt_1","type":"user.signed_up"}`)); other == want {
+		t.Error("Sign ignores the secret")
+	}
+	if other := webhooks.Sign("whsec_test", "1700000001", []byte(`{"id":"evt_1","type":"user.signed_up"}`)); other == want {
+		t.Error("Sign ignores the timestamp")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+	for _, test := range tests {
+		if got := webhooks.Backoff(webhookConfig, test.attempts); got != test.want {
+			t.Errorf("Backoff(%d) = %s, want %s", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestDeliveryIsQueuedAgainUntilItFails(t *testing.T) {
+	status := http.StatusInternalServerError
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	endpoint := &models.WebhookEndpoint{Id: "endpoint", Url: server.URL, Secret: "whsec_test"}
+	delivery := &models.WebhookDelivery{Id: "delivery", EndpointId: endpoint.Id, EventId: "evt_1", EventType: "user.signed_up", Payload: `{"id":"evt_1"}`}
+	now := time.Now()
+
+	// the receiver answers 500 to every attempt, the last one allowed fails the delivery
+	want := []models.WebhookDeliveryStatus{models.WebhookDeliveryStatus_QUEUED, models.WebhookDeliveryStatus_QUEUED, models.WebhookDeliveryStatus_FAILED}
+	for attempts, wantStatus := range want {
+		delivery.Attempts = int32(attempts)
+		attempt := webhooks.Send(webhookConfig, endpoint, delivery)
+		if attempt.StatusCode != http.StatusInternalServerError || attempt.Error == "" {
+			t.Fatalf("attempt %d = %d %q, want a failed 500", attempts+1, attempt.StatusCode, attempt.Error)
+		}
+		gotStatus, nextAttemptAt := webhooks.NextStatus(webhookConfig, delivery, attempt, false, now)
+		if gotStatus != wantStatus {
+			t.Errorf("attempt %d: status %s, want %s", attempts+1, gotStatus, wantStatus)
+		}
+		if wantBackoff := webhooks.Backoff(webhookConfig, int32(attempts+1)); !nextAttemptAt.Equal(now.Add(wantBackoff)) {
+			t.Errorf("attempt %d: next attempt in %s, want %s", attempts+1, nextAttemptAt.Sub(now), wantBackoff)
+		}
+	}
+
+	timestamp := received.Header.Get(constants.WebhookTimestampHeader)
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Fatalf("%s = %q, want unix seconds", constants.WebhookTimestampHeader, timestamp)
+	}
+	if got, want := received.Header.Get(constants.WebhookSignatureHeader), webhooks.Sign(endpoint.Secret, timestamp, body); got != want {
+		t.Errorf("%s = %s, want %s", constants.WebhookSignatureHeader, got, want)
+	}
+	if received.Header.Get(constants.WebhookIdHeader) != "evt_1" || received.Header.Get(constants.WebhookEventHeader) != "user.signed_up" {
+		t.Errorf("event headers = %v", received.Header)
+	}
+
+	status = http.StatusNoContent
+	delivery.Attempts = 1
+	attempt := webhooks.Send(webhookConfig, endpoint, delivery)
+	if gotStatus, _ := webhooks.NextStatus(webhookConfig, delivery, attempt, false, now); gotStatus != models.WebhookDeliveryStatus_SUCCEEDED {
+		t.Errorf("2xx answer: status %s, want SUCCEEDED", gotStatus)
+	}
+}
+
+func TestDeliveryToDeletedEndpointFails(t *testing.T) {
+	delivery := &models.WebhookDelivery{Id: "delivery", Attempts: 0}
+	attempt := &models.WebhookAttempt{Error: "endpoint was deleted"}
+	if status, _ := webhooks.NextStatus(webhookConfig, delivery, attempt, true, time.Now()); status != models.WebhookDeliveryStatus_FAILED {
+		t.Errorf("status %s, want FAILED on the first attempt", status)
+	}
+}
@@ -4,5 +4,6 @@ go get -u google.golang.org/protobuf
 
 go install github.com/bufbuild/buf/cmd/buf@v1.5.0
 go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.28.1
+go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.3.0
 
 go install github.com/favadi/protoc-go-inject-tag
\ No newline at end of file